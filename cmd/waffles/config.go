@@ -0,0 +1,115 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+	"github.com/toozej/waffles/pkg/config"
+)
+
+var configCmd = &cobra.Command{
+	Use:   "config",
+	Short: "Inspect the effective configuration",
+}
+
+var configShowCmd = &cobra.Command{
+	Use:   "show [name...]",
+	Short: "Show the effective configuration",
+	Long: `Show the effective value of every configuration variable and the .env files
+that were loaded. Names given as arguments limit the listing to those
+variables.
+
+Settings are taken from, highest precedence first:
+  1. Environment variables
+  2. The file given with --env-file
+  3. The file given with --config
+  4. .env in the current directory
+  5. .waffles.env in the current directory
+  6. ~/.config/waffles/.env
+  7. Built-in defaults
+
+Use --origin to show which of these set each value, and --json for a
+machine-readable listing.
+
+Examples:
+  waffles config show
+  waffles config show --origin
+  waffles config show WAFFLES_DEFAULT_MODEL WAFFLES_LOG_DB_PATH
+  waffles --config team.env config show --origin --json`,
+	Run: configShowRun,
+}
+
+func configShowRun(cmd *cobra.Command, args []string) {
+	showOrigin, _ := cmd.Flags().GetBool("origin")
+	asJSON, _ := cmd.Flags().GetBool("json")
+
+	settings := cfg.Settings()
+	if len(args) > 0 {
+		var selected []config.Setting
+		for _, name := range args {
+			found := false
+			for _, setting := range settings {
+				if setting.Name == name {
+					selected = append(selected, setting)
+					found = true
+				}
+			}
+			if !found {
+				fmt.Printf("❌ Unknown configuration variable: %s\n", name)
+				os.Exit(1)
+			}
+		}
+		settings = selected
+	}
+
+	if asJSON {
+		if !showOrigin {
+			for i := range settings {
+				settings[i].Origin = ""
+			}
+		}
+		data, err := json.MarshalIndent(struct {
+			Sources  []string         `json:"sources"`
+			Settings []config.Setting `json:"settings"`
+		}{append([]string{}, cfg.Sources...), settings}, "", "  ")
+		if err != nil {
+			fmt.Printf("❌ Failed to marshal JSON: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Println(string(data))
+		return
+	}
+
+	fmt.Println("⚙️  Waffles Configuration")
+	fmt.Println("========================")
+	if len(cfg.Sources) == 0 {
+		fmt.Println("Files: none, using environment and defaults")
+	} else {
+		fmt.Println("Files (lowest precedence first):")
+		for _, source := range cfg.Sources {
+			fmt.Printf("  %s\n", source)
+		}
+	}
+	fmt.Println()
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	for _, setting := range settings {
+		if showOrigin {
+			_, _ = fmt.Fprintf(w, "%s=%s\t%s\n", setting.Name, setting.Value, setting.Origin)
+		} else {
+			_, _ = fmt.Fprintf(w, "%s=%s\n", setting.Name, setting.Value)
+		}
+	}
+	_ = w.Flush()
+}
+
+func init() {
+	configShowCmd.Flags().Bool("origin", false, "Show which file, the environment or the defaults set each value")
+	configShowCmd.Flags().Bool("json", false, "Output the configuration as JSON")
+
+	configCmd.AddCommand(configShowCmd)
+	rootCmd.AddCommand(configCmd)
+}
@@ -0,0 +1,492 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/toozej/waffles/pkg/config"
+	"github.com/toozej/waffles/pkg/logging"
+)
+
+var dbCmd = &cobra.Command{
+	Use:   "db",
+	Short: "Manage the log database",
+	Long: `Maintain the SQLite database where waffles logs executions.
+
+Use these commands to inspect and migrate the schema, check integrity,
+take backups, enforce a retention policy and reclaim disk space.
+
+Unlike other commands, status, migrate, check and restore do not migrate the
+database automatically, so they also work on databases with a broken or
+unexpected schema version.`,
+}
+
+var dbStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Show the schema version and size of the log database",
+	Long: `Show the log database path, size, applied schema migrations and row counts.
+
+Examples:
+  waffles db status`,
+	Run: dbStatusRun,
+}
+
+var dbMigrateCmd = &cobra.Command{
+	Use:   "migrate",
+	Short: "Migrate the log database schema",
+	Long: `Apply pending schema migrations, or migrate down to an earlier version.
+
+Down-migrations remove tables and columns and the data stored in them, so
+they require --force. Take a backup first with 'waffles db backup'.
+
+Examples:
+  waffles db migrate
+  waffles db migrate --to 1 --force`,
+	Run: dbMigrateRun,
+}
+
+var dbCheckCmd = &cobra.Command{
+	Use:   "check",
+	Short: "Check the log database for corruption",
+	Long: `Run SQLite's integrity check and look for files and steps whose
+execution no longer exists. Exits with a non-zero status if problems are found.
+
+Examples:
+  waffles db check`,
+	Run: dbCheckRun,
+}
+
+var dbBackupCmd = &cobra.Command{
+	Use:   "backup [destination]",
+	Short: "Back up the log database",
+	Long: `Write a consistent copy of the log database using the SQLite online backup
+API. The database can be used by other waffles runs during the backup.
+
+Without a destination, the backup is written next to the database with a
+timestamp suffix.
+
+Examples:
+  waffles db backup
+  waffles db backup ~/backups/waffles.sqlite --force`,
+	Args: cobra.MaximumNArgs(1),
+	Run:  dbBackupRun,
+}
+
+var dbRestoreCmd = &cobra.Command{
+	Use:   "restore <backup>",
+	Short: "Restore the log database from a backup",
+	Long: `Replace the contents of the log database with a backup.
+
+The backup is integrity-checked before anything is changed, and the current
+database is saved to a timestamped file first unless --no-backup is given.
+
+Examples:
+  waffles db restore llm-logs.sqlite.backup-20240115-103000`,
+	Args: cobra.ExactArgs(1),
+	Run:  dbRestoreRun,
+}
+
+var dbPruneCmd = &cobra.Command{
+	Use:   "prune",
+	Short: "Delete old executions from the log database",
+	Long: `Delete executions older than a given age together with their files and steps.
+
+Without flags, the retention policy from configuration is applied
+(WAFFLES_RETENTION_*). Ages accept d, w and y suffixes (90d, 12w, 1y) or Go
+durations such as 36h.
+
+Examples:
+  waffles db prune --older-than 90d --keep-failed
+  waffles db prune --strip-output-older-than 30d
+  waffles db prune --older-than 1y --keep-last 100 --dry-run
+  waffles db prune --older-than 90d --vacuum`,
+	Run: dbPruneRun,
+}
+
+var dbVacuumCmd = &cobra.Command{
+	Use:   "vacuum",
+	Short: "Reclaim unused space in the log database",
+	Long: `Rebuild the log database file to release space left behind by deleted rows.
+
+Examples:
+  waffles db vacuum`,
+	Run: dbVacuumRun,
+}
+
+func dbStatusRun(cmd *cobra.Command, args []string) {
+	db := openMaintenanceDatabase()
+	defer closeMaintenanceDatabase(db)
+
+	status, err := db.Status()
+	if err != nil {
+		fmt.Printf("❌ Failed to read database status: %v\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Println("🗄️  Log Database")
+	fmt.Println("================")
+	fmt.Printf("Path: %s\n", status.Path)
+	fmt.Printf("Size: %s\n", formatBytes(status.SizeBytes))
+	fmt.Printf("Schema version: %d (latest %d)\n", status.CurrentVersion, status.LatestVersion)
+
+	switch {
+	case status.IsNewer():
+		fmt.Println("⚠️  Database was migrated by a newer version of waffles")
+	case status.Pending() > 0:
+		fmt.Printf("⚠️  %d pending migration(s), run 'waffles db migrate'\n", status.Pending())
+	default:
+		fmt.Println("✅ Schema is up to date")
+	}
+
+	if len(status.Applied) > 0 {
+		fmt.Println()
+		fmt.Println("Applied migrations:")
+		for _, record := range status.Applied {
+			fmt.Printf("  %d  %s\n", record.Version, record.AppliedAt.Format("2006-01-02 15:04:05"))
+		}
+	}
+
+	if len(status.Tables) > 0 {
+		fmt.Println()
+		fmt.Println("Tables:")
+		for _, table := range status.Tables {
+			fmt.Printf("  %-20s %d rows\n", table.Table, table.Rows)
+		}
+	}
+}
+
+func dbMigrateRun(cmd *cobra.Command, args []string) {
+	target, _ := cmd.Flags().GetInt("to")
+	force, _ := cmd.Flags().GetBool("force")
+	if !cmd.Flags().Changed("to") {
+		target = logging.GetCurrentSchemaVersion()
+	}
+
+	db := openMaintenanceDatabase()
+	defer closeMaintenanceDatabase(db)
+
+	current, err := db.SchemaVersion()
+	if err != nil {
+		fmt.Printf("❌ %v\n", err)
+		os.Exit(1)
+	}
+
+	if current == target {
+		fmt.Printf("✅ Schema is already at version %d\n", current)
+		return
+	}
+
+	if target < current && !force {
+		fmt.Printf("❌ Migrating down from version %d to %d drops data\n", current, target)
+		fmt.Println("Back up first with 'waffles db backup', then re-run with --force")
+		os.Exit(1)
+	}
+
+	if err := db.MigrateTo(target); err != nil {
+		fmt.Printf("❌ Migration failed: %v\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("✅ Migrated schema from version %d to %d\n", current, target)
+}
+
+func dbCheckRun(cmd *cobra.Command, args []string) {
+	db := openMaintenanceDatabase()
+	defer closeMaintenanceDatabase(db)
+
+	result, err := db.Check()
+	if err != nil {
+		fmt.Printf("❌ Check failed: %v\n", err)
+		os.Exit(1)
+	}
+
+	if len(result.IntegrityErrors) == 0 {
+		fmt.Println("✅ Integrity check passed")
+	} else {
+		fmt.Printf("❌ Integrity check found %d problem(s):\n", len(result.IntegrityErrors))
+		for _, message := range result.IntegrityErrors {
+			fmt.Printf("  - %s\n", message)
+		}
+	}
+
+	if result.OrphanedFiles > 0 || result.OrphanedSteps > 0 {
+		fmt.Printf("⚠️  Found %d file and %d step records without an execution\n", result.OrphanedFiles, result.OrphanedSteps)
+	} else {
+		fmt.Println("✅ No orphaned file or step records")
+	}
+
+	if !result.OK() {
+		os.Exit(1)
+	}
+}
+
+func dbBackupRun(cmd *cobra.Command, args []string) {
+	force, _ := cmd.Flags().GetBool("force")
+
+	destination := timestampedPath(cfg.LogDBPath, "backup")
+	if len(args) > 0 {
+		destination = args[0]
+	}
+
+	if _, err := os.Stat(destination); err == nil && !force {
+		fmt.Printf("❌ Backup destination already exists: %s\n", destination)
+		fmt.Println("Use --force to overwrite it")
+		os.Exit(1)
+	}
+
+	db := openMaintenanceDatabase()
+	defer closeMaintenanceDatabase(db)
+
+	if err := db.Backup(destination); err != nil {
+		fmt.Printf("❌ %v\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("✅ Database backed up to %s\n", destination)
+}
+
+func dbRestoreRun(cmd *cobra.Command, args []string) {
+	noBackup, _ := cmd.Flags().GetBool("no-backup")
+
+	db := openMaintenanceDatabase()
+	defer closeMaintenanceDatabase(db)
+
+	if !noBackup {
+		safetyCopy := timestampedPath(cfg.LogDBPath, "pre-restore")
+		if err := db.Backup(safetyCopy); err != nil {
+			fmt.Printf("❌ Failed to save current database: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("💾 Current database saved to %s\n", safetyCopy)
+	}
+
+	if err := db.Restore(args[0]); err != nil {
+		fmt.Printf("❌ %v\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("✅ Database restored from %s\n", args[0])
+
+	status, err := db.Status()
+	if err == nil && status.Pending() > 0 {
+		fmt.Printf("💡 The backup uses schema version %d, run 'waffles db migrate' to upgrade it\n", status.CurrentVersion)
+	}
+}
+
+// openMaintenanceDatabase opens the log database without running migrations
+func openMaintenanceDatabase() *logging.Database {
+	db, err := logging.OpenDatabase(cfg.LogDBPath)
+	if err != nil {
+		fmt.Printf("❌ Failed to open log database: %v\n", err)
+		os.Exit(1)
+	}
+	return db
+}
+
+// closeMaintenanceDatabase closes the log database, warning on failure
+func closeMaintenanceDatabase(db *logging.Database) {
+	if err := db.Close(); err != nil {
+		fmt.Printf("Warning: failed to close database: %v\n", err)
+	}
+}
+
+// timestampedPath returns a sibling path of the database for backups
+func timestampedPath(dbPath, label string) string {
+	return fmt.Sprintf("%s.%s-%s", dbPath, label, time.Now().Format("20060102-150405"))
+}
+
+func dbPruneRun(cmd *cobra.Command, args []string) {
+	policy, err := retentionPolicyFromConfig(cfg)
+	if err != nil {
+		fmt.Printf("❌ Invalid retention configuration: %v\n", err)
+		os.Exit(1)
+	}
+
+	flags := cmd.Flags()
+	if flags.Changed("older-than") {
+		value, _ := flags.GetString("older-than")
+		if policy.MaxAge, err = logging.ParseAge(value); err != nil {
+			fmt.Printf("❌ %v\n", err)
+			os.Exit(1)
+		}
+	}
+	if flags.Changed("strip-output-older-than") {
+		value, _ := flags.GetString("strip-output-older-than")
+		if policy.StripOutputAfter, err = logging.ParseAge(value); err != nil {
+			fmt.Printf("❌ %v\n", err)
+			os.Exit(1)
+		}
+	}
+	if flags.Changed("keep-failed") {
+		policy.KeepFailed, _ = flags.GetBool("keep-failed")
+	}
+	if flags.Changed("keep-last") {
+		policy.KeepLast, _ = flags.GetInt("keep-last")
+	}
+	policy.DryRun, _ = flags.GetBool("dry-run")
+	vacuum, _ := flags.GetBool("vacuum")
+
+	if policy.IsEmpty() {
+		fmt.Println("❌ No retention policy given")
+		fmt.Println("Use --older-than or --strip-output-older-than, or set WAFFLES_RETENTION_MAX_AGE")
+		os.Exit(1)
+	}
+
+	db, err := logging.NewDatabase(cfg.LogDBPath)
+	if err != nil {
+		fmt.Printf("❌ Failed to open log database: %v\n", err)
+		os.Exit(1)
+	}
+	defer func() {
+		if closeErr := db.Close(); closeErr != nil {
+			fmt.Printf("Warning: failed to close database: %v\n", closeErr)
+		}
+	}()
+
+	result, err := db.Prune(policy)
+	if err != nil {
+		fmt.Printf("❌ Prune failed: %v\n", err)
+		os.Exit(1)
+	}
+
+	if result.DryRun {
+		fmt.Println("🔍 Dry run - nothing was changed")
+		fmt.Printf("Would delete %d executions (%d files, %d steps)\n",
+			result.DeletedExecutions, result.DeletedFiles, result.DeletedSteps)
+		if result.DetachedChildren > 0 {
+			fmt.Printf("Would clear the parent of %d kept executions\n", result.DetachedChildren)
+		}
+		if policy.StripOutputAfter > 0 {
+			fmt.Printf("Would strip output from %d steps\n", result.StrippedSteps)
+		}
+		return
+	}
+
+	fmt.Printf("🧹 Deleted %d executions (%d files, %d steps)\n",
+		result.DeletedExecutions, result.DeletedFiles, result.DeletedSteps)
+	if result.DetachedChildren > 0 {
+		fmt.Printf("Cleared the parent of %d kept executions\n", result.DetachedChildren)
+	}
+	if policy.StripOutputAfter > 0 {
+		fmt.Printf("✂️  Stripped output from %d steps\n", result.StrippedSteps)
+	}
+
+	if vacuum {
+		reportVacuum(db)
+	}
+}
+
+func dbVacuumRun(cmd *cobra.Command, args []string) {
+	db, err := logging.NewDatabase(cfg.LogDBPath)
+	if err != nil {
+		fmt.Printf("❌ Failed to open log database: %v\n", err)
+		os.Exit(1)
+	}
+	defer func() {
+		if closeErr := db.Close(); closeErr != nil {
+			fmt.Printf("Warning: failed to close database: %v\n", closeErr)
+		}
+	}()
+
+	reportVacuum(db)
+}
+
+// reportVacuum vacuums the database and prints the space reclaimed
+func reportVacuum(db *logging.Database) {
+	before, after, err := db.Vacuum()
+	if err != nil {
+		fmt.Printf("❌ %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Printf("📦 Vacuumed database: %s → %s\n", formatBytes(before), formatBytes(after))
+}
+
+// retentionPolicyFromConfig builds a retention policy from WAFFLES_RETENTION_* settings
+func retentionPolicyFromConfig(c *config.Config) (*logging.RetentionPolicy, error) {
+	maxAge, err := logging.ParseAge(c.RetentionMaxAge)
+	if err != nil {
+		return nil, fmt.Errorf("WAFFLES_RETENTION_MAX_AGE: %w", err)
+	}
+
+	stripAfter, err := logging.ParseAge(c.RetentionStripOutputAfter)
+	if err != nil {
+		return nil, fmt.Errorf("WAFFLES_RETENTION_STRIP_OUTPUT_AFTER: %w", err)
+	}
+
+	return &logging.RetentionPolicy{
+		MaxAge:           maxAge,
+		StripOutputAfter: stripAfter,
+		KeepFailed:       c.RetentionKeepFailed,
+		KeepLast:         c.RetentionKeepLast,
+	}, nil
+}
+
+// autoPrune applies the configured retention policy after a run, warning
+// instead of failing since the run itself already completed
+func autoPrune(c *config.Config) {
+	policy, err := retentionPolicyFromConfig(c)
+	if err != nil {
+		fmt.Printf("⚠️  Skipping automatic pruning: %v\n", err)
+		return
+	}
+	if policy.IsEmpty() {
+		return
+	}
+
+	db, err := logging.NewDatabase(c.LogDBPath)
+	if err != nil {
+		fmt.Printf("⚠️  Skipping automatic pruning: %v\n", err)
+		return
+	}
+	defer db.Close()
+
+	result, err := db.Prune(policy)
+	if err != nil {
+		fmt.Printf("⚠️  Automatic pruning failed: %v\n", err)
+		return
+	}
+
+	if c.Verbose && (result.DeletedExecutions > 0 || result.StrippedSteps > 0) {
+		fmt.Printf("🧹 Pruned %d old executions, stripped output from %d steps\n",
+			result.DeletedExecutions, result.StrippedSteps)
+	}
+}
+
+// formatBytes renders a byte count in human-readable units
+func formatBytes(size int64) string {
+	const unit = 1024
+	if size < unit {
+		return fmt.Sprintf("%d B", size)
+	}
+	div, exp := int64(unit), 0
+	for n := size / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(size)/float64(div), "KMGTPE"[exp])
+}
+
+func init() {
+	dbPruneCmd.Flags().String("older-than", "", "Delete executions older than this age (e.g. 90d, 12w, 1y)")
+	dbPruneCmd.Flags().Bool("keep-failed", false, "Keep failed executions regardless of age")
+	dbPruneCmd.Flags().Int("keep-last", 0, "Always keep the N most recent executions")
+	dbPruneCmd.Flags().String("strip-output-older-than", "", "Clear step output older than this age, keeping metadata")
+	dbPruneCmd.Flags().Bool("dry-run", false, "Show what would be pruned without changing anything")
+	dbPruneCmd.Flags().Bool("vacuum", false, "Vacuum the database after pruning")
+
+	dbMigrateCmd.Flags().Int("to", 0, "Target schema version (default: latest)")
+	dbMigrateCmd.Flags().Bool("force", false, "Allow down-migrations that drop data")
+	dbBackupCmd.Flags().Bool("force", false, "Overwrite an existing backup file")
+	dbRestoreCmd.Flags().Bool("no-backup", false, "Don't save the current database before restoring")
+
+	dbCmd.AddCommand(dbStatusCmd)
+	dbCmd.AddCommand(dbMigrateCmd)
+	dbCmd.AddCommand(dbCheckCmd)
+	dbCmd.AddCommand(dbBackupCmd)
+	dbCmd.AddCommand(dbRestoreCmd)
+	dbCmd.AddCommand(dbPruneCmd)
+	dbCmd.AddCommand(dbVacuumCmd)
+	rootCmd.AddCommand(dbCmd)
+}
@@ -1,6 +1,7 @@
 package cmd
 
 import (
+	"errors"
 	"fmt"
 	"os"
 	"strings"
@@ -19,7 +20,11 @@ and available in your system PATH:
 - wheresmyprompt (Go-based prompt retrieval)
 - files2prompt (Go-based context extraction)  
 - llm (Python-based LLM CLI with SQLite logging)
-- Required LLM plugins (llm-anthropic, llm-ollama, etc.)`,
+- Required LLM plugins (llm-anthropic, llm-ollama, etc.)
+
+The tools are checked concurrently, and each result is cached until the
+binary it checked changes, for at most a day. Use --refresh to check every
+tool again.`,
 	Run: depsRun,
 }
 
@@ -36,20 +41,145 @@ var depsInstallCmd = &cobra.Command{
 	Long: `Automatically install missing dependencies where possible.
 
 This command will attempt to install missing dependencies using the best
-available method for your platform (Homebrew, Go, pip, pipx, etc.).
+available method for your platform (Homebrew, Go, uv, pipx, pip, etc.).
+
+Use --via to choose the package manager instead: go, homebrew, apt, dnf, yum,
+pacman, apk, nix, uv, pipx or pip. The system package managers install pipx
+for llm, and wheresmyprompt and files2prompt come from their GitHub releases.
+uv installs llm together with its plugins with 'uv tool install'.
+
+Use --dry-run to print the exact commands that would run without running them,
+or --instructions-only to see general installation instructions.
 
-Use --instructions-only to see installation commands without executing them.`,
+With --locked, the exact versions pinned in the lockfile (waffles.lock) are
+installed instead of the latest ones: wheresmyprompt and files2prompt from
+their GitHub release archives, llm and its plugins from PyPI. Every download
+is checked against the SHA-256 checksum recorded in the lockfile. Names given
+as arguments limit the install to those tools and plugins.
+
+With --from-bundle, everything is installed from a bundle created by
+'waffles deps bundle', without network access. Every file in the bundle is
+checked against the SHA-256 checksums in its manifest first.`,
 	Run: depsInstallRun,
 }
 
-var instructionsOnly bool
+var depsLockCmd = &cobra.Command{
+	Use:   "lock [name...]",
+	Short: "Pin tool and plugin versions in waffles.lock",
+	Long: `Record the exact versions and checksums of wheresmyprompt, files2prompt,
+llm and each llm plugin in a lockfile (waffles.lock), so that
+'waffles deps install --locked' installs the same versions everywhere.
+
+The installed versions are pinned where they can be determined and the latest
+releases otherwise; --upgrade pins the latest releases instead. Checksums
+are taken from the release checksums.txt, whose signature is verified where
+one is published, and from PyPI. Names given as arguments update only those
+entries of an existing lockfile.
+
+Commit the lockfile to share it with your team.
+
+Examples:
+  waffles deps lock
+  waffles deps lock --upgrade files2prompt
+  waffles deps install --locked`,
+	Run: depsLockRun,
+}
+
+var depsBundleCmd = &cobra.Command{
+	Use:   "bundle",
+	Short: "Create a bundle for offline installation",
+	Long: `Download the pinned versions of wheresmyprompt, files2prompt, llm and each
+llm plugin for this platform into a single tar.gz bundle, for installing on
+hosts without network access.
+
+The bundle holds the GitHub release archives of the Go tools, wheels of llm,
+its plugins and all of their Python dependencies, and a manifest with the
+SHA-256 checksum of every file. Versions come from the lockfile if there is
+one, and are otherwise resolved as by 'waffles deps lock'.
+
+Wheels are resolved for the local Python version, so create the bundle on a
+host with the same platform and Python version as the target hosts.
+
+Examples:
+  waffles deps bundle
+  waffles deps bundle --output /media/usb/waffles-bundle.tar.gz
+  waffles deps install --from-bundle /media/usb/waffles-bundle.tar.gz`,
+	Run: depsBundleRun,
+}
+
+var depsUpgradeCmd = &cobra.Command{
+	Use:   "upgrade [name...]",
+	Short: "Upgrade tools and llm plugins to their latest releases",
+	Long: `Compare the installed versions of wheresmyprompt, files2prompt, llm and the llm
+plugins with their latest releases, show a link to the changelog of each
+newer release, and upgrade them. Names given as arguments limit the upgrade
+to those tools and plugins.
+
+Each tool is upgraded the way it was installed: in waffles' private prefix,
+or with the package manager 'waffles deps install' would use (Homebrew, go
+install, pipx or pip). Plugins are upgraded with 'llm install --upgrade'.
+Releases outside the version range required by configuration are skipped.
+
+Use --check to only show the available upgrades.
+
+Examples:
+  waffles deps upgrade --check
+  waffles deps upgrade
+  waffles deps upgrade files2prompt llm-ollama`,
+	Run: depsUpgradeRun,
+}
+
+var depsUninstallCmd = &cobra.Command{
+	Use:   "uninstall name[@version]...",
+	Short: "Uninstall tools and llm plugins",
+	Long: `Remove tools and llm plugins that waffles installed.
+
+Tools are removed the way they were installed: from waffles' private prefix,
+or with the package manager 'waffles deps install' would use. Binaries
+installed with go install are only removed from Go's bin directory. Plugins
+are removed with 'llm uninstall'.
+
+Give a version as name@version to remove only that version from the private
+prefix. If it was the active version, the newest remaining one is activated.
+
+Examples:
+  waffles deps uninstall llm-commit
+  waffles deps uninstall files2prompt@0.3.0
+  waffles deps uninstall wheresmyprompt files2prompt`,
+	Args: cobra.MinimumNArgs(1),
+	Run:  depsUninstallRun,
+}
+
+var (
+	upgradeCheckOnly bool
+	instructionsOnly bool
+	fromBundle       string
+	bundleOutput     string
+	lockedInstall    bool
+	lockUpgrade      bool
+	lockfilePath     string
+	refreshChecks    bool
+	installVia       string
+	installDryRun    bool
+)
+
+// checkDependencies checks all dependencies, first discarding the cached
+// results if --refresh was given
+func checkDependencies() ([]deps.DependencyStatus, error) {
+	if refreshChecks {
+		if err := deps.ClearCheckCache(); err != nil {
+			return nil, err
+		}
+	}
+	return deps.CheckAllDependencies()
+}
 
 func depsRun(cmd *cobra.Command, args []string) {
 	fmt.Println("🔍 Checking Waffles Dependencies")
 	fmt.Println("================================")
 	fmt.Println()
 
-	statuses, err := deps.CheckAllDependencies()
+	statuses, err := checkDependencies()
 	if err != nil {
 		fmt.Printf("❌ Error checking dependencies: %v\n", err)
 		os.Exit(1)
@@ -97,7 +227,7 @@ func depsRun(cmd *cobra.Command, args []string) {
 }
 
 func depsCheckRun(cmd *cobra.Command, args []string) {
-	statuses, err := deps.CheckAllDependencies()
+	statuses, err := checkDependencies()
 	if err != nil {
 		fmt.Printf("Error checking dependencies: %v\n", err)
 		os.Exit(1)
@@ -128,17 +258,29 @@ func depsCheckRun(cmd *cobra.Command, args []string) {
 }
 
 func depsInstallRun(cmd *cobra.Command, args []string) {
+	if (installVia != "" || installDryRun) && (instructionsOnly || fromBundle != "" || lockedInstall) {
+		fmt.Println("❌ --via and --dry-run cannot be combined with --instructions-only, --locked or --from-bundle")
+		os.Exit(1)
+	}
 	if instructionsOnly {
 		depsInstallInstructionsOnly()
 		return
 	}
+	if fromBundle != "" {
+		depsInstallFromBundle(args)
+		return
+	}
+	if lockedInstall {
+		depsInstallLocked(args)
+		return
+	}
 
 	fmt.Println("📦 Auto-Installing Missing Dependencies")
 	fmt.Println("======================================")
 	fmt.Println()
 
 	// Check current status first
-	statuses, err := deps.CheckAllDependencies()
+	statuses, err := checkDependencies()
 	if err != nil {
 		fmt.Printf("❌ Error checking dependencies: %v\n", err)
 		os.Exit(1)
@@ -159,12 +301,19 @@ func depsInstallRun(cmd *cobra.Command, args []string) {
 
 	// Show platform info
 	installer := deps.NewPlatformInstaller()
+	installer.DryRun = installDryRun
+	if installVia != "" {
+		if err := installer.UsePackageManager(installVia); err != nil {
+			fmt.Printf("❌ %v\n", err)
+			os.Exit(1)
+		}
+	}
 	fmt.Printf("🖥️  Platform: %s %s\n", installer.OS, installer.Architecture)
 	fmt.Printf("📦 Package Manager: %s\n", installer.PackageManager)
 	fmt.Println()
 
 	// Attempt auto-installation
-	if err := deps.AutoInstallAll(); err != nil {
+	if err := installer.AutoInstallAll(); err != nil {
 		fmt.Printf("❌ Auto-installation completed with issues: %v\n", err)
 		fmt.Println()
 		fmt.Println("💡 For manual installation instructions, run:")
@@ -172,16 +321,214 @@ func depsInstallRun(cmd *cobra.Command, args []string) {
 		os.Exit(1)
 	}
 
+	if installDryRun {
+		return
+	}
 	fmt.Println()
 	fmt.Println("✅ Installation completed! Run 'waffles deps check' to verify.")
 }
 
+func depsInstallLocked(names []string) {
+	fmt.Println("🔒 Installing Locked Dependency Versions")
+	fmt.Println("=======================================")
+	fmt.Println()
+
+	lock, err := deps.ReadLockfile(lockfilePath)
+	if err != nil {
+		fmt.Printf("❌ %v\n", err)
+		fmt.Println("   Run 'waffles deps lock' to create one")
+		os.Exit(1)
+	}
+
+	installer := deps.NewPlatformInstaller()
+	fmt.Printf("🖥️  Platform: %s %s\n", installer.OS, installer.Architecture)
+	fmt.Printf("📄 Lockfile: %s\n", lockfilePath)
+	fmt.Println()
+
+	if err := installer.InstallLocked(lock, names); err != nil {
+		fmt.Printf("❌ %v\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Println()
+	fmt.Println("✅ Locked versions installed! Run 'waffles deps check' to verify.")
+}
+
+func depsInstallFromBundle(names []string) {
+	fmt.Println("📦 Installing From Bundle")
+	fmt.Println("========================")
+	fmt.Println()
+
+	installer := deps.NewPlatformInstaller()
+	fmt.Printf("🖥️  Platform: %s %s\n", installer.OS, installer.Architecture)
+	fmt.Printf("📄 Bundle: %s\n", fromBundle)
+	fmt.Println()
+
+	if err := installer.InstallBundle(fromBundle, names); err != nil {
+		fmt.Printf("❌ %v\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Println()
+	fmt.Println("✅ Bundle installed! Run 'waffles deps check' to verify.")
+}
+
+func depsBundleRun(cmd *cobra.Command, args []string) {
+	installer := deps.NewPlatformInstaller()
+	output := bundleOutput
+	if output == "" {
+		output = fmt.Sprintf("waffles-bundle-%s-%s.tar.gz", installer.OS, installer.Architecture)
+	}
+
+	lock, err := deps.ReadLockfile(lockfilePath)
+	if errors.Is(err, os.ErrNotExist) {
+		fmt.Println("🔒 Resolving dependency versions...")
+		lock, err = installer.Lock(nil, deps.LockOptions{})
+	}
+	if err != nil {
+		fmt.Printf("❌ %v\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("📦 Bundling for %s %s\n", installer.OS, installer.Architecture)
+	manifest, err := installer.Bundle(lock, output)
+	if err != nil {
+		fmt.Printf("❌ %v\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Println()
+	for _, list := range [][]deps.LockedTool{manifest.Tools, manifest.Plugins} {
+		for _, tool := range list {
+			fmt.Printf("  %-22s %s\n", tool.Name, tool.Version)
+		}
+	}
+	fmt.Println()
+	fmt.Printf("✅ Wrote %s (%d files)\n", output, len(manifest.Files))
+	fmt.Printf("   Install it with: waffles deps install --from-bundle %s\n", output)
+}
+
+func depsUpgradeRun(cmd *cobra.Command, args []string) {
+	installer := deps.NewPlatformInstaller()
+
+	fmt.Println("🔍 Checking for upgrades...")
+	upgrades, err := installer.CheckUpgrades(args)
+	if err != nil {
+		fmt.Printf("❌ %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Println()
+
+	var available []deps.UpgradeInfo
+	for _, upgrade := range upgrades {
+		switch {
+		case upgrade.Available():
+			fmt.Printf("⬆️  %s: %s → %s (%s)\n", upgrade.Name, upgrade.Current, upgrade.Latest, upgrade.Method)
+			fmt.Printf("   Changelog: %s\n", upgrade.Changelog)
+			available = append(available, upgrade)
+		case upgrade.Blocked != "":
+			fmt.Printf("⚠️  %s: %s\n", upgrade.Name, upgrade.Blocked)
+		default:
+			fmt.Printf("✅ %s: %s is up to date\n", upgrade.Name, upgrade.Current)
+		}
+	}
+	fmt.Println()
+
+	if len(available) == 0 {
+		fmt.Println("✅ Everything is up to date")
+		return
+	}
+	if upgradeCheckOnly {
+		fmt.Printf("%d upgrade(s) available, run 'waffles deps upgrade' to install them\n", len(available))
+		return
+	}
+
+	failed := 0
+	for _, upgrade := range available {
+		fmt.Printf("Upgrading %s...\n", upgrade.Name)
+		result, err := installer.Upgrade(upgrade)
+		if err != nil || !result.Success {
+			fmt.Printf("❌ %s\n", result.Error)
+			failed++
+			continue
+		}
+		fmt.Printf("✅ %s\n", result.Message)
+	}
+
+	if _, err := os.Stat(lockfilePath); err == nil {
+		fmt.Println()
+		fmt.Printf("💡 %s still pins the previous versions, update it with 'waffles deps lock --upgrade'\n", lockfilePath)
+	}
+	if failed > 0 {
+		os.Exit(1)
+	}
+}
+
+func depsUninstallRun(cmd *cobra.Command, args []string) {
+	installer := deps.NewPlatformInstaller()
+
+	failed := 0
+	for _, arg := range args {
+		name, version, _ := strings.Cut(arg, "@")
+		result, err := installer.Uninstall(name, version)
+		if err != nil || !result.Success {
+			fmt.Printf("❌ %s\n", result.Error)
+			failed++
+			continue
+		}
+		fmt.Printf("✅ %s\n", result.Message)
+	}
+
+	if failed > 0 {
+		os.Exit(1)
+	}
+}
+
+func depsLockRun(cmd *cobra.Command, args []string) {
+	var existing *deps.Lockfile
+	if _, err := os.Stat(lockfilePath); err == nil {
+		if existing, err = deps.ReadLockfile(lockfilePath); err != nil {
+			fmt.Printf("❌ %v\n", err)
+			os.Exit(1)
+		}
+	} else if len(args) > 0 {
+		fmt.Printf("❌ %s does not exist; run 'waffles deps lock' without names first\n", lockfilePath)
+		os.Exit(1)
+	}
+
+	fmt.Println("🔒 Resolving dependency versions...")
+	installer := deps.NewPlatformInstaller()
+	lock, err := installer.Lock(existing, deps.LockOptions{Names: args, Upgrade: lockUpgrade})
+	if err != nil {
+		fmt.Printf("❌ %v\n", err)
+		os.Exit(1)
+	}
+
+	if err := lock.Write(lockfilePath); err != nil {
+		fmt.Printf("❌ %v\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Println()
+	for _, list := range [][]deps.LockedTool{lock.Tools, lock.Plugins} {
+		for _, tool := range list {
+			change := ""
+			if old := existing.Find(tool.Name); old != nil && old.Version != tool.Version {
+				change = fmt.Sprintf(" (was %s)", old.Version)
+			}
+			fmt.Printf("  %-22s %s%s\n", tool.Name, tool.Version, change)
+		}
+	}
+	fmt.Println()
+	fmt.Printf("✅ Wrote %s\n", lockfilePath)
+}
+
 func depsInstallInstructionsOnly() {
 	fmt.Println("📋 Installation Instructions")
 	fmt.Println("===========================")
 	fmt.Println()
 
-	statuses, err := deps.CheckAllDependencies()
+	statuses, err := checkDependencies()
 	if err != nil {
 		fmt.Printf("❌ Error checking dependencies: %v\n", err)
 		os.Exit(1)
@@ -220,10 +567,23 @@ func depsInstallInstructionsOnly() {
 func init() {
 	// Add flags to install command
 	depsInstallCmd.Flags().BoolVar(&instructionsOnly, "instructions-only", false, "Show installation instructions without executing them")
+	depsInstallCmd.Flags().BoolVar(&lockedInstall, "locked", false, "Install the exact versions pinned in the lockfile")
+	depsInstallCmd.Flags().StringVar(&installVia, "via", "", "Package manager to install with instead of the detected one")
+	depsInstallCmd.Flags().BoolVar(&installDryRun, "dry-run", false, "Print the commands that would run without installing anything")
+	depsInstallCmd.Flags().StringVar(&fromBundle, "from-bundle", "", "Install from a bundle created by 'waffles deps bundle'")
+	depsBundleCmd.Flags().StringVarP(&bundleOutput, "output", "o", "", "Path of the bundle (default waffles-bundle-<os>-<arch>.tar.gz)")
+	depsUpgradeCmd.Flags().BoolVar(&upgradeCheckOnly, "check", false, "Only show the available upgrades")
+	depsLockCmd.Flags().BoolVar(&lockUpgrade, "upgrade", false, "Pin the latest releases instead of the installed versions")
+	depsCmd.PersistentFlags().StringVar(&lockfilePath, "lockfile", deps.LockfileName, "Path of the lockfile")
+	depsCmd.PersistentFlags().BoolVar(&refreshChecks, "refresh", false, "Check every tool again instead of using cached results")
 
 	// Add subcommands
 	depsCmd.AddCommand(depsCheckCmd)
 	depsCmd.AddCommand(depsInstallCmd)
+	depsCmd.AddCommand(depsLockCmd)
+	depsCmd.AddCommand(depsBundleCmd)
+	depsCmd.AddCommand(depsUpgradeCmd)
+	depsCmd.AddCommand(depsUninstallCmd)
 
 	// Add to root command
 	rootCmd.AddCommand(depsCmd)
@@ -0,0 +1,88 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/toozej/waffles/internal/compare"
+	"github.com/toozej/waffles/pkg/logging"
+)
+
+var diffCmd = &cobra.Command{
+	Use:   "diff <id-a> <id-b>",
+	Short: "Compare two executions",
+	Long: `Compare two logged executions side by side.
+
+Shows differences in configuration (model, provider, language and tool
+arguments), the files included in the context, per-step durations and a
+unified diff of the final answers. Useful after 'waffles rerun' or when
+trying another model on the same question.
+
+Supported formats: text, markdown
+
+Examples:
+  waffles diff 3f2a9c1e 7b4d0e22
+  waffles diff 3f2a9c1e 7b4d0e22 --format markdown --output comparison.md`,
+	Args: cobra.ExactArgs(2),
+	Run:  diffRun,
+}
+
+func diffRun(cmd *cobra.Command, args []string) {
+	format, _ := cmd.Flags().GetString("format")
+	output, _ := cmd.Flags().GetString("output")
+	contextLines, _ := cmd.Flags().GetInt("context")
+
+	diffFormat := compare.Format(format)
+	switch diffFormat {
+	case compare.FormatText, compare.FormatMarkdown:
+		// Valid formats
+	default:
+		fmt.Printf("❌ Unsupported format: %s\n", format)
+		fmt.Println("Supported formats: text, markdown")
+		os.Exit(1)
+	}
+
+	db, err := logging.NewDatabase(cfg.LogDBPath)
+	if err != nil {
+		fmt.Printf("❌ Failed to open log database: %v\n", err)
+		os.Exit(1)
+	}
+
+	comparison, err := compare.Executions(db, args[0], args[1], &compare.Options{ContextLines: contextLines})
+	if closeErr := db.Close(); closeErr != nil {
+		fmt.Printf("Warning: failed to close database: %v\n", closeErr)
+	}
+	if err != nil {
+		fmt.Printf("❌ Comparison failed: %v\n", err)
+		os.Exit(1)
+	}
+
+	writer := os.Stdout
+	if output != "" {
+		file, err := os.Create(output) // #nosec G304 -- Output path from user-specified flag
+		if err != nil {
+			fmt.Printf("❌ Failed to create output file: %v\n", err)
+			os.Exit(1)
+		}
+		defer file.Close()
+		writer = file
+	}
+
+	if err := comparison.Write(diffFormat, writer); err != nil {
+		fmt.Printf("❌ Failed to write comparison: %v\n", err)
+		os.Exit(1)
+	}
+
+	if output != "" {
+		fmt.Printf("✅ Comparison written to %s\n", output)
+	}
+}
+
+func init() {
+	diffCmd.Flags().String("format", "text", "Output format (text, markdown)")
+	diffCmd.Flags().StringP("output", "o", "", "Output file (default: stdout)")
+	diffCmd.Flags().Int("context", 3, "Lines of context in the output diff")
+
+	rootCmd.AddCommand(diffCmd)
+}
@@ -0,0 +1,154 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/toozej/waffles/internal/doctor"
+	"github.com/toozej/waffles/pkg/deps"
+)
+
+var doctorCmd = &cobra.Command{
+	Use:   "doctor",
+	Short: "Diagnose the waffles installation",
+	Long: `Examine everything waffles depends on and suggest fixes for the problems found:
+the host system, the pipeline tools and llm plugins, the configuration files
+that were loaded, the health and schema version of the log database, and
+whether an API key is available for each provider. Key values are never shown.
+
+Exits with a non-zero status if any problem would break the pipeline, or with
+--strict if there are any problems at all, which makes it suitable for gating
+CI jobs. Use --json for a machine-readable report.
+
+Examples:
+  waffles doctor
+  waffles doctor --json
+  waffles doctor --strict --refresh`,
+	Run: doctorRun,
+}
+
+func doctorRun(cmd *cobra.Command, args []string) {
+	asJSON, _ := cmd.Flags().GetBool("json")
+	strict, _ := cmd.Flags().GetBool("strict")
+	refresh, _ := cmd.Flags().GetBool("refresh")
+
+	if refresh {
+		if err := deps.ClearCheckCache(); err != nil {
+			fmt.Printf("⚠️  Failed to clear cached dependency checks: %v\n", err)
+		}
+	}
+
+	report, err := doctor.Examine(cfg)
+	if err != nil {
+		fmt.Printf("❌ %v\n", err)
+		os.Exit(1)
+	}
+
+	if asJSON {
+		data, err := json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			fmt.Printf("❌ Failed to marshal JSON: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Println(string(data))
+	} else {
+		showDoctorReport(report)
+	}
+
+	if !report.OK || (strict && len(report.Suggestions) > 0) {
+		os.Exit(1)
+	}
+}
+
+// showDoctorReport prints a doctor report section by section
+func showDoctorReport(report *doctor.Report) {
+	fmt.Println("🩺 Waffles Doctor")
+	fmt.Println("=================")
+	fmt.Printf("Version: %s\n", report.Version)
+	fmt.Printf("System:  %s/%s, shell %s\n", report.System.OS, report.System.Architecture, report.System.Shell)
+	fmt.Println()
+
+	fmt.Println("Dependencies:")
+	for _, status := range report.Dependencies {
+		switch {
+		case status.Installed && status.Valid:
+			fmt.Printf("  ✅ %s %s (%s)\n", status.Name, status.Version, status.Path)
+		case status.Installed:
+			fmt.Printf("  ⚠️  %s %s: %s\n", status.Name, status.Version, status.Error)
+		default:
+			fmt.Printf("  ❌ %s: not installed\n", status.Name)
+		}
+	}
+	for _, plugin := range report.Plugins {
+		if plugin.Installed {
+			fmt.Printf("  ✅ %s\n", plugin.Name)
+		} else {
+			fmt.Printf("  ❌ %s: not installed\n", plugin.Name)
+		}
+	}
+	fmt.Println()
+
+	fmt.Println("Configuration:")
+	if len(report.Config.Sources) == 0 {
+		fmt.Println("  Files:    none, using environment and defaults")
+	} else {
+		fmt.Printf("  Files:    %s\n", strings.Join(report.Config.Sources, ", "))
+	}
+	fmt.Printf("  Provider: %s\n", report.Config.DefaultProvider)
+	fmt.Printf("  Model:    %s\n", report.Config.DefaultModel)
+	fmt.Println()
+
+	fmt.Println("Log database:")
+	database := report.Database
+	fmt.Printf("  Path:     %s\n", database.Path)
+	switch {
+	case !database.Exists:
+		fmt.Println("  Not created yet, the first pipeline run creates it")
+	case database.Error != "":
+		fmt.Printf("  ❌ %s\n", database.Error)
+	default:
+		fmt.Printf("  Size:     %s\n", formatBytes(database.SizeBytes))
+		fmt.Printf("  Schema:   version %d (latest %d)\n", database.SchemaVersion, database.LatestVersion)
+	}
+	fmt.Println()
+
+	fmt.Println("API keys:")
+	for _, provider := range report.Providers {
+		label := provider.Provider
+		if provider.Default {
+			label += " (default)"
+		}
+		if provider.Present {
+			fmt.Printf("  ✅ %s: set via %s\n", label, provider.Source)
+		} else {
+			fmt.Printf("  ➖ %s: not set\n", label)
+		}
+	}
+	fmt.Println()
+
+	if len(report.Suggestions) == 0 {
+		fmt.Println("✅ No problems found")
+		return
+	}
+
+	fmt.Printf("Found %d problem(s):\n", len(report.Suggestions))
+	for _, suggestion := range report.Suggestions {
+		icon := "⚠️ "
+		if suggestion.Severity == doctor.SeverityError {
+			icon = "❌"
+		}
+		fmt.Printf("  %s %s: %s\n", icon, suggestion.Component, suggestion.Problem)
+		fmt.Printf("     💡 %s\n", suggestion.Fix)
+	}
+}
+
+func init() {
+	doctorCmd.Flags().Bool("json", false, "Output the report as JSON")
+	doctorCmd.Flags().Bool("strict", false, "Exit with a non-zero status on warnings too")
+	doctorCmd.Flags().Bool("refresh", false, "Check every tool again instead of using cached results")
+
+	rootCmd.AddCommand(doctorCmd)
+}
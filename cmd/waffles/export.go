@@ -7,7 +7,6 @@ import (
 	"github.com/spf13/cobra"
 	"github.com/toozej/waffles/internal/export"
 	"github.com/toozej/waffles/internal/query"
-	"github.com/toozej/waffles/pkg/config"
 	"github.com/toozej/waffles/pkg/logging"
 )
 
@@ -19,18 +18,25 @@ var exportCmd = &cobra.Command{
 This command allows you to export your logged LLM conversations and
 execution data to different formats for analysis, backup, or sharing.
 
-Supported formats: json, csv, markdown, sql, template`,
+JSON Lines, CSV, SQL, Parquet and SQLite exports are streamed in batches, so
+even very large histories are written without loading them into memory.
+
+Parquet files nest files and steps as list columns and can be queried directly
+from DuckDB or pandas. SQLite exports are standalone databases containing only
+the selected rows of the waffles_executions, waffles_files and waffles_steps
+tables. Both are binary formats and require --output.
+
+Executions can be filtered with the same --where expressions as
+'waffles query', e.g. --where "success=false and duration>30s".
+
+HTML exports are self-contained offline reports with summary statistics,
+charts and a searchable table of executions linking to per-run details.
+
+Supported formats: json, jsonl, csv, markdown, html, sql, parquet, sqlite, template`,
 	Run: exportRun,
 }
 
 func exportRun(cmd *cobra.Command, args []string) {
-	// Load configuration to get database path
-	cfg, err := config.LoadConfig()
-	if err != nil {
-		fmt.Printf("❌ Failed to load configuration: %v\n", err)
-		os.Exit(1)
-	}
-
 	// Parse flags
 	format, _ := cmd.Flags().GetString("format")
 	output, _ := cmd.Flags().GetString("output")
@@ -45,15 +51,25 @@ func exportRun(cmd *cobra.Command, args []string) {
 	includeStats, _ := cmd.Flags().GetBool("include-stats")
 	limit, _ := cmd.Flags().GetInt("limit")
 	templateFile, _ := cmd.Flags().GetString("template")
+	batchSize, _ := cmd.Flags().GetInt("batch-size")
+	success, _ := cmd.Flags().GetString("success")
+	search, _ := cmd.Flags().GetString("search")
+	where, _ := cmd.Flags().GetString("where")
 
 	// Validate format
 	exportFormat := export.ExportFormat(format)
 	switch exportFormat {
-	case export.FormatJSON, export.FormatCSV, export.FormatMarkdown, export.FormatSQL, export.FormatTemplate:
+	case export.FormatJSON, export.FormatJSONL, export.FormatCSV, export.FormatMarkdown, export.FormatHTML, export.FormatSQL,
+		export.FormatParquet, export.FormatSQLite, export.FormatTemplate:
 		// Valid formats
 	default:
 		fmt.Printf("❌ Unsupported format: %s\n", format)
-		fmt.Println("Supported formats: json, csv, markdown, sql, template")
+		fmt.Println("Supported formats: json, jsonl, csv, markdown, html, sql, parquet, sqlite, template")
+		os.Exit(1)
+	}
+
+	if exportFormat.IsBinary() && output == "" {
+		fmt.Printf("❌ The %s format writes binary data; use --output to choose a file\n", format)
 		os.Exit(1)
 	}
 
@@ -66,10 +82,11 @@ func exportRun(cmd *cobra.Command, args []string) {
 
 	// Build filter
 	filter := &logging.ExecutionFilter{
-		Language: language,
-		Model:    model,
-		Provider: provider,
-		Limit:    limit,
+		Language:    language,
+		Model:       model,
+		Provider:    provider,
+		SearchQuery: search,
+		Limit:       limit,
 	}
 
 	// Parse date filters
@@ -97,6 +114,30 @@ func exportRun(cmd *cobra.Command, args []string) {
 		filter.DateTo = untilTime
 	}
 
+	if success != "" {
+		successBool, err := query.ParseBoolFilter(success)
+		if err != nil {
+			fmt.Printf("❌ Invalid success value: %v\n", err)
+			if closeErr := queryEngine.Close(); closeErr != nil {
+				fmt.Printf("Warning: failed to close query engine: %v\n", closeErr)
+			}
+			os.Exit(1)
+		}
+		filter.Success = successBool
+	}
+
+	if where != "" {
+		conditions, err := query.ParseFilterExpression(where)
+		if err != nil {
+			fmt.Printf("❌ Invalid --where expression: %v\n", err)
+			if closeErr := queryEngine.Close(); closeErr != nil {
+				fmt.Printf("Warning: failed to close query engine: %v\n", closeErr)
+			}
+			os.Exit(1)
+		}
+		filter.Conditions = conditions
+	}
+
 	// Prepare export options
 	options := &export.ExportOptions{
 		Format:       exportFormat,
@@ -105,6 +146,7 @@ func exportRun(cmd *cobra.Command, args []string) {
 		IncludeSteps: includeSteps,
 		IncludeStats: includeStats,
 		Compress:     compress,
+		BatchSize:    batchSize,
 	}
 
 	// Handle template format
@@ -164,19 +206,23 @@ func exportRun(cmd *cobra.Command, args []string) {
 
 func init() {
 	// Add export flags
-	exportCmd.Flags().String("format", "json", "Export format (json, csv, markdown, sql, template)")
+	exportCmd.Flags().String("format", "json", "Export format (json, jsonl, csv, markdown, html, sql, parquet, sqlite, template)")
 	exportCmd.Flags().StringP("output", "o", "", "Output file (default: stdout)")
 	exportCmd.Flags().String("since", "", "Export data since date (YYYY-MM-DD)")
 	exportCmd.Flags().String("until", "", "Export data until date (YYYY-MM-DD)")
 	exportCmd.Flags().String("model", "", "Filter by LLM model")
 	exportCmd.Flags().String("provider", "", "Filter by LLM provider")
 	exportCmd.Flags().String("language", "", "Filter by detected language")
+	exportCmd.Flags().String("search", "", "Search in prompts, commands and errors")
+	exportCmd.Flags().String("success", "", "Filter by success status (true/false)")
+	exportCmd.Flags().String("where", "", `Filter expression, e.g. "success=false and duration>30s"`)
 	exportCmd.Flags().Int("limit", 0, "Maximum number of records (0 = no limit)")
 	exportCmd.Flags().Bool("compress", false, "Compress output using gzip")
 	exportCmd.Flags().Bool("include-files", false, "Include file information in export")
 	exportCmd.Flags().Bool("include-steps", false, "Include pipeline step details in export")
 	exportCmd.Flags().Bool("include-stats", false, "Include statistical analysis in export")
 	exportCmd.Flags().String("template", "", "Template file path (required for template format)")
+	exportCmd.Flags().Int("batch-size", 500, "Executions per batch when streaming jsonl, csv, sql, parquet and sqlite exports")
 
 	// Add to root command
 	rootCmd.AddCommand(exportCmd)
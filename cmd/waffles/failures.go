@@ -0,0 +1,128 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/toozej/waffles/internal/failures"
+	"github.com/toozej/waffles/internal/query"
+)
+
+var failuresCmd = &cobra.Command{
+	Use:   "failures",
+	Short: "Group failed executions by cause",
+	Long: `Analyze failed executions and group them by cause.
+
+Failures are clustered by pipeline phase, tool and a normalized error
+signature, in which IDs, paths, quoted values and numbers are replaced by
+placeholders so that repeated occurrences of the same problem group together.
+Each cluster shows how often it happened, when it was first and last seen,
+example execution IDs and, where the error or the step stderr points at a
+known cause, a suggested remedy (missing dependency, timeout, authentication,
+rate limit, context length, unknown model, network).
+
+Use 'waffles query --where "id=<id>"' or 'waffles rerun <id>' with an example ID
+to look into a cluster.
+
+Examples:
+  waffles failures
+  waffles failures --since 2024-01-01 --model gpt-4o
+  waffles failures --where "duration>60s" --format json`,
+	Run: failuresRun,
+}
+
+func failuresRun(cmd *cobra.Command, args []string) {
+	limit, _ := cmd.Flags().GetInt("limit")
+	format, _ := cmd.Flags().GetString("format")
+
+	filters, err := historyFilters(cmd)
+	if err != nil {
+		fmt.Printf("❌ %v\n", err)
+		os.Exit(1)
+	}
+
+	queryEngine, err := query.NewQueryEngine(cfg.LogDBPath)
+	if err != nil {
+		fmt.Printf("❌ Failed to create query engine: %v\n", err)
+		os.Exit(1)
+	}
+
+	report, err := failures.Analyze(queryEngine, filters)
+	if closeErr := queryEngine.Close(); closeErr != nil {
+		fmt.Printf("Warning: failed to close query engine: %v\n", closeErr)
+	}
+	if err != nil {
+		fmt.Printf("❌ Failure analysis failed: %v\n", err)
+		os.Exit(1)
+	}
+
+	if limit > 0 && len(report.Clusters) > limit {
+		report.Clusters = report.Clusters[:limit]
+	}
+
+	if format == "json" {
+		data, err := json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			fmt.Printf("❌ Failed to marshal JSON: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Println(string(data))
+		return
+	}
+
+	showFailureReport(report)
+}
+
+// showFailureReport prints failure clusters with their remedies
+func showFailureReport(report *failures.Report) {
+	if report.TotalFailures == 0 {
+		fmt.Println("✅ No failed executions found")
+		return
+	}
+
+	fmt.Printf("🔍 %d failed executions\n\n", report.TotalFailures)
+
+	const layout = "2006-01-02 15:04"
+	for i, cluster := range report.Clusters {
+		location := cluster.Phase
+		if cluster.Tool != "" {
+			location += " · " + cluster.Tool
+		}
+		share := float64(cluster.Count) / float64(report.TotalFailures) * 100
+
+		fmt.Printf("%d. %s — %d failures (%.0f%%)\n", i+1, location, cluster.Count, share)
+		fmt.Printf("   Signature: %s\n", cluster.Signature)
+		if message := firstErrorLine(cluster.Message); message != "" && message != cluster.Signature {
+			fmt.Printf("   Latest:    %s\n", message)
+		}
+		fmt.Printf("   Seen:      %s → %s\n", cluster.FirstSeen.Local().Format(layout), cluster.LastSeen.Local().Format(layout))
+		fmt.Printf("   Examples:  %s\n", strings.Join(cluster.ExecutionIDs, ", "))
+		for _, remedy := range cluster.Remedies {
+			fmt.Printf("   💡 %s: %s\n", remedy.Name, remedy.Suggestion)
+		}
+		fmt.Println()
+	}
+}
+
+// firstErrorLine returns the first line of an error message
+func firstErrorLine(message string) string {
+	line, _, _ := strings.Cut(strings.TrimSpace(message), "\n")
+	return line
+}
+
+func init() {
+	failuresCmd.Flags().String("since", "", "Only include failures since date (YYYY-MM-DD)")
+	failuresCmd.Flags().String("until", "", "Only include failures until date (YYYY-MM-DD)")
+	failuresCmd.Flags().String("model", "", "Filter by LLM model")
+	failuresCmd.Flags().String("provider", "", "Filter by LLM provider")
+	failuresCmd.Flags().String("language", "", "Filter by detected language")
+	failuresCmd.Flags().String("source", "", "Filter by import source tag")
+	failuresCmd.Flags().String("where", "", `Filter expression, e.g. "model~gpt and age<7d"`)
+	failuresCmd.Flags().Int("limit", 20, "Maximum number of clusters to show (0 for all)")
+	failuresCmd.Flags().String("format", "text", "Output format (text, json)")
+
+	rootCmd.AddCommand(failuresCmd)
+}
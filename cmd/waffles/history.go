@@ -0,0 +1,156 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/toozej/waffles/pkg/logging"
+	"github.com/toozej/waffles/pkg/pipeline"
+)
+
+// recordExecution persists a pipeline run, its files and its steps to the log
+// database so it can later be queried, exported and re-run. The parentID links
+// re-runs back to the execution they were derived from.
+func recordExecution(p *pipeline.Pipeline, execCtx *pipeline.ExecutionContext, parentID string) (string, error) {
+	db, err := logging.NewDatabase(p.Config.LogDBPath)
+	if err != nil {
+		return "", fmt.Errorf("failed to open log database: %w", err)
+	}
+	defer db.Close()
+
+	exec := &logging.WafflesExecution{
+		CommandArgs:         strings.Join(os.Args[1:], " "),
+		WheresmypromptQuery: execCtx.PromptQuery,
+		WheresmypromptArgs:  p.Config.WheresmypromptArgs,
+		Files2promptArgs:    p.Config.Files2promptArgs,
+		LLMArgs:             p.Config.LLMArgs,
+		DetectedLanguage:    p.Config.LanguageOverride,
+		FileCount:           len(execCtx.Files),
+		ExecutionTimeMS:     execCtx.Duration.Milliseconds(),
+		Success:             execCtx.Success,
+		ErrorMessage:        execCtx.Error,
+		ModelUsed:           p.Config.DefaultModel,
+		ProviderUsed:        p.Config.DefaultProvider,
+		ParentExecutionID:   parentID,
+		Created:             execCtx.StartTime,
+	}
+
+	repoInfo := p.GetRepoInfo()
+	if repoInfo != nil {
+		exec.DetectedLanguage = string(repoInfo.Language)
+	}
+
+	if err := db.LogExecution(exec); err != nil {
+		return "", err
+	}
+
+	if repoInfo != nil {
+		files := make([]logging.WafflesFile, 0, len(repoInfo.DetectedFiles))
+		for _, file := range repoInfo.DetectedFiles {
+			record := logging.WafflesFile{
+				FilePath: file.Path,
+				FileSize: file.Size,
+				Included: file.Included,
+			}
+			if !file.Included {
+				record.ExclusionReason = file.Reason
+			}
+			files = append(files, record)
+		}
+		if err := db.LogFiles(exec.ID, files); err != nil {
+			return exec.ID, err
+		}
+	}
+
+	steps := make([]logging.WafflesStep, 0, len(execCtx.ExecutionSteps))
+	for i, step := range execCtx.ExecutionSteps {
+		record := logging.WafflesStep{
+			Tool:       step.Tool,
+			Command:    strings.Join(step.Command, " "),
+			Output:     step.Output,
+			Success:    step.Success,
+			DurationMS: step.Duration.Milliseconds(),
+			StepOrder:  i + 1,
+			Created:    step.StartTime,
+		}
+		if step.Error != nil {
+			record.ErrorOutput = step.Error.Error()
+		}
+		steps = append(steps, record)
+	}
+	if err := db.LogSteps(exec.ID, steps); err != nil {
+		return exec.ID, err
+	}
+
+	return exec.ID, nil
+}
+
+// showExecutionResult prints the outcome of a pipeline run and reports whether it succeeded
+func showExecutionResult(execCtx *pipeline.ExecutionContext, err error) bool {
+	if err != nil {
+		fmt.Printf("❌ Pipeline execution failed: %v\n", err)
+		fmt.Println()
+
+		// Show execution steps that completed (only if execContext is not nil)
+		if execCtx != nil && len(execCtx.ExecutionSteps) > 0 {
+			fmt.Println("Steps completed:")
+			for i, step := range execCtx.ExecutionSteps {
+				status := "❌"
+				if step.Success {
+					status = "✅"
+				}
+				fmt.Printf("  %d. %s %s (%.2fs)\n", i+1, status, step.Tool, step.Duration.Seconds())
+			}
+		}
+
+		return false
+	}
+
+	// Show successful execution results
+	fmt.Println("✅ Pipeline completed successfully!")
+	fmt.Printf("⏱️  Total execution time: %.2fs\n", execCtx.Duration.Seconds())
+	fmt.Println()
+
+	// Show execution steps
+	fmt.Println("Execution steps:")
+	for i, step := range execCtx.ExecutionSteps {
+		fmt.Printf("  %d. ✅ %s (%.2fs)\n", i+1, step.Tool, step.Duration.Seconds())
+		if cfg.Verbose && step.Output != "" {
+			fmt.Printf("     Output: %s\n", truncateOutput(step.Output, 200))
+		}
+	}
+
+	fmt.Println()
+	fmt.Println("🎯 Final Result:")
+	fmt.Println("================")
+	fmt.Println(execCtx.FinalOutput)
+
+	return true
+}
+
+// recordAndReport records an execution and prints its ID, warning instead of
+// failing when the log database is unavailable
+func recordAndReport(p *pipeline.Pipeline, execCtx *pipeline.ExecutionContext, parentID string) string {
+	if execCtx == nil {
+		return ""
+	}
+
+	executionID, err := recordExecution(p, execCtx, parentID)
+	if err != nil {
+		fmt.Printf("⚠️  Failed to record execution: %v\n", err)
+		return executionID
+	}
+
+	fmt.Println()
+	fmt.Printf("🆔 Execution ID: %s\n", executionID)
+
+	if p.Config.RetentionAutoPrune {
+		autoPrune(p.Config)
+	}
+	if p.Config.MetricsTextfile != "" {
+		updateMetricsTextfile(p.Config)
+	}
+
+	return executionID
+}
@@ -0,0 +1,159 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/toozej/waffles/internal/export"
+	"github.com/toozej/waffles/pkg/logging"
+)
+
+// maxReportedConflicts limits how many conflicts are listed individually
+const maxReportedConflicts = 20
+
+var importCmd = &cobra.Command{
+	Use:   "import <file>",
+	Short: "Import executions from an export",
+	Long: `Import executions from a waffles export into the log database.
+
+Accepts the JSON, JSON Lines, SQL and SQLite files written by 'waffles export',
+optionally gzip-compressed. Export with --include-files and --include-steps to
+carry file and step details along.
+
+Executions are matched by ID. An execution that already exists with the same
+content is skipped as a duplicate; one that exists with different content is
+reported as a conflict and kept as it is, unless --replace is given.
+
+Use --source to tag every imported execution, for example with the name of
+the developer or machine it came from, when merging several histories into
+one database.
+
+Examples:
+  waffles import history.jsonl
+  waffles import alice.sql.gz --source alice
+  waffles import laptop.sqlite --source laptop --dry-run
+  waffles import export.json --replace`,
+	Args: cobra.ExactArgs(1),
+	Run:  importRun,
+}
+
+func importRun(cmd *cobra.Command, args []string) {
+	path := args[0]
+	format, _ := cmd.Flags().GetString("format")
+	source, _ := cmd.Flags().GetString("source")
+	replace, _ := cmd.Flags().GetBool("replace")
+	dryRun, _ := cmd.Flags().GetBool("dry-run")
+	batchSize, _ := cmd.Flags().GetInt("batch-size")
+
+	importFormat := export.ExportFormat(format)
+	if format == "" {
+		detected, err := export.DetectFormat(path)
+		if err != nil {
+			fmt.Printf("❌ %v\n", err)
+			os.Exit(1)
+		}
+		importFormat = detected
+	}
+
+	supported := false
+	names := make([]string, len(export.ImportableFormats))
+	for i, f := range export.ImportableFormats {
+		names[i] = string(f)
+		supported = supported || f == importFormat
+	}
+	if !supported {
+		fmt.Printf("❌ Unsupported import format: %s\n", importFormat)
+		fmt.Printf("Supported formats: %s\n", strings.Join(names, ", "))
+		os.Exit(1)
+	}
+
+	reader, err := export.OpenReader(path, importFormat, batchSize)
+	if err != nil {
+		fmt.Printf("❌ Failed to read %s: %v\n", path, err)
+		os.Exit(1)
+	}
+	defer func() {
+		if closeErr := reader.Close(); closeErr != nil {
+			fmt.Printf("Warning: failed to close import file: %v\n", closeErr)
+		}
+	}()
+
+	db, err := logging.NewDatabase(cfg.LogDBPath)
+	if err != nil {
+		fmt.Printf("❌ Failed to open log database: %v\n", err)
+		os.Exit(1)
+	}
+	defer func() {
+		if closeErr := db.Close(); closeErr != nil {
+			fmt.Printf("Warning: failed to close database: %v\n", closeErr)
+		}
+	}()
+
+	fmt.Printf("📥 Importing %s (%s)\n", path, importFormat)
+
+	opts := logging.ImportOptions{Source: source, Replace: replace, DryRun: dryRun}
+	result := &logging.ImportResult{}
+	for {
+		batch, err := reader.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			fmt.Printf("❌ Failed to read %s: %v\n", path, err)
+			os.Exit(1)
+		}
+
+		if err := db.Import(batch.Executions, batch.Files, batch.Steps, opts, result); err != nil {
+			fmt.Printf("❌ Import failed: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	reportImport(result)
+}
+
+// reportImport prints the outcome of an import, listing conflicts
+func reportImport(result *logging.ImportResult) {
+	if result.DryRun {
+		fmt.Println("🔍 Dry run - nothing was changed")
+		fmt.Printf("Would import %d executions, skip %d duplicates and replace %d\n",
+			result.Imported, result.Duplicates, result.Replaced)
+	} else {
+		fmt.Printf("✅ Imported %d executions, skipped %d duplicates, replaced %d\n",
+			result.Imported, result.Duplicates, result.Replaced)
+	}
+
+	if len(result.Conflicts) == 0 {
+		return
+	}
+
+	fmt.Printf("\n⚠️  %d conflicting executions (same ID, different content):\n", len(result.Conflicts))
+	for i, conflict := range result.Conflicts {
+		if i == maxReportedConflicts {
+			fmt.Printf("  ... and %d more\n", len(result.Conflicts)-maxReportedConflicts)
+			break
+		}
+		action := "kept existing"
+		if conflict.Replaced {
+			action = "replaced"
+		}
+		fmt.Printf("  %s  %s (%s)\n", conflict.ID, strings.Join(conflict.Fields, ", "), action)
+	}
+	if result.Replaced == 0 {
+		fmt.Println("\nUse --replace to overwrite conflicting executions with the imported ones")
+	}
+}
+
+func init() {
+	importCmd.Flags().String("format", "", "Import format (json, jsonl, sql, sqlite; default: detect from extension)")
+	importCmd.Flags().String("source", "", "Tag imported executions with this source")
+	importCmd.Flags().Bool("replace", false, "Replace existing executions that conflict with imported ones")
+	importCmd.Flags().Bool("dry-run", false, "Show what would be imported without changing anything")
+	importCmd.Flags().Int("batch-size", 500, "Executions per import transaction")
+
+	rootCmd.AddCommand(importCmd)
+}
@@ -0,0 +1,85 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/toozej/waffles/internal/metrics"
+	"github.com/toozej/waffles/pkg/config"
+)
+
+var metricsCmd = &cobra.Command{
+	Use:   "metrics",
+	Short: "Print usage metrics in OpenMetrics format",
+	Long: `Print usage metrics computed from the log database in the OpenMetrics
+text format, for scraping by Prometheus or compatible collectors.
+
+Exported metrics:
+  waffles_runs                         runs by model, provider, language and success
+  waffles_step_duration_seconds        histogram of step durations by tool
+  waffles_run_files                    histogram of files processed per run
+  waffles_last_run_timestamp_seconds   time of the most recent run
+
+Values are computed from the database and go down when executions are
+pruned, which Prometheus treats as a reset of the histograms. Token usage is
+not recorded; see llm logs for it.
+
+With --output the metrics are written atomically to a file instead, in the
+Prometheus format read by the node_exporter textfile collector. Set
+WAFFLES_METRICS_TEXTFILE to rewrite that file after every run.
+
+Examples:
+  waffles metrics
+  waffles metrics --format prometheus
+  waffles metrics --output /var/lib/node_exporter/textfile/waffles.prom`,
+	Run: metricsRun,
+}
+
+func metricsRun(cmd *cobra.Command, args []string) {
+	format, _ := cmd.Flags().GetString("format")
+	output, _ := cmd.Flags().GetString("output")
+
+	if !cmd.Flags().Changed("format") && output != "" {
+		format = string(metrics.FormatPrometheus)
+	}
+
+	snapshot, err := metrics.Collect(cfg.LogDBPath)
+	if err != nil {
+		fmt.Printf("❌ Failed to collect metrics: %v\n", err)
+		os.Exit(1)
+	}
+
+	if output != "" {
+		if err := metrics.WriteFile(output, snapshot, metrics.Format(format)); err != nil {
+			fmt.Printf("❌ Failed to write metrics: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("✅ Metrics written to %s\n", output)
+		return
+	}
+
+	if err := metrics.Write(os.Stdout, snapshot, metrics.Format(format)); err != nil {
+		fmt.Printf("❌ Failed to write metrics: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// updateMetricsTextfile rewrites the configured textfile collector output
+// after a run, warning instead of failing since the run itself already completed
+func updateMetricsTextfile(c *config.Config) {
+	snapshot, err := metrics.Collect(c.LogDBPath)
+	if err == nil {
+		err = metrics.WriteFile(c.MetricsTextfile, snapshot, metrics.FormatPrometheus)
+	}
+	if err != nil {
+		fmt.Printf("⚠️  Failed to update metrics textfile: %v\n", err)
+	}
+}
+
+func init() {
+	metricsCmd.Flags().String("format", string(metrics.FormatOpenMetrics), "Output format (openmetrics, prometheus)")
+	metricsCmd.Flags().String("output", "", "Write metrics atomically to this file instead of stdout (default format: prometheus)")
+
+	rootCmd.AddCommand(metricsCmd)
+}
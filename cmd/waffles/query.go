@@ -4,12 +4,13 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
+	"sort"
 	"text/tabwriter"
 	"time"
 
 	"github.com/spf13/cobra"
 	"github.com/toozej/waffles/internal/query"
-	"github.com/toozej/waffles/pkg/config"
+	"github.com/toozej/waffles/pkg/logging"
 )
 
 var queryCmd = &cobra.Command{
@@ -18,18 +19,28 @@ var queryCmd = &cobra.Command{
 	Long: `Query and search through logged LLM conversations and executions.
 
 This command allows you to search through the SQLite database of logged
-conversations, filter by various criteria, and analyze usage patterns.`,
+conversations, filter by various criteria, and analyze usage patterns.
+
+Use --where for filters beyond the dedicated flags. An expression is a list
+of comparisons joined with "and", evaluated in the database:
+
+  success=false and duration>30s
+  model~gpt and files>=10 and age<7d
+  provider!=openai and error~"rate limit"
+
+Operators are = != < <= > >= and ~ (contains). Fields are id, conversation,
+parent, command, query, language, model, provider, source, error, success,
+files, duration, created and age. Durations accept values like 30s or 1m30s
+(bare numbers are milliseconds), created accepts dates and age accepts ages
+like 36h, 7d or 2w. The same expressions work with 'waffles export --where'.
+
+Use --stats to summarize the filtered executions, including p50/p90/p99
+durations per model and step tool, and --compare to compare the period from
+--since to --until (default: the last 7 days) with the period before it.`,
 	Run: queryRun,
 }
 
 func queryRun(cmd *cobra.Command, args []string) {
-	// Load configuration to get database path
-	cfg, err := config.LoadConfig()
-	if err != nil {
-		fmt.Printf("❌ Failed to load configuration: %v\n", err)
-		os.Exit(1)
-	}
-
 	// Create query engine
 	queryEngine, err := query.NewQueryEngine(cfg.LogDBPath)
 	if err != nil {
@@ -48,6 +59,8 @@ func queryRun(cmd *cobra.Command, args []string) {
 	stats, _ := cmd.Flags().GetBool("stats")
 	format, _ := cmd.Flags().GetString("format")
 	success, _ := cmd.Flags().GetString("success")
+	where, _ := cmd.Flags().GetString("where")
+	compare, _ := cmd.Flags().GetBool("compare")
 
 	// Build filters
 	filters := query.QueryFilters{
@@ -98,9 +111,25 @@ func queryRun(cmd *cobra.Command, args []string) {
 		filters.Success = successBool
 	}
 
+	// Parse filter expression
+	if where != "" {
+		conditions, err := query.ParseFilterExpression(where)
+		if err != nil {
+			fmt.Printf("❌ Invalid --where expression: %v\n", err)
+			if closeErr := queryEngine.Close(); closeErr != nil {
+				fmt.Printf("Warning: failed to close query engine: %v\n", closeErr)
+			}
+			os.Exit(1)
+		}
+		filters.Conditions = conditions
+	}
+
 	// Show statistics if requested
-	if stats {
-		showStatistics(queryEngine, filters)
+	if stats || compare {
+		showStatistics(queryEngine, filters, format, compare)
+		if closeErr := queryEngine.Close(); closeErr != nil {
+			fmt.Printf("Warning: failed to close query engine: %v\n", closeErr)
+		}
 		return
 	}
 
@@ -132,8 +161,14 @@ func queryRun(cmd *cobra.Command, args []string) {
 	}
 }
 
-func showStatistics(queryEngine *query.QueryEngine, filters query.QueryFilters) {
-	stats, err := queryEngine.GenerateStatistics(filters)
+func showStatistics(queryEngine *query.QueryEngine, filters query.QueryFilters, format string, compare bool) {
+	var report interface{}
+	var err error
+	if compare {
+		report, err = queryEngine.CompareWithPreviousPeriod(filters)
+	} else {
+		report, err = queryEngine.GenerateStatistics(filters)
+	}
 	if err != nil {
 		fmt.Printf("❌ Failed to generate statistics: %v\n", err)
 		if closeErr := queryEngine.Close(); closeErr != nil {
@@ -142,6 +177,25 @@ func showStatistics(queryEngine *query.QueryEngine, filters query.QueryFilters)
 		os.Exit(1)
 	}
 
+	if format == "json" {
+		data, err := json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			fmt.Printf("❌ Failed to marshal JSON: %v\n", err)
+			return
+		}
+		fmt.Println(string(data))
+		return
+	}
+
+	switch report := report.(type) {
+	case *query.PeriodComparison:
+		showComparison(report)
+	case *query.ExecutionStats:
+		showExecutionStats(report)
+	}
+}
+
+func showExecutionStats(stats *query.ExecutionStats) {
 	fmt.Println("📊 Execution Statistics")
 	fmt.Println("=======================")
 	fmt.Printf("Total Executions: %d\n", stats.TotalExecutions)
@@ -152,33 +206,150 @@ func showStatistics(queryEngine *query.QueryEngine, filters query.QueryFilters)
 	fmt.Printf("Average Files: %.1f\n", stats.AverageFileCount)
 	fmt.Println()
 
-	if len(stats.LanguageBreakdown) > 0 {
-		fmt.Println("Language Breakdown:")
-		for lang, count := range stats.LanguageBreakdown {
-			fmt.Printf("  %s: %d\n", lang, count)
+	showBreakdown("Language Breakdown:", stats.LanguageBreakdown)
+	showBreakdown("Model Usage:", stats.ModelBreakdown)
+	showBreakdown("Provider Usage:", stats.ProviderBreakdown)
+
+	showPercentiles("Duration by Model:", "MODEL", stats.ModelDurations)
+	showPercentiles("Duration by Step Tool:", "TOOL", stats.ToolDurations)
+
+	if len(stats.DailyStats) > 0 {
+		days := make([]string, 0, len(stats.DailyStats))
+		for day := range stats.DailyStats {
+			days = append(days, day)
+		}
+		sort.Sort(sort.Reverse(sort.StringSlice(days)))
+		if len(days) > 10 {
+			days = days[:10]
+		}
+
+		fmt.Println("Daily Usage (Last 10 days):")
+		for _, day := range days {
+			fmt.Printf("  %s: %d\n", day, stats.DailyStats[day])
 		}
 		fmt.Println()
 	}
+}
+
+// showBreakdown prints counts sorted from most to least frequent
+func showBreakdown(title string, counts map[string]int) {
+	if len(counts) == 0 {
+		return
+	}
 
-	if len(stats.ModelBreakdown) > 0 {
-		fmt.Println("Model Usage:")
-		for model, count := range stats.ModelBreakdown {
-			fmt.Printf("  %s: %d\n", model, count)
+	keys := make([]string, 0, len(counts))
+	for key := range counts {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if counts[keys[i]] != counts[keys[j]] {
+			return counts[keys[i]] > counts[keys[j]]
 		}
-		fmt.Println()
+		return keys[i] < keys[j]
+	})
+
+	fmt.Println(title)
+	for _, key := range keys {
+		fmt.Printf("  %s: %d\n", key, counts[key])
 	}
+	fmt.Println()
+}
 
-	if len(stats.DailyStats) > 0 {
-		fmt.Println("Daily Usage (Last 10 days):")
-		count := 0
-		for day, usage := range stats.DailyStats {
-			if count >= 10 {
-				break
-			}
-			fmt.Printf("  %s: %d\n", day, usage)
-			count++
+// showPercentiles prints a table of duration percentiles per group
+func showPercentiles(title, groupHeader string, percentiles []logging.DurationPercentiles) {
+	if len(percentiles) == 0 {
+		return
+	}
+
+	fmt.Println(title)
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintf(w, "  %s\tCOUNT\tAVG\tP50\tP90\tP99\tMAX\n", groupHeader)
+	for _, p := range percentiles {
+		group := p.Group
+		if group == "" {
+			group = "(unknown)"
 		}
-		fmt.Println()
+		_, _ = fmt.Fprintf(w, "  %s\t%d\t%s\t%s\t%s\t%s\t%s\n", group, p.Count,
+			formatMillis(int64(p.Average)), formatMillis(p.P50), formatMillis(p.P90), formatMillis(p.P99), formatMillis(p.Max))
+	}
+	if err := w.Flush(); err != nil {
+		fmt.Printf("Warning: failed to flush table: %v\n", err)
+	}
+	fmt.Println()
+}
+
+// showComparison prints the statistics of a period next to the previous one
+func showComparison(comparison *query.PeriodComparison) {
+	const layout = "2006-01-02 15:04"
+
+	fmt.Println("📈 Trends")
+	fmt.Println("=========")
+	fmt.Printf("Current:  %s → %s\n", comparison.CurrentStart.Format(layout), comparison.CurrentEnd.Format(layout))
+	fmt.Printf("Previous: %s → %s\n", comparison.PreviousStart.Format(layout), comparison.PreviousEnd.Format(layout))
+	fmt.Println()
+
+	labels := map[string]string{
+		"executions":          "Executions",
+		"failed_runs":         "Failed",
+		"success_rate":        "Success Rate",
+		"average_duration_ms": "Average Duration",
+		"average_file_count":  "Average Files",
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(w, "METRIC\tCURRENT\tPREVIOUS\tCHANGE")
+	for _, trend := range comparison.Trends {
+		_, _ = fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", labels[trend.Metric],
+			formatTrendValue(trend.Metric, trend.Current), formatTrendValue(trend.Metric, trend.Previous), formatTrendChange(trend))
+	}
+	if err := w.Flush(); err != nil {
+		fmt.Printf("Warning: failed to flush table: %v\n", err)
+	}
+	fmt.Println()
+
+	showPercentiles("Duration by Model (current period):", "MODEL", comparison.Current.ModelDurations)
+	showPercentiles("Duration by Step Tool (current period):", "TOOL", comparison.Current.ToolDurations)
+}
+
+// formatTrendValue formats a trend metric value for display
+func formatTrendValue(metric string, value float64) string {
+	switch metric {
+	case "success_rate":
+		return fmt.Sprintf("%.1f%%", value)
+	case "average_duration_ms":
+		return formatMillis(int64(value))
+	case "average_file_count":
+		return fmt.Sprintf("%.1f", value)
+	default:
+		return fmt.Sprintf("%.0f", value)
+	}
+}
+
+// formatTrendChange formats the change of a metric; success rates change by
+// percentage points, everything else relative to the previous value
+func formatTrendChange(trend query.Trend) string {
+	switch {
+	case trend.Metric == "success_rate":
+		return fmt.Sprintf("%+.1f pts", trend.Delta)
+	case trend.Previous == 0 && trend.Current == 0:
+		return "-"
+	case trend.Previous == 0:
+		return "new"
+	default:
+		return fmt.Sprintf("%+.1f%%", trend.Change)
+	}
+}
+
+// formatMillis formats a duration in milliseconds, rounded for readability
+func formatMillis(ms int64) string {
+	d := time.Duration(ms) * time.Millisecond
+	switch {
+	case d < time.Second:
+		return fmt.Sprintf("%dms", ms)
+	case d < time.Minute:
+		return d.Round(100 * time.Millisecond).String()
+	default:
+		return d.Round(time.Second).String()
 	}
 }
 
@@ -262,8 +433,10 @@ func init() {
 	queryCmd.Flags().String("language", "", "Filter by detected language")
 	queryCmd.Flags().String("search", "", "Search in prompts and responses")
 	queryCmd.Flags().String("success", "", "Filter by success status (true/false)")
+	queryCmd.Flags().String("where", "", `Filter expression, e.g. "success=false and duration>30s"`)
 	queryCmd.Flags().Int("limit", 10, "Maximum number of results")
 	queryCmd.Flags().Bool("stats", false, "Show usage statistics")
+	queryCmd.Flags().Bool("compare", false, "Compare statistics with the previous period of the same length (default period: last 7 days)")
 	queryCmd.Flags().String("format", "table", "Output format (table, json, csv)")
 
 	// Add to root command
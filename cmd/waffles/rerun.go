@@ -0,0 +1,123 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/toozej/waffles/pkg/logging"
+	"github.com/toozej/waffles/pkg/pipeline"
+	"github.com/toozej/waffles/pkg/repo"
+)
+
+var rerunCmd = &cobra.Command{
+	Use:   "rerun <execution-id>",
+	Short: "Re-run a past execution",
+	Long: `Replay a logged execution with the same inputs.
+
+The prompt query, tool arguments, language and file selection are
+reconstructed from the log database. Use --model or --provider to run the
+same question against a different model. The new run is recorded with a
+link to the original execution so both answers can be compared.
+
+Execution IDs may be abbreviated as long as the prefix is unique.
+
+Examples:
+  waffles rerun 3f2a9c1e
+  waffles rerun 3f2a9c1e --model claude-3-opus`,
+	Args: cobra.ExactArgs(1),
+	Run:  rerunRun,
+}
+
+func rerunRun(cmd *cobra.Command, args []string) {
+	db, err := logging.NewDatabase(cfg.LogDBPath)
+	if err != nil {
+		fmt.Printf("❌ Failed to open log database: %v\n", err)
+		os.Exit(1)
+	}
+
+	parent, files, err := loadReplayInputs(db, args[0])
+	if closeErr := db.Close(); closeErr != nil {
+		fmt.Printf("Warning: failed to close database: %v\n", closeErr)
+	}
+	if err != nil {
+		fmt.Printf("❌ %v\n", err)
+		os.Exit(1)
+	}
+
+	runCfg := *cfg
+	runCfg.DefaultModel = parent.ModelUsed
+	runCfg.DefaultProvider = parent.ProviderUsed
+	runCfg.WheresmypromptArgs = parent.WheresmypromptArgs
+	runCfg.Files2promptArgs = parent.Files2promptArgs
+	runCfg.LLMArgs = parent.LLMArgs
+	runCfg.LanguageOverride = ""
+	if parent.DetectedLanguage != "" && repo.Language(parent.DetectedLanguage) != repo.LanguageUnknown {
+		runCfg.LanguageOverride = parent.DetectedLanguage
+	}
+
+	if cmd.Flags().Changed("model") {
+		runCfg.DefaultModel, _ = cmd.Flags().GetString("model")
+	}
+	if cmd.Flags().Changed("provider") {
+		runCfg.DefaultProvider, _ = cmd.Flags().GetString("provider")
+	}
+
+	fmt.Printf("🧇 Re-running execution %s\n", parent.ID)
+	fmt.Printf("Original: Model=%s, Provider=%s, Created=%s\n",
+		parent.ModelUsed, parent.ProviderUsed, parent.Created.Format("2006-01-02 15:04:05"))
+	fmt.Printf("Re-run:   Model=%s, Provider=%s\n", runCfg.DefaultModel, runCfg.DefaultProvider)
+	fmt.Printf("Prompt query: %s\n", parent.WheresmypromptQuery)
+	fmt.Printf("Files: %d\n", len(files))
+	fmt.Println()
+
+	pipelineInstance := pipeline.NewPipeline(&runCfg)
+	pipelineInstance.Files = files
+
+	execContext, err := pipelineInstance.Execute(parent.WheresmypromptQuery, []string{})
+	succeeded := showExecutionResult(execContext, err)
+	executionID := recordAndReport(pipelineInstance, execContext, parent.ID)
+	fmt.Printf("↩️  Parent execution: %s\n", parent.ID)
+	if executionID != "" {
+		fmt.Printf("💡 Compare the answers with: waffles diff %s %s\n", parent.ID, executionID)
+	}
+
+	if !succeeded {
+		os.Exit(1)
+	}
+}
+
+// loadReplayInputs loads the execution to replay together with the files
+// that were included in its context
+func loadReplayInputs(db *logging.Database, id string) (*logging.WafflesExecution, []string, error) {
+	fullID, err := db.ResolveExecutionID(id)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	exec, err := db.GetExecution(fullID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	loggedFiles, err := db.GetExecutionFiles(fullID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var files []string
+	for _, file := range loggedFiles {
+		if file.Included {
+			files = append(files, file.FilePath)
+		}
+	}
+
+	return exec, files, nil
+}
+
+func init() {
+	rerunCmd.Flags().StringP("model", "m", "", "LLM model to use instead of the original")
+	rerunCmd.Flags().String("provider", "", "LLM provider to use instead of the original")
+
+	rootCmd.AddCommand(rerunCmd)
+}
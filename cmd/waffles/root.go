@@ -33,6 +33,7 @@ import (
 	"github.com/spf13/cobra"
 
 	"github.com/toozej/waffles/pkg/config"
+	"github.com/toozej/waffles/pkg/deps"
 	"github.com/toozej/waffles/pkg/man"
 	"github.com/toozej/waffles/pkg/pipeline"
 	"github.com/toozej/waffles/pkg/version"
@@ -77,50 +78,24 @@ func rootCmdRun(cmd *cobra.Command, args []string) {
 		return
 	}
 
+	if refresh, _ := cmd.Flags().GetBool("refresh-deps"); refresh {
+		if err := deps.ClearCheckCache(); err != nil {
+			fmt.Printf("⚠️  Failed to clear cached dependency checks: %v\n", err)
+		}
+	}
+
 	fmt.Println("🚀 Starting Waffles pipeline execution...")
 	fmt.Println("Pipeline: wheresmyprompt → files2prompt → llm")
 	fmt.Println()
 
 	// Execute pipeline
 	execContext, err := pipelineInstance.Execute(promptQuery, []string{})
+	succeeded := showExecutionResult(execContext, err)
+	recordAndReport(pipelineInstance, execContext, "")
 
-	if err != nil {
-		fmt.Printf("❌ Pipeline execution failed: %v\n", err)
-		fmt.Println()
-
-		// Show execution steps that completed (only if execContext is not nil)
-		if execContext != nil && len(execContext.ExecutionSteps) > 0 {
-			fmt.Println("Steps completed:")
-			for i, step := range execContext.ExecutionSteps {
-				status := "❌"
-				if step.Success {
-					status = "✅"
-				}
-				fmt.Printf("  %d. %s %s (%.2fs)\n", i+1, status, step.Tool, step.Duration.Seconds())
-			}
-		}
-
+	if !succeeded {
 		os.Exit(1)
 	}
-
-	// Show successful execution results
-	fmt.Println("✅ Pipeline completed successfully!")
-	fmt.Printf("⏱️  Total execution time: %.2fs\n", execContext.Duration.Seconds())
-	fmt.Println()
-
-	// Show execution steps
-	fmt.Println("Execution steps:")
-	for i, step := range execContext.ExecutionSteps {
-		fmt.Printf("  %d. ✅ %s (%.2fs)\n", i+1, step.Tool, step.Duration.Seconds())
-		if cfg.Verbose && step.Output != "" {
-			fmt.Printf("     Output: %s\n", truncateOutput(step.Output, 200))
-		}
-	}
-
-	fmt.Println()
-	fmt.Println("🎯 Final Result:")
-	fmt.Println("================")
-	fmt.Println(execContext.FinalOutput)
 }
 
 // truncateOutput truncates output for display
@@ -134,7 +109,9 @@ func truncateOutput(output string, maxLen int) string {
 func rootCmdPreRun(cmd *cobra.Command, args []string) {
 	// Load configuration
 	var err error
-	cfg, err = config.LoadConfig()
+	configFile, _ := cmd.Flags().GetString("config")
+	envFile, _ := cmd.Flags().GetString("env-file")
+	cfg, err = config.Load(config.Options{ConfigFile: configFile, EnvFile: envFile})
 	if err != nil {
 		log.Fatalf("Error loading configuration: %v", err)
 	}
@@ -142,6 +119,14 @@ func rootCmdPreRun(cmd *cobra.Command, args []string) {
 	// Override config with CLI flags
 	overrideConfigWithFlags(cmd)
 
+	// Apply the tools, versions and plugins required by configuration
+	if err := deps.SetRequirements(deps.RequirementsFromConfig(cfg)); err != nil {
+		log.Fatalf("Invalid dependency requirements: %v", err)
+	}
+	if err := deps.SetAssetTemplate(cfg.ReleaseAssetTemplate); err != nil {
+		log.Fatalf("Invalid release asset template: %v", err)
+	}
+
 	// Set debug level if requested
 	debug, _ := cmd.Flags().GetBool("debug")
 	if debug || cfg.Verbose {
@@ -221,6 +206,7 @@ func init() {
 	// Installation and Setup
 	rootCmd.Flags().Bool("install", false, "Auto-install missing dependencies")
 	rootCmd.Flags().Bool("check-deps", false, "Only check dependencies")
+	rootCmd.Flags().Bool("refresh-deps", false, "Check dependencies again instead of using cached results")
 
 	// Output and Logging
 	rootCmd.Flags().StringP("output", "o", "", "Output file (default: stdout)")
@@ -228,9 +214,9 @@ func init() {
 	rootCmd.Flags().BoolP("quiet", "q", false, "Suppress progress output")
 	rootCmd.Flags().BoolP("verbose", "v", false, "Detailed execution logging")
 
-	// Configuration
-	rootCmd.Flags().String("config", "", "Configuration file path (.env)")
-	rootCmd.Flags().String("env-file", "", "Alternative .env file path")
+	// Configuration, loaded before any command runs
+	rootCmd.PersistentFlags().String("config", "", "Configuration file (.env format), overriding the discovered files")
+	rootCmd.PersistentFlags().String("env-file", "", "Additional .env file, overriding --config")
 
 	// add sub-commands
 	rootCmd.AddCommand(
@@ -0,0 +1,119 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/toozej/waffles/internal/dashboard"
+	"github.com/toozej/waffles/internal/query"
+)
+
+var statsCmd = &cobra.Command{
+	Use:   "stats",
+	Short: "Show usage analytics for logged executions",
+	Long: `Show usage analytics for logged executions.
+
+By default the statistics are printed once, like 'waffles query --stats'.
+With --tui an interactive terminal dashboard opens instead, with:
+
+  Overview  sparkline of daily runs and a weekday/hour activity heatmap
+  Models    success rate and p50/p90/p99 latency per model
+  Steps     latency per pipeline step tool and the slowest individual steps
+  Failures  recent failed executions; press enter to see their steps and stderr
+
+The dashboard covers the last 30 days unless --since is given. Press r to
+refresh, or use --refresh to reload periodically.
+
+Examples:
+  waffles stats
+  waffles stats --tui
+  waffles stats --tui --model gpt-4o --refresh 30s
+  waffles stats --tui --where "provider=openai" --since 2024-01-01`,
+	Run: statsRun,
+}
+
+func statsRun(cmd *cobra.Command, args []string) {
+	tui, _ := cmd.Flags().GetBool("tui")
+	refresh, _ := cmd.Flags().GetDuration("refresh")
+	format, _ := cmd.Flags().GetString("format")
+	compare, _ := cmd.Flags().GetBool("compare")
+
+	filters, err := historyFilters(cmd)
+	if err != nil {
+		fmt.Printf("❌ %v\n", err)
+		os.Exit(1)
+	}
+
+	queryEngine, err := query.NewQueryEngine(cfg.LogDBPath)
+	if err != nil {
+		fmt.Printf("❌ Failed to create query engine: %v\n", err)
+		os.Exit(1)
+	}
+
+	if !tui {
+		showStatistics(queryEngine, filters, format, compare)
+		if closeErr := queryEngine.Close(); closeErr != nil {
+			fmt.Printf("Warning: failed to close query engine: %v\n", closeErr)
+		}
+		return
+	}
+
+	err = dashboard.Run(queryEngine, dashboard.Options{Filters: filters, RefreshInterval: refresh})
+	if closeErr := queryEngine.Close(); closeErr != nil {
+		fmt.Printf("Warning: failed to close query engine: %v\n", closeErr)
+	}
+	if err != nil {
+		fmt.Printf("❌ Dashboard failed: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// historyFilters builds query filters from the since, until, model, provider,
+// language, source and where flags of the stats and failures commands
+func historyFilters(cmd *cobra.Command) (query.QueryFilters, error) {
+	since, _ := cmd.Flags().GetString("since")
+	until, _ := cmd.Flags().GetString("until")
+	where, _ := cmd.Flags().GetString("where")
+
+	filters := query.QueryFilters{}
+	filters.Model, _ = cmd.Flags().GetString("model")
+	filters.Provider, _ = cmd.Flags().GetString("provider")
+	filters.Language, _ = cmd.Flags().GetString("language")
+	filters.Source, _ = cmd.Flags().GetString("source")
+
+	var err error
+	if since != "" {
+		if filters.Since, err = query.ParseDateFilter(since); err != nil {
+			return filters, fmt.Errorf("invalid since date: %w", err)
+		}
+	}
+	if until != "" {
+		if filters.Until, err = query.ParseDateFilter(until); err != nil {
+			return filters, fmt.Errorf("invalid until date: %w", err)
+		}
+	}
+	if where != "" {
+		if filters.Conditions, err = query.ParseFilterExpression(where); err != nil {
+			return filters, fmt.Errorf("invalid --where expression: %w", err)
+		}
+	}
+
+	return filters, nil
+}
+
+func init() {
+	statsCmd.Flags().Bool("tui", false, "Open the interactive terminal dashboard")
+	statsCmd.Flags().Duration("refresh", 0, "Reload the dashboard at this interval, e.g. 30s (default: only on r)")
+	statsCmd.Flags().String("since", "", "Only include executions since date (YYYY-MM-DD)")
+	statsCmd.Flags().String("until", "", "Only include executions until date (YYYY-MM-DD)")
+	statsCmd.Flags().String("model", "", "Filter by LLM model")
+	statsCmd.Flags().String("provider", "", "Filter by LLM provider")
+	statsCmd.Flags().String("language", "", "Filter by detected language")
+	statsCmd.Flags().String("source", "", "Filter by import source tag")
+	statsCmd.Flags().String("where", "", `Filter expression, e.g. "success=false and duration>30s"`)
+	statsCmd.Flags().String("format", "text", "Output format without --tui (text, json)")
+	statsCmd.Flags().Bool("compare", false, "Compare with the previous period of the same length (without --tui)")
+
+	rootCmd.AddCommand(statsCmd)
+}
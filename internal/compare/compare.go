@@ -0,0 +1,235 @@
+// Package compare produces side-by-side comparisons of logged executions,
+// covering configuration, file selection, step timings and final answers.
+package compare
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+
+	"github.com/toozej/waffles/pkg/logging"
+)
+
+// ExecutionComparison describes the differences between two executions
+type ExecutionComparison struct {
+	A            *logging.WafflesExecution `json:"a"`
+	B            *logging.WafflesExecution `json:"b"`
+	Config       []FieldComparison         `json:"config"`
+	FilesAdded   []string                  `json:"files_added"`
+	FilesRemoved []string                  `json:"files_removed"`
+	FilesShared  int                       `json:"files_shared"`
+	Steps        []StepComparison          `json:"steps"`
+	OutputA      string                    `json:"output_a"`
+	OutputB      string                    `json:"output_b"`
+	OutputDiff   string                    `json:"output_diff,omitempty"`
+}
+
+// FieldComparison holds the value of one configuration field in both executions
+type FieldComparison struct {
+	Name    string `json:"name"`
+	A       string `json:"a"`
+	B       string `json:"b"`
+	Changed bool   `json:"changed"`
+}
+
+// StepComparison holds the timing and outcome of one pipeline tool in both executions
+type StepComparison struct {
+	Tool        string `json:"tool"`
+	DurationA   int64  `json:"duration_a_ms"`
+	DurationB   int64  `json:"duration_b_ms"`
+	SuccessA    bool   `json:"success_a"`
+	SuccessB    bool   `json:"success_b"`
+	PresentA    bool   `json:"present_a"`
+	PresentB    bool   `json:"present_b"`
+	OutputDiffs bool   `json:"output_differs"`
+}
+
+// DurationDelta returns the change in duration from A to B in milliseconds
+func (s StepComparison) DurationDelta() int64 {
+	return s.DurationB - s.DurationA
+}
+
+// Options controls how executions are compared
+type Options struct {
+	ContextLines int
+}
+
+// DefaultOptions returns the default comparison options
+func DefaultOptions() *Options {
+	return &Options{ContextLines: 3}
+}
+
+// Executions loads two executions (IDs may be abbreviated) and compares them
+func Executions(db *logging.Database, idA, idB string, options *Options) (*ExecutionComparison, error) {
+	if options == nil {
+		options = DefaultOptions()
+	}
+
+	execA, filesA, stepsA, err := loadExecution(db, idA)
+	if err != nil {
+		return nil, err
+	}
+	execB, filesB, stepsB, err := loadExecution(db, idB)
+	if err != nil {
+		return nil, err
+	}
+
+	comparison := &ExecutionComparison{
+		A:       execA,
+		B:       execB,
+		Config:  compareConfig(execA, execB),
+		Steps:   compareSteps(stepsA, stepsB),
+		OutputA: finalOutput(stepsA),
+		OutputB: finalOutput(stepsB),
+	}
+
+	comparison.FilesAdded, comparison.FilesRemoved, comparison.FilesShared = compareFiles(filesA, filesB)
+	comparison.OutputDiff = Unified(comparison.OutputA, comparison.OutputB,
+		"a/"+shortID(execA.ID), "b/"+shortID(execB.ID), options.ContextLines)
+
+	return comparison, nil
+}
+
+// loadExecution loads an execution with its files and steps
+func loadExecution(db *logging.Database, id string) (*logging.WafflesExecution, []logging.WafflesFile, []logging.WafflesStep, error) {
+	fullID, err := db.ResolveExecutionID(id)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	exec, err := db.GetExecution(fullID)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	files, err := db.GetExecutionFiles(fullID)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	steps, err := db.GetExecutionSteps(fullID)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	return exec, files, steps, nil
+}
+
+// compareConfig compares the settings that determine an execution's answer
+func compareConfig(a, b *logging.WafflesExecution) []FieldComparison {
+	fields := []FieldComparison{
+		{Name: "Model", A: a.ModelUsed, B: b.ModelUsed},
+		{Name: "Provider", A: a.ProviderUsed, B: b.ProviderUsed},
+		{Name: "Language", A: a.DetectedLanguage, B: b.DetectedLanguage},
+		{Name: "Query", A: a.WheresmypromptQuery, B: b.WheresmypromptQuery},
+		{Name: "wheresmyprompt args", A: a.WheresmypromptArgs, B: b.WheresmypromptArgs},
+		{Name: "files2prompt args", A: a.Files2promptArgs, B: b.Files2promptArgs},
+		{Name: "llm args", A: a.LLMArgs, B: b.LLMArgs},
+		{Name: "File count", A: strconv.Itoa(a.FileCount), B: strconv.Itoa(b.FileCount)},
+		{Name: "Duration", A: fmt.Sprintf("%dms", a.ExecutionTimeMS), B: fmt.Sprintf("%dms", b.ExecutionTimeMS)},
+		{Name: "Success", A: strconv.FormatBool(a.Success), B: strconv.FormatBool(b.Success)},
+	}
+
+	for i := range fields {
+		fields[i].Changed = fields[i].A != fields[i].B
+	}
+
+	return fields
+}
+
+// compareFiles returns files only included in B, files only included in A and
+// the number of files included in both
+func compareFiles(a, b []logging.WafflesFile) (added, removed []string, shared int) {
+	inA := includedSet(a)
+	inB := includedSet(b)
+
+	for path := range inB {
+		if inA[path] {
+			shared++
+		} else {
+			added = append(added, path)
+		}
+	}
+	for path := range inA {
+		if !inB[path] {
+			removed = append(removed, path)
+		}
+	}
+
+	sort.Strings(added)
+	sort.Strings(removed)
+	return added, removed, shared
+}
+
+// includedSet returns the paths of all files that were included in the context
+func includedSet(files []logging.WafflesFile) map[string]bool {
+	set := make(map[string]bool, len(files))
+	for _, file := range files {
+		if file.Included {
+			set[file.FilePath] = true
+		}
+	}
+	return set
+}
+
+// compareSteps pairs up steps by tool, keeping the pipeline order
+func compareSteps(a, b []logging.WafflesStep) []StepComparison {
+	var order []string
+	byTool := make(map[string]*StepComparison)
+	outputs := make(map[string][2]string)
+
+	get := func(tool string) *StepComparison {
+		if step, exists := byTool[tool]; exists {
+			return step
+		}
+		order = append(order, tool)
+		byTool[tool] = &StepComparison{Tool: tool}
+		return byTool[tool]
+	}
+
+	for _, step := range a {
+		comparison := get(step.Tool)
+		comparison.PresentA = true
+		comparison.DurationA = step.DurationMS
+		comparison.SuccessA = step.Success
+		pair := outputs[step.Tool]
+		pair[0] = step.Output
+		outputs[step.Tool] = pair
+	}
+	for _, step := range b {
+		comparison := get(step.Tool)
+		comparison.PresentB = true
+		comparison.DurationB = step.DurationMS
+		comparison.SuccessB = step.Success
+		pair := outputs[step.Tool]
+		pair[1] = step.Output
+		outputs[step.Tool] = pair
+	}
+
+	steps := make([]StepComparison, 0, len(order))
+	for _, tool := range order {
+		comparison := byTool[tool]
+		comparison.OutputDiffs = outputs[tool][0] != outputs[tool][1]
+		steps = append(steps, *comparison)
+	}
+
+	return steps
+}
+
+// finalOutput returns the answer produced by the llm step, if any
+func finalOutput(steps []logging.WafflesStep) string {
+	for i := len(steps) - 1; i >= 0; i-- {
+		if steps[i].Tool == "llm" && steps[i].Success {
+			return steps[i].Output
+		}
+	}
+	return ""
+}
+
+// shortID abbreviates an execution ID for display
+func shortID(id string) string {
+	if len(id) > 8 {
+		return id[:8]
+	}
+	return id
+}
@@ -0,0 +1,210 @@
+package compare
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// Format represents an output format for comparisons
+type Format string
+
+const (
+	FormatText     Format = "text"
+	FormatMarkdown Format = "markdown"
+)
+
+// Write renders the comparison in the given format
+func (c *ExecutionComparison) Write(format Format, writer io.Writer) error {
+	switch format {
+	case FormatText, "":
+		return c.WriteText(writer)
+	case FormatMarkdown:
+		return c.WriteMarkdown(writer)
+	default:
+		return fmt.Errorf("unsupported diff format: %s", format)
+	}
+}
+
+// WriteText renders the comparison for the terminal
+func (c *ExecutionComparison) WriteText(writer io.Writer) error {
+	fmt.Fprintf(writer, "🔀 Comparing executions\n")
+	fmt.Fprintf(writer, "A: %s (%s)\n", c.A.ID, c.A.Created.Format(time.RFC3339))
+	fmt.Fprintf(writer, "B: %s (%s)\n", c.B.ID, c.B.Created.Format(time.RFC3339))
+	if c.B.ParentExecutionID == c.A.ID {
+		fmt.Fprintf(writer, "B is a re-run of A\n")
+	} else if c.A.ParentExecutionID == c.B.ID {
+		fmt.Fprintf(writer, "A is a re-run of B\n")
+	}
+	fmt.Fprintln(writer)
+
+	fmt.Fprintln(writer, "Configuration:")
+	w := tabwriter.NewWriter(writer, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "  \tField\tA\tB")
+	for _, field := range c.Config {
+		marker := " "
+		if field.Changed {
+			marker = "*"
+		}
+		fmt.Fprintf(w, "  %s\t%s\t%s\t%s\n", marker, field.Name, displayValue(field.A), displayValue(field.B))
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	fmt.Fprintln(writer)
+
+	fmt.Fprintf(writer, "Files: %d shared, %d added, %d removed\n", c.FilesShared, len(c.FilesAdded), len(c.FilesRemoved))
+	for _, path := range c.FilesAdded {
+		fmt.Fprintf(writer, "  + %s\n", path)
+	}
+	for _, path := range c.FilesRemoved {
+		fmt.Fprintf(writer, "  - %s\n", path)
+	}
+	fmt.Fprintln(writer)
+
+	if len(c.Steps) > 0 {
+		fmt.Fprintln(writer, "Steps:")
+		w = tabwriter.NewWriter(writer, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "  Tool\tA\tB\tDelta\tOutput")
+		for _, step := range c.Steps {
+			output := "same"
+			if step.OutputDiffs {
+				output = "differs"
+			}
+			fmt.Fprintf(w, "  %s\t%s\t%s\t%s\t%s\n", step.Tool,
+				stepCell(step.PresentA, step.SuccessA, step.DurationA),
+				stepCell(step.PresentB, step.SuccessB, step.DurationB),
+				formatDelta(step.DurationDelta()), output)
+		}
+		if err := w.Flush(); err != nil {
+			return err
+		}
+		fmt.Fprintln(writer)
+	}
+
+	fmt.Fprintln(writer, "Final output:")
+	if c.OutputDiff == "" {
+		fmt.Fprintln(writer, "  (identical)")
+		return nil
+	}
+	_, err := io.WriteString(writer, c.OutputDiff)
+	return err
+}
+
+// WriteMarkdown renders the comparison as a Markdown report
+func (c *ExecutionComparison) WriteMarkdown(writer io.Writer) error {
+	fmt.Fprintf(writer, "# Execution Comparison\n\n")
+	fmt.Fprintf(writer, "- **A**: `%s` (%s)\n", c.A.ID, c.A.Created.Format(time.RFC3339))
+	fmt.Fprintf(writer, "- **B**: `%s` (%s)\n", c.B.ID, c.B.Created.Format(time.RFC3339))
+	if c.B.ParentExecutionID == c.A.ID {
+		fmt.Fprintf(writer, "- B is a re-run of A\n")
+	} else if c.A.ParentExecutionID == c.B.ID {
+		fmt.Fprintf(writer, "- A is a re-run of B\n")
+	}
+	fmt.Fprintf(writer, "\n")
+
+	fmt.Fprintf(writer, "## Configuration\n\n")
+	fmt.Fprintf(writer, "| Field | A | B | Changed |\n")
+	fmt.Fprintf(writer, "|-------|---|---|---------|\n")
+	for _, field := range c.Config {
+		changed := ""
+		if field.Changed {
+			changed = "✏️"
+		}
+		fmt.Fprintf(writer, "| **%s** | %s | %s | %s |\n", field.Name,
+			markdownCell(field.A), markdownCell(field.B), changed)
+	}
+	fmt.Fprintf(writer, "\n")
+
+	fmt.Fprintf(writer, "## Files\n\n")
+	fmt.Fprintf(writer, "%d shared, %d added, %d removed\n\n", c.FilesShared, len(c.FilesAdded), len(c.FilesRemoved))
+	for _, path := range c.FilesAdded {
+		fmt.Fprintf(writer, "- ➕ `%s`\n", path)
+	}
+	for _, path := range c.FilesRemoved {
+		fmt.Fprintf(writer, "- ➖ `%s`\n", path)
+	}
+	if len(c.FilesAdded)+len(c.FilesRemoved) > 0 {
+		fmt.Fprintf(writer, "\n")
+	}
+
+	if len(c.Steps) > 0 {
+		fmt.Fprintf(writer, "## Steps\n\n")
+		fmt.Fprintf(writer, "| Tool | A | B | Delta | Output |\n")
+		fmt.Fprintf(writer, "|------|---|---|-------|--------|\n")
+		for _, step := range c.Steps {
+			output := "same"
+			if step.OutputDiffs {
+				output = "differs"
+			}
+			fmt.Fprintf(writer, "| %s | %s | %s | %s | %s |\n", step.Tool,
+				stepCell(step.PresentA, step.SuccessA, step.DurationA),
+				stepCell(step.PresentB, step.SuccessB, step.DurationB),
+				formatDelta(step.DurationDelta()), output)
+		}
+		fmt.Fprintf(writer, "\n")
+	}
+
+	fmt.Fprintf(writer, "## Final Output\n\n")
+	if c.OutputDiff == "" {
+		fmt.Fprintf(writer, "The final outputs are identical.\n")
+		return nil
+	}
+	fence := codeFence(c.OutputDiff)
+	fmt.Fprintf(writer, "%sdiff\n%s%s\n", fence, c.OutputDiff, fence)
+	return nil
+}
+
+// codeFence returns a backtick fence one longer than the longest run of
+// backticks in content, and at least three, so that content cannot close it
+func codeFence(content string) string {
+	longest, run := 0, 0
+	for _, r := range content {
+		if r != '`' {
+			run = 0
+			continue
+		}
+		run++
+		longest = max(longest, run)
+	}
+	return strings.Repeat("`", max(3, longest+1))
+}
+
+// stepCell formats a step's outcome and duration
+func stepCell(present, success bool, durationMS int64) string {
+	if !present {
+		return "—"
+	}
+	status := "✅"
+	if !success {
+		status = "❌"
+	}
+	return fmt.Sprintf("%s %dms", status, durationMS)
+}
+
+// formatDelta formats a duration change with an explicit sign
+func formatDelta(deltaMS int64) string {
+	if deltaMS > 0 {
+		return fmt.Sprintf("+%dms", deltaMS)
+	}
+	return fmt.Sprintf("%dms", deltaMS)
+}
+
+// displayValue shows empty values explicitly in terminal output
+func displayValue(value string) string {
+	if value == "" {
+		return "(none)"
+	}
+	return value
+}
+
+// markdownCell escapes a value for use inside a Markdown table cell
+func markdownCell(value string) string {
+	if value == "" {
+		return "_none_"
+	}
+	value = strings.ReplaceAll(value, "|", "\\|")
+	return strings.ReplaceAll(value, "\n", " ")
+}
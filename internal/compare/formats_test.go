@@ -0,0 +1,22 @@
+package compare
+
+import "testing"
+
+func TestCodeFence(t *testing.T) {
+	tests := []struct {
+		content string
+		want    string
+	}{
+		{"", "```"},
+		{"-a\n+b\n", "```"},
+		{"+use `code` here\n", "```"},
+		{"-```go\n+```\n", "````"},
+		{"+`````\n-``\n", "``````"},
+	}
+
+	for _, tt := range tests {
+		if got := codeFence(tt.content); got != tt.want {
+			t.Errorf("codeFence(%q) = %q, expected %q", tt.content, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,239 @@
+package compare
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// diffOp is a single line-level edit: ' ' keeps, '-' removes and '+' adds a line
+type diffOp struct {
+	kind byte
+	text string
+}
+
+// Unified returns a unified diff of two texts with the given number of
+// context lines, or an empty string when the texts are identical
+func Unified(a, b, labelA, labelB string, context int) string {
+	if a == b {
+		return ""
+	}
+	if context < 0 {
+		context = 0
+	}
+
+	ops := diffLines(splitLines(a), splitLines(b))
+
+	// Line offsets in a and b before each op, used for hunk headers
+	aOffset := make([]int, len(ops)+1)
+	bOffset := make([]int, len(ops)+1)
+	for i, op := range ops {
+		aOffset[i+1] = aOffset[i]
+		bOffset[i+1] = bOffset[i]
+		if op.kind != '+' {
+			aOffset[i+1]++
+		}
+		if op.kind != '-' {
+			bOffset[i+1]++
+		}
+	}
+
+	var out strings.Builder
+	fmt.Fprintf(&out, "--- %s\n", labelA)
+	fmt.Fprintf(&out, "+++ %s\n", labelB)
+
+	prevEnd := 0
+	i := 0
+	for i < len(ops) {
+		// Skip to the next change
+		for i < len(ops) && ops[i].kind == ' ' {
+			i++
+		}
+		if i == len(ops) {
+			break
+		}
+
+		start := i - context
+		if start < prevEnd {
+			start = prevEnd
+		}
+
+		// Extend the hunk until the changes are separated by more than
+		// twice the context size
+		end := i
+		for end < len(ops) {
+			if ops[end].kind != ' ' {
+				end++
+				continue
+			}
+			next := end
+			for next < len(ops) && ops[next].kind == ' ' {
+				next++
+			}
+			if next == len(ops) || next-end > 2*context {
+				end += context
+				if end > len(ops) {
+					end = len(ops)
+				}
+				break
+			}
+			end = next
+		}
+
+		aCount := aOffset[end] - aOffset[start]
+		bCount := bOffset[end] - bOffset[start]
+		fmt.Fprintf(&out, "@@ -%s +%s @@\n", hunkRange(aOffset[start], aCount), hunkRange(bOffset[start], bCount))
+		for _, op := range ops[start:end] {
+			out.WriteByte(op.kind)
+			out.WriteString(op.text)
+			out.WriteByte('\n')
+		}
+
+		prevEnd = end
+		i = end
+	}
+
+	return out.String()
+}
+
+// hunkRange formats a unified diff hunk range
+func hunkRange(offset, count int) string {
+	if count == 0 {
+		return fmt.Sprintf("%d,0", offset)
+	}
+	if count == 1 {
+		return fmt.Sprintf("%d", offset+1)
+	}
+	return fmt.Sprintf("%d,%d", offset+1, count)
+}
+
+// diffLines computes a minimal line edit script with Myers' linear-space
+// algorithm, listing the removed lines of each change before the added ones
+func diffLines(a, b []string) []diffOp {
+	ops := appendDiff(make([]diffOp, 0, len(a)+len(b)), a, b)
+
+	// Within each run of changes, removals first
+	for i := 0; i < len(ops); {
+		if ops[i].kind == ' ' {
+			i++
+			continue
+		}
+		end := i
+		for end < len(ops) && ops[end].kind != ' ' {
+			end++
+		}
+		sort.SliceStable(ops[i:end], func(x, y int) bool {
+			return ops[i+x].kind == '-' && ops[i+y].kind == '+'
+		})
+		i = end
+	}
+	return ops
+}
+
+// appendDiff appends the edit script turning a into b to ops, splitting the
+// problem at the middle snake so that memory stays linear in the input
+func appendDiff(ops []diffOp, a, b []string) []diffOp {
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		ops = append(ops, diffOp{kind: ' ', text: a[prefix]})
+		prefix++
+	}
+	a, b = a[prefix:], b[prefix:]
+
+	suffix := 0
+	for suffix < len(a) && suffix < len(b) && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+	common := a[len(a)-suffix:]
+	a, b = a[:len(a)-suffix], b[:len(b)-suffix]
+
+	switch {
+	case len(a) == 0:
+		for _, line := range b {
+			ops = append(ops, diffOp{kind: '+', text: line})
+		}
+	case len(b) == 0:
+		for _, line := range a {
+			ops = append(ops, diffOp{kind: '-', text: line})
+		}
+	default:
+		x, y, u, v := middleSnake(a, b)
+		ops = appendDiff(ops, a[:x], b[:y])
+		for _, line := range a[x:u] {
+			ops = append(ops, diffOp{kind: ' ', text: line})
+		}
+		ops = appendDiff(ops, a[u:], b[v:])
+	}
+
+	for _, line := range common {
+		ops = append(ops, diffOp{kind: ' ', text: line})
+	}
+	return ops
+}
+
+// middleSnake finds the middle snake of a shortest edit script from a to b,
+// the run of equal lines from a[x:u] = b[y:v] where the forward and reverse
+// searches meet. Both a and b must be non-empty.
+func middleSnake(a, b []string) (x, y, u, v int) {
+	n, m := len(a), len(b)
+	delta := n - m
+	odd := delta%2 != 0
+	limit := (n + m + 1) / 2
+	offset := limit + 1
+
+	// forward[k] is the furthest x on diagonal x-y = k from the start, and
+	// reverse[k] the furthest distance from the end on diagonal k counted
+	// from the end, which is diagonal delta-k from the start
+	forward := make([]int, 2*limit+3)
+	reverse := make([]int, 2*limit+3)
+
+	for d := 0; d <= limit; d++ {
+		for k := -d; k <= d; k += 2 {
+			if k == -d || (k != d && forward[offset+k-1] < forward[offset+k+1]) {
+				x = forward[offset+k+1]
+			} else {
+				x = forward[offset+k-1] + 1
+			}
+			y = x - k
+			u, v = x, y
+			for u < n && v < m && a[u] == b[v] {
+				u++
+				v++
+			}
+			forward[offset+k] = u
+			if odd && delta-k >= -(d-1) && delta-k <= d-1 && u+reverse[offset+delta-k] >= n {
+				return x, y, u, v
+			}
+		}
+
+		for k := -d; k <= d; k += 2 {
+			var rx int
+			if k == -d || (k != d && reverse[offset+k-1] < reverse[offset+k+1]) {
+				rx = reverse[offset+k+1]
+			} else {
+				rx = reverse[offset+k-1] + 1
+			}
+			ry := rx - k
+			sx, sy := rx, ry
+			for rx < n && ry < m && a[n-1-rx] == b[m-1-ry] {
+				rx++
+				ry++
+			}
+			reverse[offset+k] = rx
+			if !odd && delta-k >= -d && delta-k <= d && rx+forward[offset+delta-k] >= n {
+				return n - rx, m - ry, n - sx, m - sy
+			}
+		}
+	}
+
+	// Unreachable: the searches always meet within limit steps
+	return 0, 0, 0, 0
+}
+
+// splitLines splits text into lines without a trailing empty line
+func splitLines(text string) []string {
+	if text == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(text, "\n"), "\n")
+}
@@ -0,0 +1,174 @@
+package compare
+
+import (
+	"fmt"
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+// lines joins numbered lines "1".."n" with the given replacements
+func lines(n int, replace map[int]string) string {
+	var b strings.Builder
+	for i := 1; i <= n; i++ {
+		if line, ok := replace[i]; ok {
+			if line != "" {
+				b.WriteString(line + "\n")
+			}
+			continue
+		}
+		fmt.Fprintf(&b, "%d\n", i)
+	}
+	return b.String()
+}
+
+func TestUnified(t *testing.T) {
+	tests := []struct {
+		name    string
+		a, b    string
+		context int
+		want    string
+	}{
+		{
+			name: "identical",
+			a:    "one\ntwo\n",
+			b:    "one\ntwo\n",
+			want: "",
+		},
+		{
+			name: "insert into empty",
+			a:    "",
+			b:    "one\ntwo\n",
+			want: "--- a\n+++ b\n@@ -0,0 +1,2 @@\n+one\n+two\n",
+		},
+		{
+			name: "delete everything",
+			a:    "one\ntwo\n",
+			b:    "",
+			want: "--- a\n+++ b\n@@ -1,2 +0,0 @@\n-one\n-two\n",
+		},
+		{
+			name:    "pure insert",
+			a:       "1\n2\n3\n4\n",
+			b:       "1\n2\nnew\n3\n4\n",
+			context: 1,
+			want:    "--- a\n+++ b\n@@ -2,2 +2,3 @@\n 2\n+new\n 3\n",
+		},
+		{
+			name:    "pure delete",
+			a:       "1\n2\n3\n4\n",
+			b:       "1\n2\n4\n",
+			context: 1,
+			want:    "--- a\n+++ b\n@@ -2,3 +2,2 @@\n 2\n-3\n 4\n",
+		},
+		{
+			name:    "replacement lists removals first",
+			a:       "1\nold\n3\n",
+			b:       "1\nnew\n3\n",
+			context: 0,
+			want:    "--- a\n+++ b\n@@ -2 +2 @@\n-old\n+new\n",
+		},
+		{
+			name:    "adjacent hunks merge within twice the context",
+			a:       lines(12, nil),
+			b:       lines(12, map[int]string{3: "three", 8: "eight"}),
+			context: 2,
+			want:    "--- a\n+++ b\n@@ -1,10 +1,10 @@\n 1\n 2\n-3\n+three\n 4\n 5\n 6\n 7\n-8\n+eight\n 9\n 10\n",
+		},
+		{
+			name:    "distant hunks stay separate",
+			a:       lines(12, nil),
+			b:       lines(12, map[int]string{2: "two", 11: ""}),
+			context: 1,
+			want:    "--- a\n+++ b\n@@ -1,3 +1,3 @@\n 1\n-2\n+two\n 3\n@@ -10,3 +10,2 @@\n 10\n-11\n 12\n",
+		},
+		{
+			name:    "negative context",
+			a:       "1\n2\n3\n",
+			b:       "1\nx\n3\n",
+			context: -1,
+			want:    "--- a\n+++ b\n@@ -2 +2 @@\n-2\n+x\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Unified(tt.a, tt.b, "a", "b", tt.context); got != tt.want {
+				t.Errorf("Expected:\n%s\ngot:\n%s", tt.want, got)
+			}
+		})
+	}
+}
+
+// lcsLength returns the length of the longest common subsequence of a and b
+func lcsLength(a, b []string) int {
+	prev := make([]int, len(b)+1)
+	for i := range a {
+		cur := make([]int, len(b)+1)
+		for j := range b {
+			switch {
+			case a[i] == b[j]:
+				cur[j+1] = prev[j] + 1
+			case prev[j+1] >= cur[j]:
+				cur[j+1] = prev[j+1]
+			default:
+				cur[j+1] = cur[j]
+			}
+		}
+		prev = cur
+	}
+	return prev[len(b)]
+}
+
+func TestDiffLinesMinimal(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	random := func() []string {
+		out := make([]string, rng.Intn(12))
+		for i := range out {
+			out[i] = string(rune('a' + rng.Intn(4)))
+		}
+		return out
+	}
+
+	for i := 0; i < 2000; i++ {
+		a, b := random(), random()
+		ops := diffLines(a, b)
+
+		var gotA, gotB []string
+		edits := 0
+		for _, op := range ops {
+			if op.kind != '+' {
+				gotA = append(gotA, op.text)
+			}
+			if op.kind != '-' {
+				gotB = append(gotB, op.text)
+			}
+			if op.kind != ' ' {
+				edits++
+			}
+		}
+		if strings.Join(gotA, ",") != strings.Join(a, ",") || strings.Join(gotB, ",") != strings.Join(b, ",") {
+			t.Fatalf("Edit script of %v -> %v does not reproduce the inputs: %v", a, b, ops)
+		}
+		if want := len(a) + len(b) - 2*lcsLength(a, b); edits != want {
+			t.Fatalf("Expected %d edits for %v -> %v, got %d: %v", want, a, b, edits, ops)
+		}
+	}
+}
+
+func TestDiffLinesLargeInput(t *testing.T) {
+	// An LCS table for these would take 20000 * 20000 ints
+	a := strings.Split(lines(20000, nil), "\n")
+	b := strings.Split(lines(20000, map[int]string{10: "x", 15000: "y"}), "\n")
+	ops := diffLines(a, b)
+
+	edits := 0
+	for _, op := range ops {
+		if op.kind != ' ' {
+			edits++
+		}
+	}
+	if edits != 4 {
+		t.Errorf("Expected 4 edits, got %d", edits)
+	}
+}
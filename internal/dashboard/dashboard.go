@@ -0,0 +1,255 @@
+// Package dashboard implements an interactive terminal dashboard for the
+// usage analytics of logged executions, backed by the query engine.
+package dashboard
+
+import (
+	"fmt"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/toozej/waffles/internal/query"
+	"github.com/toozej/waffles/pkg/logging"
+)
+
+// DefaultPeriod is the period shown when the filters have no start date
+const DefaultPeriod = 30 * 24 * time.Hour
+
+// Options configures the dashboard
+type Options struct {
+	// Filters select the executions to analyze. Without Since the dashboard
+	// shows the last Period, moving along with each refresh.
+	Filters query.QueryFilters
+	Period  time.Duration
+	// RefreshInterval reloads the data periodically; zero refreshes on demand only
+	RefreshInterval time.Duration
+	// FailureLimit is the number of recent failures listed
+	FailureLimit int
+	// SlowStepLimit is the number of slowest steps listed
+	SlowStepLimit int
+}
+
+// Run shows the dashboard until the user quits
+func Run(engine *query.QueryEngine, opts Options) error {
+	if opts.Period <= 0 {
+		opts.Period = DefaultPeriod
+	}
+	if opts.FailureLimit <= 0 {
+		opts.FailureLimit = 50
+	}
+	if opts.SlowStepLimit <= 0 {
+		opts.SlowStepLimit = 15
+	}
+
+	_, err := tea.NewProgram(newModel(engine, opts), tea.WithAltScreen()).Run()
+	return err
+}
+
+// tab identifies a dashboard view
+type tab int
+
+const (
+	tabOverview tab = iota
+	tabModels
+	tabSteps
+	tabFailures
+)
+
+var tabNames = []string{"Overview", "Models", "Steps", "Failures"}
+
+// snapshot is one load of all dashboard data
+type snapshot struct {
+	since, until time.Time
+	stats        *query.ExecutionStats
+	slowSteps    []logging.WafflesStep
+	failures     []logging.WafflesExecution
+	loadedAt     time.Time
+}
+
+// failureDetail is a failed execution with its steps, shown on drill-down
+type failureDetail struct {
+	exec  logging.WafflesExecution
+	steps []logging.WafflesStep
+}
+
+type (
+	loadedMsg struct {
+		data *snapshot
+		err  error
+	}
+	detailMsg struct {
+		detail *failureDetail
+		err    error
+	}
+	tickMsg time.Time
+)
+
+// model is the bubbletea model of the dashboard
+type model struct {
+	engine *query.QueryEngine
+	opts   Options
+
+	width, height int
+	tab           tab
+	data          *snapshot
+	err           error
+	loading       bool
+
+	cursor int            // Selected failure
+	detail *failureDetail // Failure being drilled into, nil in the list
+	scroll int            // First line shown of the failure detail
+}
+
+func newModel(engine *query.QueryEngine, opts Options) *model {
+	return &model{engine: engine, opts: opts, loading: true}
+}
+
+func (m *model) Init() tea.Cmd {
+	return tea.Batch(m.load(), m.tick())
+}
+
+// load reads all dashboard data in the background
+func (m *model) load() tea.Cmd {
+	engine, opts := m.engine, m.opts
+	return func() tea.Msg {
+		data, err := loadSnapshot(engine, opts)
+		return loadedMsg{data: data, err: err}
+	}
+}
+
+// tick schedules the next automatic refresh
+func (m *model) tick() tea.Cmd {
+	if m.opts.RefreshInterval <= 0 {
+		return nil
+	}
+	return tea.Tick(m.opts.RefreshInterval, func(t time.Time) tea.Msg { return tickMsg(t) })
+}
+
+// loadDetail reads the steps of the selected failure in the background
+func (m *model) loadDetail(exec logging.WafflesExecution) tea.Cmd {
+	engine := m.engine
+	return func() tea.Msg {
+		steps, err := engine.GetExecutionSteps([]string{exec.ID})
+		if err != nil {
+			return detailMsg{err: fmt.Errorf("failed to load steps of %s: %w", exec.ID, err)}
+		}
+		return detailMsg{detail: &failureDetail{exec: exec, steps: steps[exec.ID]}}
+	}
+}
+
+func loadSnapshot(engine *query.QueryEngine, opts Options) (*snapshot, error) {
+	filters := opts.Filters
+	filters.Limit, filters.Offset = 0, 0
+
+	data := &snapshot{until: time.Now(), loadedAt: time.Now()}
+	if filters.Until != nil {
+		data.until = *filters.Until
+	}
+	data.since = data.until.Add(-opts.Period)
+	if filters.Since != nil {
+		data.since = *filters.Since
+	}
+	filters.Since = &data.since
+
+	var err error
+	if data.stats, err = engine.GenerateStatistics(filters); err != nil {
+		return nil, err
+	}
+	if data.slowSteps, err = engine.SlowestSteps(filters, opts.SlowStepLimit); err != nil {
+		return nil, fmt.Errorf("failed to load slowest steps: %w", err)
+	}
+
+	failed := false
+	filters.Success = &failed
+	filters.Limit = opts.FailureLimit
+	result, err := engine.QueryExecutions(filters)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load recent failures: %w", err)
+	}
+	data.failures = result.Executions
+
+	return data, nil
+}
+
+func (m *model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width, m.height = msg.Width, msg.Height
+
+	case loadedMsg:
+		m.loading = false
+		m.err = msg.err
+		if msg.err == nil {
+			m.data = msg.data
+			m.cursor = min(m.cursor, max(len(m.data.failures)-1, 0))
+		}
+
+	case detailMsg:
+		m.err = msg.err
+		m.detail = msg.detail
+		m.scroll = 0
+
+	case tickMsg:
+		cmds := []tea.Cmd{m.tick()}
+		if !m.loading {
+			m.loading = true
+			cmds = append(cmds, m.load())
+		}
+		return m, tea.Batch(cmds...)
+
+	case tea.KeyMsg:
+		return m.handleKey(msg)
+	}
+
+	return m, nil
+}
+
+func (m *model) handleKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "q", "ctrl+c":
+		return m, tea.Quit
+	case "r":
+		if !m.loading {
+			m.loading = true
+			return m, m.load()
+		}
+	case "tab", "right", "l":
+		m.switchTab((m.tab + 1) % tab(len(tabNames)))
+	case "shift+tab", "left", "h":
+		m.switchTab((m.tab + tab(len(tabNames)) - 1) % tab(len(tabNames)))
+	case "1", "2", "3", "4":
+		m.switchTab(tab(msg.String()[0] - '1'))
+	case "esc", "backspace":
+		m.detail = nil
+	case "up", "k":
+		m.move(-1)
+	case "down", "j":
+		m.move(1)
+	case "pgup":
+		m.move(-10)
+	case "pgdown":
+		m.move(10)
+	case "enter":
+		if m.tab == tabFailures && m.detail == nil && m.data != nil && m.cursor < len(m.data.failures) {
+			return m, m.loadDetail(m.data.failures[m.cursor])
+		}
+	}
+
+	return m, nil
+}
+
+func (m *model) switchTab(t tab) {
+	m.tab = t
+	m.detail = nil
+}
+
+// move moves the failure selection, or scrolls the failure detail
+func (m *model) move(delta int) {
+	if m.tab != tabFailures || m.data == nil {
+		return
+	}
+	if m.detail != nil {
+		m.scroll = max(m.scroll+delta, 0)
+		return
+	}
+	m.cursor = min(max(m.cursor+delta, 0), max(len(m.data.failures)-1, 0))
+}
@@ -0,0 +1,395 @@
+package dashboard
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+	"github.com/toozej/waffles/pkg/logging"
+)
+
+var (
+	titleStyle    = lipgloss.NewStyle().Bold(true)
+	activeTab     = lipgloss.NewStyle().Bold(true).Reverse(true).Padding(0, 1)
+	inactiveTab   = lipgloss.NewStyle().Faint(true).Padding(0, 1)
+	headingStyle  = lipgloss.NewStyle().Bold(true).Underline(true)
+	faintStyle    = lipgloss.NewStyle().Faint(true)
+	errorStyle    = lipgloss.NewStyle().Foreground(lipgloss.Color("1"))
+	selectedStyle = lipgloss.NewStyle().Reverse(true)
+)
+
+// sparkBlocks are the sparkline levels, lowest first
+var sparkBlocks = []rune("▁▂▃▄▅▆▇█")
+
+// heatShades are the heatmap levels, empty first
+var heatShades = []string{"  ", "░░", "▒▒", "▓▓", "██"}
+
+func (m *model) View() string {
+	width := m.width
+	if width <= 0 {
+		width = 80
+	}
+
+	var header strings.Builder
+	header.WriteString(titleStyle.Render("🧇 waffles stats"))
+	if m.data != nil {
+		header.WriteString(faintStyle.Render(fmt.Sprintf("  %s → %s  · updated %s",
+			m.data.since.Format("2006-01-02"), m.data.until.Format("2006-01-02"), m.data.loadedAt.Format("15:04:05"))))
+	}
+	if m.loading {
+		header.WriteString(faintStyle.Render("  · loading…"))
+	}
+	header.WriteString("\n")
+	for i, name := range tabNames {
+		label := fmt.Sprintf("%d %s", i+1, name)
+		if tab(i) == m.tab {
+			header.WriteString(activeTab.Render(label))
+		} else {
+			header.WriteString(inactiveTab.Render(label))
+		}
+	}
+	header.WriteString("\n")
+
+	var body string
+	switch {
+	case m.data == nil && m.err != nil:
+		body = errorStyle.Render("Error: " + m.err.Error())
+	case m.data == nil:
+		body = "Loading statistics…"
+	default:
+		switch m.tab {
+		case tabOverview:
+			body = m.overview(width)
+		case tabModels:
+			body = m.models()
+		case tabSteps:
+			body = m.steps()
+		case tabFailures:
+			if m.detail != nil {
+				body = m.failureDetail(width)
+			} else {
+				body = m.failureList(width)
+			}
+		}
+		if m.err != nil {
+			body = errorStyle.Render("Error: "+m.err.Error()) + "\n" + body
+		}
+	}
+
+	help := "tab/1-4 switch · r refresh · q quit"
+	if m.tab == tabFailures {
+		if m.detail != nil {
+			help = "↑/↓ scroll · esc back · " + help
+		} else {
+			help = "↑/↓ select · enter details · " + help
+		}
+	}
+	footer := faintStyle.Render(truncate(help, width))
+
+	// Keep the header and footer on screen, cutting the body to fit
+	lines := strings.Split(strings.TrimRight(body, "\n"), "\n")
+	for i, line := range lines {
+		lines[i] = truncate(line, width)
+	}
+	if m.tab == tabFailures && m.detail != nil {
+		m.scroll = min(m.scroll, max(len(lines)-1, 0))
+		lines = lines[m.scroll:]
+	}
+	if m.height > 0 {
+		available := max(m.height-4, 1)
+		if len(lines) > available {
+			lines = lines[:available]
+		}
+	}
+
+	return header.String() + "\n" + strings.Join(lines, "\n") + "\n\n" + footer
+}
+
+// overview renders the summary, daily sparkline and weekday/hour heatmap
+func (m *model) overview(width int) string {
+	stats := m.data.stats
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "Runs %d · Failed %d · Success %.1f%% · Avg %s · Avg files %.1f\n\n",
+		stats.TotalExecutions, stats.FailedRuns, stats.SuccessRate,
+		formatMillis(int64(stats.AverageDuration)), stats.AverageFileCount)
+
+	// One value per day of the period, including days without runs
+	var days []string
+	var counts []int
+	for day := m.data.since; !day.After(m.data.until); day = day.AddDate(0, 0, 1) {
+		key := day.Format("2006-01-02")
+		days = append(days, key)
+		counts = append(counts, stats.DailyStats[key])
+	}
+	if limit := max(width-2, 10); len(counts) > limit {
+		days, counts = days[len(days)-limit:], counts[len(counts)-limit:]
+	}
+
+	b.WriteString(headingStyle.Render("Daily runs") + "\n")
+	if len(counts) > 0 {
+		peak := maxInt(counts)
+		b.WriteString(sparkline(counts, peak) + "\n")
+		b.WriteString(faintStyle.Render(fmt.Sprintf("%s → %s, peak %d/day", days[0], days[len(days)-1], peak)) + "\n\n")
+	}
+
+	b.WriteString(headingStyle.Render("Activity by weekday and hour") + "\n")
+	b.WriteString(heatmap(stats.WeekdayHourly))
+
+	return b.String()
+}
+
+// models renders success rates and latency percentiles per model
+func (m *model) models() string {
+	durations := m.data.stats.ModelDurations
+	if len(durations) == 0 {
+		return "No executions in this period."
+	}
+
+	var b strings.Builder
+	b.WriteString(headingStyle.Render("Models") + "\n")
+	w := tabwriter.NewWriter(&b, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(w, "MODEL\tRUNS\tSUCCESS\t\tAVG\tP50\tP90\tP99")
+	for _, p := range durations {
+		rate := float64(p.Count-p.Failed) / float64(p.Count)
+		_, _ = fmt.Fprintf(w, "%s\t%d\t%5.1f%%\t%s\t%s\t%s\t%s\t%s\n", groupName(p.Group), p.Count, rate*100, bar(rate, 10),
+			formatMillis(int64(p.Average)), formatMillis(p.P50), formatMillis(p.P90), formatMillis(p.P99))
+	}
+	_ = w.Flush() // Writing to a strings.Builder cannot fail
+
+	return b.String()
+}
+
+// steps renders latency per step tool and the slowest individual steps
+func (m *model) steps() string {
+	var b strings.Builder
+
+	tools := append([]logging.DurationPercentiles(nil), m.data.stats.ToolDurations...)
+	sort.SliceStable(tools, func(i, j int) bool { return tools[i].P90 > tools[j].P90 })
+
+	b.WriteString(headingStyle.Render("Latency by step tool") + "\n")
+	if len(tools) == 0 {
+		b.WriteString("No steps recorded in this period.\n")
+	} else {
+		w := tabwriter.NewWriter(&b, 0, 0, 2, ' ', 0)
+		_, _ = fmt.Fprintln(w, "TOOL\tRUNS\tFAILED\tAVG\tP50\tP90\tP99\tMAX")
+		for _, p := range tools {
+			_, _ = fmt.Fprintf(w, "%s\t%d\t%d\t%s\t%s\t%s\t%s\t%s\n", groupName(p.Group), p.Count, p.Failed,
+				formatMillis(int64(p.Average)), formatMillis(p.P50), formatMillis(p.P90), formatMillis(p.P99), formatMillis(p.Max))
+		}
+		_ = w.Flush()
+	}
+
+	b.WriteString("\n" + headingStyle.Render("Slowest steps") + "\n")
+	if len(m.data.slowSteps) == 0 {
+		b.WriteString("No steps recorded in this period.\n")
+		return b.String()
+	}
+	w := tabwriter.NewWriter(&b, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(w, "DURATION\tTOOL\tEXECUTION\tWHEN\tSTATUS")
+	for _, step := range m.data.slowSteps {
+		_, _ = fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", formatMillis(step.DurationMS), step.Tool, shortID(step.ExecutionID),
+			step.Created.Local().Format("01-02 15:04"), status(step.Success))
+	}
+	_ = w.Flush()
+
+	return b.String()
+}
+
+// failureList renders the recent failures with the selection highlighted
+func (m *model) failureList(width int) string {
+	failures := m.data.failures
+	if len(failures) == 0 {
+		return "No failed executions in this period. 🎉"
+	}
+
+	var b strings.Builder
+	b.WriteString(headingStyle.Render(fmt.Sprintf("Recent failures (%d)", len(failures))) + "\n")
+
+	// Keep the selection visible on short terminals
+	visible := len(failures)
+	if m.height > 0 {
+		visible = max(m.height-7, 1)
+	}
+	start := max(min(m.cursor-visible/2, len(failures)-visible), 0)
+
+	for i := start; i < len(failures) && i < start+visible; i++ {
+		exec := failures[i]
+		line := fmt.Sprintf("%s  %-8s  %-20s  %s", exec.Created.Local().Format("01-02 15:04"), shortID(exec.ID),
+			truncate(groupName(exec.ModelUsed), 20), firstLine(exec.ErrorMessage))
+		line = truncate(line, width-2)
+		if i == m.cursor {
+			b.WriteString(selectedStyle.Render("> "+line) + "\n")
+		} else {
+			b.WriteString("  " + line + "\n")
+		}
+	}
+
+	return b.String()
+}
+
+// failureDetail renders a failed execution with the output of its steps
+func (m *model) failureDetail(width int) string {
+	exec := m.detail.exec
+	var b strings.Builder
+
+	b.WriteString(headingStyle.Render("Execution "+exec.ID) + "\n")
+	fmt.Fprintf(&b, "Created:  %s\n", exec.Created.Local().Format("2006-01-02 15:04:05"))
+	fmt.Fprintf(&b, "Model:    %s (%s)\n", groupName(exec.ModelUsed), groupName(exec.ProviderUsed))
+	fmt.Fprintf(&b, "Duration: %s, %d files\n", formatMillis(exec.ExecutionTimeMS), exec.FileCount)
+	if exec.CommandArgs != "" {
+		fmt.Fprintf(&b, "Command:  waffles %s\n", exec.CommandArgs)
+	}
+	if exec.ErrorMessage != "" {
+		b.WriteString("\n" + errorStyle.Render("Error") + "\n")
+		b.WriteString(indent(wrap(exec.ErrorMessage, width-2), "  ") + "\n")
+	}
+
+	b.WriteString("\n" + headingStyle.Render("Steps") + "\n")
+	if len(m.detail.steps) == 0 {
+		b.WriteString("No steps recorded.\n")
+	}
+	for _, step := range m.detail.steps {
+		fmt.Fprintf(&b, "%s %s (%s)\n", status(step.Success), step.Tool, formatMillis(step.DurationMS))
+		if step.Command != "" {
+			b.WriteString(faintStyle.Render(truncate("  $ "+step.Command, width)) + "\n")
+		}
+		if stderr := strings.TrimSpace(step.ErrorOutput); stderr != "" {
+			b.WriteString(indent(wrap(stderr, width-4), "    ") + "\n")
+		}
+	}
+
+	return b.String()
+}
+
+// sparkline renders one block per value, scaled to peak
+func sparkline(values []int, peak int) string {
+	var b strings.Builder
+	for _, v := range values {
+		if peak == 0 || v == 0 {
+			b.WriteRune(' ')
+			continue
+		}
+		level := (v*len(sparkBlocks) - 1) / peak
+		b.WriteRune(sparkBlocks[min(level, len(sparkBlocks)-1)])
+	}
+	return b.String()
+}
+
+// heatmap renders executions per weekday and hour, Monday first
+func heatmap(counts [7][24]int) string {
+	peak := 0
+	for _, row := range counts {
+		peak = max(peak, maxInt(row[:]))
+	}
+
+	var b strings.Builder
+	b.WriteString("     ")
+	for hour := 0; hour < 24; hour += 3 {
+		fmt.Fprintf(&b, "%-6s", fmt.Sprintf("%02d", hour))
+	}
+	b.WriteString("\n")
+
+	for i := 1; i <= 7; i++ {
+		day := time.Weekday(i % 7)
+		b.WriteString(day.String()[:3] + "  ")
+		for _, count := range counts[day] {
+			level := 0
+			if count > 0 {
+				level = 1 + (count*(len(heatShades)-1)-1)/peak
+			}
+			b.WriteString(heatShades[level])
+		}
+		b.WriteString("\n")
+	}
+	b.WriteString(faintStyle.Render(fmt.Sprintf("     %s fewest  %s most (%d runs)", heatShades[1], heatShades[len(heatShades)-1], peak)) + "\n")
+
+	return b.String()
+}
+
+// bar renders a horizontal bar filled to ratio
+func bar(ratio float64, width int) string {
+	filled := int(ratio*float64(width) + 0.5)
+	return strings.Repeat("█", filled) + strings.Repeat("░", width-filled)
+}
+
+func maxInt(values []int) int {
+	peak := 0
+	for _, v := range values {
+		peak = max(peak, v)
+	}
+	return peak
+}
+
+func status(success bool) string {
+	if success {
+		return "✓"
+	}
+	return "✗"
+}
+
+// groupName shows missing models, providers and tools as "(unknown)"
+func groupName(name string) string {
+	if name == "" {
+		return "(unknown)"
+	}
+	return name
+}
+
+// shortID returns the first eight characters of an ID
+func shortID(id string) string {
+	if len(id) > 8 {
+		return id[:8]
+	}
+	return id
+}
+
+func firstLine(s string) string {
+	line, _, _ := strings.Cut(strings.TrimSpace(s), "\n")
+	return line
+}
+
+// truncate cuts a possibly styled line to width cells, marking the cut with
+// an ellipsis
+func truncate(s string, width int) string {
+	if width <= 0 {
+		return s
+	}
+	return ansi.Truncate(s, width, "…")
+}
+
+// wrap breaks text into lines of at most width runes
+func wrap(text string, width int) string {
+	width = max(width, 20)
+	var lines []string
+	for _, line := range strings.Split(text, "\n") {
+		runes := []rune(line)
+		for len(runes) > width {
+			lines = append(lines, string(runes[:width]))
+			runes = runes[width:]
+		}
+		lines = append(lines, string(runes))
+	}
+	return strings.Join(lines, "\n")
+}
+
+func indent(text, prefix string) string {
+	return prefix + strings.ReplaceAll(text, "\n", "\n"+prefix)
+}
+
+// formatMillis formats a duration in milliseconds, rounded for readability
+func formatMillis(ms int64) string {
+	d := time.Duration(ms) * time.Millisecond
+	switch {
+	case d < time.Second:
+		return fmt.Sprintf("%dms", ms)
+	case d < time.Minute:
+		return d.Round(100 * time.Millisecond).String()
+	default:
+		return d.Round(time.Second).String()
+	}
+}
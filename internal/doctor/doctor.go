@@ -0,0 +1,292 @@
+// Package doctor examines a waffles installation as a whole: the host, the
+// pipeline tools and llm plugins, the configuration, the log database and
+// provider API keys. Every problem found comes with a suggested fix.
+package doctor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/toozej/waffles/pkg/config"
+	"github.com/toozej/waffles/pkg/deps"
+	"github.com/toozej/waffles/pkg/logging"
+	"github.com/toozej/waffles/pkg/version"
+)
+
+// keysTimeout bounds how long listing the keys stored by llm may take
+const keysTimeout = 10 * time.Second
+
+// Severity tells whether a problem breaks the pipeline or only degrades it
+type Severity string
+
+const (
+	SeverityError   Severity = "error"
+	SeverityWarning Severity = "warning"
+)
+
+// Suggestion is a problem found by the doctor and how to fix it
+type Suggestion struct {
+	Severity  Severity `json:"severity"`
+	Component string   `json:"component"`
+	Problem   string   `json:"problem"`
+	Fix       string   `json:"fix"`
+}
+
+// ConfigReport describes where the configuration came from
+type ConfigReport struct {
+	Sources         []string `json:"sources"` // .env files loaded, lowest precedence first
+	DefaultProvider string   `json:"default_provider"`
+	DefaultModel    string   `json:"default_model"`
+	LogDBPath       string   `json:"log_db_path"`
+}
+
+// DatabaseReport describes the health of the log database
+type DatabaseReport struct {
+	Path            string   `json:"path"`
+	Exists          bool     `json:"exists"`
+	SizeBytes       int64    `json:"size_bytes"`
+	SchemaVersion   int      `json:"schema_version"`
+	LatestVersion   int      `json:"latest_version"`
+	IntegrityErrors []string `json:"integrity_errors,omitempty"`
+	OrphanedFiles   int      `json:"orphaned_files"`
+	OrphanedSteps   int      `json:"orphaned_steps"`
+	Error           string   `json:"error,omitempty"`
+}
+
+// ProviderReport tells whether an API key is available for a provider,
+// without revealing it
+type ProviderReport struct {
+	Provider string `json:"provider"`
+	Default  bool   `json:"default"`
+	Present  bool   `json:"present"`
+	Source   string `json:"source,omitempty"` // Environment variable or "llm keys"
+}
+
+// Report is the result of examining an installation
+type Report struct {
+	OK           bool                    `json:"ok"`
+	Version      string                  `json:"version"`
+	GeneratedAt  time.Time               `json:"generated_at"`
+	System       *deps.SystemInfo        `json:"system"`
+	Dependencies []deps.DependencyStatus `json:"dependencies"`
+	Plugins      []deps.PluginStatus     `json:"plugins"`
+	Config       ConfigReport            `json:"config"`
+	Database     DatabaseReport          `json:"database"`
+	Providers    []ProviderReport        `json:"providers"`
+	Suggestions  []Suggestion            `json:"suggestions"`
+}
+
+// Errors returns the number of suggestions with error severity
+func (r *Report) Errors() int {
+	count := 0
+	for _, suggestion := range r.Suggestions {
+		if suggestion.Severity == SeverityError {
+			count++
+		}
+	}
+	return count
+}
+
+// provider describes where llm looks for the API key of a provider
+type provider struct {
+	name    string
+	keys    []string // Names of keys stored with 'llm keys set'
+	envVars []string
+}
+
+// providers lists the providers that need an API key, ollama runs locally
+var providers = []provider{
+	{name: "anthropic", keys: []string{"anthropic", "claude"}, envVars: []string{"ANTHROPIC_API_KEY"}},
+	{name: "openai", keys: []string{"openai"}, envVars: []string{"OPENAI_API_KEY"}},
+	{name: "google", keys: []string{"gemini", "google"}, envVars: []string{"LLM_GEMINI_KEY", "GOOGLE_API_KEY"}},
+}
+
+// Examine checks the installation described by cfg
+func Examine(cfg *config.Config) (*Report, error) {
+	report := &Report{
+		GeneratedAt: time.Now().UTC(),
+		System:      deps.GetSystemInfo(),
+		Plugins:     []deps.PluginStatus{},
+		Suggestions: []Suggestion{},
+		Config: ConfigReport{
+			Sources:         append([]string{}, cfg.Sources...),
+			DefaultProvider: cfg.DefaultProvider,
+			DefaultModel:    cfg.DefaultModel,
+			LogDBPath:       cfg.LogDBPath,
+		},
+	}
+	if info, err := version.Get(); err == nil {
+		report.Version = info.Version
+	}
+
+	statuses, err := deps.CheckAllDependencies()
+	if err != nil {
+		return nil, fmt.Errorf("failed to check dependencies: %w", err)
+	}
+	report.Dependencies = statuses
+	report.examineDependencies()
+	report.examineDatabase(cfg.LogDBPath)
+	report.examineProviders(cfg.DefaultProvider)
+
+	report.OK = report.Errors() == 0
+	return report, nil
+}
+
+// suggest records a problem and its fix
+func (r *Report) suggest(severity Severity, component, problem, fix string) {
+	r.Suggestions = append(r.Suggestions, Suggestion{
+		Severity:  severity,
+		Component: component,
+		Problem:   problem,
+		Fix:       fix,
+	})
+}
+
+// examineDependencies suggests fixes for missing or unsuitable tools and
+// missing llm plugins
+func (r *Report) examineDependencies() {
+	for _, status := range r.Dependencies {
+		switch {
+		case !status.Installed:
+			r.suggest(SeverityError, status.Name, status.Error, "Run 'waffles deps install'")
+		case !status.Valid:
+			r.suggest(SeverityError, status.Name, status.Error,
+				fmt.Sprintf("Run 'waffles deps install' for a supported version, or relax WAFFLES_%s_VERSION", strings.ToUpper(status.Name)))
+		case status.Error != "":
+			r.suggest(SeverityWarning, status.Name, status.Error, "Run 'waffles deps check --refresh' for details")
+		}
+
+		for _, plugin := range status.Plugins {
+			r.Plugins = append(r.Plugins, plugin)
+			if !plugin.Installed {
+				r.suggest(SeverityError, plugin.Name, plugin.Error, fmt.Sprintf("Run 'llm install %s'", plugin.Name))
+			}
+		}
+	}
+}
+
+// examineDatabase checks the schema version and integrity of the log
+// database, which is not created if missing
+func (r *Report) examineDatabase(path string) {
+	r.Database = DatabaseReport{Path: path, LatestVersion: logging.GetCurrentSchemaVersion()}
+
+	if _, err := os.Stat(path); errors.Is(err, os.ErrNotExist) {
+		// Created on the first pipeline run
+		return
+	}
+	r.Database.Exists = true
+
+	db, err := logging.OpenDatabase(path)
+	if err != nil {
+		r.databaseError(err)
+		return
+	}
+	defer func() { _ = db.Close() }()
+
+	status, err := db.Status()
+	if err != nil {
+		r.databaseError(err)
+		return
+	}
+	r.Database.SizeBytes = status.SizeBytes
+	r.Database.SchemaVersion = status.CurrentVersion
+
+	switch {
+	case status.IsNewer():
+		r.suggest(SeverityError, "database",
+			fmt.Sprintf("Schema version %d is newer than this waffles supports (%d)", status.CurrentVersion, status.LatestVersion),
+			"Upgrade waffles, or use another database with --log-db")
+	case status.Pending() > 0:
+		r.suggest(SeverityWarning, "database",
+			fmt.Sprintf("%d pending schema migration(s)", status.Pending()),
+			"Run 'waffles db migrate', or let the next pipeline run migrate it")
+	}
+
+	result, err := db.Check()
+	if err != nil {
+		r.databaseError(err)
+		return
+	}
+	r.Database.IntegrityErrors = result.IntegrityErrors
+	r.Database.OrphanedFiles = result.OrphanedFiles
+	r.Database.OrphanedSteps = result.OrphanedSteps
+
+	if len(result.IntegrityErrors) > 0 {
+		r.suggest(SeverityError, "database",
+			fmt.Sprintf("Integrity check found %d problem(s)", len(result.IntegrityErrors)),
+			"Restore a backup with 'waffles db restore <backup>'")
+	}
+	if result.OrphanedFiles > 0 || result.OrphanedSteps > 0 {
+		r.suggest(SeverityWarning, "database",
+			fmt.Sprintf("%d file and %d step records without an execution", result.OrphanedFiles, result.OrphanedSteps),
+			"Run 'waffles db check' for details, and restore a backup with 'waffles db restore' if needed")
+	}
+}
+
+// databaseError records a log database that cannot be read
+func (r *Report) databaseError(err error) {
+	r.Database.Error = err.Error()
+	r.suggest(SeverityError, "database", err.Error(), "Run 'waffles db check', or restore a backup with 'waffles db restore <backup>'")
+}
+
+// examineProviders reports which providers have an API key, requiring one
+// for the default provider
+func (r *Report) examineProviders(defaultProvider string) {
+	stored := storedKeys()
+
+	for _, p := range providers {
+		report := ProviderReport{Provider: p.name, Default: p.name == defaultProvider}
+		for _, envVar := range p.envVars {
+			if os.Getenv(envVar) != "" {
+				report.Present = true
+				report.Source = envVar
+				break
+			}
+		}
+		if !report.Present {
+			for _, key := range p.keys {
+				if stored[key] {
+					report.Present = true
+					report.Source = "llm keys"
+					break
+				}
+			}
+		}
+		r.Providers = append(r.Providers, report)
+
+		if report.Default && !report.Present {
+			r.suggest(SeverityError, p.name,
+				fmt.Sprintf("No API key for the default provider %s", p.name),
+				fmt.Sprintf("Run 'llm keys set %s' or set %s", p.keys[0], p.envVars[0]))
+		}
+	}
+}
+
+// storedKeys returns the names of the keys stored with 'llm keys set'
+func storedKeys() map[string]bool {
+	keys := make(map[string]bool)
+
+	llm, err := deps.LookPath("llm")
+	if err != nil {
+		return keys
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), keysTimeout)
+	defer cancel()
+
+	// Lists key names only, never their values
+	output, err := exec.CommandContext(ctx, llm, "keys", "list").Output() // #nosec G204 -- llm resolved from the tool prefix or PATH
+	if err != nil {
+		return keys
+	}
+	for _, line := range strings.Split(string(output), "\n") {
+		if name := strings.TrimSpace(line); name != "" && !strings.Contains(name, " ") {
+			keys[name] = true
+		}
+	}
+	return keys
+}
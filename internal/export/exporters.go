@@ -80,6 +80,9 @@ func (e *Exporter) SetProgressReporter(reporter ProgressReporter) {
 	e.progress = reporter
 }
 
+// defaultStreamBatchSize is the number of executions loaded per batch when streaming
+const defaultStreamBatchSize = 500
+
 // Export performs the complete export process
 func (e *Exporter) Export(writer io.Writer) error {
 	// Prepare the writer (add compression if requested)
@@ -97,6 +100,18 @@ func (e *Exporter) Export(writer io.Writer) error {
 
 	e.progress.Start(totalCount)
 
+	// Formats that can be written incrementally never hold the full history in memory
+	if stream := e.streamWriter(finalWriter); stream != nil {
+		exported, err := e.exportStream(stream, totalCount)
+		if err != nil {
+			e.progress.Finish(false, fmt.Sprintf("Failed to write data: %v", err))
+			return fmt.Errorf("failed to stream export: %w", err)
+		}
+
+		e.progress.Finish(true, fmt.Sprintf("Exported %d records in %s format", exported, e.options.Format))
+		return nil
+	}
+
 	// Prepare export data
 	exportData, err := e.prepareExportData()
 	if err != nil {
@@ -116,6 +131,91 @@ func (e *Exporter) Export(writer io.Writer) error {
 	return nil
 }
 
+// streamWriter returns a stream writer for formats that support incremental
+// output, or nil if the format must be rendered from the complete data set
+func (e *Exporter) streamWriter(writer io.Writer) StreamWriter {
+	switch e.options.Format {
+	case FormatJSONL:
+		return (&JSONLinesFormatter{}).NewStreamWriter(writer)
+	case FormatCSV:
+		return (&CSVFormatter{IncludeHeaders: true}).NewStreamWriter(writer)
+	case FormatSQL:
+		return (&SQLFormatter{IncludeSchema: true, BatchSize: 100}).NewStreamWriter(writer)
+	case FormatParquet:
+		return (&ParquetFormatter{}).NewStreamWriter(writer)
+	case FormatSQLite:
+		return (&SQLiteFormatter{}).NewStreamWriter(writer)
+	default:
+		return nil
+	}
+}
+
+// exportStream iterates over matching executions and writes them batch by
+// batch, loading files and steps per batch and reporting progress after each
+func (e *Exporter) exportStream(stream StreamWriter, totalCount int) (int, error) {
+	batchSize := e.options.BatchSize
+	if batchSize <= 0 {
+		batchSize = defaultStreamBatchSize
+	}
+
+	iter, err := e.queryEngine.IterateExecutions(e.convertToQueryFilters())
+	if err != nil {
+		_ = stream.Close() // Report the query error rather than the cleanup error
+		return 0, err
+	}
+	defer iter.Close()
+
+	metadata := e.newMetadata(totalCount)
+	if err := stream.WriteHeader(metadata); err != nil {
+		_ = stream.Close() // Report the header error rather than the cleanup error
+		return 0, err
+	}
+
+	exported := 0
+	batch := make([]logging.WafflesExecution, 0, batchSize)
+
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+
+		data := &ExportData{Metadata: metadata, Executions: batch}
+		if err := e.loadDetails(data); err != nil {
+			return err
+		}
+		if err := stream.WriteBatch(data); err != nil {
+			return err
+		}
+
+		exported += len(batch)
+		e.progress.Update(exported, fmt.Sprintf("Wrote batch of %d records", len(batch)))
+		batch = batch[:0]
+		return nil
+	}
+
+	for iter.Next() {
+		batch = append(batch, *iter.Execution())
+		if len(batch) == batchSize {
+			if err := flush(); err != nil {
+				_ = stream.Close() // Report the batch error rather than the cleanup error
+				return exported, err
+			}
+		}
+	}
+
+	if err := iter.Err(); err != nil {
+		_ = stream.Close() // Report the iteration error rather than the cleanup error
+		return exported, fmt.Errorf("failed to read executions: %w", err)
+	}
+
+	if err := flush(); err != nil {
+		_ = stream.Close() // Report the batch error rather than the cleanup error
+		return exported, err
+	}
+
+	return exported, stream.Close()
+}
+
 // ExportToFile exports data directly to a file
 func (e *Exporter) ExportToFile(filename string) error {
 	file, err := os.Create(filename) // #nosec G304 -- Filename from user-specified export path
@@ -138,12 +238,7 @@ func (e *Exporter) prepareWriter(writer io.Writer) (io.Writer, func(), error) {
 
 // getTotalCount gets the total number of records that will be exported
 func (e *Exporter) getTotalCount() (int, error) {
-	filters := e.convertToQueryFilters()
-	result, err := e.queryEngine.QueryExecutions(filters)
-	if err != nil {
-		return 0, err
-	}
-	return result.TotalCount, nil
+	return e.queryEngine.CountExecutions(e.convertToQueryFilters())
 }
 
 // prepareExportData prepares all export data
@@ -166,23 +261,57 @@ func (e *Exporter) prepareExportData() (*ExportData, error) {
 
 	// Prepare export data structure
 	exportData := &ExportData{
-		Metadata: ExportMetadata{
-			ExportedAt:    time.Now(),
-			Format:        e.options.Format,
-			RecordCount:   len(result.Executions),
-			FilterApplied: e.options.Filter != nil,
-			Version:       "1.0",
-		},
+		Metadata:   e.newMetadata(len(result.Executions)),
 		Executions: result.Executions,
 		Statistics: e.convertStats(result.Statistics),
 	}
 
-	// Note: Files and Steps would be included here if the database methods were available
-	// For now, we'll just include the basic execution data
+	if err := e.loadDetails(exportData); err != nil {
+		return nil, err
+	}
 
 	return exportData, nil
 }
 
+// newMetadata describes an export of recordCount executions
+func (e *Exporter) newMetadata(recordCount int) ExportMetadata {
+	return ExportMetadata{
+		ExportedAt:    time.Now(),
+		Format:        e.options.Format,
+		RecordCount:   recordCount,
+		FilterApplied: e.options.Filter != nil,
+		Version:       "1.0",
+	}
+}
+
+// loadDetails loads the files and steps requested by the export options for
+// the executions in data, using one batched query per table
+func (e *Exporter) loadDetails(data *ExportData) error {
+	if !e.options.IncludeFiles && !e.options.IncludeSteps {
+		return nil
+	}
+
+	ids := make([]string, len(data.Executions))
+	for i, exec := range data.Executions {
+		ids[i] = exec.ID
+	}
+
+	var err error
+	if e.options.IncludeFiles {
+		if data.Files, err = e.queryEngine.GetExecutionFiles(ids); err != nil {
+			return fmt.Errorf("failed to load execution files: %w", err)
+		}
+	}
+
+	if e.options.IncludeSteps {
+		if data.Steps, err = e.queryEngine.GetExecutionSteps(ids); err != nil {
+			return fmt.Errorf("failed to load execution steps: %w", err)
+		}
+	}
+
+	return nil
+}
+
 // formatAndWrite formats the data and writes it to the writer
 func (e *Exporter) formatAndWrite(data *ExportData, writer io.Writer) error {
 	switch e.options.Format {
@@ -190,6 +319,10 @@ func (e *Exporter) formatAndWrite(data *ExportData, writer io.Writer) error {
 		formatter := &JSONFormatter{Pretty: true}
 		return formatter.FormatJSON(data, writer)
 
+	case FormatJSONL:
+		formatter := &JSONLinesFormatter{}
+		return formatter.FormatJSONLines(data, writer)
+
 	case FormatCSV:
 		formatter := &CSVFormatter{IncludeHeaders: true}
 		return formatter.FormatCSV(data, writer)
@@ -201,6 +334,10 @@ func (e *Exporter) formatAndWrite(data *ExportData, writer io.Writer) error {
 		}
 		return formatter.FormatMarkdown(data, writer)
 
+	case FormatHTML:
+		formatter := &HTMLFormatter{}
+		return formatter.FormatHTML(data, writer)
+
 	case FormatSQL:
 		formatter := &SQLFormatter{
 			IncludeSchema: true,
@@ -208,6 +345,14 @@ func (e *Exporter) formatAndWrite(data *ExportData, writer io.Writer) error {
 		}
 		return formatter.FormatSQL(data, writer)
 
+	case FormatParquet:
+		formatter := &ParquetFormatter{}
+		return formatter.FormatParquet(data, writer)
+
+	case FormatSQLite:
+		formatter := &SQLiteFormatter{}
+		return formatter.FormatSQLite(data, writer)
+
 	case FormatTemplate:
 		if e.options.Template == "" {
 			return fmt.Errorf("template is required for template format")
@@ -237,7 +382,11 @@ func (e *Exporter) convertToQueryFilters() query.QueryFilters {
 		filters.Success = e.options.Filter.Success
 		filters.MinDuration = e.options.Filter.MinDuration
 		filters.MaxDuration = e.options.Filter.MaxDuration
+		filters.MinFileCount = e.options.Filter.MinFileCount
+		filters.MaxFileCount = e.options.Filter.MaxFileCount
 		filters.Search = e.options.Filter.SearchQuery
+		filters.Source = e.options.Filter.Source
+		filters.Conditions = e.options.Filter.Conditions
 		filters.Limit = e.options.Filter.Limit
 		filters.Offset = e.options.Filter.Offset
 	}
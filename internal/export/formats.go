@@ -1,7 +1,6 @@
 package export
 
 import (
-	"encoding/csv"
 	"encoding/json"
 	"fmt"
 	"html/template"
@@ -22,10 +21,20 @@ const (
 	FormatJSON     ExportFormat = "json"
 	FormatCSV      ExportFormat = "csv"
 	FormatMarkdown ExportFormat = "markdown"
+	FormatHTML     ExportFormat = "html"
 	FormatSQL      ExportFormat = "sql"
+	FormatJSONL    ExportFormat = "jsonl"
+	FormatParquet  ExportFormat = "parquet"
+	FormatSQLite   ExportFormat = "sqlite"
 	FormatTemplate ExportFormat = "template"
 )
 
+// IsBinary reports whether the format produces binary output that should be
+// written to a file rather than a terminal
+func (f ExportFormat) IsBinary() bool {
+	return f == FormatParquet || f == FormatSQLite
+}
+
 // ExportOptions contains options for exporting data
 type ExportOptions struct {
 	Format       ExportFormat             `json:"format"`
@@ -36,6 +45,7 @@ type ExportOptions struct {
 	Compress     bool                     `json:"compress"`
 	Template     string                   `json:"template,omitempty"`
 	TemplateData map[string]interface{}   `json:"template_data,omitempty"`
+	BatchSize    int                      `json:"batch_size,omitempty"` // Executions per batch when streaming (default 500)
 }
 
 // ExportData represents the complete export data structure
@@ -61,7 +71,23 @@ type JSONFormatter struct {
 	Pretty bool
 }
 
-// FormatJSON exports data in JSON format
+// ExecutionRecord is an execution with its files and steps nested inside it,
+// as written by the JSON formatter
+type ExecutionRecord struct {
+	logging.WafflesExecution
+	Files []logging.WafflesFile `json:"files,omitempty"`
+	Steps []logging.WafflesStep `json:"steps,omitempty"`
+}
+
+// nestedExportData is the JSON document layout with files and steps nested per execution
+type nestedExportData struct {
+	Metadata   ExportMetadata          `json:"metadata"`
+	Executions []ExecutionRecord       `json:"executions"`
+	Statistics *logging.ExecutionStats `json:"statistics,omitempty"`
+}
+
+// FormatJSON exports data in JSON format, nesting files and steps under
+// the execution they belong to
 func (f *JSONFormatter) FormatJSON(data *ExportData, writer io.Writer) error {
 	encoder := json.NewEncoder(writer)
 
@@ -69,7 +95,25 @@ func (f *JSONFormatter) FormatJSON(data *ExportData, writer io.Writer) error {
 		encoder.SetIndent("", "  ")
 	}
 
-	return encoder.Encode(data)
+	nested := nestedExportData{
+		Metadata:   data.Metadata,
+		Executions: make([]ExecutionRecord, len(data.Executions)),
+		Statistics: data.Statistics,
+	}
+	for i, exec := range data.Executions {
+		nested.Executions[i] = data.Record(exec)
+	}
+
+	return encoder.Encode(nested)
+}
+
+// Record returns an execution together with its exported files and steps
+func (d *ExportData) Record(exec logging.WafflesExecution) ExecutionRecord {
+	return ExecutionRecord{
+		WafflesExecution: exec,
+		Files:            d.Files[exec.ID],
+		Steps:            d.Steps[exec.ID],
+	}
 }
 
 // CSVFormatter handles CSV export formatting
@@ -78,46 +122,10 @@ type CSVFormatter struct {
 	CustomColumns  []string
 }
 
-// FormatCSV exports executions in CSV format
+// FormatCSV exports executions in CSV format. Files and steps, when
+// present, follow as separate tables separated by a blank line.
 func (f *CSVFormatter) FormatCSV(data *ExportData, writer io.Writer) error {
-	csvWriter := csv.NewWriter(writer)
-	defer csvWriter.Flush()
-
-	// Define columns
-	columns := []string{
-		"ID", "ConversationID", "CommandArgs", "WheresmypromptQuery",
-		"Files2promptArgs", "LLMArgs", "DetectedLanguage", "FileCount",
-		"ExecutionTimeMS", "Success", "ErrorMessage", "ModelUsed",
-		"ProviderUsed", "Created", "Updated",
-	}
-
-	// Use custom columns if provided
-	if len(f.CustomColumns) > 0 {
-		columns = f.CustomColumns
-	}
-
-	// Write header
-	if f.IncludeHeaders {
-		if err := csvWriter.Write(columns); err != nil {
-			return fmt.Errorf("failed to write CSV header: %w", err)
-		}
-	}
-
-	// Write data rows
-	for _, exec := range data.Executions {
-		record := []string{
-			exec.ID, exec.ConversationID, exec.CommandArgs, exec.WheresmypromptQuery,
-			exec.Files2promptArgs, exec.LLMArgs, exec.DetectedLanguage, fmt.Sprintf("%d", exec.FileCount),
-			fmt.Sprintf("%d", exec.ExecutionTimeMS), fmt.Sprintf("%t", exec.Success), exec.ErrorMessage, exec.ModelUsed,
-			exec.ProviderUsed, exec.Created.Format(time.RFC3339), exec.Updated.Format(time.RFC3339),
-		}
-
-		if err := csvWriter.Write(record); err != nil {
-			return fmt.Errorf("failed to write CSV record: %w", err)
-		}
-	}
-
-	return nil
+	return writeAll(f.NewStreamWriter(writer), data)
 }
 
 // MarkdownFormatter handles Markdown export formatting
@@ -274,56 +282,7 @@ type SQLFormatter struct {
 
 // FormatSQL exports data as SQL INSERT statements
 func (f *SQLFormatter) FormatSQL(data *ExportData, writer io.Writer) error {
-	tableName := f.TableName
-	if tableName == "" {
-		tableName = "waffles_executions"
-	}
-
-	// Write header
-	fmt.Fprintf(writer, "-- Waffles Execution Data Export\n")
-	fmt.Fprintf(writer, "-- Generated: %s\n", data.Metadata.ExportedAt.Format(time.RFC3339))
-	fmt.Fprintf(writer, "-- Records: %d\n\n", data.Metadata.RecordCount)
-
-	// Write schema if requested
-	if f.IncludeSchema {
-		if err := f.writeSchema(writer); err != nil {
-			return fmt.Errorf("failed to write schema: %w", err)
-		}
-	}
-
-	// Write data
-	batchSize := f.BatchSize
-	if batchSize == 0 {
-		batchSize = 100
-	}
-
-	for i, exec := range data.Executions {
-		if i%batchSize == 0 && i > 0 {
-			fmt.Fprintf(writer, "\n-- Batch %d\n", i/batchSize+1)
-		}
-
-		fmt.Fprintf(writer,
-			"INSERT INTO %s (id, conversation_id, command_args, wheresmyprompt_query, files2prompt_args, llm_args, detected_language, file_count, execution_time_ms, success, error_message, model_used, provider_used, created, updated) VALUES ('%s', %s, %s, %s, %s, %s, '%s', %d, %d, %t, %s, '%s', '%s', '%s', '%s');\n",
-			tableName,
-			exec.ID,
-			f.sqlQuote(exec.ConversationID),
-			f.sqlQuote(exec.CommandArgs),
-			f.sqlQuote(exec.WheresmypromptQuery),
-			f.sqlQuote(exec.Files2promptArgs),
-			f.sqlQuote(exec.LLMArgs),
-			exec.DetectedLanguage,
-			exec.FileCount,
-			exec.ExecutionTimeMS,
-			exec.Success,
-			f.sqlQuote(exec.ErrorMessage),
-			exec.ModelUsed,
-			exec.ProviderUsed,
-			exec.Created.Format(time.RFC3339),
-			exec.Updated.Format(time.RFC3339),
-		)
-	}
-
-	return nil
+	return writeAll(f.NewStreamWriter(writer), data)
 }
 
 // writeSchema writes the database schema
@@ -332,7 +291,7 @@ func (f *SQLFormatter) writeSchema(writer io.Writer) error {
 CREATE TABLE IF NOT EXISTS waffles_executions (
     id TEXT PRIMARY KEY,
     conversation_id TEXT,
-    command_args TEXT,
+    command_args TEXT NOT NULL,
     wheresmyprompt_query TEXT,
     files2prompt_args TEXT,
     llm_args TEXT,
@@ -343,26 +302,58 @@ CREATE TABLE IF NOT EXISTS waffles_executions (
     error_message TEXT,
     model_used TEXT,
     provider_used TEXT,
+    wheresmyprompt_args TEXT,
+    parent_execution_id TEXT,
+    source TEXT,
     created DATETIME,
     updated DATETIME
 );
 
+CREATE TABLE IF NOT EXISTS waffles_files (
+    id TEXT PRIMARY KEY,
+    execution_id TEXT NOT NULL,
+    file_path TEXT NOT NULL,
+    file_size INTEGER,
+    included BOOLEAN,
+    exclusion_reason TEXT,
+    created DATETIME
+);
+
+CREATE TABLE IF NOT EXISTS waffles_steps (
+    id TEXT PRIMARY KEY,
+    execution_id TEXT NOT NULL,
+    tool TEXT NOT NULL,
+    command TEXT NOT NULL,
+    output TEXT,
+    error_output TEXT,
+    success BOOLEAN,
+    duration_ms INTEGER,
+    step_order INTEGER NOT NULL,
+    created DATETIME
+);
+
 -- Data
 `
 	_, err := writer.Write([]byte(schema))
 	return err
 }
 
-// sqlQuote properly quotes SQL string values
+// sqlQuote properly quotes SQL string values, including empty strings
 func (f *SQLFormatter) sqlQuote(value string) string {
-	if value == "" {
-		return "NULL"
-	}
 	// Escape single quotes
 	escaped := strings.ReplaceAll(value, "'", "''")
 	return fmt.Sprintf("'%s'", escaped)
 }
 
+// sqlNullable quotes the value of an optional column, which the database
+// stores as NULL when empty
+func (f *SQLFormatter) sqlNullable(value string) string {
+	if value == "" {
+		return "NULL"
+	}
+	return f.sqlQuote(value)
+}
+
 // TemplateFormatter handles custom template formatting
 type TemplateFormatter struct {
 	Template string
@@ -475,6 +466,26 @@ func GetFormatterForFormat(format ExportFormat, options map[string]interface{})
 		}
 		return formatter, nil
 
+	case FormatHTML:
+		formatter := &HTMLFormatter{}
+		if title, ok := options["title"].(string); ok {
+			formatter.Title = title
+		}
+		return formatter, nil
+
+	case FormatJSONL:
+		return &JSONLinesFormatter{}, nil
+
+	case FormatParquet:
+		return &ParquetFormatter{}, nil
+
+	case FormatSQLite:
+		formatter := &SQLiteFormatter{}
+		if dir, ok := options["temp_dir"].(string); ok {
+			formatter.TempDir = dir
+		}
+		return formatter, nil
+
 	case FormatTemplate:
 		templateStr, ok := options["template"].(string)
 		if !ok || templateStr == "" {
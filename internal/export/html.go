@@ -0,0 +1,425 @@
+package export
+
+import (
+	"fmt"
+	"html/template"
+	"io"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/toozej/waffles/internal/query"
+	"github.com/toozej/waffles/pkg/logging"
+)
+
+// HTMLFormatter handles HTML report export formatting. The report is a single
+// offline file: styles, charts and the search script are all inlined.
+type HTMLFormatter struct {
+	Title string
+}
+
+// chartBar is a single labelled value in a chart
+type chartBar struct {
+	Label string
+	Tick  string // Shorter axis label, defaults to Label
+	Value int
+}
+
+// htmlReport is the view model rendered by the HTML template
+type htmlReport struct {
+	Title       string
+	Metadata    ExportMetadata
+	Stats       *logging.ExecutionStats
+	SuccessRate float64
+	Daily       template.HTML
+	Heatmap     template.HTML
+	Durations   template.HTML
+	FileCounts  template.HTML
+	Models      template.HTML
+	Providers   template.HTML
+	Executions  []ExecutionRecord
+}
+
+// FormatHTML exports data as a self-contained HTML report
+func (f *HTMLFormatter) FormatHTML(data *ExportData, writer io.Writer) error {
+	title := f.Title
+	if title == "" {
+		title = "Waffles Execution Report"
+	}
+
+	stats := data.Statistics
+	if stats == nil {
+		stats = summarizeExecutions(data.Executions)
+	}
+
+	report := htmlReport{
+		Title:      title,
+		Metadata:   data.Metadata,
+		Stats:      stats,
+		Daily:      svgColumnChart(dailyUsage(data.Executions), 720, 180),
+		Heatmap:    svgHeatmap(hourlyHeatmap(data.Executions)),
+		Durations:  svgColumnChart(bucketCounts(data.Executions, query.DurationBuckets, func(e logging.WafflesExecution) string { return query.DurationBucket(e.ExecutionTimeMS) }), 340, 180),
+		FileCounts: svgColumnChart(bucketCounts(data.Executions, query.FileCountBuckets, func(e logging.WafflesExecution) string { return query.FileCountBucket(e.FileCount) }), 340, 180),
+		Models:     svgBarList(sortedBreakdown(stats.ModelBreakdown), 340),
+		Providers:  svgBarList(sortedBreakdown(stats.ProviderBreakdown), 340),
+		Executions: make([]ExecutionRecord, len(data.Executions)),
+	}
+	if stats.TotalExecutions > 0 {
+		report.SuccessRate = float64(stats.SuccessfulExecutions) / float64(stats.TotalExecutions) * 100
+	}
+	for i, exec := range data.Executions {
+		report.Executions[i] = data.Record(exec)
+	}
+
+	tmpl, err := template.New("report").Funcs(template.FuncMap{
+		"shortID":  shortID,
+		"datetime": func(t time.Time) string { return t.Local().Format("2006-01-02 15:04:05") },
+		"duration": func(ms int64) string { return (time.Duration(ms) * time.Millisecond).String() },
+	}).Parse(htmlReportTemplate)
+	if err != nil {
+		return fmt.Errorf("failed to parse HTML template: %w", err)
+	}
+
+	if err := tmpl.Execute(writer, report); err != nil {
+		return fmt.Errorf("failed to render HTML report: %w", err)
+	}
+	return nil
+}
+
+// summarizeExecutions computes summary statistics for a set of executions
+func summarizeExecutions(executions []logging.WafflesExecution) *logging.ExecutionStats {
+	stats := &logging.ExecutionStats{
+		TotalExecutions:   len(executions),
+		LanguageBreakdown: make(map[string]int),
+		ModelBreakdown:    make(map[string]int),
+		ProviderBreakdown: make(map[string]int),
+	}
+
+	var totalTime int64
+	for _, exec := range executions {
+		if exec.Success {
+			stats.SuccessfulExecutions++
+		} else {
+			stats.FailedExecutions++
+		}
+		totalTime += exec.ExecutionTimeMS
+		stats.TotalFiles += exec.FileCount
+		if exec.DetectedLanguage != "" {
+			stats.LanguageBreakdown[exec.DetectedLanguage]++
+		}
+		if exec.ModelUsed != "" {
+			stats.ModelBreakdown[exec.ModelUsed]++
+		}
+		if exec.ProviderUsed != "" {
+			stats.ProviderBreakdown[exec.ProviderUsed]++
+		}
+	}
+	if len(executions) > 0 {
+		stats.AverageExecutionTime = float64(totalTime) / float64(len(executions))
+	}
+
+	return stats
+}
+
+// shortID returns the first eight characters of an execution ID
+func shortID(id string) string {
+	if len(id) > 8 {
+		return id[:8]
+	}
+	return id
+}
+
+// dailyUsage counts executions per local calendar day, including empty days
+// between the first and last execution
+func dailyUsage(executions []logging.WafflesExecution) []chartBar {
+	if len(executions) == 0 {
+		return nil
+	}
+
+	counts := make(map[string]int)
+	first, last := executions[0].Created.Local(), executions[0].Created.Local()
+	for _, exec := range executions {
+		created := exec.Created.Local()
+		counts[created.Format("2006-01-02")]++
+		if created.Before(first) {
+			first = created
+		}
+		if created.After(last) {
+			last = created
+		}
+	}
+
+	var bars []chartBar
+	day := time.Date(first.Year(), first.Month(), first.Day(), 0, 0, 0, 0, time.Local)
+	for !day.After(last) {
+		label := day.Format("2006-01-02")
+		bars = append(bars, chartBar{Label: label, Tick: day.Format("Jan 2"), Value: counts[label]})
+		day = day.AddDate(0, 0, 1)
+	}
+	return bars
+}
+
+// hourlyHeatmap counts executions per local weekday and hour
+func hourlyHeatmap(executions []logging.WafflesExecution) [7][24]int {
+	var grid [7][24]int
+	for _, exec := range executions {
+		created := exec.Created.Local()
+		grid[created.Weekday()][created.Hour()]++
+	}
+	return grid
+}
+
+// bucketCounts counts executions per bucket, keeping the given bucket order
+func bucketCounts(executions []logging.WafflesExecution, buckets []string, bucketOf func(logging.WafflesExecution) string) []chartBar {
+	counts := make(map[string]int)
+	for _, exec := range executions {
+		counts[bucketOf(exec)]++
+	}
+
+	bars := make([]chartBar, len(buckets))
+	for i, bucket := range buckets {
+		bars[i] = chartBar{Label: bucket, Value: counts[bucket]}
+	}
+	return bars
+}
+
+// sortedBreakdown orders a breakdown by descending count, then by name
+func sortedBreakdown(breakdown map[string]int) []chartBar {
+	bars := make([]chartBar, 0, len(breakdown))
+	for label, value := range breakdown {
+		bars = append(bars, chartBar{Label: label, Value: value})
+	}
+	sort.Slice(bars, func(i, j int) bool {
+		if bars[i].Value != bars[j].Value {
+			return bars[i].Value > bars[j].Value
+		}
+		return bars[i].Label < bars[j].Label
+	})
+	return bars
+}
+
+// maxValue returns the largest value in a chart, at least 1
+func maxValue(bars []chartBar) int {
+	largest := 1
+	for _, bar := range bars {
+		if bar.Value > largest {
+			largest = bar.Value
+		}
+	}
+	return largest
+}
+
+// svgColumnChart renders a vertical bar chart. Labels are shown below the
+// bars when they fit and are always available as hover titles.
+func svgColumnChart(bars []chartBar, width, height int) template.HTML {
+	if len(bars) == 0 {
+		return template.HTML(`<p class="empty">No data</p>`) // #nosec G203 -- Static markup
+	}
+
+	const top, bottom = 10, 30
+	plot := float64(height - top - bottom)
+	step := math.Min(float64(width)/float64(len(bars)), 48)
+	largest := float64(maxValue(bars))
+	// Show at most ~12 axis labels so they never overlap
+	labelEvery := int(math.Ceil(float64(len(bars)) / 12))
+
+	var b strings.Builder
+	fmt.Fprintf(&b, `<svg class="chart" viewBox="0 0 %d %d" role="img">`, width, height)
+	for i, bar := range bars {
+		h := float64(bar.Value) / largest * plot
+		x := float64(i) * step
+		fmt.Fprintf(&b, `<rect x="%.1f" y="%.1f" width="%.1f" height="%.1f"><title>%s: %d</title></rect>`,
+			x+step*0.1, float64(top)+plot-h, step*0.8, h, template.HTMLEscapeString(bar.Label), bar.Value)
+		if i%labelEvery == 0 {
+			tick := bar.Tick
+			if tick == "" {
+				tick = bar.Label
+			}
+			fmt.Fprintf(&b, `<text x="%.1f" y="%d" text-anchor="middle">%s</text>`,
+				x+step/2, height-bottom+16, template.HTMLEscapeString(tick))
+		}
+	}
+	fmt.Fprintf(&b, `<line x1="0" y1="%.1f" x2="%d" y2="%.1f"/>`, float64(top)+plot, width, float64(top)+plot)
+	b.WriteString(`</svg>`)
+	return template.HTML(b.String()) // #nosec G203 -- Labels are escaped above
+}
+
+// svgBarList renders a horizontal bar chart, one labelled row per entry
+func svgBarList(bars []chartBar, width int) template.HTML {
+	if len(bars) == 0 {
+		return template.HTML(`<p class="empty">No data</p>`) // #nosec G203 -- Static markup
+	}
+
+	const row, labelWidth, countWidth = 22, 130, 50
+	plot := float64(width - labelWidth - countWidth)
+	largest := float64(maxValue(bars))
+
+	var b strings.Builder
+	fmt.Fprintf(&b, `<svg class="chart" viewBox="0 0 %d %d" role="img">`, width, len(bars)*row)
+	for i, bar := range bars {
+		y := i * row
+		w := float64(bar.Value) / largest * plot
+		label := template.HTMLEscapeString(bar.Label)
+		fmt.Fprintf(&b, `<text x="%d" y="%d" text-anchor="end">%s</text>`, labelWidth-6, y+15, label)
+		fmt.Fprintf(&b, `<rect x="%d" y="%d" width="%.1f" height="%d"><title>%s: %d</title></rect>`,
+			labelWidth, y+4, w, row-8, label, bar.Value)
+		fmt.Fprintf(&b, `<text x="%.1f" y="%d">%d</text>`, float64(labelWidth)+w+6, y+15, bar.Value)
+	}
+	b.WriteString(`</svg>`)
+	return template.HTML(b.String()) // #nosec G203 -- Labels are escaped above
+}
+
+// svgHeatmap renders executions per weekday and hour as a shaded grid
+func svgHeatmap(grid [7][24]int) template.HTML {
+	const cell, left, top = 26, 40, 18
+	days := []string{"Sun", "Mon", "Tue", "Wed", "Thu", "Fri", "Sat"}
+
+	largest := 1
+	for _, hours := range grid {
+		for _, count := range hours {
+			if count > largest {
+				largest = count
+			}
+		}
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, `<svg class="chart heatmap" viewBox="0 0 %d %d" role="img">`, left+24*cell, top+7*cell)
+	for hour := 0; hour < 24; hour += 3 {
+		fmt.Fprintf(&b, `<text x="%d" y="12" text-anchor="middle">%02d</text>`, left+hour*cell+cell/2, hour)
+	}
+	for day, hours := range grid {
+		fmt.Fprintf(&b, `<text x="%d" y="%d" text-anchor="end">%s</text>`, left-6, top+day*cell+cell/2+4, days[day])
+		for hour, count := range hours {
+			opacity := 0.05
+			if count > 0 {
+				opacity = 0.15 + 0.85*float64(count)/float64(largest)
+			}
+			fmt.Fprintf(&b, `<rect x="%d" y="%d" width="%d" height="%d" fill-opacity="%.2f"><title>%s %02d:00: %d</title></rect>`,
+				left+hour*cell+1, top+day*cell+1, cell-2, cell-2, opacity, days[day], hour, count)
+		}
+	}
+	b.WriteString(`</svg>`)
+	return template.HTML(b.String()) // #nosec G203 -- Generated from numbers and static labels
+}
+
+const htmlReportTemplate = `<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>{{.Title}}</title>
+<style>
+body { font-family: -apple-system, "Segoe UI", Roboto, sans-serif; margin: 0; color: #1f2328; background: #f6f8fa; }
+main { max-width: 1100px; margin: 0 auto; padding: 24px; }
+h1 { margin-bottom: 4px; }
+.meta { color: #59636e; margin-top: 0; }
+.cards { display: grid; grid-template-columns: repeat(auto-fit, minmax(150px, 1fr)); gap: 12px; margin: 20px 0; }
+.card, section.panel, article { background: #fff; border: 1px solid #d1d9e0; border-radius: 6px; padding: 14px 16px; }
+.card .value { font-size: 1.6em; font-weight: 600; }
+.card .label { color: #59636e; font-size: .85em; }
+.grid { display: grid; grid-template-columns: repeat(auto-fit, minmax(340px, 1fr)); gap: 12px; margin-bottom: 12px; }
+section.panel { margin-bottom: 12px; }
+h2 { font-size: 1.1em; margin: 0 0 10px; }
+.chart { width: 100%; height: auto; font-size: 10px; fill: #59636e; }
+.chart rect { fill: #0969da; }
+.chart line { stroke: #d1d9e0; }
+.empty { color: #59636e; }
+input[type=search] { width: 100%; padding: 8px; margin-bottom: 10px; border: 1px solid #d1d9e0; border-radius: 6px; box-sizing: border-box; }
+table { width: 100%; border-collapse: collapse; font-size: .9em; }
+th, td { text-align: left; padding: 6px 8px; border-bottom: 1px solid #d1d9e0; vertical-align: top; }
+th { background: #f6f8fa; }
+.ok { color: #1a7f37; }
+.fail { color: #d1242f; }
+code, pre { font-family: ui-monospace, SFMono-Regular, Menlo, monospace; font-size: .85em; }
+pre { background: #f6f8fa; padding: 8px; overflow-x: auto; white-space: pre-wrap; word-break: break-word; }
+article { margin-bottom: 12px; }
+article dl { display: grid; grid-template-columns: 160px 1fr; gap: 4px 12px; }
+article dt { color: #59636e; }
+article dd { margin: 0; word-break: break-word; }
+</style>
+</head>
+<body>
+<main>
+<h1>{{.Title}}</h1>
+<p class="meta">Generated {{datetime .Metadata.ExportedAt}} &middot; {{.Metadata.RecordCount}} executions &middot; times shown in local time</p>
+
+<div class="cards">
+<div class="card"><div class="value">{{.Stats.TotalExecutions}}</div><div class="label">Executions</div></div>
+<div class="card"><div class="value">{{printf "%.1f" .SuccessRate}}%</div><div class="label">Success rate</div></div>
+<div class="card"><div class="value">{{.Stats.FailedExecutions}}</div><div class="label">Failures</div></div>
+<div class="card"><div class="value">{{printf "%.0f" .Stats.AverageExecutionTime}}ms</div><div class="label">Average duration</div></div>
+<div class="card"><div class="value">{{.Stats.TotalFiles}}</div><div class="label">Files processed</div></div>
+</div>
+
+<section class="panel"><h2>Daily usage</h2>{{.Daily}}</section>
+<section class="panel"><h2>Activity by weekday and hour</h2>{{.Heatmap}}</section>
+<div class="grid">
+<section class="panel"><h2>Duration</h2>{{.Durations}}</section>
+<section class="panel"><h2>File count</h2>{{.FileCounts}}</section>
+<section class="panel"><h2>Models</h2>{{.Models}}</section>
+<section class="panel"><h2>Providers</h2>{{.Providers}}</section>
+</div>
+
+<section class="panel">
+<h2>Executions</h2>
+<input type="search" id="search" placeholder="Search by ID, model, provider, language, query or error..." aria-label="Search executions">
+<table id="executions">
+<thead><tr><th>ID</th><th>Created</th><th>Model</th><th>Provider</th><th>Language</th><th>Files</th><th>Duration</th><th>Status</th></tr></thead>
+<tbody>
+{{range .Executions}}<tr data-search="{{.ID}} {{.Source}} {{.ModelUsed}} {{.ProviderUsed}} {{.DetectedLanguage}} {{.WheresmypromptQuery}} {{.ErrorMessage}}">
+<td><a href="#run-{{.ID}}"><code>{{shortID .ID}}</code></a></td>
+<td>{{datetime .Created}}</td>
+<td>{{.ModelUsed}}</td>
+<td>{{.ProviderUsed}}</td>
+<td>{{.DetectedLanguage}}</td>
+<td>{{.FileCount}}</td>
+<td>{{duration .ExecutionTimeMS}}</td>
+<td>{{if .Success}}<span class="ok">success</span>{{else}}<span class="fail">failed</span>{{end}}</td>
+</tr>
+{{end}}</tbody>
+</table>
+</section>
+
+<h2>Execution details</h2>
+{{range .Executions}}<article id="run-{{.ID}}">
+<h2><code>{{.ID}}</code> {{if .Success}}<span class="ok">success</span>{{else}}<span class="fail">failed</span>{{end}}</h2>
+<dl>
+<dt>Created</dt><dd>{{datetime .Created}}</dd>
+<dt>Model</dt><dd>{{.ModelUsed}}</dd>
+<dt>Provider</dt><dd>{{.ProviderUsed}}</dd>
+<dt>Language</dt><dd>{{.DetectedLanguage}}</dd>
+<dt>Duration</dt><dd>{{duration .ExecutionTimeMS}}</dd>
+<dt>File count</dt><dd>{{.FileCount}}</dd>
+{{if .ConversationID}}<dt>Conversation</dt><dd><code>{{.ConversationID}}</code></dd>{{end}}
+{{if .ParentExecutionID}}<dt>Parent</dt><dd><a href="#run-{{.ParentExecutionID}}"><code>{{.ParentExecutionID}}</code></a></dd>{{end}}
+{{if .Source}}<dt>Source</dt><dd>{{.Source}}</dd>{{end}}
+{{if .CommandArgs}}<dt>Command</dt><dd><code>{{.CommandArgs}}</code></dd>{{end}}
+{{if .WheresmypromptQuery}}<dt>Query</dt><dd>{{.WheresmypromptQuery}}</dd>{{end}}
+{{if .ErrorMessage}}<dt>Error</dt><dd class="fail">{{.ErrorMessage}}</dd>{{end}}
+</dl>
+{{if .Files}}<h3>Files ({{len .Files}})</h3>
+<ul>{{range .Files}}<li>{{if .Included}}<span class="ok">&#10003;</span>{{else}}<span class="fail">&#10007;</span>{{end}} <code>{{.FilePath}}</code> ({{.FileSize}} bytes){{if .ExclusionReason}} <em>excluded: {{.ExclusionReason}}</em>{{end}}</li>{{end}}</ul>
+{{end}}{{if .Steps}}<h3>Pipeline steps</h3>
+{{range .Steps}}<details>
+<summary>{{.StepOrder}}. {{if .Success}}<span class="ok">&#10003;</span>{{else}}<span class="fail">&#10007;</span>{{end}} <strong>{{.Tool}}</strong> ({{duration .DurationMS}})</summary>
+{{if .Command}}<pre>{{.Command}}</pre>{{end}}
+{{if .Output}}<pre>{{.Output}}</pre>{{end}}
+{{if .ErrorOutput}}<pre class="fail">{{.ErrorOutput}}</pre>{{end}}
+</details>
+{{end}}{{end}}<p><a href="#executions">Back to table</a></p>
+</article>
+{{end}}
+</main>
+<script>
+document.getElementById("search").addEventListener("input", function () {
+  var term = this.value.toLowerCase();
+  document.querySelectorAll("#executions tbody tr").forEach(function (row) {
+    row.hidden = term !== "" && row.dataset.search.toLowerCase().indexOf(term) === -1;
+  });
+});
+</script>
+</body>
+</html>
+`
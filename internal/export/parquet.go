@@ -0,0 +1,156 @@
+package export
+
+import (
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/parquet-go/parquet-go"
+	"github.com/toozej/waffles/pkg/logging"
+)
+
+// ParquetFormatter handles Apache Parquet export formatting. Each row is one
+// execution; files and steps are nested as list columns so the output can be
+// queried directly from DuckDB or pandas.
+type ParquetFormatter struct{}
+
+// parquetExecution is the Parquet row layout for an execution
+type parquetExecution struct {
+	ID                  string        `parquet:"id"`
+	ConversationID      string        `parquet:"conversation_id"`
+	CommandArgs         string        `parquet:"command_args"`
+	WheresmypromptQuery string        `parquet:"wheresmyprompt_query"`
+	WheresmypromptArgs  string        `parquet:"wheresmyprompt_args"`
+	Files2promptArgs    string        `parquet:"files2prompt_args"`
+	LLMArgs             string        `parquet:"llm_args"`
+	DetectedLanguage    string        `parquet:"detected_language"`
+	FileCount           int64         `parquet:"file_count"`
+	ExecutionTimeMS     int64         `parquet:"execution_time_ms"`
+	Success             bool          `parquet:"success"`
+	ErrorMessage        string        `parquet:"error_message"`
+	ModelUsed           string        `parquet:"model_used"`
+	ProviderUsed        string        `parquet:"provider_used"`
+	ParentExecutionID   string        `parquet:"parent_execution_id"`
+	Source              string        `parquet:"source"`
+	Created             time.Time     `parquet:"created,timestamp(millisecond)"`
+	Updated             time.Time     `parquet:"updated,timestamp(millisecond)"`
+	Files               []parquetFile `parquet:"files,list"`
+	Steps               []parquetStep `parquet:"steps,list"`
+}
+
+// parquetFile is the Parquet layout for a file nested in an execution row
+type parquetFile struct {
+	ID              string    `parquet:"id"`
+	FilePath        string    `parquet:"file_path"`
+	FileSize        int64     `parquet:"file_size"`
+	Included        bool      `parquet:"included"`
+	ExclusionReason string    `parquet:"exclusion_reason"`
+	Created         time.Time `parquet:"created,timestamp(millisecond)"`
+}
+
+// parquetStep is the Parquet layout for a step nested in an execution row
+type parquetStep struct {
+	ID          string    `parquet:"id"`
+	Tool        string    `parquet:"tool"`
+	Command     string    `parquet:"command"`
+	Output      string    `parquet:"output"`
+	ErrorOutput string    `parquet:"error_output"`
+	Success     bool      `parquet:"success"`
+	DurationMS  int64     `parquet:"duration_ms"`
+	StepOrder   int64     `parquet:"step_order"`
+	Created     time.Time `parquet:"created,timestamp(millisecond)"`
+}
+
+// FormatParquet exports data in Apache Parquet format
+func (f *ParquetFormatter) FormatParquet(data *ExportData, writer io.Writer) error {
+	return writeAll(f.NewStreamWriter(writer), data)
+}
+
+// NewStreamWriter returns a stream writer producing Parquet. Each batch is
+// written as its own row group.
+func (f *ParquetFormatter) NewStreamWriter(writer io.Writer) StreamWriter {
+	return &parquetStreamWriter{writer: parquet.NewGenericWriter[parquetExecution](writer)}
+}
+
+type parquetStreamWriter struct {
+	writer *parquet.GenericWriter[parquetExecution]
+}
+
+func (w *parquetStreamWriter) WriteHeader(metadata ExportMetadata) error {
+	w.writer.SetKeyValueMetadata("waffles.exported_at", metadata.ExportedAt.Format(time.RFC3339))
+	w.writer.SetKeyValueMetadata("waffles.version", metadata.Version)
+	return nil
+}
+
+func (w *parquetStreamWriter) WriteBatch(batch *ExportData) error {
+	rows := make([]parquetExecution, 0, len(batch.Executions))
+	for _, exec := range batch.Executions {
+		rows = append(rows, newParquetExecution(exec, batch.Files[exec.ID], batch.Steps[exec.ID]))
+	}
+
+	if _, err := w.writer.Write(rows); err != nil {
+		return fmt.Errorf("failed to write parquet rows: %w", err)
+	}
+	if err := w.writer.Flush(); err != nil {
+		return fmt.Errorf("failed to flush parquet row group: %w", err)
+	}
+	return nil
+}
+
+func (w *parquetStreamWriter) Close() error {
+	if err := w.writer.Close(); err != nil {
+		return fmt.Errorf("failed to close parquet writer: %w", err)
+	}
+	return nil
+}
+
+// newParquetExecution converts an execution and its details to a Parquet row
+func newParquetExecution(exec logging.WafflesExecution, files []logging.WafflesFile, steps []logging.WafflesStep) parquetExecution {
+	row := parquetExecution{
+		ID:                  exec.ID,
+		ConversationID:      exec.ConversationID,
+		CommandArgs:         exec.CommandArgs,
+		WheresmypromptQuery: exec.WheresmypromptQuery,
+		WheresmypromptArgs:  exec.WheresmypromptArgs,
+		Files2promptArgs:    exec.Files2promptArgs,
+		LLMArgs:             exec.LLMArgs,
+		DetectedLanguage:    exec.DetectedLanguage,
+		FileCount:           int64(exec.FileCount),
+		ExecutionTimeMS:     exec.ExecutionTimeMS,
+		Success:             exec.Success,
+		ErrorMessage:        exec.ErrorMessage,
+		ModelUsed:           exec.ModelUsed,
+		ProviderUsed:        exec.ProviderUsed,
+		ParentExecutionID:   exec.ParentExecutionID,
+		Source:              exec.Source,
+		Created:             exec.Created,
+		Updated:             exec.Updated,
+	}
+
+	for _, file := range files {
+		row.Files = append(row.Files, parquetFile{
+			ID:              file.ID,
+			FilePath:        file.FilePath,
+			FileSize:        file.FileSize,
+			Included:        file.Included,
+			ExclusionReason: file.ExclusionReason,
+			Created:         file.Created,
+		})
+	}
+
+	for _, step := range steps {
+		row.Steps = append(row.Steps, parquetStep{
+			ID:          step.ID,
+			Tool:        step.Tool,
+			Command:     step.Command,
+			Output:      step.Output,
+			ErrorOutput: step.ErrorOutput,
+			Success:     step.Success,
+			DurationMS:  step.DurationMS,
+			StepOrder:   int64(step.StepOrder),
+			Created:     step.Created,
+		})
+	}
+
+	return row
+}
@@ -0,0 +1,342 @@
+package export
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/toozej/waffles/pkg/logging"
+)
+
+// Reader reads executions back from an export, one batch at a time
+type Reader interface {
+	// Next returns the next batch of executions with their files and steps,
+	// or io.EOF when the export is exhausted
+	Next() (*ExportData, error)
+	// Close releases the underlying file and any temporary data
+	Close() error
+}
+
+// ImportableFormats lists the export formats that can be read back
+var ImportableFormats = []ExportFormat{FormatJSON, FormatJSONL, FormatSQL, FormatSQLite}
+
+// DetectFormat guesses the export format of a file from its extension,
+// ignoring a trailing .gz
+func DetectFormat(path string) (ExportFormat, error) {
+	ext := strings.ToLower(filepath.Ext(strings.TrimSuffix(strings.ToLower(path), ".gz")))
+	switch ext {
+	case ".json":
+		return FormatJSON, nil
+	case ".jsonl", ".ndjson":
+		return FormatJSONL, nil
+	case ".sql":
+		return FormatSQL, nil
+	case ".sqlite", ".sqlite3", ".db":
+		return FormatSQLite, nil
+	default:
+		return "", fmt.Errorf("cannot detect export format of %s; use --format", path)
+	}
+}
+
+// OpenReader opens an export file for reading in batches of batchSize
+// executions. Gzip-compressed exports are decompressed transparently.
+func OpenReader(path string, format ExportFormat, batchSize int) (Reader, error) {
+	if batchSize <= 0 {
+		batchSize = defaultStreamBatchSize
+	}
+
+	switch format {
+	case FormatSQLite:
+		return newDatabaseReader(path, batchSize, func(db *logging.Database) error { return nil })
+	case FormatSQL:
+		content, err := readExport(path)
+		if err != nil {
+			return nil, err
+		}
+		return newDatabaseReader("", batchSize, func(db *logging.Database) error {
+			return db.ExecScript(string(content))
+		})
+	case FormatJSON, FormatJSONL:
+		file, err := os.Open(path) // #nosec G304 -- Import file from user-specified path
+		if err != nil {
+			return nil, fmt.Errorf("failed to open %s: %w", path, err)
+		}
+		reader, err := decompress(file)
+		if err != nil {
+			_ = file.Close()
+			return nil, err
+		}
+		if format == FormatJSON {
+			return newJSONReader(reader, file, batchSize)
+		}
+		return &jsonLinesReader{decoder: json.NewDecoder(reader), file: file, batchSize: batchSize}, nil
+	default:
+		return nil, fmt.Errorf("importing %s exports is not supported", format)
+	}
+}
+
+// decompress wraps a reader in a gzip reader when it starts with the gzip magic bytes
+func decompress(file *os.File) (io.Reader, error) {
+	buffered := bufio.NewReader(file)
+	magic, err := buffered.Peek(2)
+	if err == nil && bytes.Equal(magic, []byte{0x1f, 0x8b}) {
+		gz, err := gzip.NewReader(buffered)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read gzip data: %w", err)
+		}
+		return gz, nil
+	}
+	return buffered, nil
+}
+
+// decompressToDir returns path unchanged for uncompressed files, or the path of
+// a decompressed copy inside dir for gzip-compressed ones
+func decompressToDir(path, dir string) (string, error) {
+	file, err := os.Open(path) // #nosec G304 -- Import file from user-specified path
+	if err != nil {
+		return "", fmt.Errorf("failed to open %s: %w", path, err)
+	}
+	defer file.Close()
+
+	reader, err := decompress(file)
+	if err != nil {
+		return "", err
+	}
+	if _, ok := reader.(*gzip.Reader); !ok {
+		return path, nil
+	}
+
+	dest := filepath.Join(dir, "source.sqlite")
+	out, err := os.Create(dest) // #nosec G304 -- Path inside our own temporary directory
+	if err != nil {
+		return "", fmt.Errorf("failed to create %s: %w", dest, err)
+	}
+	if _, err := io.Copy(out, reader); err != nil {
+		_ = out.Close()
+		return "", fmt.Errorf("failed to decompress %s: %w", path, err)
+	}
+	if err := out.Close(); err != nil {
+		return "", fmt.Errorf("failed to write %s: %w", dest, err)
+	}
+	return dest, nil
+}
+
+// readExport reads a complete, possibly compressed, export file
+func readExport(path string) ([]byte, error) {
+	file, err := os.Open(path) // #nosec G304 -- Import file from user-specified path
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s: %w", path, err)
+	}
+	defer file.Close()
+
+	reader, err := decompress(file)
+	if err != nil {
+		return nil, err
+	}
+	content, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	return content, nil
+}
+
+// jsonExport is the JSON export layout. Files and steps are nested per
+// execution; older exports kept them in top-level maps keyed by execution ID.
+type jsonExport struct {
+	Executions []ExecutionRecord                `json:"executions"`
+	Files      map[string][]logging.WafflesFile `json:"files"`
+	Steps      map[string][]logging.WafflesStep `json:"steps"`
+}
+
+// jsonReader returns the executions of a JSON export in batches
+type jsonReader struct {
+	records   []ExecutionRecord
+	batchSize int
+}
+
+func newJSONReader(reader io.Reader, file *os.File, batchSize int) (Reader, error) {
+	defer file.Close()
+
+	var doc jsonExport
+	if err := json.NewDecoder(reader).Decode(&doc); err != nil {
+		return nil, fmt.Errorf("failed to parse JSON export: %w", err)
+	}
+
+	for i := range doc.Executions {
+		record := &doc.Executions[i]
+		if record.Files == nil {
+			record.Files = doc.Files[record.ID]
+		}
+		if record.Steps == nil {
+			record.Steps = doc.Steps[record.ID]
+		}
+	}
+
+	return &jsonReader{records: doc.Executions, batchSize: batchSize}, nil
+}
+
+func (r *jsonReader) Next() (*ExportData, error) {
+	if len(r.records) == 0 {
+		return nil, io.EOF
+	}
+
+	n := min(r.batchSize, len(r.records))
+	batch := recordsToData(r.records[:n])
+	r.records = r.records[n:]
+	return batch, nil
+}
+
+func (r *jsonReader) Close() error {
+	return nil
+}
+
+// jsonLinesReader decodes one execution per line from a JSON Lines export
+type jsonLinesReader struct {
+	decoder   *json.Decoder
+	file      *os.File
+	batchSize int
+}
+
+func (r *jsonLinesReader) Next() (*ExportData, error) {
+	var records []ExecutionRecord
+	for len(records) < r.batchSize {
+		var record ExecutionRecord
+		if err := r.decoder.Decode(&record); err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return nil, fmt.Errorf("failed to parse JSON line %d: %w", len(records)+1, err)
+		}
+		records = append(records, record)
+	}
+
+	if len(records) == 0 {
+		return nil, io.EOF
+	}
+	return recordsToData(records), nil
+}
+
+func (r *jsonLinesReader) Close() error {
+	return r.file.Close()
+}
+
+// recordsToData converts nested execution records to export data
+func recordsToData(records []ExecutionRecord) *ExportData {
+	data := &ExportData{
+		Executions: make([]logging.WafflesExecution, len(records)),
+		Files:      make(map[string][]logging.WafflesFile),
+		Steps:      make(map[string][]logging.WafflesStep),
+	}
+	for i, record := range records {
+		data.Executions[i] = record.WafflesExecution
+		if len(record.Files) > 0 {
+			data.Files[record.ID] = record.Files
+		}
+		if len(record.Steps) > 0 {
+			data.Steps[record.ID] = record.Steps
+		}
+	}
+	return data
+}
+
+// databaseReader reads executions from a temporary, fully migrated copy of
+// a SQLite export or of a database built from a SQL export
+type databaseReader struct {
+	dir       string
+	db        *logging.Database
+	iter      *logging.ExecutionIterator
+	batchSize int
+}
+
+// newDatabaseReader creates a temporary database, copying source into it
+// when given, and runs load against it before reading
+func newDatabaseReader(source string, batchSize int, load func(db *logging.Database) error) (Reader, error) {
+	dir, err := os.MkdirTemp("", "waffles-import-*")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temporary directory: %w", err)
+	}
+	r := &databaseReader{dir: dir, batchSize: batchSize}
+	path := filepath.Join(dir, "import.sqlite")
+
+	if source != "" {
+		if source, err = decompressToDir(source, dir); err != nil {
+			_ = r.Close()
+			return nil, err
+		}
+		src, err := logging.OpenDatabase(source)
+		if err != nil {
+			_ = r.Close()
+			return nil, err
+		}
+		err = src.Backup(path)
+		_ = src.Close() // The copy is all we need from here on
+		if err != nil {
+			_ = r.Close()
+			return nil, fmt.Errorf("failed to copy %s: %w", source, err)
+		}
+	}
+
+	// Bring older exports up to the current schema before reading them
+	r.db, err = logging.NewDatabase(path)
+	if err != nil {
+		_ = r.Close()
+		return nil, err
+	}
+	if err := load(r.db); err != nil {
+		_ = r.Close()
+		return nil, err
+	}
+
+	r.iter, err = r.db.IterateExecutions(&logging.ExecutionFilter{})
+	if err != nil {
+		_ = r.Close()
+		return nil, fmt.Errorf("failed to read executions: %w", err)
+	}
+	return r, nil
+}
+
+func (r *databaseReader) Next() (*ExportData, error) {
+	data := &ExportData{}
+	for len(data.Executions) < r.batchSize && r.iter.Next() {
+		data.Executions = append(data.Executions, *r.iter.Execution())
+	}
+	if err := r.iter.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read executions: %w", err)
+	}
+	if len(data.Executions) == 0 {
+		return nil, io.EOF
+	}
+
+	ids := make([]string, len(data.Executions))
+	for i, exec := range data.Executions {
+		ids[i] = exec.ID
+	}
+
+	var err error
+	if data.Files, err = r.db.GetFilesForExecutions(ids); err != nil {
+		return nil, err
+	}
+	if data.Steps, err = r.db.GetStepsForExecutions(ids); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+func (r *databaseReader) Close() error {
+	defer func() { _ = os.RemoveAll(r.dir) }()
+
+	if r.iter != nil {
+		_ = r.iter.Close() // Nothing useful to report once reading is done
+	}
+	if r.db != nil {
+		return r.db.Close()
+	}
+	return nil
+}
@@ -0,0 +1,114 @@
+package export
+
+import (
+	"bytes"
+	"encoding/csv"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/toozej/waffles/pkg/logging"
+)
+
+func TestSQLRoundTrip(t *testing.T) {
+	created := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	data := &ExportData{
+		Metadata: ExportMetadata{ExportedAt: created, Format: FormatSQL, RecordCount: 2},
+		Executions: []logging.WafflesExecution{
+			// Empty strings in NOT NULL columns must not become NULL
+			{ID: "parent", CommandArgs: "", Success: true, Created: created, Updated: created},
+			{ID: "child", CommandArgs: "waffles query 'it''s'", ParentExecutionID: "parent", Source: "alice", Created: created, Updated: created},
+		},
+		Files: map[string][]logging.WafflesFile{
+			"parent": {{ID: "file", ExecutionID: "parent", FilePath: "", Included: true, Created: created}},
+		},
+		Steps: map[string][]logging.WafflesStep{
+			"parent": {{ID: "step", ExecutionID: "parent", Tool: "llm", Command: "", Output: "", StepOrder: 1, Created: created}},
+		},
+	}
+
+	var buf bytes.Buffer
+	if err := (&SQLFormatter{IncludeSchema: true}).FormatSQL(data, &buf); err != nil {
+		t.Fatalf("Failed to export: %v", err)
+	}
+	path := filepath.Join(t.TempDir(), "export.sql")
+	if err := os.WriteFile(path, buf.Bytes(), 0600); err != nil {
+		t.Fatalf("Failed to write export: %v", err)
+	}
+
+	reader, err := OpenReader(path, FormatSQL, 10)
+	if err != nil {
+		t.Fatalf("Failed to open export: %v", err)
+	}
+	defer reader.Close()
+
+	db, err := logging.NewDatabase(filepath.Join(t.TempDir(), "import.db"))
+	if err != nil {
+		t.Fatalf("Failed to create database: %v", err)
+	}
+	defer db.Close()
+
+	for {
+		batch, err := reader.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("Failed to read export: %v", err)
+		}
+		result := &logging.ImportResult{}
+		if err := db.Import(batch.Executions, batch.Files, batch.Steps, logging.ImportOptions{}, result); err != nil {
+			t.Fatalf("Failed to import: %v", err)
+		}
+	}
+
+	for _, want := range data.Executions {
+		got, err := db.GetExecution(want.ID)
+		if err != nil {
+			t.Fatalf("Execution %s missing: %v", want.ID, err)
+		}
+		if got.CommandArgs != want.CommandArgs || got.ParentExecutionID != want.ParentExecutionID ||
+			got.Source != want.Source || got.Success != want.Success || !got.Created.Equal(want.Created) {
+			t.Errorf("Expected %+v, got %+v", want, got)
+		}
+	}
+
+	files, err := db.GetExecutionFiles("parent")
+	if err != nil || len(files) != 1 || files[0].FilePath != "" {
+		t.Errorf("Expected the file to round-trip, got %+v: %v", files, err)
+	}
+	steps, err := db.GetExecutionSteps("parent")
+	if err != nil || len(steps) != 1 || steps[0].Command != "" || steps[0].Tool != "llm" {
+		t.Errorf("Expected the step to round-trip, got %+v: %v", steps, err)
+	}
+}
+
+func TestCSVExecutionColumns(t *testing.T) {
+	created := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	data := &ExportData{
+		Executions: []logging.WafflesExecution{
+			{ID: "child", WheresmypromptArgs: "--section go", ParentExecutionID: "parent", Source: "alice", Created: created, Updated: created},
+		},
+	}
+
+	var buf bytes.Buffer
+	if err := (&CSVFormatter{IncludeHeaders: true}).FormatCSV(data, &buf); err != nil {
+		t.Fatalf("Failed to export: %v", err)
+	}
+	records, err := csv.NewReader(&buf).ReadAll()
+	if err != nil || len(records) != 2 {
+		t.Fatalf("Expected a header and one row, got %v: %v", records, err)
+	}
+
+	row := make(map[string]string)
+	for i, column := range records[0] {
+		row[column] = records[1][i]
+	}
+	for column, want := range map[string]string{"WheresmypromptArgs": "--section go", "ParentExecutionID": "parent", "Source": "alice"} {
+		if row[column] != want {
+			t.Errorf("Expected %s %q, got %q", column, want, row[column])
+		}
+	}
+}
@@ -0,0 +1,91 @@
+package export
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/toozej/waffles/pkg/logging"
+)
+
+// SQLiteFormatter handles SQLite export formatting. The output is a
+// standalone database containing the waffles_executions, waffles_files and
+// waffles_steps tables with only the exported rows, using the same schema as
+// the live log database so it can be opened by any waffles command.
+type SQLiteFormatter struct {
+	// TempDir is where the database is built before being copied to the
+	// writer (default: the system temporary directory)
+	TempDir string
+}
+
+// FormatSQLite exports data as a SQLite database file
+func (f *SQLiteFormatter) FormatSQLite(data *ExportData, writer io.Writer) error {
+	return writeAll(f.NewStreamWriter(writer), data)
+}
+
+// NewStreamWriter returns a stream writer producing a SQLite database. Rows
+// are inserted batch by batch into a temporary database, which is copied to
+// the writer when the writer is closed.
+func (f *SQLiteFormatter) NewStreamWriter(writer io.Writer) StreamWriter {
+	return &sqliteStreamWriter{formatter: f, writer: writer}
+}
+
+type sqliteStreamWriter struct {
+	formatter *SQLiteFormatter
+	writer    io.Writer
+	dir       string
+	db        *logging.Database
+}
+
+func (w *sqliteStreamWriter) WriteHeader(metadata ExportMetadata) error {
+	dir, err := os.MkdirTemp(w.formatter.TempDir, "waffles-export-*")
+	if err != nil {
+		return fmt.Errorf("failed to create temporary directory: %w", err)
+	}
+	w.dir = dir
+
+	db, err := logging.NewDatabase(filepath.Join(dir, "export.sqlite"))
+	if err != nil {
+		return fmt.Errorf("failed to create export database: %w", err)
+	}
+	w.db = db
+	return nil
+}
+
+func (w *sqliteStreamWriter) WriteBatch(batch *ExportData) error {
+	if w.db == nil {
+		return fmt.Errorf("export database not initialized")
+	}
+	if err := w.db.InsertBatch(batch.Executions, batch.Files, batch.Steps); err != nil {
+		return fmt.Errorf("failed to write batch to export database: %w", err)
+	}
+	return nil
+}
+
+func (w *sqliteStreamWriter) Close() error {
+	if w.dir == "" {
+		return nil
+	}
+	defer func() { _ = os.RemoveAll(w.dir) }()
+
+	if w.db == nil {
+		return nil
+	}
+
+	path := w.db.Path()
+	if err := w.db.Close(); err != nil {
+		return fmt.Errorf("failed to close export database: %w", err)
+	}
+
+	file, err := os.Open(path) // #nosec G304 -- Path inside our own temporary directory
+	if err != nil {
+		return fmt.Errorf("failed to open export database: %w", err)
+	}
+	defer file.Close()
+
+	if _, err := io.Copy(w.writer, file); err != nil {
+		return fmt.Errorf("failed to copy export database: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,392 @@
+package export
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"time"
+)
+
+// StreamWriter writes an export incrementally, one batch of executions at a
+// time, so large histories never have to be held in memory
+type StreamWriter interface {
+	// WriteHeader writes anything that precedes the first batch
+	WriteHeader(metadata ExportMetadata) error
+	// WriteBatch writes a batch of executions together with their files and steps
+	WriteBatch(batch *ExportData) error
+	// Close writes anything that follows the last batch and releases resources
+	Close() error
+}
+
+// writeAll writes a complete in-memory export through a stream writer
+func writeAll(stream StreamWriter, data *ExportData) error {
+	if err := stream.WriteHeader(data.Metadata); err != nil {
+		_ = stream.Close() // Report the header error rather than the cleanup error
+		return err
+	}
+	if err := stream.WriteBatch(data); err != nil {
+		_ = stream.Close() // Report the batch error rather than the cleanup error
+		return err
+	}
+	return stream.Close()
+}
+
+// JSONLinesFormatter handles JSON Lines export formatting, writing one
+// execution with its nested files and steps per line
+type JSONLinesFormatter struct{}
+
+// FormatJSONLines exports data in JSON Lines format
+func (f *JSONLinesFormatter) FormatJSONLines(data *ExportData, writer io.Writer) error {
+	return writeAll(f.NewStreamWriter(writer), data)
+}
+
+// NewStreamWriter returns a stream writer producing JSON Lines
+func (f *JSONLinesFormatter) NewStreamWriter(writer io.Writer) StreamWriter {
+	return &jsonLinesStreamWriter{encoder: json.NewEncoder(writer)}
+}
+
+type jsonLinesStreamWriter struct {
+	encoder *json.Encoder
+}
+
+func (w *jsonLinesStreamWriter) WriteHeader(metadata ExportMetadata) error {
+	return nil
+}
+
+func (w *jsonLinesStreamWriter) WriteBatch(batch *ExportData) error {
+	for _, exec := range batch.Executions {
+		if err := w.encoder.Encode(batch.Record(exec)); err != nil {
+			return fmt.Errorf("failed to write JSON line: %w", err)
+		}
+	}
+	return nil
+}
+
+func (w *jsonLinesStreamWriter) Close() error {
+	return nil
+}
+
+// NewStreamWriter returns a stream writer producing CSV. Executions are
+// written as they arrive; file and step rows are spooled to temporary files
+// and appended as separate tables when the writer is closed.
+func (f *CSVFormatter) NewStreamWriter(writer io.Writer) StreamWriter {
+	return &csvStreamWriter{
+		formatter: f,
+		writer:    writer,
+		csv:       csv.NewWriter(writer),
+	}
+}
+
+// csvFilesHeader and csvStepsHeader are the headers of the CSV files and steps tables
+var (
+	csvFilesHeader = []string{"ExecutionID", "ID", "FilePath", "FileSize", "Included", "ExclusionReason", "Created"}
+	csvStepsHeader = []string{"ExecutionID", "ID", "StepOrder", "Tool", "Command", "Success", "DurationMS", "Output", "ErrorOutput", "Created"}
+)
+
+type csvStreamWriter struct {
+	formatter *CSVFormatter
+	writer    io.Writer
+	csv       *csv.Writer
+	files     *csvSpool
+	steps     *csvSpool
+}
+
+func (w *csvStreamWriter) WriteHeader(metadata ExportMetadata) error {
+	if !w.formatter.IncludeHeaders {
+		return nil
+	}
+
+	// Define columns
+	columns := []string{
+		"ID", "ConversationID", "CommandArgs", "WheresmypromptQuery",
+		"WheresmypromptArgs", "Files2promptArgs", "LLMArgs", "DetectedLanguage",
+		"FileCount", "ExecutionTimeMS", "Success", "ErrorMessage", "ModelUsed",
+		"ProviderUsed", "ParentExecutionID", "Source", "Created", "Updated",
+	}
+
+	// Use custom columns if provided
+	if len(w.formatter.CustomColumns) > 0 {
+		columns = w.formatter.CustomColumns
+	}
+
+	if err := w.csv.Write(columns); err != nil {
+		return fmt.Errorf("failed to write CSV header: %w", err)
+	}
+	return nil
+}
+
+func (w *csvStreamWriter) WriteBatch(batch *ExportData) error {
+	// Write data rows
+	for _, exec := range batch.Executions {
+		record := []string{
+			exec.ID, exec.ConversationID, exec.CommandArgs, exec.WheresmypromptQuery,
+			exec.WheresmypromptArgs, exec.Files2promptArgs, exec.LLMArgs, exec.DetectedLanguage,
+			fmt.Sprintf("%d", exec.FileCount), fmt.Sprintf("%d", exec.ExecutionTimeMS), fmt.Sprintf("%t", exec.Success), exec.ErrorMessage, exec.ModelUsed,
+			exec.ProviderUsed, exec.ParentExecutionID, exec.Source, exec.Created.Format(time.RFC3339), exec.Updated.Format(time.RFC3339),
+		}
+
+		if err := w.csv.Write(record); err != nil {
+			return fmt.Errorf("failed to write CSV record: %w", err)
+		}
+	}
+
+	w.csv.Flush()
+	if err := w.csv.Error(); err != nil {
+		return fmt.Errorf("failed to write CSV records: %w", err)
+	}
+
+	if batch.Files != nil {
+		if err := w.spoolFiles(batch); err != nil {
+			return err
+		}
+	}
+
+	if batch.Steps != nil {
+		if err := w.spoolSteps(batch); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// spoolFiles buffers the file rows of a batch for the files table
+func (w *csvStreamWriter) spoolFiles(batch *ExportData) error {
+	if w.files == nil {
+		spool, err := newCSVSpool(csvFilesHeader, w.formatter.IncludeHeaders)
+		if err != nil {
+			return err
+		}
+		w.files = spool
+	}
+
+	for _, exec := range batch.Executions {
+		for _, file := range batch.Files[exec.ID] {
+			record := []string{
+				exec.ID, file.ID, file.FilePath, fmt.Sprintf("%d", file.FileSize),
+				fmt.Sprintf("%t", file.Included), file.ExclusionReason, file.Created.Format(time.RFC3339),
+			}
+			if err := w.files.csv.Write(record); err != nil {
+				return fmt.Errorf("failed to write CSV file record: %w", err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// spoolSteps buffers the step rows of a batch for the steps table
+func (w *csvStreamWriter) spoolSteps(batch *ExportData) error {
+	if w.steps == nil {
+		spool, err := newCSVSpool(csvStepsHeader, w.formatter.IncludeHeaders)
+		if err != nil {
+			return err
+		}
+		w.steps = spool
+	}
+
+	for _, exec := range batch.Executions {
+		for _, step := range batch.Steps[exec.ID] {
+			record := []string{
+				exec.ID, step.ID, fmt.Sprintf("%d", step.StepOrder), step.Tool, step.Command,
+				fmt.Sprintf("%t", step.Success), fmt.Sprintf("%d", step.DurationMS),
+				step.Output, step.ErrorOutput, step.Created.Format(time.RFC3339),
+			}
+			if err := w.steps.csv.Write(record); err != nil {
+				return fmt.Errorf("failed to write CSV step record: %w", err)
+			}
+		}
+	}
+
+	return nil
+}
+
+func (w *csvStreamWriter) Close() error {
+	var firstErr error
+	for _, spool := range []*csvSpool{w.files, w.steps} {
+		if spool == nil {
+			continue
+		}
+		if firstErr == nil {
+			firstErr = spool.appendTo(w.writer)
+		}
+		spool.remove()
+	}
+	return firstErr
+}
+
+// csvSpool buffers the rows of a secondary CSV table in a temporary file
+type csvSpool struct {
+	file *os.File
+	csv  *csv.Writer
+}
+
+// newCSVSpool creates a spool and writes the table header into it
+func newCSVSpool(header []string, includeHeader bool) (*csvSpool, error) {
+	file, err := os.CreateTemp("", "waffles-export-*.csv")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create CSV spool file: %w", err)
+	}
+
+	spool := &csvSpool{file: file, csv: csv.NewWriter(file)}
+	if includeHeader {
+		if err := spool.csv.Write(header); err != nil {
+			spool.remove()
+			return nil, fmt.Errorf("failed to write CSV table header: %w", err)
+		}
+	}
+
+	return spool, nil
+}
+
+// appendTo copies the spooled table to writer, preceded by the blank line
+// that separates tables
+func (s *csvSpool) appendTo(writer io.Writer) error {
+	s.csv.Flush()
+	if err := s.csv.Error(); err != nil {
+		return fmt.Errorf("failed to flush CSV table: %w", err)
+	}
+	if _, err := s.file.Seek(0, io.SeekStart); err != nil {
+		return fmt.Errorf("failed to rewind CSV spool file: %w", err)
+	}
+	if _, err := io.WriteString(writer, "\n"); err != nil {
+		return fmt.Errorf("failed to separate CSV tables: %w", err)
+	}
+	if _, err := io.Copy(writer, s.file); err != nil {
+		return fmt.Errorf("failed to write CSV table: %w", err)
+	}
+	return nil
+}
+
+// remove closes and deletes the spool file
+func (s *csvSpool) remove() {
+	_ = s.file.Close()           // Spool is temporary; close errors don't matter
+	_ = os.Remove(s.file.Name()) // Best-effort cleanup of the temporary file
+}
+
+// NewStreamWriter returns a stream writer producing SQL INSERT statements
+func (f *SQLFormatter) NewStreamWriter(writer io.Writer) StreamWriter {
+	return &sqlStreamWriter{formatter: f, writer: writer}
+}
+
+type sqlStreamWriter struct {
+	formatter *SQLFormatter
+	writer    io.Writer
+	written   int
+}
+
+func (w *sqlStreamWriter) WriteHeader(metadata ExportMetadata) error {
+	// Write header
+	fmt.Fprintf(w.writer, "-- Waffles Execution Data Export\n")
+	fmt.Fprintf(w.writer, "-- Generated: %s\n", metadata.ExportedAt.Format(time.RFC3339))
+	fmt.Fprintf(w.writer, "-- Records: %d\n\n", metadata.RecordCount)
+
+	// Write schema if requested
+	if w.formatter.IncludeSchema {
+		if err := w.formatter.writeSchema(w.writer); err != nil {
+			return fmt.Errorf("failed to write schema: %w", err)
+		}
+	}
+
+	return nil
+}
+
+func (w *sqlStreamWriter) WriteBatch(batch *ExportData) error {
+	f := w.formatter
+
+	tableName := f.TableName
+	if tableName == "" {
+		tableName = "waffles_executions"
+	}
+
+	batchSize := f.BatchSize
+	if batchSize == 0 {
+		batchSize = 100
+	}
+
+	for _, exec := range batch.Executions {
+		if w.written%batchSize == 0 && w.written > 0 {
+			fmt.Fprintf(w.writer, "\n-- Batch %d\n", w.written/batchSize+1)
+		}
+		w.written++
+
+		_, err := fmt.Fprintf(w.writer,
+			"INSERT INTO %s (id, conversation_id, command_args, wheresmyprompt_query, wheresmyprompt_args, files2prompt_args, llm_args, detected_language, file_count, execution_time_ms, success, error_message, model_used, provider_used, parent_execution_id, source, created, updated) VALUES (%s, %s, %s, %s, %s, %s, %s, %s, %d, %d, %t, %s, %s, %s, %s, %s, '%s', '%s');\n",
+			tableName,
+			f.sqlQuote(exec.ID),
+			f.sqlNullable(exec.ConversationID),
+			f.sqlQuote(exec.CommandArgs),
+			f.sqlQuote(exec.WheresmypromptQuery),
+			f.sqlQuote(exec.WheresmypromptArgs),
+			f.sqlQuote(exec.Files2promptArgs),
+			f.sqlQuote(exec.LLMArgs),
+			f.sqlQuote(exec.DetectedLanguage),
+			exec.FileCount,
+			exec.ExecutionTimeMS,
+			exec.Success,
+			f.sqlQuote(exec.ErrorMessage),
+			f.sqlQuote(exec.ModelUsed),
+			f.sqlQuote(exec.ProviderUsed),
+			f.sqlNullable(exec.ParentExecutionID),
+			f.sqlNullable(exec.Source),
+			exec.Created.Format(time.RFC3339Nano),
+			exec.Updated.Format(time.RFC3339Nano),
+		)
+		if err != nil {
+			return fmt.Errorf("failed to write SQL insert: %w", err)
+		}
+	}
+
+	if batch.Files != nil {
+		fmt.Fprintf(w.writer, "\n-- Files\n")
+		for _, exec := range batch.Executions {
+			for _, file := range batch.Files[exec.ID] {
+				_, err := fmt.Fprintf(w.writer,
+					"INSERT INTO waffles_files (id, execution_id, file_path, file_size, included, exclusion_reason, created) VALUES (%s, %s, %s, %d, %t, %s, '%s');\n",
+					f.sqlQuote(file.ID),
+					f.sqlQuote(exec.ID),
+					f.sqlQuote(file.FilePath),
+					file.FileSize,
+					file.Included,
+					f.sqlQuote(file.ExclusionReason),
+					file.Created.Format(time.RFC3339Nano),
+				)
+				if err != nil {
+					return fmt.Errorf("failed to write SQL insert: %w", err)
+				}
+			}
+		}
+	}
+
+	if batch.Steps != nil {
+		fmt.Fprintf(w.writer, "\n-- Steps\n")
+		for _, exec := range batch.Executions {
+			for _, step := range batch.Steps[exec.ID] {
+				_, err := fmt.Fprintf(w.writer,
+					"INSERT INTO waffles_steps (id, execution_id, tool, command, output, error_output, success, duration_ms, step_order, created) VALUES (%s, %s, %s, %s, %s, %s, %t, %d, %d, '%s');\n",
+					f.sqlQuote(step.ID),
+					f.sqlQuote(exec.ID),
+					f.sqlQuote(step.Tool),
+					f.sqlQuote(step.Command),
+					f.sqlQuote(step.Output),
+					f.sqlQuote(step.ErrorOutput),
+					step.Success,
+					step.DurationMS,
+					step.StepOrder,
+					step.Created.Format(time.RFC3339Nano),
+				)
+				if err != nil {
+					return fmt.Errorf("failed to write SQL insert: %w", err)
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+func (w *sqlStreamWriter) Close() error {
+	return nil
+}
@@ -0,0 +1,285 @@
+// Package failures clusters failed executions by pipeline phase, tool and a
+// normalized error signature, and suggests remedies for known causes.
+package failures
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/toozej/waffles/internal/query"
+	"github.com/toozej/waffles/pkg/logging"
+	"github.com/toozej/waffles/pkg/pipeline"
+)
+
+// UnknownPhase is used for failures whose phase cannot be determined
+const UnknownPhase = "unknown"
+
+// maxExamples limits the execution IDs kept per cluster
+const maxExamples = 5
+
+// batchSize is the number of failed executions whose steps are loaded at once
+const batchSize = 500
+
+// Cluster is a group of failures with the same phase, tool and signature
+type Cluster struct {
+	Phase        string    `json:"phase"`
+	Tool         string    `json:"tool,omitempty"`
+	Signature    string    `json:"signature"`
+	Count        int       `json:"count"`
+	FirstSeen    time.Time `json:"first_seen"`
+	LastSeen     time.Time `json:"last_seen"`
+	Message      string    `json:"message"`       // Error of the most recent failure
+	ExecutionIDs []string  `json:"execution_ids"` // Most recent failures first
+	Remedies     []Remedy  `json:"remedies,omitempty"`
+}
+
+// Report is the result of a failure analysis
+type Report struct {
+	TotalFailures int       `json:"total_failures"`
+	Clusters      []Cluster `json:"clusters"`
+}
+
+// Analyzer accumulates failed executions into clusters
+type Analyzer struct {
+	clusters map[string]*Cluster
+	total    int
+}
+
+// NewAnalyzer creates an empty analyzer
+func NewAnalyzer() *Analyzer {
+	return &Analyzer{clusters: make(map[string]*Cluster)}
+}
+
+// Analyze clusters the failed executions matching the filters, loading their
+// steps in batches
+func Analyze(engine *query.QueryEngine, filters query.QueryFilters) (*Report, error) {
+	failed := false
+	filters.Success = &failed
+	filters.Limit, filters.Offset = 0, 0
+
+	iter, err := engine.IterateExecutions(filters)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query failed executions: %w", err)
+	}
+	defer iter.Close()
+
+	analyzer := NewAnalyzer()
+	batch := make([]logging.WafflesExecution, 0, batchSize)
+
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		ids := make([]string, len(batch))
+		for i, exec := range batch {
+			ids[i] = exec.ID
+		}
+		steps, err := engine.GetExecutionSteps(ids)
+		if err != nil {
+			return err
+		}
+		for _, exec := range batch {
+			analyzer.Add(exec, steps[exec.ID])
+		}
+		batch = batch[:0]
+		return nil
+	}
+
+	for iter.Next() {
+		batch = append(batch, *iter.Execution())
+		if len(batch) == batchSize {
+			if err := flush(); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if err := iter.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read failed executions: %w", err)
+	}
+	if err := flush(); err != nil {
+		return nil, err
+	}
+
+	return analyzer.Report(), nil
+}
+
+// Add records a failed execution with its steps
+func (a *Analyzer) Add(exec logging.WafflesExecution, steps []logging.WafflesStep) {
+	phase, tool, message := classify(exec, steps)
+
+	// Tool output carries the actual cause when the pipeline message is generic
+	var stderr string
+	if step := failedStep(steps); step != nil {
+		stderr = strings.TrimSpace(step.ErrorOutput + "\n" + step.Output)
+	}
+
+	signature := Normalize(message)
+	if line := keyLine(stderr); line != "" {
+		switch normalized := Normalize(line); {
+		case signature == "":
+			signature = normalized
+		case !strings.Contains(signature, normalized):
+			signature += " | " + normalized
+		}
+	}
+	if signature == "" {
+		signature = "(no error message)"
+	}
+
+	key := phase + "\x00" + tool + "\x00" + signature
+	cluster, ok := a.clusters[key]
+	if !ok {
+		cluster = &Cluster{
+			Phase:     phase,
+			Tool:      tool,
+			Signature: signature,
+			FirstSeen: exec.Created,
+			LastSeen:  exec.Created,
+			Message:   exec.ErrorMessage,
+		}
+		a.clusters[key] = cluster
+	}
+
+	a.total++
+	cluster.Count++
+	if exec.Created.Before(cluster.FirstSeen) {
+		cluster.FirstSeen = exec.Created
+	}
+	if exec.Created.After(cluster.LastSeen) {
+		cluster.LastSeen = exec.Created
+		cluster.Message = exec.ErrorMessage
+	}
+	// Executions arrive newest first, so these are the most recent examples
+	if len(cluster.ExecutionIDs) < maxExamples {
+		cluster.ExecutionIDs = append(cluster.ExecutionIDs, exec.ID)
+	}
+
+	for _, remedy := range SuggestRemedies(phase, exec.ErrorMessage+"\n"+stderr) {
+		cluster.addRemedy(remedy)
+	}
+}
+
+// Report returns the clusters, most frequent first
+func (a *Analyzer) Report() *Report {
+	report := &Report{TotalFailures: a.total, Clusters: make([]Cluster, 0, len(a.clusters))}
+	for _, cluster := range a.clusters {
+		report.Clusters = append(report.Clusters, *cluster)
+	}
+
+	sort.Slice(report.Clusters, func(i, j int) bool {
+		a, b := report.Clusters[i], report.Clusters[j]
+		if a.Count != b.Count {
+			return a.Count > b.Count
+		}
+		return a.LastSeen.After(b.LastSeen)
+	})
+
+	return report
+}
+
+func (c *Cluster) addRemedy(remedy Remedy) {
+	for _, existing := range c.Remedies {
+		if existing.Name == remedy.Name {
+			return
+		}
+	}
+	c.Remedies = append(c.Remedies, remedy)
+}
+
+// classify determines the phase, tool and bare error message of a failure.
+// The phase and tool come from the stored PipelineError text when there is
+// one, and otherwise from the failed step.
+func classify(exec logging.WafflesExecution, steps []logging.WafflesStep) (phase, tool, message string) {
+	message = strings.TrimSpace(exec.ErrorMessage)
+	if pipelineErr, ok := pipeline.ParsePipelineError(message); ok {
+		phase, tool, message = string(pipelineErr.Phase), pipelineErr.Tool, pipelineErr.Message
+	}
+
+	if step := failedStep(steps); step != nil {
+		if tool == "" {
+			tool = step.Tool
+		}
+		if phase == "" {
+			phase = string(pipeline.ToolPhase(step.Tool))
+		}
+	}
+	if phase == "" {
+		phase = UnknownPhase
+	}
+
+	return phase, tool, message
+}
+
+// failedStep returns the last unsuccessful step, or nil
+func failedStep(steps []logging.WafflesStep) *logging.WafflesStep {
+	for i := len(steps) - 1; i >= 0; i-- {
+		if !steps[i].Success {
+			return &steps[i]
+		}
+	}
+	return nil
+}
+
+var errorLinePattern = regexp.MustCompile(`(?i)error|fail|denied|not found|timed? ?out|exception|invalid|refused|unauthori[sz]ed|forbidden|limit`)
+
+// keyLine picks the line of tool output that best describes an error: the
+// first one that looks like an error, otherwise the last non-empty line
+func keyLine(output string) string {
+	var last string
+	for _, line := range strings.Split(output, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		if errorLinePattern.MatchString(line) {
+			return line
+		}
+		last = line
+	}
+	return last
+}
+
+// maxSignatureLength limits signatures to a readable length
+const maxSignatureLength = 160
+
+var (
+	urlPattern    = regexp.MustCompile(`[a-z][a-z0-9+.-]*://\S+`)
+	uuidPattern   = regexp.MustCompile(`[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}`)
+	quotedPattern = regexp.MustCompile(`"[^"]*"|'[^']*'|` + "`[^`]*`")
+	pathPattern   = regexp.MustCompile(`(?:~|\.{1,2})?(?:/[\w.@+-]+){2,}/?|(?:~|\.{1,2})/[\w.@+-]+`)
+	hexPattern    = regexp.MustCompile(`\b(?:0x)?[0-9a-f]{7,}\b`)
+	numberPattern = regexp.MustCompile(`\b\d+(?:\.\d+)?(?:ms|s|m|h|k|kb|mb|gb)?\b`)
+	spacePattern  = regexp.MustCompile(`\s+`)
+	digitPattern  = regexp.MustCompile(`\d`)
+)
+
+// hexPlaceholder replaces hex words that look like hashes or IDs, which
+// unlike ordinary words contain a digit
+func hexPlaceholder(s string) string {
+	if digitPattern.MatchString(s) {
+		return "<hex>"
+	}
+	return s
+}
+
+// Normalize reduces an error message to a signature shared by failures with
+// the same cause, replacing URLs, IDs, quoted values, paths and numbers with
+// placeholders
+func Normalize(message string) string {
+	signature := strings.ToLower(strings.TrimSpace(message))
+	signature = urlPattern.ReplaceAllString(signature, "<url>")
+	signature = uuidPattern.ReplaceAllString(signature, "<id>")
+	signature = quotedPattern.ReplaceAllString(signature, "<str>")
+	signature = pathPattern.ReplaceAllString(signature, "<path>")
+	signature = hexPattern.ReplaceAllStringFunc(signature, hexPlaceholder)
+	signature = numberPattern.ReplaceAllString(signature, "<n>")
+	signature = strings.TrimSpace(spacePattern.ReplaceAllString(signature, " "))
+
+	if runes := []rune(signature); len(runes) > maxSignatureLength {
+		signature = string(runes[:maxSignatureLength-1]) + "…"
+	}
+	return signature
+}
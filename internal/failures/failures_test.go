@@ -0,0 +1,152 @@
+package failures
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/toozej/waffles/pkg/logging"
+	"github.com/toozej/waffles/pkg/pipeline"
+)
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		name     string
+		messages []string
+		want     string
+	}{
+		{
+			name: "execution IDs",
+			messages: []string{
+				"execution 3f2b8c1e-9a4d-4e2f-8b6a-1c2d3e4f5a6b not found",
+				"Execution 00000000-1111-2222-3333-444444444444 not found",
+			},
+			want: "execution <id> not found",
+		},
+		{
+			name: "commit hashes",
+			messages: []string{
+				"object 4b825dc642cb6eb9a060e54bf8d69288fbee4904 is missing",
+				"object 0x1a2b3c4d5e is missing",
+			},
+			want: "object <hex> is missing",
+		},
+		{
+			name: "paths",
+			messages: []string{
+				"open /home/alice/src/app/main.go: permission denied",
+				"open ./cmd/waffles/root.go: permission denied",
+				"open ~/notes.md: permission denied",
+			},
+			want: "open <path>: permission denied",
+		},
+		{
+			name: "numbers and durations",
+			messages: []string{
+				"exit status 1 after 30s",
+				"Exit status 137 after 1.5m",
+				"exit status 2 after 250ms",
+			},
+			want: "exit status <n> after <n>",
+		},
+		{
+			name: "quoted values and URLs",
+			messages: []string{
+				`unknown model "gpt-5" at https://api.openai.com/v1/models`,
+				"unknown model 'llama3:70b' at http://localhost:11434/api/tags",
+			},
+			want: "unknown model <str> at <url>",
+		},
+		{
+			name:     "whitespace",
+			messages: []string{"  connection\n\trefused  ", "connection refused"},
+			want:     "connection refused",
+		},
+		{
+			name:     "words with hex letters stay",
+			messages: []string{"deadbeefcafe failed"},
+			want:     "deadbeefcafe failed",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, message := range tt.messages {
+				if got := Normalize(message); got != tt.want {
+					t.Errorf("Normalize(%q) = %q, expected %q", message, got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestNormalizeKeepsCausesApart(t *testing.T) {
+	pairs := [][2]string{
+		{"exit status 1: command not found", "exit status 1: permission denied"},
+		{"open /tmp/a/b: no such file or directory", "open /tmp/a/b: permission denied"},
+		{"context deadline exceeded", "context canceled"},
+		{"HTTP 401 unauthorized", "HTTP 429 too many requests"},
+	}
+	for _, pair := range pairs {
+		if a, b := Normalize(pair[0]), Normalize(pair[1]); a == b {
+			t.Errorf("Expected %q and %q to differ, both became %q", pair[0], pair[1], a)
+		}
+	}
+}
+
+func TestNormalizeTruncates(t *testing.T) {
+	long := ""
+	for len(long) < 2*maxSignatureLength {
+		long += "error "
+	}
+	if got := []rune(Normalize(long)); len(got) != maxSignatureLength || got[len(got)-1] != '…' {
+		t.Errorf("Expected a signature of %d runes ending in an ellipsis, got %q", maxSignatureLength, string(got))
+	}
+}
+
+func TestAnalyzerClusters(t *testing.T) {
+	base := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	failure := func(id string, hours int, message, stderr string) (logging.WafflesExecution, []logging.WafflesStep) {
+		exec := logging.WafflesExecution{ID: id, ErrorMessage: message, Created: base.Add(time.Duration(hours) * time.Hour)}
+		steps := []logging.WafflesStep{{ExecutionID: id, Tool: "llm", ErrorOutput: stderr}}
+		return exec, steps
+	}
+
+	analyzer := NewAnalyzer()
+	// Newest first, as Analyze reads them
+	analyzer.Add(failure("c", 3, "pipeline error in llm_execution phase (tool: llm): exit status 1", "Error: request timed out after 60s"))
+	analyzer.Add(failure("b", 2, "pipeline error in llm_execution phase (tool: llm): exit status 1", "Error: request timed out after 30s"))
+	analyzer.Add(failure("a", 1, "pipeline error in llm_execution phase (tool: llm): exit status 1", "Error: 401 unauthorized"))
+
+	report := analyzer.Report()
+	if report.TotalFailures != 3 || len(report.Clusters) != 2 {
+		t.Fatalf("Expected 3 failures in 2 clusters, got %+v", report)
+	}
+
+	timeouts := report.Clusters[0]
+	if timeouts.Phase != string(pipeline.PhaseLLMExecution) || timeouts.Tool != "llm" || timeouts.Count != 2 ||
+		timeouts.Signature != "exit status <n> | error: request timed out after <n>" {
+		t.Errorf("Unexpected timeout cluster: %+v", timeouts)
+	}
+	if !reflect.DeepEqual(timeouts.ExecutionIDs, []string{"c", "b"}) || !timeouts.FirstSeen.Equal(base.Add(2*time.Hour)) ||
+		!timeouts.LastSeen.Equal(base.Add(3*time.Hour)) {
+		t.Errorf("Unexpected timeout cluster examples: %+v", timeouts)
+	}
+	if len(timeouts.Remedies) != 1 || timeouts.Remedies[0].Name != "timeout" {
+		t.Errorf("Expected the timeout remedy, got %+v", timeouts.Remedies)
+	}
+
+	if auth := report.Clusters[1]; auth.Count != 1 || len(auth.Remedies) != 1 || auth.Remedies[0].Name != "authentication" {
+		t.Errorf("Unexpected authentication cluster: %+v", auth)
+	}
+}
+
+func TestAnalyzerWithoutMessage(t *testing.T) {
+	analyzer := NewAnalyzer()
+	analyzer.Add(logging.WafflesExecution{ID: "a"}, nil)
+
+	cluster := analyzer.Report().Clusters[0]
+	if cluster.Phase != UnknownPhase || cluster.Signature != "(no error message)" {
+		t.Errorf("Unexpected cluster for a failure without details: %+v", cluster)
+	}
+}
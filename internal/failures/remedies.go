@@ -0,0 +1,90 @@
+package failures
+
+import (
+	"regexp"
+
+	"github.com/toozej/waffles/pkg/pipeline"
+)
+
+// Remedy is a suggested fix for a known cause of failure
+type Remedy struct {
+	Name       string `json:"name"`
+	Suggestion string `json:"suggestion"`
+}
+
+// remedyRule suggests a remedy when the error text matches its pattern, or
+// when the failure happened in one of its phases
+type remedyRule struct {
+	remedy  Remedy
+	pattern *regexp.Regexp
+	phases  []pipeline.ExecutionPhase
+}
+
+var remedyRules = []remedyRule{
+	{
+		remedy: Remedy{
+			Name:       "missing dependency",
+			Suggestion: "A pipeline tool is missing or not on PATH. Run 'waffles deps check' and install it with 'waffles deps install'.",
+		},
+		pattern: regexp.MustCompile(`(?i)command not found|executable file not found|fork/exec \S+: no such file or directory|not installed|is not recognized as an internal or external command|no module named`),
+		phases:  []pipeline.ExecutionPhase{pipeline.PhaseDepCheck},
+	},
+	{
+		remedy: Remedy{
+			Name:       "timeout",
+			Suggestion: "The tool ran out of time. Retry, select fewer files with --include/--exclude, or pick a faster model.",
+		},
+		pattern: regexp.MustCompile(`(?i)timed? ?out|deadline exceeded|signal: killed|context canceled`),
+	},
+	{
+		remedy: Remedy{
+			Name:       "authentication",
+			Suggestion: "The provider rejected the credentials. Set the API key with 'llm keys set <provider>' or the provider's API key environment variable.",
+		},
+		pattern: regexp.MustCompile(`(?i)\b40[13]\b|unauthori[sz]ed|forbidden|invalid (?:api )?key|api key|authentication|permission denied for model|incorrect api key`),
+	},
+	{
+		remedy: Remedy{
+			Name:       "rate limit",
+			Suggestion: "The provider is throttling requests. Wait before retrying, check the account quota, or switch to another model.",
+		},
+		pattern: regexp.MustCompile(`(?i)\b429\b|rate.?limit|too many requests|quota|overloaded`),
+	},
+	{
+		remedy: Remedy{
+			Name:       "context too long",
+			Suggestion: "The prompt exceeded the model's context window. Select fewer files with --include/--exclude or use a model with a larger context.",
+		},
+		pattern: regexp.MustCompile(`(?i)context.?length|maximum context|context window|too many tokens|token limit|prompt is too long`),
+	},
+	{
+		remedy: Remedy{
+			Name:       "unknown model",
+			Suggestion: "The model is not available to llm. List models with 'llm models' and install the plugin providing it with 'llm install'.",
+		},
+		pattern: regexp.MustCompile(`(?i)unknown model|model .* not found|no such model`),
+	},
+	{
+		remedy: Remedy{
+			Name:       "network",
+			Suggestion: "The provider could not be reached. Check the network connection, proxy settings and the provider's status page.",
+		},
+		pattern: regexp.MustCompile(`(?i)connection refused|connection reset|no such host|network is unreachable|tls handshake|temporary failure in name resolution`),
+	},
+}
+
+// SuggestRemedies returns the remedies whose rules match the failure phase or
+// the error text, which should include the step stderr
+func SuggestRemedies(phase, text string) []Remedy {
+	var remedies []Remedy
+	for _, rule := range remedyRules {
+		matched := rule.pattern.MatchString(text)
+		for _, p := range rule.phases {
+			matched = matched || string(p) == phase
+		}
+		if matched {
+			remedies = append(remedies, rule.remedy)
+		}
+	}
+	return remedies
+}
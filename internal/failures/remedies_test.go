@@ -0,0 +1,81 @@
+package failures
+
+import (
+	"testing"
+
+	"github.com/toozej/waffles/pkg/pipeline"
+)
+
+func TestSuggestRemedies(t *testing.T) {
+	tests := []struct {
+		remedy string
+		match  []string
+		ignore []string
+	}{
+		{
+			remedy: "missing dependency",
+			match: []string{
+				"sh: 1: files2prompt: command not found",
+				`exec: "llm": executable file not found in $PATH`,
+				"fork/exec /usr/local/bin/llm: no such file or directory",
+				"'llm' is not recognized as an internal or external command",
+				"ModuleNotFoundError: No module named 'llm_ollama'",
+			},
+			ignore: []string{"open config.yaml: no such file or directory", "model not found"},
+		},
+		{
+			remedy: "timeout",
+			match: []string{
+				"Error: request timed out",
+				"read tcp: i/o timeout",
+				"context deadline exceeded",
+				"signal: killed",
+			},
+			ignore: []string{"exit status 1", "output was truncated at 4000 tokens"},
+		},
+		{
+			remedy: "authentication",
+			match: []string{
+				"Error: 401 Unauthorized",
+				"HTTP 403 Forbidden",
+				"Error: Incorrect API key provided: sk-abc",
+				"No key found - add one using 'llm keys set openai' or set the OPENAI_API_KEY environment variable... api key",
+			},
+			ignore: []string{"exit status 4013", "permission denied", "processed 4030 files"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.remedy, func(t *testing.T) {
+			for _, text := range tt.match {
+				if !hasRemedy(SuggestRemedies(string(pipeline.PhaseLLMExecution), text), tt.remedy) {
+					t.Errorf("Expected %q to suggest %s", text, tt.remedy)
+				}
+			}
+			for _, text := range tt.ignore {
+				if hasRemedy(SuggestRemedies(string(pipeline.PhaseLLMExecution), text), tt.remedy) {
+					t.Errorf("Expected %q not to suggest %s", text, tt.remedy)
+				}
+			}
+		})
+	}
+}
+
+func TestSuggestRemediesByPhase(t *testing.T) {
+	// Any dependency check failure is a missing dependency, whatever the text
+	if !hasRemedy(SuggestRemedies(string(pipeline.PhaseDepCheck), "exit status 1"), "missing dependency") {
+		t.Error("Expected the dependency check phase to suggest installing dependencies")
+	}
+	if remedies := SuggestRemedies(string(pipeline.PhaseLLMExecution), "something unexpected happened"); len(remedies) != 0 {
+		t.Errorf("Expected no remedies for unrelated text, got %+v", remedies)
+	}
+}
+
+func hasRemedy(remedies []Remedy, name string) bool {
+	for _, remedy := range remedies {
+		if remedy.Name == name {
+			return true
+		}
+	}
+	return false
+}
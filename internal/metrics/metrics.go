@@ -0,0 +1,196 @@
+// Package metrics renders usage metrics from the log database in the
+// OpenMetrics and Prometheus text exposition formats.
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/toozej/waffles/pkg/logging"
+)
+
+// Format is a text exposition format
+type Format string
+
+const (
+	// FormatOpenMetrics is the OpenMetrics 1.0 text format
+	FormatOpenMetrics Format = "openmetrics"
+	// FormatPrometheus is the Prometheus 0.0.4 text format, as read by the
+	// node_exporter textfile collector
+	FormatPrometheus Format = "prometheus"
+)
+
+// Collect opens the log database and collects a metrics snapshot
+func Collect(dbPath string) (*logging.MetricsSnapshot, error) {
+	db, err := logging.NewDatabase(dbPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open log database: %w", err)
+	}
+	defer db.Close()
+
+	return db.CollectMetrics()
+}
+
+// Write renders a metrics snapshot in the given format
+func Write(w io.Writer, snapshot *logging.MetricsSnapshot, format Format) error {
+	if format != FormatOpenMetrics && format != FormatPrometheus {
+		return fmt.Errorf("unsupported metrics format: %s", format)
+	}
+
+	e := &encoder{w: bufio.NewWriter(w), format: format}
+
+	// Pruning removes runs, so their number is a gauge rather than a counter
+	e.family("waffles_runs", "gauge", "", "Pipeline runs currently in the log database.")
+	for _, run := range snapshot.Runs {
+		e.sample("waffles_runs", labels{
+			{"model", run.Model}, {"provider", run.Provider}, {"language", run.Language},
+			{"success", strconv.FormatBool(run.Success)},
+		}, float64(run.Count))
+	}
+
+	e.family("waffles_step_duration_seconds", "histogram", "seconds", "Duration of pipeline steps by tool.")
+	for _, h := range snapshot.StepDurations {
+		e.histogram("waffles_step_duration_seconds", labels{{"tool", h.Label}}, h)
+	}
+
+	e.family("waffles_run_files", "histogram", "", "Number of files processed per run.")
+	e.histogram("waffles_run_files", nil, snapshot.FileCounts)
+
+	if snapshot.LastRun != nil {
+		e.family("waffles_last_run_timestamp_seconds", "gauge", "seconds", "Time of the most recent run.")
+		e.sample("waffles_last_run_timestamp_seconds", nil, float64(snapshot.LastRun.UnixMilli())/1000)
+	}
+
+	e.family("waffles_metrics_collect_duration_seconds", "gauge", "seconds", "Time taken to collect these metrics from the log database.")
+	e.sample("waffles_metrics_collect_duration_seconds", nil, snapshot.CollectTime.Seconds())
+
+	if format == FormatOpenMetrics {
+		e.line("# EOF")
+	}
+
+	if e.err != nil {
+		return e.err
+	}
+	return e.w.Flush()
+}
+
+// WriteFile atomically replaces path with the rendered metrics, so that a
+// collector never reads a partially written file
+func WriteFile(path string, snapshot *logging.MetricsSnapshot, format Format) error {
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0750); err != nil {
+		return fmt.Errorf("failed to create %s: %w", dir, err)
+	}
+
+	tmp, err := os.CreateTemp(dir, "."+filepath.Base(path)+".*")
+	if err != nil {
+		return fmt.Errorf("failed to create temporary metrics file: %w", err)
+	}
+	defer func() { _ = os.Remove(tmp.Name()) }() // No-op once renamed
+
+	if err := Write(tmp, snapshot, format); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write metrics file: %w", err)
+	}
+	// Collectors run as other users; metrics are not secret
+	if err := os.Chmod(tmp.Name(), 0644); err != nil { // #nosec G302 -- World-readable on purpose
+		return fmt.Errorf("failed to set metrics file permissions: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("failed to replace %s: %w", path, err)
+	}
+	return nil
+}
+
+// label is a metric label name and value
+type label struct {
+	name, value string
+}
+
+type labels []label
+
+// encoder writes metric families, remembering the first write error
+type encoder struct {
+	w      *bufio.Writer
+	format Format
+	err    error
+}
+
+func (e *encoder) line(s string) {
+	if e.err == nil {
+		_, e.err = e.w.WriteString(s + "\n")
+	}
+}
+
+// family writes the metadata of a metric family. Only OpenMetrics has units.
+func (e *encoder) family(name, metricType, unit, help string) {
+	e.line("# HELP " + name + " " + escapeHelp(help))
+	e.line("# TYPE " + name + " " + metricType)
+	if unit != "" && e.format == FormatOpenMetrics {
+		e.line("# UNIT " + name + " " + unit)
+	}
+}
+
+func (e *encoder) sample(name string, l labels, value float64) {
+	e.line(name + l.String() + " " + formatFloat(value))
+}
+
+func (e *encoder) histogram(name string, l labels, h logging.Histogram) {
+	for i, bound := range h.Bounds {
+		e.sample(name+"_bucket", append(l[:len(l):len(l)], label{"le", formatBound(bound)}), float64(h.Counts[i]))
+	}
+	e.sample(name+"_bucket", append(l[:len(l):len(l)], label{"le", "+Inf"}), float64(h.Count))
+	e.sample(name+"_count", l, float64(h.Count))
+	e.sample(name+"_sum", l, h.Sum)
+}
+
+func (l labels) String() string {
+	if len(l) == 0 {
+		return ""
+	}
+	parts := make([]string, len(l))
+	for i, lbl := range l {
+		parts[i] = lbl.name + `="` + escapeLabel(lbl.value) + `"`
+	}
+	return "{" + strings.Join(parts, ",") + "}"
+}
+
+var (
+	labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+	helpEscaper  = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+)
+
+func escapeLabel(s string) string { return labelEscaper.Replace(s) }
+func escapeHelp(s string) string  { return helpEscaper.Replace(s) }
+
+// formatFloat formats a sample value in plain decimal notation
+func formatFloat(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	case math.IsNaN(v):
+		return "NaN"
+	default:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	}
+}
+
+// formatBound formats a histogram bucket bound, writing whole numbers with a
+// trailing ".0" as OpenMetrics requires for canonical le values
+func formatBound(v float64) string {
+	if v == math.Trunc(v) {
+		return strconv.FormatFloat(v, 'f', 1, 64)
+	}
+	return formatFloat(v)
+}
@@ -0,0 +1,138 @@
+package metrics
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/toozej/waffles/pkg/logging"
+)
+
+func testSnapshot() *logging.MetricsSnapshot {
+	lastRun := time.Date(2026, 3, 15, 9, 30, 0, 500_000_000, time.UTC)
+	return &logging.MetricsSnapshot{
+		Runs: []logging.RunCount{
+			{Model: "gpt-\"4\"\\o\nx", Provider: "openai", Language: "go", Success: true, Count: 3},
+		},
+		StepDurations: []logging.Histogram{
+			{Label: "llm", Bounds: []float64{0.5, 1}, Counts: []int{1, 2}, Count: 2, Sum: 1.25},
+		},
+		FileCounts:  logging.Histogram{Bounds: []float64{0, 5}, Counts: []int{0, 1}, Count: 1, Sum: 3},
+		LastRun:     &lastRun,
+		CollectTime: 250 * time.Millisecond,
+	}
+}
+
+const expectedOpenMetrics = `# HELP waffles_runs Pipeline runs currently in the log database.
+# TYPE waffles_runs gauge
+waffles_runs{model="gpt-\"4\"\\o\nx",provider="openai",language="go",success="true"} 3
+# HELP waffles_step_duration_seconds Duration of pipeline steps by tool.
+# TYPE waffles_step_duration_seconds histogram
+# UNIT waffles_step_duration_seconds seconds
+waffles_step_duration_seconds_bucket{tool="llm",le="0.5"} 1
+waffles_step_duration_seconds_bucket{tool="llm",le="1.0"} 2
+waffles_step_duration_seconds_bucket{tool="llm",le="+Inf"} 2
+waffles_step_duration_seconds_count{tool="llm"} 2
+waffles_step_duration_seconds_sum{tool="llm"} 1.25
+# HELP waffles_run_files Number of files processed per run.
+# TYPE waffles_run_files histogram
+waffles_run_files_bucket{le="0.0"} 0
+waffles_run_files_bucket{le="5.0"} 1
+waffles_run_files_bucket{le="+Inf"} 1
+waffles_run_files_count 1
+waffles_run_files_sum 3
+# HELP waffles_last_run_timestamp_seconds Time of the most recent run.
+# TYPE waffles_last_run_timestamp_seconds gauge
+# UNIT waffles_last_run_timestamp_seconds seconds
+waffles_last_run_timestamp_seconds 1773567000.5
+# HELP waffles_metrics_collect_duration_seconds Time taken to collect these metrics from the log database.
+# TYPE waffles_metrics_collect_duration_seconds gauge
+# UNIT waffles_metrics_collect_duration_seconds seconds
+waffles_metrics_collect_duration_seconds 0.25
+# EOF
+`
+
+func TestWriteOpenMetrics(t *testing.T) {
+	var buf bytes.Buffer
+	if err := Write(&buf, testSnapshot(), FormatOpenMetrics); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	if buf.String() != expectedOpenMetrics {
+		t.Errorf("Expected:\n%s\ngot:\n%s", expectedOpenMetrics, buf.String())
+	}
+}
+
+func TestWritePrometheus(t *testing.T) {
+	var buf bytes.Buffer
+	if err := Write(&buf, testSnapshot(), FormatPrometheus); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+
+	// The same samples, without units or the EOF marker
+	var expected strings.Builder
+	for _, line := range strings.SplitAfter(expectedOpenMetrics, "\n") {
+		if !strings.HasPrefix(line, "# UNIT ") && line != "# EOF\n" {
+			expected.WriteString(line)
+		}
+	}
+	if buf.String() != expected.String() {
+		t.Errorf("Expected:\n%s\ngot:\n%s", expected.String(), buf.String())
+	}
+}
+
+func TestWriteEmpty(t *testing.T) {
+	var buf bytes.Buffer
+	if err := Write(&buf, &logging.MetricsSnapshot{}, FormatOpenMetrics); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	if strings.Contains(buf.String(), "waffles_last_run_timestamp_seconds") || !strings.HasSuffix(buf.String(), "\n# EOF\n") {
+		t.Errorf("Unexpected metrics for an empty database:\n%s", buf.String())
+	}
+
+	if err := Write(&buf, testSnapshot(), Format("json")); err == nil {
+		t.Error("Expected an error for an unsupported format")
+	}
+}
+
+func TestEscaping(t *testing.T) {
+	if got := (labels{{"a", "x\"y\\z\nw"}, {"b", ""}}).String(); got != `{a="x\"y\\z\nw",b=""}` {
+		t.Errorf("Unexpected label escaping: %s", got)
+	}
+	// Quotes are only escaped in label values
+	if got := escapeHelp("say \"hi\"\\\n"); got != `say "hi"\\\n` {
+		t.Errorf("Unexpected help escaping: %s", got)
+	}
+}
+
+func TestWriteFile(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "textfile")
+	path := filepath.Join(dir, "waffles.prom")
+
+	if err := WriteFile(path, testSnapshot(), FormatOpenMetrics); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+	data, err := os.ReadFile(path) // #nosec G304 -- Test file
+	if err != nil || string(data) != expectedOpenMetrics {
+		t.Errorf("Unexpected file content %q: %v", data, err)
+	}
+	if info, err := os.Stat(path); err != nil || info.Mode().Perm() != 0644 {
+		t.Errorf("Expected a world-readable file, got %v: %v", info.Mode(), err)
+	}
+
+	// A failed write leaves the previous file in place
+	if err := WriteFile(path, testSnapshot(), Format("json")); err == nil {
+		t.Fatal("Expected an error for an unsupported format")
+	}
+	if data, _ := os.ReadFile(path); string(data) != expectedOpenMetrics { // #nosec G304 -- Test file
+		t.Error("Expected the previous file to be kept")
+	}
+
+	// No temporary files are left behind either way
+	entries, err := os.ReadDir(dir)
+	if err != nil || len(entries) != 1 {
+		t.Errorf("Expected only the metrics file, got %v: %v", entries, err)
+	}
+}
@@ -32,21 +32,23 @@ func (qe *QueryEngine) Close() error {
 
 // QueryFilters represents filters for querying executions
 type QueryFilters struct {
-	Since        *time.Time `json:"since,omitempty"`
-	Until        *time.Time `json:"until,omitempty"`
-	Model        string     `json:"model,omitempty"`
-	Provider     string     `json:"provider,omitempty"`
-	Language     string     `json:"language,omitempty"`
-	Success      *bool      `json:"success,omitempty"`
-	MinDuration  *int64     `json:"min_duration,omitempty"`
-	MaxDuration  *int64     `json:"max_duration,omitempty"`
-	MinFileCount *int       `json:"min_file_count,omitempty"`
-	MaxFileCount *int       `json:"max_file_count,omitempty"`
-	Search       string     `json:"search,omitempty"`
-	Limit        int        `json:"limit"`
-	Offset       int        `json:"offset"`
-	OrderBy      string     `json:"order_by"`
-	OrderDesc    bool       `json:"order_desc"`
+	Since        *time.Time          `json:"since,omitempty"`
+	Until        *time.Time          `json:"until,omitempty"`
+	Model        string              `json:"model,omitempty"`
+	Provider     string              `json:"provider,omitempty"`
+	Language     string              `json:"language,omitempty"`
+	Success      *bool               `json:"success,omitempty"`
+	MinDuration  *int64              `json:"min_duration,omitempty"`
+	MaxDuration  *int64              `json:"max_duration,omitempty"`
+	MinFileCount *int                `json:"min_file_count,omitempty"`
+	MaxFileCount *int                `json:"max_file_count,omitempty"`
+	Search       string              `json:"search,omitempty"`
+	Source       string              `json:"source,omitempty"`
+	Conditions   []logging.Condition `json:"conditions,omitempty"` // Parsed from a filter expression, see ParseFilterExpression
+	Limit        int                 `json:"limit"`
+	Offset       int                 `json:"offset"`
+	OrderBy      string              `json:"order_by"`
+	OrderDesc    bool                `json:"order_desc"`
 }
 
 // QueryResult represents the results of a query
@@ -72,8 +74,12 @@ type ExecutionStats struct {
 	ProviderBreakdown map[string]int `json:"provider_breakdown"`
 	DailyStats        map[string]int `json:"daily_stats"`
 	HourlyStats       map[int]int    `json:"hourly_stats"`
+	WeekdayHourly     [7][24]int     `json:"weekday_hourly"` // Sunday first
 	FileCountBuckets  map[string]int `json:"file_count_buckets"`
 	DurationBuckets   map[string]int `json:"duration_buckets"`
+	// Duration percentiles per model and per pipeline step tool
+	ModelDurations []logging.DurationPercentiles `json:"model_durations"`
+	ToolDurations  []logging.DurationPercentiles `json:"tool_durations"`
 }
 
 // QueryExecutions performs a filtered query for executions
@@ -105,6 +111,31 @@ func (qe *QueryEngine) QueryExecutions(filters QueryFilters) (*QueryResult, erro
 	return result, nil
 }
 
+// IterateExecutions returns a row iterator over the executions matching the
+// filters, for processing large histories without loading them into memory
+func (qe *QueryEngine) IterateExecutions(filters QueryFilters) (*logging.ExecutionIterator, error) {
+	return qe.db.IterateExecutions(qe.convertToExecutionFilter(filters))
+}
+
+// CountExecutions returns the number of executions the filters select,
+// taking Limit and Offset into account
+func (qe *QueryEngine) CountExecutions(filters QueryFilters) (int, error) {
+	count, err := qe.db.CountExecutions(qe.convertToExecutionFilter(filters))
+	if err != nil {
+		return 0, err
+	}
+
+	count -= filters.Offset
+	if count < 0 {
+		count = 0
+	}
+	if filters.Limit > 0 && count > filters.Limit {
+		count = filters.Limit
+	}
+
+	return count, nil
+}
+
 // QueryExecutionsWithStats performs a query and includes statistical analysis
 func (qe *QueryEngine) QueryExecutionsWithStats(filters QueryFilters) (*QueryResult, error) {
 	result, err := qe.QueryExecutions(filters)
@@ -122,70 +153,169 @@ func (qe *QueryEngine) QueryExecutionsWithStats(filters QueryFilters) (*QueryRes
 	return result, nil
 }
 
-// GenerateStatistics generates comprehensive statistics for executions
+// GenerateStatistics generates comprehensive statistics for executions. All
+// aggregation happens in the database, so the executions are never loaded.
 func (qe *QueryEngine) GenerateStatistics(filters QueryFilters) (*ExecutionStats, error) {
 	// Convert filters to ExecutionFilter for stats
 	execFilter := qe.convertToExecutionFilter(filters)
 	execFilter.Limit = 0 // No limit for stats
 	execFilter.Offset = 0
 
-	// Use existing GetExecutionStats method
-	existingStats, err := qe.db.GetExecutionStats(execFilter)
+	totals, err := qe.db.GetExecutionStats(execFilter)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get execution stats: %w", err)
 	}
 
-	// Convert to our stats format
-	stats := &ExecutionStats{
-		TotalExecutions:   existingStats.TotalExecutions,
-		SuccessfulRuns:    existingStats.SuccessfulExecutions,
-		FailedRuns:        existingStats.FailedExecutions,
-		SuccessRate:       float64(existingStats.SuccessfulExecutions) / float64(existingStats.TotalExecutions) * 100,
-		AverageFileCount:  float64(existingStats.TotalFiles) / float64(existingStats.TotalExecutions),
-		AverageDuration:   existingStats.AverageExecutionTime,
-		TotalDuration:     int64(existingStats.AverageExecutionTime * float64(existingStats.TotalExecutions)),
-		LanguageBreakdown: existingStats.LanguageBreakdown,
-		ModelBreakdown:    existingStats.ModelBreakdown,
-		ProviderBreakdown: existingStats.ProviderBreakdown,
-		DailyStats:        make(map[string]int),
-		HourlyStats:       make(map[int]int),
-		FileCountBuckets:  make(map[string]int),
-		DurationBuckets:   make(map[string]int),
-	}
-
-	// Get additional detailed stats by querying executions
-	executions, err := qe.db.QueryExecutions(execFilter)
+	dist, err := qe.db.GetExecutionDistribution(execFilter)
 	if err != nil {
-		return stats, nil // Return basic stats if detailed query fails
+		return nil, fmt.Errorf("failed to get execution distribution: %w", err)
 	}
 
-	// Add time-based and bucket breakdowns
-	for _, exec := range executions {
-		// Daily stats
-		dayKey := exec.Created.Format("2006-01-02")
-		stats.DailyStats[dayKey]++
-
-		// Hourly stats
-		stats.HourlyStats[exec.Created.Hour()]++
+	modelDurations, err := qe.db.GetModelDurationPercentiles(execFilter)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get model durations: %w", err)
+	}
 
-		// File count buckets
-		fileCountBucket := qe.getFileCountBucket(exec.FileCount)
-		stats.FileCountBuckets[fileCountBucket]++
+	toolDurations, err := qe.db.GetStepDurationPercentiles(execFilter)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get step durations: %w", err)
+	}
 
-		// Duration buckets
-		durationBucket := qe.getDurationBucket(exec.ExecutionTimeMS)
-		stats.DurationBuckets[durationBucket]++
+	stats := &ExecutionStats{
+		TotalExecutions:   totals.TotalExecutions,
+		SuccessfulRuns:    totals.SuccessfulExecutions,
+		FailedRuns:        totals.FailedExecutions,
+		AverageDuration:   totals.AverageExecutionTime,
+		TotalDuration:     totals.TotalExecutionTime,
+		LanguageBreakdown: totals.LanguageBreakdown,
+		ModelBreakdown:    totals.ModelBreakdown,
+		ProviderBreakdown: totals.ProviderBreakdown,
+		DailyStats:        dist.Daily,
+		HourlyStats:       dist.Hourly,
+		WeekdayHourly:     dist.Heatmap,
+		FileCountBuckets:  dist.FileCountBuckets,
+		DurationBuckets:   dist.DurationBuckets,
+		ModelDurations:    modelDurations,
+		ToolDurations:     toolDurations,
+	}
+
+	if totals.TotalExecutions > 0 {
+		stats.SuccessRate = float64(totals.SuccessfulExecutions) / float64(totals.TotalExecutions) * 100
+		stats.AverageFileCount = float64(totals.TotalFiles) / float64(totals.TotalExecutions)
 	}
 
 	return stats, nil
 }
 
+// SlowestSteps returns the longest running pipeline steps of the executions
+// matching the filters, without their output
+func (qe *QueryEngine) SlowestSteps(filters QueryFilters, limit int) ([]logging.WafflesStep, error) {
+	execFilter := qe.convertToExecutionFilter(filters)
+	execFilter.Limit = 0
+	execFilter.Offset = 0
+
+	return qe.db.GetSlowestSteps(execFilter, limit)
+}
+
+// DefaultComparisonPeriod is the period compared when no start date is given
+const DefaultComparisonPeriod = 7 * 24 * time.Hour
+
+// PeriodComparison holds the statistics of a period and of the period of the
+// same length immediately before it
+type PeriodComparison struct {
+	CurrentStart  time.Time       `json:"current_start"`
+	CurrentEnd    time.Time       `json:"current_end"`
+	PreviousStart time.Time       `json:"previous_start"`
+	PreviousEnd   time.Time       `json:"previous_end"`
+	Current       *ExecutionStats `json:"current"`
+	Previous      *ExecutionStats `json:"previous"`
+	Trends        []Trend         `json:"trends"`
+}
+
+// Trend is the change of a single metric between two periods
+type Trend struct {
+	Metric   string  `json:"metric"`
+	Current  float64 `json:"current"`
+	Previous float64 `json:"previous"`
+	Delta    float64 `json:"delta"`
+	// Change is the relative change in percent, zero when the previous value is zero
+	Change float64 `json:"change_percent"`
+}
+
+// CompareWithPreviousPeriod generates statistics for the period selected by
+// Since and Until and for the period of the same length right before it.
+// Until defaults to now and Since to DefaultComparisonPeriod before Until;
+// the other filters apply to both periods.
+func (qe *QueryEngine) CompareWithPreviousPeriod(filters QueryFilters) (*PeriodComparison, error) {
+	end := time.Now()
+	if filters.Until != nil {
+		end = *filters.Until
+	}
+	start := end.Add(-DefaultComparisonPeriod)
+	if filters.Since != nil {
+		start = *filters.Since
+	}
+	if !start.Before(end) {
+		return nil, fmt.Errorf("comparison period start %s is not before its end %s", start.Format(time.RFC3339), end.Format(time.RFC3339))
+	}
+
+	comparison := &PeriodComparison{
+		CurrentStart:  start,
+		CurrentEnd:    end,
+		PreviousStart: start.Add(-end.Sub(start)),
+		PreviousEnd:   start,
+	}
+
+	var err error
+	filters.Since, filters.Until = &comparison.CurrentStart, &comparison.CurrentEnd
+	if comparison.Current, err = qe.GenerateStatistics(filters); err != nil {
+		return nil, err
+	}
+
+	// Stop just before the current period so executions are not counted twice
+	previousEnd := comparison.PreviousEnd.Add(-time.Nanosecond)
+	filters.Since, filters.Until = &comparison.PreviousStart, &previousEnd
+	if comparison.Previous, err = qe.GenerateStatistics(filters); err != nil {
+		return nil, err
+	}
+
+	current, previous := comparison.Current, comparison.Previous
+	comparison.Trends = []Trend{
+		newTrend("executions", float64(current.TotalExecutions), float64(previous.TotalExecutions)),
+		newTrend("failed_runs", float64(current.FailedRuns), float64(previous.FailedRuns)),
+		newTrend("success_rate", current.SuccessRate, previous.SuccessRate),
+		newTrend("average_duration_ms", current.AverageDuration, previous.AverageDuration),
+		newTrend("average_file_count", current.AverageFileCount, previous.AverageFileCount),
+	}
+
+	return comparison, nil
+}
+
+// newTrend computes the change between a current and a previous value
+func newTrend(metric string, current, previous float64) Trend {
+	trend := Trend{Metric: metric, Current: current, Previous: previous, Delta: current - previous}
+	if previous != 0 {
+		trend.Change = trend.Delta / previous * 100
+	}
+	return trend
+}
+
 // SearchFullText performs full-text search across prompts and responses
 func (qe *QueryEngine) SearchFullText(searchTerm string, filters QueryFilters) (*QueryResult, error) {
 	filters.Search = searchTerm
 	return qe.QueryExecutions(filters)
 }
 
+// GetExecutionFiles loads the files for a set of executions in batched queries
+func (qe *QueryEngine) GetExecutionFiles(executionIDs []string) (map[string][]logging.WafflesFile, error) {
+	return qe.db.GetFilesForExecutions(executionIDs)
+}
+
+// GetExecutionSteps loads the steps for a set of executions in batched queries
+func (qe *QueryEngine) GetExecutionSteps(executionIDs []string) (map[string][]logging.WafflesStep, error) {
+	return qe.db.GetStepsForExecutions(executionIDs)
+}
+
 // GetUsagePatterns analyzes usage patterns over time
 func (qe *QueryEngine) GetUsagePatterns(days int) (map[string]interface{}, error) {
 	since := time.Now().AddDate(0, 0, -days)
@@ -249,54 +379,50 @@ func (qe *QueryEngine) convertToExecutionFilter(filters QueryFilters) *logging.E
 		execFilter.MaxDuration = filters.MaxDuration
 	}
 
+	if filters.MinFileCount != nil {
+		execFilter.MinFileCount = filters.MinFileCount
+	}
+
+	if filters.MaxFileCount != nil {
+		execFilter.MaxFileCount = filters.MaxFileCount
+	}
+
 	if filters.Search != "" {
 		execFilter.SearchQuery = filters.Search
 	}
 
+	if filters.Source != "" {
+		execFilter.Source = filters.Source
+	}
+
+	execFilter.Conditions = filters.Conditions
+
 	return execFilter
 }
 
-// getFileCountBucket categorizes file counts into buckets
-func (qe *QueryEngine) getFileCountBucket(fileCount int) string {
-	switch {
-	case fileCount == 0:
-		return "0"
-	case fileCount <= 5:
-		return "1-5"
-	case fileCount <= 10:
-		return "6-10"
-	case fileCount <= 25:
-		return "11-25"
-	case fileCount <= 50:
-		return "26-50"
-	case fileCount <= 100:
-		return "51-100"
-	default:
-		return "100+"
+// FileCountBuckets lists the file count bucket labels in ascending order
+var FileCountBuckets = bucketLabels(logging.FileCountBuckets)
+
+// DurationBuckets lists the duration bucket labels in ascending order
+var DurationBuckets = bucketLabels(logging.DurationBuckets)
+
+// bucketLabels returns the labels of a list of buckets
+func bucketLabels(buckets []logging.Bucket) []string {
+	labels := make([]string, len(buckets))
+	for i, bucket := range buckets {
+		labels[i] = bucket.Label
 	}
+	return labels
 }
 
-// getDurationBucket categorizes execution durations into buckets
-func (qe *QueryEngine) getDurationBucket(durationMS int64) string {
-	seconds := durationMS / 1000
-	switch {
-	case seconds < 1:
-		return "<1s"
-	case seconds <= 5:
-		return "1-5s"
-	case seconds <= 15:
-		return "6-15s"
-	case seconds <= 30:
-		return "16-30s"
-	case seconds <= 60:
-		return "31-60s"
-	case seconds <= 120:
-		return "1-2min"
-	case seconds <= 300:
-		return "2-5min"
-	default:
-		return "5min+"
-	}
+// FileCountBucket categorizes file counts into buckets
+func FileCountBucket(fileCount int) string {
+	return logging.BucketLabel(int64(fileCount), logging.FileCountBuckets)
+}
+
+// DurationBucket categorizes execution durations into buckets
+func DurationBucket(durationMS int64) string {
+	return logging.BucketLabel(durationMS/1000, logging.DurationBuckets)
 }
 
 // ParseDateFilter parses date strings into time.Time
@@ -0,0 +1,243 @@
+package query
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/toozej/waffles/pkg/logging"
+)
+
+// fieldAliases maps alternative field names to their canonical names
+var fieldAliases = map[string]string{
+	"file_count": "files",
+	"date":       "created",
+	"status":     "success",
+}
+
+// operators lists the comparison operators, longest first so that ">="
+// is matched before ">"
+var operators = []string{">=", "<=", "!=", "==", "=", ">", "<", "~"}
+
+// ParseFilterExpression parses a filter expression such as
+//
+//	success=false and duration>30s and model~gpt
+//
+// into conditions that are pushed down to SQL. An expression is a list of
+// comparisons joined with "and". Values containing spaces can be quoted.
+// Besides the fields in logging.FilterFields, "age" compares how long ago an
+// execution ran, so "age<7d" selects the last week.
+func ParseFilterExpression(expr string) ([]logging.Condition, error) {
+	terms, err := splitTerms(expr)
+	if err != nil {
+		return nil, err
+	}
+
+	conditions := make([]logging.Condition, 0, len(terms))
+	for _, term := range terms {
+		condition, err := parseCondition(term)
+		if err != nil {
+			return nil, fmt.Errorf("invalid condition %q: %w", term, err)
+		}
+		conditions = append(conditions, condition)
+	}
+
+	return conditions, nil
+}
+
+// splitTerms splits an expression on the "and" keyword outside quotes. A
+// quote only opens at the start of a value, so "query~don't" needs none.
+func splitTerms(expr string) ([]string, error) {
+	var terms []string
+	var current strings.Builder
+	var quote rune
+
+	flush := func() error {
+		term := strings.TrimSpace(current.String())
+		if term == "" {
+			return fmt.Errorf("empty condition in filter expression %q", expr)
+		}
+		terms = append(terms, term)
+		current.Reset()
+		return nil
+	}
+
+	runes := []rune(expr)
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+		switch {
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			}
+		case (r == '"' || r == '\'') && atValueStart(current.String()):
+			quote = r
+		case unicode.IsSpace(r) && isAndKeyword(runes, i+1):
+			if err := flush(); err != nil {
+				return nil, err
+			}
+			i += len("and")
+			continue
+		}
+		current.WriteRune(r)
+	}
+
+	if quote != 0 {
+		return nil, fmt.Errorf("unterminated quote in filter expression %q", expr)
+	}
+	if strings.TrimSpace(expr) == "" {
+		return nil, nil
+	}
+	if err := flush(); err != nil {
+		return nil, err
+	}
+
+	return terms, nil
+}
+
+// atValueStart reports whether a term ends with an operator, so that what
+// follows starts its value
+func atValueStart(term string) bool {
+	term = strings.TrimRightFunc(term, unicode.IsSpace)
+	return term != "" && strings.ContainsRune("=<>!~", rune(term[len(term)-1]))
+}
+
+// isAndKeyword reports whether runes[i:] starts with "and" followed by whitespace
+func isAndKeyword(runes []rune, i int) bool {
+	if i+4 > len(runes) {
+		return false
+	}
+	return strings.EqualFold(string(runes[i:i+3]), "and") && unicode.IsSpace(runes[i+3])
+}
+
+// parseCondition parses a single "field op value" comparison
+func parseCondition(term string) (logging.Condition, error) {
+	end := strings.IndexFunc(term, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '_'
+	})
+	if end <= 0 {
+		return logging.Condition{}, fmt.Errorf("expected a field name")
+	}
+	name := strings.ToLower(term[:end])
+	rest := strings.TrimSpace(term[end:])
+
+	operator := ""
+	for _, op := range operators {
+		if strings.HasPrefix(rest, op) {
+			operator = op
+			break
+		}
+	}
+	if operator == "" {
+		return logging.Condition{}, fmt.Errorf("expected one of %s after %q", strings.Join(operators, " "), name)
+	}
+	value := unquote(strings.TrimSpace(rest[len(operator):]))
+	if operator == "==" {
+		operator = logging.OpEqual
+	}
+
+	if name == "age" {
+		return ageCondition(operator, value)
+	}
+	if alias, ok := fieldAliases[name]; ok {
+		name = alias
+	}
+
+	field, ok := logging.FilterFields[name]
+	if !ok {
+		return logging.Condition{}, fmt.Errorf("unknown field %q (known fields: age, %s)", name, strings.Join(logging.FilterFieldNames(), ", "))
+	}
+
+	parsed, err := parseFieldValue(field.Kind, value)
+	if err != nil {
+		return logging.Condition{}, err
+	}
+
+	condition := logging.Condition{Field: name, Operator: operator, Value: parsed}
+	return condition, condition.Validate()
+}
+
+// parseFieldValue converts a literal to the Go type of a field kind
+func parseFieldValue(kind logging.FieldKind, value string) (interface{}, error) {
+	switch kind {
+	case logging.FieldBool:
+		switch strings.ToLower(value) {
+		case "success", "ok":
+			return true, nil
+		case "failed", "failure":
+			return false, nil
+		}
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid boolean value: %s", value)
+		}
+		return b, nil
+	case logging.FieldInt:
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid integer value: %s", value)
+		}
+		return n, nil
+	case logging.FieldDuration:
+		return parseDuration(value)
+	case logging.FieldTime:
+		t, err := ParseDateFilter(value)
+		if err != nil {
+			return nil, err
+		}
+		if t == nil {
+			return nil, fmt.Errorf("missing date value")
+		}
+		return *t, nil
+	default:
+		return value, nil
+	}
+}
+
+// parseDuration parses Go durations such as "30s" or "1m30s"; bare numbers
+// are milliseconds
+func parseDuration(value string) (time.Duration, error) {
+	if ms, err := strconv.ParseInt(value, 10, 64); err == nil {
+		return time.Duration(ms) * time.Millisecond, nil
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid duration: %s", value)
+	}
+	return d, nil
+}
+
+// ageCondition turns "age op value" into a comparison on created, inverting
+// the operator since a smaller age means a later creation time
+func ageCondition(operator, value string) (logging.Condition, error) {
+	age, err := logging.ParseAge(value)
+	if err != nil {
+		age, err = parseDuration(value)
+		if err != nil {
+			return logging.Condition{}, fmt.Errorf("invalid age: %s", value)
+		}
+	}
+
+	inverted := map[string]string{
+		logging.OpLess:         logging.OpGreater,
+		logging.OpLessEqual:    logging.OpGreaterEqual,
+		logging.OpGreater:      logging.OpLess,
+		logging.OpGreaterEqual: logging.OpLessEqual,
+	}
+	op, ok := inverted[operator]
+	if !ok {
+		return logging.Condition{}, fmt.Errorf("operator %q is not supported for age", operator)
+	}
+
+	return logging.Condition{Field: "created", Operator: op, Value: time.Now().Add(-age)}, nil
+}
+
+// unquote strips matching single or double quotes around a value
+func unquote(value string) string {
+	if len(value) >= 2 && (value[0] == '"' || value[0] == '\'') && value[len(value)-1] == value[0] {
+		return value[1 : len(value)-1]
+	}
+	return value
+}
@@ -0,0 +1,155 @@
+package query
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/toozej/waffles/pkg/logging"
+)
+
+func TestParseFilterExpression(t *testing.T) {
+	created := time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name string
+		expr string
+		want []logging.Condition
+	}{
+		{
+			name: "empty",
+			expr: "  ",
+			want: nil,
+		},
+		{
+			name: "and keyword",
+			expr: "success=false and duration>30s AND model~gpt",
+			want: []logging.Condition{
+				{Field: "success", Operator: logging.OpEqual, Value: false},
+				{Field: "duration", Operator: logging.OpGreater, Value: 30 * time.Second},
+				{Field: "model", Operator: logging.OpContains, Value: "gpt"},
+			},
+		},
+		{
+			name: "and inside words",
+			expr: "command~android and language=go",
+			want: []logging.Condition{
+				{Field: "command", Operator: logging.OpContains, Value: "android"},
+				{Field: "language", Operator: logging.OpEqual, Value: "go"},
+			},
+		},
+		{
+			name: "quoted values keep spaces and and",
+			expr: `query~"rock and roll" and error!='not found'`,
+			want: []logging.Condition{
+				{Field: "query", Operator: logging.OpContains, Value: "rock and roll"},
+				{Field: "error", Operator: logging.OpNotEqual, Value: "not found"},
+			},
+		},
+		{
+			name: "quote after spaces",
+			expr: `model = "gpt 4"`,
+			want: []logging.Condition{{Field: "model", Operator: logging.OpEqual, Value: "gpt 4"}},
+		},
+		{
+			name: "apostrophe inside a value",
+			expr: "query~don't and model=gpt",
+			want: []logging.Condition{
+				{Field: "query", Operator: logging.OpContains, Value: "don't"},
+				{Field: "model", Operator: logging.OpEqual, Value: "gpt"},
+			},
+		},
+		{
+			name: "other quote inside a quoted value",
+			expr: `query~"it's"`,
+			want: []logging.Condition{{Field: "query", Operator: logging.OpContains, Value: "it's"}},
+		},
+		{
+			name: "aliases",
+			expr: "status=ok and file_count>=3 and date<2024-01-15",
+			want: []logging.Condition{
+				{Field: "success", Operator: logging.OpEqual, Value: true},
+				{Field: "files", Operator: logging.OpGreaterEqual, Value: 3},
+				{Field: "created", Operator: logging.OpLess, Value: created},
+			},
+		},
+		{
+			name: "double equals",
+			expr: "Model==gpt-4o and duration<=1500",
+			want: []logging.Condition{
+				{Field: "model", Operator: logging.OpEqual, Value: "gpt-4o"},
+				{Field: "duration", Operator: logging.OpLessEqual, Value: 1500 * time.Millisecond},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseFilterExpression(tt.expr)
+			if err != nil {
+				t.Fatalf("ParseFilterExpression(%q) failed: %v", tt.expr, err)
+			}
+			if len(got) == 0 && len(tt.want) == 0 {
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseFilterExpression(%q) = %+v, expected %+v", tt.expr, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseFilterExpressionAge(t *testing.T) {
+	// A smaller age is a later creation time, so the operators are inverted
+	tests := []struct {
+		expr     string
+		operator string
+		age      time.Duration
+	}{
+		{"age<7d", logging.OpGreater, 7 * 24 * time.Hour},
+		{"age<=2h", logging.OpGreaterEqual, 2 * time.Hour},
+		{"age>1w", logging.OpLess, 7 * 24 * time.Hour},
+		{"age>=90m", logging.OpLessEqual, 90 * time.Minute},
+	}
+
+	for _, tt := range tests {
+		before := time.Now()
+		conditions, err := ParseFilterExpression(tt.expr)
+		if err != nil {
+			t.Fatalf("ParseFilterExpression(%q) failed: %v", tt.expr, err)
+		}
+		if len(conditions) != 1 || conditions[0].Field != "created" || conditions[0].Operator != tt.operator {
+			t.Fatalf("Unexpected conditions for %q: %+v", tt.expr, conditions)
+		}
+		cutoff := conditions[0].Value.(time.Time)
+		if cutoff.Before(before.Add(-tt.age)) || cutoff.After(time.Now().Add(-tt.age)) {
+			t.Errorf("Expected %q to compare with %s ago, got %v", tt.expr, tt.age, cutoff)
+		}
+	}
+}
+
+func TestParseFilterExpressionErrors(t *testing.T) {
+	tests := []struct {
+		expr string
+		want string
+	}{
+		{`query~"unterminated`, "unterminated quote"},
+		{"model=gpt and  and success=true", "empty condition"},
+		{"model gpt", "expected one of"},
+		{"=gpt", "expected a field name"},
+		{"colour=blue", `unknown field "colour"`},
+		{"success=maybe", "invalid boolean value"},
+		{"files>many", "invalid integer value"},
+		{"duration>soon", "invalid duration"},
+		{"age=7d", "not supported for age"},
+		{"age<recently", "invalid age"},
+		{"success~true", "not supported for bool field"},
+	}
+
+	for _, tt := range tests {
+		_, err := ParseFilterExpression(tt.expr)
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("Expected an error containing %q for %q, got %v", tt.want, tt.expr, err)
+		}
+	}
+}
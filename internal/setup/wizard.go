@@ -43,6 +43,12 @@ func (w *SetupWizard) Run() error {
 	}
 	w.config = cfg
 
+	// Check the tools, versions and plugins required by configuration
+	if err := deps.SetRequirements(deps.RequirementsFromConfig(cfg)); err != nil {
+		w.ui.ShowWarning(fmt.Sprintf("Ignoring invalid dependency requirements: %v", err))
+		_ = deps.SetRequirements(deps.Requirements{})
+	}
+
 	// Step 1: Check Dependencies
 	if err := w.checkDependencies(); err != nil {
 		return fmt.Errorf("dependency check failed: %w", err)
@@ -375,6 +381,20 @@ func (w *SetupWizard) writeConfigFile(configPath string) error {
 		content.WriteString(fmt.Sprintf("LLM_ARGS=%s\n", w.config.LLMArgs))
 	}
 
+	// Keep declared dependency requirements
+	for _, setting := range [][2]string{
+		{"WAFFLES_REQUIRED_TOOLS", w.config.RequiredTools},
+		{"WAFFLES_LLM_PLUGINS", w.config.LLMPlugins},
+		{"WAFFLES_WHERESMYPROMPT_VERSION", w.config.WheresmypromptVersion},
+		{"WAFFLES_FILES2PROMPT_VERSION", w.config.Files2promptVersion},
+		{"WAFFLES_LLM_VERSION", w.config.LLMVersion},
+		{"WAFFLES_RELEASE_ASSET_TEMPLATE", w.config.ReleaseAssetTemplate},
+	} {
+		if setting[1] != "" {
+			content.WriteString(fmt.Sprintf("%s=%q\n", setting[0], setting[1]))
+		}
+	}
+
 	// Write to file
 	return os.WriteFile(configPath, []byte(content.String()), 0600)
 }
@@ -7,15 +7,19 @@
 //
 // The configuration loading follows a priority order:
 //  1. System environment variables (highest priority)
-//  2. .env file in current working directory
-//  3. Global .env file in ~/.config/waffles/.env
-//  4. Built-in defaults (lowest priority)
+//  2. Files given with --env-file and then --config
+//  3. .env and then .waffles.env in the current working directory
+//  4. Global .env file in ~/.config/waffles/.env
+//  5. Built-in defaults (lowest priority)
 //
 // Configuration categories:
 //   - Core Application Settings: Model, provider, database paths
 //   - Tool Configurations: Arguments for wheresmyprompt, files2prompt, llm
 //   - Behavior Settings: Verbosity, auto-install, gitignore handling
 //   - Language-specific Settings: Language overrides and file patterns
+//   - Retention Settings: Log database pruning and output stripping
+//   - Metrics Settings: Textfile collector output
+//   - Dependency Requirements: Required tools, version constraints and llm plugins
 //
 // Example usage:
 //
@@ -31,8 +35,12 @@
 package config
 
 import (
+	"fmt"
 	"os"
 	"path/filepath"
+	"reflect"
+	"sort"
+	"sync"
 
 	"github.com/caarlos0/env/v11"
 	"github.com/joho/godotenv"
@@ -49,6 +57,7 @@ import (
 //   - Tool-specific arguments for pipeline components
 //   - Behavioral flags for application operation
 //   - Language and file filtering options
+//   - Log retention and automatic pruning
 //
 // Example:
 //
@@ -79,15 +88,74 @@ type Config struct {
 	LanguageOverride string `env:"WAFFLES_LANGUAGE" envDefault:""`
 	IncludePatterns  string `env:"WAFFLES_INCLUDE_PATTERNS" envDefault:""`
 	ExcludePatterns  string `env:"WAFFLES_EXCLUDE_PATTERNS" envDefault:""`
+
+	// Retention Settings
+	RetentionMaxAge           string `env:"WAFFLES_RETENTION_MAX_AGE" envDefault:""`
+	RetentionStripOutputAfter string `env:"WAFFLES_RETENTION_STRIP_OUTPUT_AFTER" envDefault:""`
+	RetentionKeepFailed       bool   `env:"WAFFLES_RETENTION_KEEP_FAILED" envDefault:"false"`
+	RetentionKeepLast         int    `env:"WAFFLES_RETENTION_KEEP_LAST" envDefault:"0"`
+	RetentionAutoPrune        bool   `env:"WAFFLES_RETENTION_AUTO_PRUNE" envDefault:"false"`
+
+	// Metrics Settings
+	MetricsTextfile string `env:"WAFFLES_METRICS_TEXTFILE" envDefault:""`
+
+	// Dependency Requirements
+	RequiredTools         string `env:"WAFFLES_REQUIRED_TOOLS" envDefault:""`
+	LLMPlugins            string `env:"WAFFLES_LLM_PLUGINS" envDefault:""`
+	WheresmypromptVersion string `env:"WAFFLES_WHERESMYPROMPT_VERSION" envDefault:""`
+	Files2promptVersion   string `env:"WAFFLES_FILES2PROMPT_VERSION" envDefault:""`
+	LLMVersion            string `env:"WAFFLES_LLM_VERSION" envDefault:""`
+	ReleaseAssetTemplate  string `env:"WAFFLES_RELEASE_ASSET_TEMPLATE" envDefault:""`
+
+	// Sources lists the .env files that were loaded, lowest precedence first
+	Sources []string
+
+	// Origins maps each variable set by a file or the environment to the
+	// file path or "environment"
+	Origins map[string]string
+}
+
+// Origins of settings that no .env file set
+const (
+	OriginEnvironment = "environment"
+	OriginDefault     = "default"
+)
+
+// Options selects .env files to load on top of the standard ones
+type Options struct {
+	ConfigFile string // Loaded after the standard files, as with --config
+	EnvFile    string // Loaded last, as with --env-file
 }
 
+// Setting is the effective value of a configuration variable
+type Setting struct {
+	Name   string `json:"name"` // Environment variable
+	Value  string `json:"value"`
+	Origin string `json:"origin,omitempty"` // File path, OriginEnvironment or OriginDefault
+}
+
+// envFile is a .env file to load, which must exist if required
+type envFile struct {
+	path     string
+	required bool
+}
+
+var (
+	// fileValuesMu guards fileValues
+	fileValuesMu sync.Mutex
+	// fileValues holds the variables set from .env files, to tell them apart
+	// from the real environment when the configuration is loaded again
+	fileValues = make(map[string]string)
+)
+
 // LoadConfig loads and returns the application configuration from multiple sources
 // with comprehensive precedence handling and error management.
 //
 // This function performs the following operations in order:
-//  1. Loads .env files from multiple locations (lowest to highest precedence)
-//  2. Parses environment variables into the Config struct (highest precedence)
-//  3. Returns the populated configuration with all defaults applied
+//  1. Reads .env files from multiple locations, later files overriding earlier ones
+//  2. Sets the variables from files that the environment does not already set
+//  3. Parses environment variables into the Config struct
+//  4. Returns the populated configuration with all defaults applied
 //
 // Configuration loading precedence (highest to lowest):
 //  1. System environment variables (WAFFLES_* prefixed)
@@ -114,10 +182,42 @@ type Config struct {
 //	// Use configuration
 //	fmt.Printf("Using %s model with %s provider\n", cfg.DefaultModel, cfg.DefaultProvider)
 func LoadConfig() (*Config, error) {
-	var cfg Config
+	return Load(Options{})
+}
+
+// Load loads the configuration like LoadConfig, adding the files selected
+// by opts above the standard ones: opts.EnvFile takes precedence over
+// opts.ConfigFile, which takes precedence over .env. Environment variables
+// still take precedence over every file. Unlike the standard files, the
+// files in opts must exist and parse.
+//
+// Example:
+//
+//	cfg, err := config.Load(config.Options{ConfigFile: "team.env"})
+//	if err != nil {
+//		log.Fatalf("Failed to load configuration: %v", err)
+//	}
+func Load(opts Options) (*Config, error) {
+	cfg := Config{Origins: make(map[string]string)}
 
-	// Load .env files in order of precedence (lower precedence first)
-	loadEnvFiles(&cfg)
+	// Read .env files in order of precedence (lower precedence first)
+	values := make(map[string]string)
+	for _, file := range envFiles(opts) {
+		data, err := godotenv.Read(file.path)
+		if err != nil {
+			if file.required {
+				return nil, fmt.Errorf("failed to load %s: %w", file.path, err)
+			}
+			// Ignore errors for optional config files
+			continue
+		}
+		cfg.Sources = append(cfg.Sources, file.path)
+		for key, value := range data {
+			values[key] = value
+			cfg.Origins[key] = file.path
+		}
+	}
+	applyFileValues(values, cfg.Origins)
 
 	// Parse environment variables into struct (highest precedence)
 	if err := LoadFromEnv(&cfg); err != nil {
@@ -127,6 +227,62 @@ func LoadConfig() (*Config, error) {
 	return &cfg, nil
 }
 
+// applyFileValues sets the variables read from .env files, except those the
+// real environment sets, which are recorded in origins instead. Variables
+// set by an earlier load that no file sets anymore are removed again.
+func applyFileValues(values, origins map[string]string) {
+	fileValuesMu.Lock()
+	defer fileValuesMu.Unlock()
+
+	for key, previous := range fileValues {
+		if _, ok := values[key]; !ok && os.Getenv(key) == previous {
+			_ = os.Unsetenv(key)
+			delete(fileValues, key)
+		}
+	}
+
+	for key, value := range values {
+		if current, ok := os.LookupEnv(key); ok {
+			if previous, fromFile := fileValues[key]; !fromFile || previous != current {
+				origins[key] = OriginEnvironment
+				delete(fileValues, key)
+				continue
+			}
+		}
+		_ = os.Setenv(key, value)
+		fileValues[key] = value
+	}
+}
+
+// Settings returns the effective value and origin of every configuration
+// variable, sorted by name
+func (c *Config) Settings() []Setting {
+	var settings []Setting
+	v := reflect.ValueOf(c).Elem()
+	for i := 0; i < v.NumField(); i++ {
+		name := v.Type().Field(i).Tag.Get("env")
+		if name == "" {
+			continue
+		}
+
+		origin := c.Origins[name]
+		if origin == "" {
+			origin = OriginDefault
+			if _, ok := os.LookupEnv(name); ok {
+				origin = OriginEnvironment
+			}
+		}
+		settings = append(settings, Setting{
+			Name:   name,
+			Value:  fmt.Sprint(v.Field(i).Interface()),
+			Origin: origin,
+		})
+	}
+
+	sort.Slice(settings, func(i, j int) bool { return settings[i].Name < settings[j].Name })
+	return settings
+}
+
 // LoadFromEnv parses environment variables into the provided Config struct.
 //
 // This function uses the github.com/caarlos0/env library to automatically
@@ -157,9 +313,10 @@ func LoadFromEnv(cfg *Config) error {
 //
 // The function does not override existing environment variables, following
 // the standard .env file behavior where existing environment takes precedence.
+// Files that were loaded are recorded in cfg.Sources.
 //
 // Parameters:
-//   - cfg: Config struct pointer (currently unused but kept for consistency)
+//   - cfg: Config struct pointer whose Sources record the loaded file
 //   - filepath: Path to the .env file to load
 //
 // Returns:
@@ -178,7 +335,11 @@ func LoadFromFile(cfg *Config, filepath string) error {
 	}
 
 	// Load the .env file, but don't override existing env vars
-	return godotenv.Load(filepath)
+	if err := godotenv.Load(filepath); err != nil {
+		return err
+	}
+	cfg.Sources = append(cfg.Sources, filepath)
+	return nil
 }
 
 // MergeWithDefaults ensures all configuration fields have appropriate default values.
@@ -203,36 +364,31 @@ func MergeWithDefaults(cfg *Config) {
 	// This function is available for any future custom default logic
 }
 
-// loadEnvFiles loads .env files from multiple locations in order of precedence.
-//
-// This internal function loads .env files from various standard locations,
-// with files loaded first having lower precedence (can be overridden by
-// files loaded later). All file loading errors are ignored since .env files
-// are optional configuration sources.
+// envFiles returns the .env files to load, lowest precedence first.
 //
 // Loading order (lowest to highest precedence):
 //  1. Global config: ~/.config/waffles/.env
 //  2. Local project config: .waffles.env
 //  3. Current directory: .env
-//
-// Parameters:
-//   - cfg: Pointer to Config struct (passed to LoadFromFile for consistency)
-//
-// Example usage (internal):
-//
-//	var cfg Config
-//	loadEnvFiles(&cfg)  // Loads all available .env files
-func loadEnvFiles(cfg *Config) {
+//  4. opts.ConfigFile, which must exist
+//  5. opts.EnvFile, which must exist
+func envFiles(opts Options) []envFile {
+	var files []envFile
+
 	// First try global config file (lowest precedence)
 	homeDir, err := os.UserHomeDir()
 	if err == nil {
-		globalConfigPath := filepath.Join(homeDir, ".config", "waffles", ".env")
-		_ = LoadFromFile(cfg, globalConfigPath) // Ignore errors for optional config files
+		files = append(files, envFile{path: filepath.Join(homeDir, ".config", "waffles", ".env")})
 	}
 
-	// Then try local .env file (higher precedence)
-	_ = LoadFromFile(cfg, ".waffles.env") // Ignore errors for optional config files
+	// Then the local project and current directory files
+	files = append(files, envFile{path: ".waffles.env"}, envFile{path: ".env"})
 
-	// Finally try current directory .env file (even higher precedence)
-	_ = LoadFromFile(cfg, ".env") // Ignore errors for optional config files
+	// Explicit files take precedence over the discovered ones
+	for _, path := range []string{opts.ConfigFile, opts.EnvFile} {
+		if path != "" {
+			files = append(files, envFile{path: path, required: true})
+		}
+	}
+	return files
 }
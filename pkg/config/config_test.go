@@ -3,6 +3,7 @@ package config
 import (
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 )
 
@@ -126,6 +127,11 @@ func TestLoadFromFile(t *testing.T) {
 	if err != nil {
 		t.Errorf("Expected no error for non-existent file, got: %v", err)
 	}
+
+	// Only the loaded file is recorded as a source
+	if len(cfg.Sources) != 1 || cfg.Sources[0] != envFile {
+		t.Errorf("Expected sources [%s], got %v", envFile, cfg.Sources)
+	}
 }
 
 func TestLoadFromEnv(t *testing.T) {
@@ -150,3 +156,105 @@ func TestLoadFromEnv(t *testing.T) {
 		t.Error("Expected Verbose to be true")
 	}
 }
+
+// writeEnvFiles writes .env files, creating their directories
+func writeEnvFiles(t *testing.T, files map[string]string) {
+	t.Helper()
+	for path, content := range files {
+		if err := os.MkdirAll(filepath.Dir(path), 0750); err != nil {
+			t.Fatalf("Failed to create %s: %v", filepath.Dir(path), err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+			t.Fatalf("Failed to write %s: %v", path, err)
+		}
+	}
+}
+
+func TestLoadPrecedence(t *testing.T) {
+	home := t.TempDir()
+	dir := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Chdir(dir)
+	for _, key := range []string{"WAFFLES_DEFAULT_MODEL", "WAFFLES_LANGUAGE", "WAFFLES_INCLUDE_PATTERNS", "WAFFLES_EXCLUDE_PATTERNS"} {
+		t.Setenv(key, "")
+		os.Unsetenv(key)
+	}
+	t.Setenv("WAFFLES_DEFAULT_PROVIDER", "openai")
+
+	global := filepath.Join(home, ".config", "waffles", ".env")
+	writeEnvFiles(t, map[string]string{
+		global:         "WAFFLES_DEFAULT_MODEL=global\nWAFFLES_INCLUDE_PATTERNS=*.go\nWAFFLES_DEFAULT_PROVIDER=ollama",
+		".waffles.env": "WAFFLES_DEFAULT_MODEL=project\nWAFFLES_LANGUAGE=go",
+		".env":         "WAFFLES_DEFAULT_MODEL=dotenv",
+		"team.env":     "WAFFLES_LANGUAGE=rust\nWAFFLES_EXCLUDE_PATTERNS=vendor",
+		"extra.env":    "WAFFLES_LANGUAGE=python",
+	})
+
+	cfg, err := LoadConfig()
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+	if cfg.DefaultModel != "dotenv" || cfg.LanguageOverride != "go" || cfg.IncludePatterns != "*.go" {
+		t.Errorf("Expected later files to override earlier ones, got %q %q %q", cfg.DefaultModel, cfg.LanguageOverride, cfg.IncludePatterns)
+	}
+	if cfg.DefaultProvider != "openai" {
+		t.Errorf("Expected the environment to override files, got %q", cfg.DefaultProvider)
+	}
+
+	cfg, err = Load(Options{ConfigFile: "team.env", EnvFile: "extra.env"})
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if cfg.LanguageOverride != "python" || cfg.ExcludePatterns != "vendor" || cfg.DefaultModel != "dotenv" {
+		t.Errorf("Expected the explicit files to take precedence, got %q %q %q", cfg.LanguageOverride, cfg.ExcludePatterns, cfg.DefaultModel)
+	}
+	wantSources := []string{global, ".waffles.env", ".env", "team.env", "extra.env"}
+	if strings.Join(cfg.Sources, ",") != strings.Join(wantSources, ",") {
+		t.Errorf("Expected sources %v, got %v", wantSources, cfg.Sources)
+	}
+
+	origins := make(map[string]string)
+	for _, setting := range cfg.Settings() {
+		origins[setting.Name] = setting.Origin
+	}
+	want := map[string]string{
+		"WAFFLES_DEFAULT_MODEL":    ".env",
+		"WAFFLES_DEFAULT_PROVIDER": OriginEnvironment,
+		"WAFFLES_LANGUAGE":         "extra.env",
+		"WAFFLES_EXCLUDE_PATTERNS": "team.env",
+		"WAFFLES_INCLUDE_PATTERNS": global,
+		"WAFFLES_LOG_DB_PATH":      OriginDefault,
+	}
+	for name, origin := range want {
+		if origins[name] != origin {
+			t.Errorf("Expected %s to come from %s, got %q", name, origin, origins[name])
+		}
+	}
+
+	// Values set from files on an earlier load are not mistaken for the
+	// environment, and disappear with their file
+	if err := os.Remove("team.env"); err != nil {
+		t.Fatalf("Failed to remove team.env: %v", err)
+	}
+	cfg, err = Load(Options{EnvFile: "extra.env"})
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if cfg.ExcludePatterns != "" || cfg.Origins["WAFFLES_LANGUAGE"] != "extra.env" {
+		t.Errorf("Expected values from the removed file to be gone, got %q from %q", cfg.ExcludePatterns, cfg.Origins["WAFFLES_LANGUAGE"])
+	}
+}
+
+func TestLoadRequiresExplicitFiles(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Chdir(t.TempDir())
+
+	if _, err := Load(Options{ConfigFile: "missing.env"}); err == nil || !strings.Contains(err.Error(), "missing.env") {
+		t.Errorf("Expected an error for a missing --config file, got %v", err)
+	}
+
+	writeEnvFiles(t, map[string]string{"broken.env": "WAFFLES_LANGUAGE='unterminated"})
+	if _, err := Load(Options{EnvFile: "broken.env"}); err == nil {
+		t.Error("Expected an error for an unparsable --env-file")
+	}
+}
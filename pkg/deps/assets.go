@@ -0,0 +1,331 @@
+package deps
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+	"text/template"
+)
+
+// DefaultAssetTemplate names release assets like GoReleaser's default archives
+const DefaultAssetTemplate = "{{.Name}}_{{.OS}}_{{.Arch}}"
+
+// maxBinarySize limits the size of an installed binary, against decompression bombs
+const maxBinarySize = 100 * 1024 * 1024
+
+// assetExtensions are the asset formats that can be installed, in order of
+// preference. A Windows binary carries .exe, others have no extension.
+var assetExtensions = []string{".tar.gz", ".tgz", ".zip", ".exe", ""}
+
+// assetPlatforms are the platforms whose assets are kept in lockfiles
+var assetPlatforms = [][2]string{
+	{"linux", "amd64"}, {"linux", "arm64"},
+	{"darwin", "amd64"}, {"darwin", "arm64"},
+	{"windows", "amd64"}, {"windows", "arm64"},
+}
+
+var (
+	assetTemplateMu sync.RWMutex
+	assetTemplate   = DefaultAssetTemplate
+)
+
+// AssetNameData is available to release asset name templates
+type AssetNameData struct {
+	Name    string // Repository name
+	Version string // Version without a leading v
+	OS      string // "linux", "macOS" or "windows"
+	Arch    string // "x86_64" or "arm64"
+	GOOS    string
+	GOARCH  string
+}
+
+// SetAssetTemplate validates a release asset name template and makes it the
+// default of new installers. An empty template restores DefaultAssetTemplate.
+func SetAssetTemplate(text string) error {
+	if text == "" {
+		text = DefaultAssetTemplate
+	}
+	if _, err := assetBaseName(text, "tool", "1.0.0", "linux", "amd64"); err != nil {
+		return err
+	}
+
+	assetTemplateMu.Lock()
+	defer assetTemplateMu.Unlock()
+	assetTemplate = text
+	return nil
+}
+
+// currentAssetTemplate returns the template set with SetAssetTemplate
+func currentAssetTemplate() string {
+	assetTemplateMu.RLock()
+	defer assetTemplateMu.RUnlock()
+	return assetTemplate
+}
+
+// assetBaseName renders the name of a release asset without its extension
+func assetBaseName(text, repo, version, goos, goarch string) (string, error) {
+	tmpl, err := template.New("asset").Option("missingkey=error").Parse(text)
+	if err != nil {
+		return "", fmt.Errorf("invalid release asset template %q: %w", text, err)
+	}
+
+	data := AssetNameData{
+		Name:    repo,
+		Version: strings.TrimPrefix(version, "v"),
+		OS:      goos,
+		Arch:    goarch,
+		GOOS:    goos,
+		GOARCH:  goarch,
+	}
+	if goos == "darwin" {
+		data.OS = "macOS"
+	}
+	if goarch == "amd64" {
+		data.Arch = "x86_64"
+	}
+
+	var name strings.Builder
+	if err := tmpl.Execute(&name, data); err != nil {
+		return "", fmt.Errorf("invalid release asset template %q: %w", text, err)
+	}
+	if name.Len() == 0 || strings.ContainsAny(name.String(), "/\\") {
+		return "", fmt.Errorf("release asset template %q gives invalid name %q", text, name.String())
+	}
+	return name.String(), nil
+}
+
+// assetTemplateText returns the asset name template of the installer
+func (p *PlatformInstaller) assetTemplateText() string {
+	if p.AssetTemplate != "" {
+		return p.AssetTemplate
+	}
+	return currentAssetTemplate()
+}
+
+// matchAsset returns which of names is the release asset of a version for
+// this platform, comparing exact names in order of assetExtensions
+func (p *PlatformInstaller) matchAsset(names []string, repo, version string) (string, error) {
+	return p.matchPlatformAsset(names, repo, version, p.OS, p.Architecture)
+}
+
+// matchPlatformAsset returns which of names is the release asset of a
+// version for a platform
+func (p *PlatformInstaller) matchPlatformAsset(names []string, repo, version, goos, goarch string) (string, error) {
+	base, err := assetBaseName(p.assetTemplateText(), repo, version, goos, goarch)
+	if err != nil {
+		return "", err
+	}
+	for _, ext := range assetExtensions {
+		if ext == ".exe" && goos != "windows" {
+			continue
+		}
+		for _, name := range names {
+			if name == base+ext {
+				return name, nil
+			}
+		}
+	}
+	return "", fmt.Errorf("no release asset %s.tar.gz, .tgz, .zip or raw binary for %s/%s", base, goos, goarch)
+}
+
+// platformAssets returns the release assets among names for every platform
+// in assetPlatforms
+func (p *PlatformInstaller) platformAssets(names []string, repo, version string) []string {
+	var assets []string
+	for _, platform := range assetPlatforms {
+		if asset, err := p.matchPlatformAsset(names, repo, version, platform[0], platform[1]); err == nil && !contains(assets, asset) {
+			assets = append(assets, asset)
+		}
+	}
+	return assets
+}
+
+// releaseMetadata is the part of a GitHub release used for installs
+type releaseMetadata struct {
+	TagName string `json:"tag_name"`
+	Assets  []struct {
+		Name string `json:"name"`
+		URL  string `json:"browser_download_url"`
+	} `json:"assets"`
+}
+
+// fetchRelease gets the metadata of the GitHub release with the given tag,
+// or of the latest release if tag is empty
+func (p *PlatformInstaller) fetchRelease(owner, repo, tag string) (*releaseMetadata, error) {
+	endpoint := p.gitHubAPIURL() + "/repos/" + owner + "/" + repo + "/releases/latest"
+	if tag != "" {
+		endpoint = p.gitHubAPIURL() + "/repos/" + owner + "/" + repo + "/releases/tags/" + url.PathEscape(tag)
+	}
+	data, err := p.fetch(endpoint)
+	if err != nil {
+		return nil, err
+	}
+
+	var release releaseMetadata
+	if err := json.Unmarshal(data, &release); err != nil {
+		return nil, fmt.Errorf("failed to parse release of %s/%s: %w", owner, repo, err)
+	}
+	if release.TagName == "" {
+		release.TagName = tag
+	}
+	if release.TagName == "" {
+		return nil, fmt.Errorf("failed to parse latest release of %s/%s", owner, repo)
+	}
+	return &release, nil
+}
+
+// assetNames returns the names of the assets of a release
+func (r *releaseMetadata) assetNames() []string {
+	names := make([]string, 0, len(r.Assets))
+	for _, asset := range r.Assets {
+		names = append(names, asset.Name)
+	}
+	return names
+}
+
+// assetURL returns the download URL of an asset of a release, or "" if the
+// metadata has none
+func (r *releaseMetadata) assetURL(name string) string {
+	for _, asset := range r.Assets {
+		if asset.Name == name {
+			return asset.URL
+		}
+	}
+	return ""
+}
+
+// assetExtension returns the extension of an asset that is an archive, or
+// "" for a raw binary
+func assetExtension(asset string) string {
+	for _, ext := range []string{".tar.gz", ".tgz", ".zip"} {
+		if strings.HasSuffix(asset, ext) {
+			return ext
+		}
+	}
+	return ""
+}
+
+// isBinaryEntry tells whether an archive entry is the binary, comparing its
+// base name exactly so that docs or completions named after it are skipped
+func isBinaryEntry(entry, binaryName string) bool {
+	base := path.Base(strings.ReplaceAll(entry, "\\", "/"))
+	return base == binaryName || base == binaryName+".exe"
+}
+
+// extractAndInstall installs the binary from a release asset, which is a
+// tar.gz or zip archive or the binary itself
+func (p *PlatformInstaller) extractAndInstall(archivePath, asset, binaryName, installPath string) error {
+	switch assetExtension(asset) {
+	case ".tar.gz", ".tgz":
+		return extractTarGz(archivePath, binaryName, installPath)
+	case ".zip":
+		return extractZip(archivePath, binaryName, installPath)
+	default:
+		file, err := os.Open(archivePath) // #nosec G304 -- Verified download in a temp file
+		if err != nil {
+			return fmt.Errorf("failed to open binary: %w", err)
+		}
+		defer file.Close()
+
+		info, err := file.Stat()
+		if err != nil {
+			return fmt.Errorf("failed to open binary: %w", err)
+		}
+		return writeBinary(file, info.Size(), asset, installPath)
+	}
+}
+
+// extractTarGz installs the binary from a tar.gz archive
+func extractTarGz(archivePath, binaryName, installPath string) error {
+	file, err := os.Open(archivePath) // #nosec G304 -- Archive path is from validated dependency configuration
+	if err != nil {
+		return fmt.Errorf("failed to open archive: %w", err)
+	}
+	defer file.Close()
+
+	gzReader, err := gzip.NewReader(file)
+	if err != nil {
+		return fmt.Errorf("failed to create gzip reader: %w", err)
+	}
+	defer gzReader.Close()
+
+	tarReader := tar.NewReader(gzReader)
+	for {
+		header, err := tarReader.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read tar entry: %w", err)
+		}
+
+		if header.Typeflag == tar.TypeReg && isBinaryEntry(header.Name, binaryName) {
+			return writeBinary(tarReader, header.Size, header.Name, installPath)
+		}
+	}
+
+	return fmt.Errorf("binary %s not found in archive", binaryName)
+}
+
+// extractZip installs the binary from a zip archive
+func extractZip(archivePath, binaryName, installPath string) error {
+	archive, err := zip.OpenReader(archivePath)
+	if err != nil {
+		return fmt.Errorf("failed to open zip archive: %w", err)
+	}
+	defer archive.Close()
+
+	for _, entry := range archive.File {
+		if !entry.Mode().IsRegular() || !isBinaryEntry(entry.Name, binaryName) {
+			continue
+		}
+		size := entry.UncompressedSize64
+		if size > maxBinarySize {
+			return fmt.Errorf("file %s is too large (%d bytes > %d bytes limit)", entry.Name, size, maxBinarySize)
+		}
+
+		reader, err := entry.Open()
+		if err != nil {
+			return fmt.Errorf("failed to read zip entry: %w", err)
+		}
+		defer reader.Close()
+		return writeBinary(reader, int64(size), entry.Name, installPath) // #nosec G115 -- Size checked against maxBinarySize
+	}
+
+	return fmt.Errorf("binary %s not found in archive", binaryName)
+}
+
+// writeBinary writes size bytes of a binary to installPath as an executable
+func writeBinary(src io.Reader, size int64, name, installPath string) error {
+	if size > maxBinarySize {
+		return fmt.Errorf("file %s is too large (%d bytes > %d bytes limit)", name, size, maxBinarySize)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(installPath), 0750); err != nil {
+		return fmt.Errorf("failed to create install directory: %w", err)
+	}
+
+	outFile, err := os.OpenFile(installPath, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0750) // #nosec G304 G302 -- Install path from validated config, 0750 appropriate for executables
+	if err != nil {
+		return fmt.Errorf("failed to create binary file: %w", err)
+	}
+
+	// Copy binary content with size limit
+	_, err = io.CopyN(outFile, src, size)
+	if closeErr := outFile.Close(); closeErr != nil {
+		return fmt.Errorf("failed to close binary file: %w", closeErr)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to write binary: %w", err)
+	}
+	return nil
+}
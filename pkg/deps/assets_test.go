@@ -0,0 +1,191 @@
+package deps
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeFixture writes a tar.gz or zip archive holding entries in order, or
+// the content of a single entry for any other name
+func writeFixture(t *testing.T, path string, entries [][2]string) {
+	t.Helper()
+
+	var buf bytes.Buffer
+	switch assetExtension(path) {
+	case ".tar.gz", ".tgz":
+		gz := gzip.NewWriter(&buf)
+		tw := tar.NewWriter(gz)
+		for _, entry := range entries {
+			if err := tw.WriteHeader(&tar.Header{Name: entry[0], Mode: 0755, Size: int64(len(entry[1])), Typeflag: tar.TypeReg}); err != nil {
+				t.Fatalf("Failed to write tar header: %v", err)
+			}
+			if _, err := tw.Write([]byte(entry[1])); err != nil {
+				t.Fatalf("Failed to write tar entry: %v", err)
+			}
+		}
+		if err := tw.Close(); err != nil {
+			t.Fatalf("Failed to close tar writer: %v", err)
+		}
+		if err := gz.Close(); err != nil {
+			t.Fatalf("Failed to close gzip writer: %v", err)
+		}
+	case ".zip":
+		zw := zip.NewWriter(&buf)
+		for _, entry := range entries {
+			w, err := zw.Create(entry[0])
+			if err != nil {
+				t.Fatalf("Failed to write zip header: %v", err)
+			}
+			if _, err := w.Write([]byte(entry[1])); err != nil {
+				t.Fatalf("Failed to write zip entry: %v", err)
+			}
+		}
+		if err := zw.Close(); err != nil {
+			t.Fatalf("Failed to close zip writer: %v", err)
+		}
+	default:
+		buf.WriteString(entries[0][1])
+	}
+
+	if err := os.WriteFile(path, buf.Bytes(), 0600); err != nil {
+		t.Fatalf("Failed to write fixture: %v", err)
+	}
+}
+
+func TestExtractAndInstallFormats(t *testing.T) {
+	// Entries named after the binary come first, which only an exact match skips
+	decoys := [][2]string{
+		{"completions/tool.bash", "complete"},
+		{"tool-docs/README.md", "docs"},
+		{"tool.sig", "signature"},
+	}
+
+	tests := []struct {
+		asset   string
+		entries [][2]string
+		want    string
+	}{
+		{"tool_linux_x86_64.tar.gz", append(decoys, [2]string{"tool_linux_x86_64/tool", "tar binary"}), "tar binary"},
+		{"tool_linux_x86_64.tgz", append(decoys, [2]string{"tool", "tgz binary"}), "tgz binary"},
+		{"tool_windows_x86_64.zip", append(decoys, [2]string{"bin\\tool.exe", "zip binary"}), "zip binary"},
+		{"tool_linux_x86_64", [][2]string{{"", "raw binary"}}, "raw binary"},
+	}
+
+	installer := &PlatformInstaller{}
+	for _, tt := range tests {
+		t.Run(tt.asset, func(t *testing.T) {
+			dir := t.TempDir()
+			archive := filepath.Join(dir, tt.asset)
+			writeFixture(t, archive, tt.entries)
+
+			installPath := filepath.Join(dir, "bin", "tool")
+			if err := installer.extractAndInstall(archive, tt.asset, "tool", installPath); err != nil {
+				t.Fatalf("extractAndInstall failed: %v", err)
+			}
+			data, err := os.ReadFile(installPath) // #nosec G304 -- Test file
+			if err != nil || string(data) != tt.want {
+				t.Errorf("Expected %q to be installed, got %q: %v", tt.want, data, err)
+			}
+		})
+	}
+
+	t.Run("missing binary", func(t *testing.T) {
+		for _, asset := range []string{"tool.tar.gz", "tool.zip"} {
+			archive := filepath.Join(t.TempDir(), asset)
+			writeFixture(t, archive, decoys)
+			err := installer.extractAndInstall(archive, asset, "tool", filepath.Join(t.TempDir(), "tool"))
+			if err == nil || !strings.Contains(err.Error(), "not found in archive") {
+				t.Errorf("Expected %s to have no binary, got %v", asset, err)
+			}
+		}
+	})
+}
+
+func TestMatchAsset(t *testing.T) {
+	names := []string{
+		"checksums.txt",
+		"tool_linux_x86_64.tar.gz.sig",
+		"tool_linux_x86_64.zip",
+		"tool_linux_x86_64.tar.gz",
+		"tool_linux_x86_64_v2.tar.gz",
+		"tool_macOS_arm64",
+		"tool-1.2.0-windows-amd64.exe",
+		"tool-1.2.0-windows-amd64",
+	}
+
+	tests := []struct {
+		template string
+		goos     string
+		goarch   string
+		want     string
+	}{
+		{"", "linux", "amd64", "tool_linux_x86_64.tar.gz"},
+		{"", "darwin", "arm64", "tool_macOS_arm64"},
+		{"", "linux", "arm64", ""},
+		{"{{.Name}}-{{.Version}}-{{.GOOS}}-{{.GOARCH}}", "windows", "amd64", "tool-1.2.0-windows-amd64.exe"},
+	}
+
+	for _, tt := range tests {
+		installer := &PlatformInstaller{OS: tt.goos, Architecture: tt.goarch, AssetTemplate: tt.template}
+		got, err := installer.matchAsset(names, "tool", "v1.2.0")
+		if got != tt.want || (err == nil) != (tt.want != "") {
+			t.Errorf("%q on %s/%s: expected %q, got %q: %v", tt.template, tt.goos, tt.goarch, tt.want, got, err)
+		}
+	}
+
+	installer := &PlatformInstaller{}
+	if assets := installer.platformAssets(names, "tool", "1.2.0"); len(assets) != 2 {
+		t.Errorf("Expected the linux/amd64 and darwin/arm64 assets, got %v", assets)
+	}
+}
+
+func TestSetAssetTemplate(t *testing.T) {
+	t.Cleanup(func() { _ = SetAssetTemplate("") })
+
+	for _, template := range []string{"{{.Name", "{{.Missing}}", "{{.Name}}/{{.OS}}", "{{if false}}x{{end}}"} {
+		if err := SetAssetTemplate(template); err == nil {
+			t.Errorf("Expected %q to be rejected", template)
+		}
+	}
+
+	if err := SetAssetTemplate("{{.Name}}-{{.GOOS}}"); err != nil {
+		t.Fatalf("SetAssetTemplate failed: %v", err)
+	}
+	if installer := NewPlatformInstaller(); installer.AssetTemplate != "{{.Name}}-{{.GOOS}}" {
+		t.Errorf("Expected new installers to use the template, got %q", installer.AssetTemplate)
+	}
+}
+
+func TestInstallBinaryFromGitHubZipAsset(t *testing.T) {
+	dir := t.TempDir()
+	asset := "tool-1.0.0-linux-amd64.zip"
+	writeFixture(t, filepath.Join(dir, asset), [][2]string{
+		{"tool-1.0.0/completions/tool.zsh", "complete"},
+		{"tool-1.0.0/tool", "#!/bin/sh\necho zip\n"},
+	})
+	archive, err := os.ReadFile(filepath.Join(dir, asset)) // #nosec G304 -- Test file
+	if err != nil {
+		t.Fatalf("Failed to read fixture: %v", err)
+	}
+
+	installer := newReleaseServer(t, map[string][]byte{
+		asset:                      archive,
+		"tool_linux_x86_64.tar.gz": releaseArchive(t, "tool", "default template"),
+		ChecksumsFile:              []byte(checksumLine(asset, archive)),
+	})
+	installer.AssetTemplate = "{{.Name}}-{{.Version}}-{{.GOOS}}-{{.GOARCH}}"
+
+	result, err := installer.installBinaryFromGitHub("toozej", "tool", "tool")
+	if err != nil || !result.Success {
+		t.Fatalf("Expected successful install, got %+v: %v", result, err)
+	}
+	if got := installedTool(t); got != "#!/bin/sh\necho zip\n" {
+		t.Errorf("Expected the binary from the zip archive, got %q", got)
+	}
+}
@@ -0,0 +1,176 @@
+package deps
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"regexp"
+	"strings"
+)
+
+// PackageManagers lists the package managers an installer can use, as
+// selected with 'waffles deps install --via'
+var PackageManagers = []string{"go", "homebrew", "apt", "dnf", "yum", "pacman", "apk", "nix", "uv", "pipx", "pip"}
+
+// packageManagerCommands maps package managers to their executables
+var packageManagerCommands = map[string]string{
+	"homebrew": "brew",
+}
+
+// systemBackend describes how a system package manager installs packages.
+// Selected with --via, it installs llm and its plugins from distribution
+// packages. Detected, it only installs pipx, which then installs llm. No
+// distribution packages the Go tools.
+type systemBackend struct {
+	Install      []string // Arguments before the package name
+	Root         bool     // Needs root, run with sudo otherwise
+	Prefix       string   // Prepended to the package name, such as "nixpkgs#"
+	Pipx         string   // Package providing pipx
+	LLM          string   // Package providing llm, or "" if there is none
+	PluginPrefix string   // Prepended to an llm plugin name to get its package
+}
+
+// systemBackends are the system package managers by name
+var systemBackends = map[string]systemBackend{
+	"apt":    {Install: []string{"install", "-y"}, Root: true, Pipx: "pipx"},
+	"dnf":    {Install: []string{"install", "-y"}, Root: true, Pipx: "pipx", LLM: "llm", PluginPrefix: "python3-"},
+	"yum":    {Install: []string{"install", "-y"}, Root: true, Pipx: "pipx"},
+	"pacman": {Install: []string{"-S", "--noconfirm", "--needed"}, Root: true, Pipx: "python-pipx", LLM: "python-llm", PluginPrefix: "python-"},
+	"apk":    {Install: []string{"add"}, Root: true, Pipx: "pipx", LLM: "py3-llm", PluginPrefix: "py3-"},
+	"nix":    {Install: []string{"profile", "install"}, Prefix: "nixpkgs#", Pipx: "pipx", LLM: "llm", PluginPrefix: "python3Packages."},
+}
+
+// packageManagerCommand returns the executable of a package manager
+func packageManagerCommand(name string) string {
+	if command, ok := packageManagerCommands[name]; ok {
+		return command
+	}
+	return name
+}
+
+// UsePackageManager makes the installer use a package manager instead of
+// the detected one, and nothing else: tools it cannot install fail instead of
+// being installed another way. Outside of dry-run mode it must be on PATH.
+func (p *PlatformInstaller) UsePackageManager(name string) error {
+	if !contains(PackageManagers, name) {
+		return fmt.Errorf("unknown package manager %q, expected one of %s", name, strings.Join(PackageManagers, ", "))
+	}
+	if !p.DryRun {
+		if _, err := exec.LookPath(packageManagerCommand(name)); err != nil {
+			return fmt.Errorf("%s not found in PATH", packageManagerCommand(name))
+		}
+	}
+	p.PackageManager = name
+	p.Strict = true
+	return nil
+}
+
+// unsupported fails the install of a tool the selected package manager
+// cannot install
+func (p *PlatformInstaller) unsupported(name string) (*InstallationResult, error) {
+	err := fmt.Errorf("%s cannot install %s; choose another package manager with --via, or omit --via", p.PackageManager, name)
+	return failedResult(err.Error(), err)
+}
+
+// installViaSystem installs a tool or llm plugin from a package of the
+// selected system package manager, failing if it has none
+func (p *PlatformInstaller) installViaSystem(name, pkg string) (*InstallationResult, error) {
+	if pkg == "" {
+		return p.unsupported(name)
+	}
+	command := systemCommand(p.PackageManager, pkg)
+	if p.DryRun {
+		return p.planned(command[0], command[1:]...)
+	}
+	result, err := runPackageCommand(fmt.Sprintf("installed %s via %s", name, p.PackageManager), command[0], command[1:]...)
+	_ = ClearCheckCache()
+	return result, err
+}
+
+// installPlugins installs llm plugins the way llm was installed: with llm
+// itself for uv, from packages for a selected system package manager, and
+// with 'llm install' otherwise
+func (p *PlatformInstaller) installPlugins(plugins []string) error {
+	if p.PackageManager == "uv" {
+		// Installed along with llm, since uv environments have no pip
+		return nil
+	}
+
+	backend, system := systemBackends[p.PackageManager]
+	if !p.DryRun && !(system && p.Strict) {
+		return installLLMPlugins(plugins)
+	}
+
+	var problems []string
+	for _, plugin := range plugins {
+		var err error
+		switch {
+		case system && p.Strict:
+			_, err = p.installViaSystem(plugin, backend.PluginPrefix+plugin)
+		default:
+			_, err = p.planned("llm", "install", plugin)
+		}
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("Failed to install %s: %v", plugin, err))
+		}
+	}
+	if len(problems) > 0 {
+		return fmt.Errorf("plugin installation issues: %v", problems)
+	}
+	return nil
+}
+
+// systemCommand returns the command installing a package with a system
+// package manager, using sudo when it needs root
+func systemCommand(manager, pkg string) []string {
+	backend := systemBackends[manager]
+	command := append([]string{manager}, backend.Install...)
+	command = append(command, backend.Prefix+pkg)
+	if backend.Root && os.Geteuid() != 0 {
+		command = append([]string{"sudo"}, command...)
+	}
+	return command
+}
+
+// installViaUv installs a Python tool with uv into its own environment,
+// along with the packages in with, such as llm plugins. uv environments
+// have no pip, so plugins cannot be added later with 'llm install'.
+func (p *PlatformInstaller) installViaUv(packageName string, with []string) (*InstallationResult, error) {
+	args := []string{"tool", "install", packageName}
+	for _, pkg := range with {
+		args = append(args, "--with", pkg)
+	}
+	if p.DryRun {
+		return p.planned("uv", args...)
+	}
+
+	if _, err := exec.LookPath("uv"); err != nil {
+		return failedResult("uv not found - please install uv first", err)
+	}
+	result, err := runPackageCommand(fmt.Sprintf("installed %s via uv", packageName), "uv", args...)
+	_ = ClearCheckCache()
+	return result, err
+}
+
+// planned prints a command that would run in dry-run mode
+func (p *PlatformInstaller) planned(name string, args ...string) (*InstallationResult, error) {
+	command := shellJoin(append([]string{name}, args...))
+	fmt.Printf("  $ %s\n", command)
+	return &InstallationResult{Success: true, Message: "Would run: " + command}, nil
+}
+
+// safeShellWord matches arguments that need no quoting
+var safeShellWord = regexp.MustCompile(`^[A-Za-z0-9_@%+=:,./#-]+$`)
+
+// shellJoin joins a command for pasting into a POSIX shell
+func shellJoin(command []string) string {
+	words := make([]string, len(command))
+	for i, word := range command {
+		if safeShellWord.MatchString(word) {
+			words[i] = word
+		} else {
+			words[i] = "'" + strings.ReplaceAll(word, "'", `'\''`) + "'"
+		}
+	}
+	return strings.Join(words, " ")
+}
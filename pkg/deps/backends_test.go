@@ -0,0 +1,163 @@
+package deps
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestUsePackageManager(t *testing.T) {
+	bin := t.TempDir()
+	t.Setenv("PATH", bin)
+	fakeCommand(t, bin, "uv")
+
+	installer := &PlatformInstaller{PackageManager: "pip"}
+	if err := installer.UsePackageManager("zypper"); err == nil || !strings.Contains(err.Error(), "unknown package manager") {
+		t.Errorf("Expected an unknown package manager error, got %v", err)
+	}
+	if err := installer.UsePackageManager("homebrew"); err == nil || !strings.Contains(err.Error(), "brew not found") {
+		t.Errorf("Expected a missing brew error, got %v", err)
+	}
+	if err := installer.UsePackageManager("uv"); err != nil || installer.PackageManager != "uv" {
+		t.Errorf("Expected uv to be selected, got %q: %v", installer.PackageManager, err)
+	}
+
+	// Dry runs may print commands for package managers that are not installed
+	installer.DryRun = true
+	if err := installer.UsePackageManager("nix"); err != nil || installer.PackageManager != "nix" {
+		t.Errorf("Expected nix to be selected for a dry run, got %q: %v", installer.PackageManager, err)
+	}
+}
+
+func TestDryRunInstallsNothing(t *testing.T) {
+	setRequirements(t, Requirements{Plugins: []string{"llm-ollama"}})
+	server, _ := newLockServer(t, "1.2.0", "0.13")
+	bin := t.TempDir()
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	t.Setenv("PATH", bin)
+
+	var logs []func() string
+	for _, manager := range []string{"uv", "dnf", "pacman", "apk", "nix", "pipx", "brew", "go", "python3"} {
+		logs = append(logs, fakeCommand(t, bin, manager))
+	}
+
+	tests := []struct {
+		manager string
+		want    string
+	}{
+		{"uv", "Would run: uv tool install llm --with llm-ollama"},
+		{"dnf", "Would run: dnf install -y llm"},
+		{"pacman", "Would run: pacman -S --noconfirm --needed python-llm"},
+		{"apk", "Would run: apk add py3-llm"},
+		{"nix", "Would run: nix profile install nixpkgs#llm"},
+		{"homebrew", "Would run: brew install llm"},
+	}
+	for _, tt := range tests {
+		installer := &PlatformInstaller{OS: "linux", Architecture: "amd64", DryRun: true}
+		if err := installer.UsePackageManager(tt.manager); err != nil {
+			t.Fatalf("%s: %v", tt.manager, err)
+		}
+		result, err := installer.InstallLLM()
+		if err != nil || !result.Success || strings.Replace(result.Message, "sudo ", "", 1) != tt.want {
+			t.Errorf("%s: expected %q, got %+v: %v", tt.manager, tt.want, result, err)
+		}
+		if tt.manager != "uv" && tt.manager != "homebrew" {
+			if err := installer.installPlugins([]string{"llm-ollama"}); err != nil {
+				t.Errorf("%s: expected the plugin package to be planned: %v", tt.manager, err)
+			}
+		}
+	}
+
+	// Tools a selected package manager has no package for fail instead of
+	// being installed another way
+	unsupported := []struct {
+		manager string
+		install func(*PlatformInstaller) (*InstallationResult, error)
+	}{
+		{"apt", (*PlatformInstaller).InstallLLM},
+		{"go", (*PlatformInstaller).InstallLLM},
+		{"nix", (*PlatformInstaller).InstallWheresmyprompt},
+		{"uv", (*PlatformInstaller).InstallFiles2prompt},
+	}
+	for _, tt := range unsupported {
+		installer := &PlatformInstaller{OS: "linux", Architecture: "amd64", DryRun: true}
+		if err := installer.UsePackageManager(tt.manager); err != nil {
+			t.Fatalf("%s: %v", tt.manager, err)
+		}
+		if result, err := tt.install(installer); err == nil || result.Success || !strings.Contains(err.Error(), tt.manager+" cannot install") {
+			t.Errorf("%s: expected an unsupported tool error, got %+v: %v", tt.manager, result, err)
+		}
+	}
+
+	// Dry runs resolve what would be downloaded and the exact commands
+	installer := *server
+	installer.DryRun = true
+	result, err := installer.installBinaryFromGitHub("toozej", "files2prompt", "files2prompt")
+	if want := "Would download " + server.ReleaseBaseURL + "/toozej/files2prompt/releases/download/v1.2.0/files2prompt_linux_x86_64.tar.gz"; err != nil || result.Message != want {
+		t.Errorf("Expected %q, got %+v: %v", want, result, err)
+	}
+	result, err = installer.installPythonPackage("llm")
+	if dir, _ := ToolDir("llm", "0.13"); err != nil || result.Message != "Would run: "+filepath.Join(dir, "bin", "pip")+" install llm==0.13" {
+		t.Errorf("Expected pip of the llm 0.13 environment to be planned, got %+v: %v", result, err)
+	}
+
+	for _, manager := range []string{"go", "pacman", "nix"} {
+		installer := *server
+		installer.PackageManager = manager
+		installer.DryRun = true
+		if err := installer.AutoInstallAll(); err != nil {
+			t.Errorf("%s: dry run failed: %v", manager, err)
+		}
+	}
+
+	for _, log := range logs {
+		if args := log(); args != "" {
+			t.Errorf("Expected nothing to run in dry-run mode, got %q", args)
+		}
+	}
+	if versions, _ := ToolVersions("files2prompt"); len(versions) != 0 {
+		t.Errorf("Expected nothing to be installed, got %v", versions)
+	}
+}
+
+func TestInstallViaUv(t *testing.T) {
+	bin := t.TempDir()
+	t.Setenv("PATH", bin)
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	setRequirements(t, Requirements{Plugins: []string{"llm-ollama", "llm-jq"}})
+	uv := fakeCommand(t, bin, "uv")
+
+	installer := &PlatformInstaller{PackageManager: "uv"}
+	if result, err := installer.InstallLLM(); err != nil || !result.Success {
+		t.Fatalf("Install failed: %+v: %v", result, err)
+	}
+	if args := uv(); args != "tool install llm --with llm-ollama --with llm-jq" {
+		t.Errorf("Expected llm and its plugins in one uv install, got %q", args)
+	}
+}
+
+func TestSystemCommand(t *testing.T) {
+	tests := map[string]string{
+		"dnf":    "dnf install -y pipx",
+		"pacman": "pacman -S --noconfirm --needed python-pipx",
+		"apk":    "apk add pipx",
+		"nix":    "nix profile install nixpkgs#pipx",
+	}
+	for manager, want := range tests {
+		command := strings.TrimPrefix(strings.Join(systemCommand(manager, systemBackends[manager].Pipx), " "), "sudo ")
+		if command != want {
+			t.Errorf("%s: expected %q, got %q", manager, want, command)
+		}
+	}
+	if command := systemCommand("nix", "pipx"); command[0] == "sudo" {
+		t.Errorf("Expected nix to run without sudo, got %v", command)
+	}
+}
+
+func TestShellJoin(t *testing.T) {
+	got := shellJoin([]string{"uv", "tool", "install", "llm>=0.13", "it's", "nixpkgs#llm"})
+	want := `uv tool install 'llm>=0.13' 'it'\''s' nixpkgs#llm`
+	if got != want {
+		t.Errorf("Expected %s, got %s", want, got)
+	}
+}
@@ -0,0 +1,420 @@
+package deps
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// A bundle is a tar.gz archive for installing the pinned tools and plugins
+// on a host without network access:
+//
+//	manifest.json        versions, platform and SHA-256 of every file
+//	releases/<archive>   GitHub release archives of the Go tools
+//	wheels/<wheel>       llm, its plugins and all of their Python dependencies
+
+// bundleManifestName is the name of the manifest inside a bundle
+const bundleManifestName = "manifest.json"
+
+// bundleVersion is the format version written to new bundle manifests
+const bundleVersion = 1
+
+// maxBundleFileSize limits the size of each file extracted from a bundle
+const maxBundleFileSize = 500 * 1024 * 1024
+
+// BundleManifest describes the contents of a bundle
+type BundleManifest struct {
+	Version      int               `json:"version"`
+	OS           string            `json:"os"`
+	Architecture string            `json:"arch"`
+	Python       string            `json:"python,omitempty"` // Python version the wheels were resolved for
+	Created      time.Time         `json:"created"`
+	Tools        []LockedTool      `json:"tools"`
+	Plugins      []LockedTool      `json:"plugins,omitempty"`
+	Files        map[string]string `json:"files"` // SHA-256 by path inside the bundle
+}
+
+// Bundle downloads the tools and plugins pinned by a lockfile for this
+// platform and writes them with a manifest to a tar.gz bundle at output.
+// Release archives are verified against the lockfile; Python wheels are
+// resolved by pip for the local interpreter.
+func (p *PlatformInstaller) Bundle(lock *Lockfile, output string) (*BundleManifest, error) {
+	staging, err := os.MkdirTemp("", "waffles-bundle-*")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temp dir: %w", err)
+	}
+	defer os.RemoveAll(staging)
+
+	manifest := &BundleManifest{
+		Version:      bundleVersion,
+		OS:           p.OS,
+		Architecture: p.Architecture,
+		Created:      time.Now().UTC(),
+		Tools:        lock.Tools,
+		Plugins:      lock.Plugins,
+		Files:        make(map[string]string),
+	}
+
+	var packages []LockedTool
+	for _, tool := range lock.Tools {
+		switch {
+		case tool.Release != nil:
+			if err := p.bundleRelease(tool, staging); err != nil {
+				return nil, fmt.Errorf("failed to bundle %s: %w", tool.Name, err)
+			}
+		case tool.Package != nil:
+			packages = append(packages, tool)
+		}
+	}
+	packages = append(packages, lock.Plugins...)
+	if len(packages) > 0 {
+		if manifest.Python, err = downloadWheels(filepath.Join(staging, "wheels"), packages); err != nil {
+			return nil, err
+		}
+	}
+
+	err = filepath.WalkDir(staging, func(file string, entry fs.DirEntry, err error) error {
+		if err != nil || entry.IsDir() {
+			return err
+		}
+		sum, err := sha256File(file)
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(staging, file)
+		if err != nil {
+			return err
+		}
+		manifest.Files[filepath.ToSlash(rel)] = sum
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to checksum bundle files: %w", err)
+	}
+
+	if err := writeBundle(output, staging, manifest); err != nil {
+		_ = os.Remove(output)
+		return nil, err
+	}
+	return manifest, nil
+}
+
+// bundleRelease downloads the release archive of a locked tool for this
+// platform into the releases directory and verifies it
+func (p *PlatformInstaller) bundleRelease(tool LockedTool, dir string) error {
+	owner, repo, _ := strings.Cut(tool.Release.Repository, "/")
+	asset, err := p.lockedAsset(tool)
+	if err != nil {
+		return err
+	}
+	checksum := tool.Release.Checksums[asset]
+
+	if err := os.MkdirAll(filepath.Join(dir, "releases"), 0750); err != nil {
+		return fmt.Errorf("failed to create releases directory: %w", err)
+	}
+	file := filepath.Join(dir, "releases", asset)
+	fmt.Printf("Downloading %s %s...\n", tool.Name, tool.Version)
+	if err := p.downloadTo(p.releaseAssetURL(owner, repo, tool.Release.Tag, asset), file); err != nil {
+		return err
+	}
+	return verifyChecksum(asset, file, checksum)
+}
+
+// downloadWheels downloads wheels of the locked Python packages and all of
+// their dependencies with pip, returning the Python version they are for
+func downloadWheels(dir string, packages []LockedTool) (string, error) {
+	python, err := findPython()
+	if err != nil {
+		return "", err
+	}
+
+	// Only wheels can be installed without fetching build dependencies
+	args := []string{"-m", "pip", "download", "--only-binary=:all:", "--dest", dir}
+	for _, pkg := range packages {
+		args = append(args, pipRequirement(pkg))
+	}
+	fmt.Printf("Downloading wheels for %d Python packages...\n", len(packages))
+	if output, err := exec.Command(python, args...).CombinedOutput(); err != nil { // #nosec G204 -- Interpreter from PATH, pinned requirements
+		return "", fmt.Errorf("pip download failed: %w\nOutput: %s", err, string(output))
+	}
+
+	// pip may pick other files than the lockfile, but those it shares must match
+	for _, pkg := range packages {
+		if pkg.Package == nil {
+			continue
+		}
+		file := filepath.Join(dir, filepath.Base(pkg.Package.File))
+		if _, err := os.Stat(file); err != nil {
+			continue
+		}
+		if err := verifyChecksum(pkg.Package.File, file, pkg.Package.SHA256); err != nil {
+			return "", err
+		}
+	}
+
+	return pythonVersion(python)
+}
+
+// pipRequirement returns the pip requirement pinning a locked package
+func pipRequirement(tool LockedTool) string {
+	name := tool.Name
+	if tool.Package != nil {
+		name = tool.Package.Name
+	}
+	return name + "==" + tool.Version
+}
+
+// pythonVersion returns the major and minor version of a Python interpreter
+func pythonVersion(python string) (string, error) {
+	output, err := exec.Command(python, "-c", "import sys; print('%d.%d' % sys.version_info[:2])").Output() // #nosec G204 -- Interpreter from PATH
+	if err != nil {
+		return "", fmt.Errorf("failed to get Python version: %w", err)
+	}
+	return strings.TrimSpace(string(output)), nil
+}
+
+// writeBundle writes the manifest and the staged files to a tar.gz archive
+func writeBundle(output, staging string, manifest *BundleManifest) error {
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal bundle manifest: %w", err)
+	}
+
+	file, err := os.OpenFile(output, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644) // #nosec G302 G304 -- Output path from command line, bundles are not secret
+	if err != nil {
+		return fmt.Errorf("failed to create bundle: %w", err)
+	}
+	defer file.Close()
+
+	gz := gzip.NewWriter(file)
+	tw := tar.NewWriter(gz)
+
+	header := &tar.Header{Name: bundleManifestName, Mode: 0644, Size: int64(len(data)), ModTime: manifest.Created, Typeflag: tar.TypeReg}
+	if err := tw.WriteHeader(header); err != nil {
+		return fmt.Errorf("failed to write bundle: %w", err)
+	}
+	if _, err := tw.Write(data); err != nil {
+		return fmt.Errorf("failed to write bundle: %w", err)
+	}
+
+	names := make([]string, 0, len(manifest.Files))
+	for name := range manifest.Files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if err := addBundleFile(tw, filepath.Join(staging, filepath.FromSlash(name)), name, manifest.Created); err != nil {
+			return err
+		}
+	}
+
+	if err := tw.Close(); err != nil {
+		return fmt.Errorf("failed to write bundle: %w", err)
+	}
+	if err := gz.Close(); err != nil {
+		return fmt.Errorf("failed to write bundle: %w", err)
+	}
+	return file.Close()
+}
+
+// addBundleFile adds one staged file to a bundle
+func addBundleFile(tw *tar.Writer, source, name string, modTime time.Time) error {
+	in, err := os.Open(source) // #nosec G304 -- File in the staging directory
+	if err != nil {
+		return fmt.Errorf("failed to open %s: %w", name, err)
+	}
+	defer in.Close()
+
+	info, err := in.Stat()
+	if err != nil {
+		return fmt.Errorf("failed to stat %s: %w", name, err)
+	}
+	header := &tar.Header{Name: name, Mode: 0644, Size: info.Size(), ModTime: modTime, Typeflag: tar.TypeReg}
+	if err := tw.WriteHeader(header); err != nil {
+		return fmt.Errorf("failed to write bundle: %w", err)
+	}
+	if _, err := io.Copy(tw, in); err != nil {
+		return fmt.Errorf("failed to add %s to bundle: %w", name, err)
+	}
+	return nil
+}
+
+// InstallBundle installs the tools and plugins of a bundle created by Bundle,
+// or only the named ones, without network access. Every file is checked
+// against the manifest before anything is installed.
+func (p *PlatformInstaller) InstallBundle(bundle string, names []string) error {
+	dir, err := os.MkdirTemp("", "waffles-bundle-*")
+	if err != nil {
+		return fmt.Errorf("failed to create temp dir: %w", err)
+	}
+	defer os.RemoveAll(dir)
+
+	manifest, err := extractBundle(bundle, dir)
+	if err != nil {
+		return err
+	}
+	if manifest.OS != p.OS || manifest.Architecture != p.Architecture {
+		return fmt.Errorf("bundle is for %s/%s, not %s/%s", manifest.OS, manifest.Architecture, p.OS, p.Architecture)
+	}
+
+	lock := &Lockfile{Version: lockfileVersion, Tools: manifest.Tools, Plugins: manifest.Plugins}
+	for _, name := range names {
+		if lock.Find(name) == nil {
+			return fmt.Errorf("%s is not in the bundle", name)
+		}
+	}
+
+	if manifest.Python != "" {
+		if python, err := findPython(); err == nil {
+			if version, err := pythonVersion(python); err == nil && version != manifest.Python {
+				fmt.Printf("⚠ Warning: bundle wheels are for Python %s, found Python %s\n", manifest.Python, version)
+			}
+		}
+	}
+
+	install := func(tool LockedTool, plugin bool) error {
+		return p.installBundledTool(dir, tool, plugin)
+	}
+	if problems := installPinned(lock, names, install); len(problems) > 0 {
+		return fmt.Errorf("failed to install from bundle:\n  - %s", strings.Join(problems, "\n  - "))
+	}
+	return nil
+}
+
+// installBundledTool installs one tool or plugin from an extracted bundle
+func (p *PlatformInstaller) installBundledTool(dir string, tool LockedTool, plugin bool) error {
+	wheels := filepath.Join(dir, "wheels")
+
+	switch {
+	case tool.Release != nil:
+		asset, err := p.lockedAsset(tool)
+		if err != nil {
+			return err
+		}
+		archive := filepath.Join(dir, "releases", asset)
+		if _, err := os.Stat(archive); err != nil {
+			return fmt.Errorf("bundle has no archive %s", asset)
+		}
+		if err := verifyChecksum(asset, archive, tool.Release.Checksums[asset]); err != nil {
+			return err
+		}
+		_, err = p.installArchive(tool.Name, tool.Version, asset, archive)
+		return err
+	case plugin:
+		// Into llm's own environment, which needs no llm-python plugin
+		python, err := llmPython()
+		if err != nil {
+			return err
+		}
+		output, err := exec.Command(python, "-m", "pip", "install", "--no-index", "--find-links", wheels, pipRequirement(tool)).CombinedOutput() // #nosec G204 -- Interpreter of the llm found on the tool prefix or PATH
+		if err != nil {
+			return fmt.Errorf("failed to install plugin %s: %w\nOutput: %s", tool.Name, err, string(output))
+		}
+		_ = ClearCheckCache()
+		return nil
+	case tool.Package != nil:
+		_, err := installPythonTool(tool.Name, tool.Version, "--no-index", "--find-links", wheels, pipRequirement(tool))
+		return err
+	default:
+		return fmt.Errorf("bundle entry has no release or package")
+	}
+}
+
+// extractBundle extracts a bundle into dir and verifies that it holds
+// exactly the files listed in its manifest, with matching checksums
+func extractBundle(bundle, dir string) (*BundleManifest, error) {
+	file, err := os.Open(bundle) // #nosec G304 -- Bundle path from command line
+	if err != nil {
+		return nil, fmt.Errorf("failed to open bundle: %w", err)
+	}
+	defer file.Close()
+
+	gz, err := gzip.NewReader(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read bundle: %w", err)
+	}
+	defer gz.Close()
+
+	var manifest *BundleManifest
+	extracted := make(map[string]bool)
+	tr := tar.NewReader(gz)
+	for {
+		header, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read bundle: %w", err)
+		}
+
+		name := header.Name
+		if header.Typeflag != tar.TypeReg || name != path.Clean(name) || path.IsAbs(name) || strings.HasPrefix(name, "../") || name == ".." {
+			return nil, fmt.Errorf("bundle contains unexpected entry %q", name)
+		}
+		if header.Size > maxBundleFileSize {
+			return nil, fmt.Errorf("bundle entry %s is too large (%d bytes > %d bytes limit)", name, header.Size, maxBundleFileSize)
+		}
+
+		if name == bundleManifestName {
+			manifest = &BundleManifest{}
+			if err := json.NewDecoder(io.LimitReader(tr, maxMetadataSize)).Decode(manifest); err != nil {
+				return nil, fmt.Errorf("failed to parse bundle manifest: %w", err)
+			}
+			if manifest.Version != bundleVersion {
+				return nil, fmt.Errorf("unsupported bundle version %d", manifest.Version)
+			}
+			continue
+		}
+
+		target := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(target), 0750); err != nil {
+			return nil, fmt.Errorf("failed to create directory: %w", err)
+		}
+		out, err := os.OpenFile(target, os.O_CREATE|os.O_WRONLY|os.O_EXCL, 0600) // #nosec G304 -- Path checked to stay inside the temp dir
+		if err != nil {
+			return nil, fmt.Errorf("failed to extract %s: %w", name, err)
+		}
+		_, err = io.CopyN(out, tr, header.Size)
+		if closeErr := out.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to extract %s: %w", name, err)
+		}
+		extracted[name] = true
+	}
+
+	if manifest == nil {
+		return nil, fmt.Errorf("bundle has no %s", bundleManifestName)
+	}
+	for name := range extracted {
+		if _, ok := manifest.Files[name]; !ok {
+			return nil, fmt.Errorf("bundle file %s is not in the manifest", name)
+		}
+	}
+	for name, sum := range manifest.Files {
+		if !extracted[name] {
+			return nil, fmt.Errorf("bundle is missing %s", name)
+		}
+		actual, err := sha256File(filepath.Join(dir, filepath.FromSlash(name)))
+		if err != nil {
+			return nil, err
+		}
+		if actual != strings.ToLower(sum) {
+			return nil, &VerificationError{Asset: name, Reason: fmt.Sprintf("SHA-256 mismatch: expected %s, got %s", sum, actual)}
+		}
+	}
+
+	return manifest, nil
+}
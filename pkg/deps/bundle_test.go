@@ -0,0 +1,224 @@
+package deps
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// releaseLock locks only the Go tools, so bundling needs no Python
+func releaseLock(t *testing.T, installer *PlatformInstaller) *Lockfile {
+	t.Helper()
+
+	lock, err := installer.Lock(nil, LockOptions{Upgrade: true})
+	if err != nil {
+		t.Fatalf("Lock failed: %v", err)
+	}
+	var tools []LockedTool
+	for _, tool := range lock.Tools {
+		if tool.Release != nil {
+			tools = append(tools, tool)
+		}
+	}
+	return &Lockfile{Version: lockfileVersion, Tools: tools}
+}
+
+// writeTarGz writes a tar.gz archive with the given entries
+func writeTarGz(t *testing.T, path string, entries map[string][]byte) {
+	t.Helper()
+
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	for name, data := range entries {
+		if err := tw.WriteHeader(&tar.Header{Name: name, Mode: 0644, Size: int64(len(data)), Typeflag: tar.TypeReg}); err != nil {
+			t.Fatalf("Failed to write tar header: %v", err)
+		}
+		if _, err := tw.Write(data); err != nil {
+			t.Fatalf("Failed to write tar entry: %v", err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatalf("Failed to close tar writer: %v", err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatalf("Failed to close gzip writer: %v", err)
+	}
+	if err := os.WriteFile(path, buf.Bytes(), 0600); err != nil {
+		t.Fatalf("Failed to write archive: %v", err)
+	}
+}
+
+func TestBundleInstall(t *testing.T) {
+	installer, _ := newLockServer(t, "1.2.0", "0.9")
+	bundle := filepath.Join(t.TempDir(), "bundle.tar.gz")
+
+	manifest, err := installer.Bundle(releaseLock(t, installer), bundle)
+	if err != nil {
+		t.Fatalf("Bundle failed: %v", err)
+	}
+	if len(manifest.Files) != 2 || manifest.Files["releases/files2prompt_linux_x86_64.tar.gz"] == "" || manifest.Python != "" {
+		t.Errorf("Unexpected manifest: %+v", manifest)
+	}
+
+	// Installing needs no server
+	installer.ReleaseBaseURL = "http://127.0.0.1:0"
+	installer.GitHubAPIURL = "http://127.0.0.1:0"
+	if err := installer.InstallBundle(bundle, []string{"files2prompt"}); err != nil {
+		t.Fatalf("InstallBundle failed: %v", err)
+	}
+	data, err := os.ReadFile(ResolveCommand("files2prompt"))
+	if err != nil || string(data) != "files2prompt 1.2.0" {
+		t.Errorf("Expected the bundled binary to be installed, got %q: %v", data, err)
+	}
+	if ResolveCommand("wheresmyprompt") != "wheresmyprompt" {
+		t.Error("Expected only the named tool to be installed")
+	}
+
+	if err := installer.InstallBundle(bundle, []string{"llm"}); err == nil || !strings.Contains(err.Error(), "not in the bundle") {
+		t.Errorf("Expected a missing entry error, got %v", err)
+	}
+
+	installer.OS = "darwin"
+	if err := installer.InstallBundle(bundle, nil); err == nil || !strings.Contains(err.Error(), "bundle is for linux/amd64") {
+		t.Errorf("Expected a platform mismatch error, got %v", err)
+	}
+}
+
+func TestInstallBundleRejectsTampering(t *testing.T) {
+	installer, files := newLockServer(t, "1.2.0", "0.9")
+	bundle := filepath.Join(t.TempDir(), "bundle.tar.gz")
+	manifest, err := installer.Bundle(releaseLock(t, installer), bundle)
+	if err != nil {
+		t.Fatalf("Bundle failed: %v", err)
+	}
+	data, err := json.Marshal(manifest)
+	if err != nil {
+		t.Fatalf("Failed to marshal manifest: %v", err)
+	}
+	archive := files["/toozej/files2prompt/releases/download/v1.2.0/files2prompt_linux_x86_64.tar.gz"]
+	other := files["/toozej/wheresmyprompt/releases/download/v1.2.0/wheresmyprompt_linux_x86_64.tar.gz"]
+
+	tests := []struct {
+		name    string
+		entries map[string][]byte
+		want    string
+	}{
+		{"modified file", map[string][]byte{
+			bundleManifestName:                            data,
+			"releases/files2prompt_linux_x86_64.tar.gz":   other,
+			"releases/wheresmyprompt_linux_x86_64.tar.gz": other,
+		}, "SHA-256 mismatch"},
+		{"missing file", map[string][]byte{
+			bundleManifestName:                          data,
+			"releases/files2prompt_linux_x86_64.tar.gz": archive,
+		}, "bundle is missing"},
+		{"unlisted file", map[string][]byte{
+			bundleManifestName:                            data,
+			"releases/files2prompt_linux_x86_64.tar.gz":   archive,
+			"releases/wheresmyprompt_linux_x86_64.tar.gz": other,
+			"wheels/extra.whl":                            []byte("extra"),
+		}, "not in the manifest"},
+		{"path traversal", map[string][]byte{
+			bundleManifestName: data,
+			"../escape":        []byte("escape"),
+		}, "unexpected entry"},
+		{"no manifest", map[string][]byte{
+			"releases/files2prompt_linux_x86_64.tar.gz": archive,
+		}, "no manifest.json"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "tampered.tar.gz")
+			writeTarGz(t, path, tt.entries)
+
+			err := installer.InstallBundle(path, nil)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("Expected an error containing %q, got %v", tt.want, err)
+			}
+			var verr *VerificationError
+			if tt.want == "SHA-256 mismatch" && !errors.As(err, &verr) {
+				t.Errorf("Expected a VerificationError, got %T", err)
+			}
+			if ResolveCommand("files2prompt") != "files2prompt" {
+				t.Error("Expected nothing to be installed")
+			}
+		})
+	}
+}
+
+// fakePython writes a python3 into bin that answers the version probe,
+// writes a wheel for each requirement given to pip download, and logs the
+// arguments of pip install
+func fakePython(t *testing.T, bin string) (installs func() string) {
+	t.Helper()
+
+	log := filepath.Join(bin, "pip-install.args")
+	script := `#!/bin/sh
+case "$1 $2 $3" in
+"-c "*) echo 3.12 ;;
+"-m pip download")
+	dest=$6
+	shift 6
+	mkdir -p "$dest"
+	for req in "$@"; do
+		name=$(echo "${req%%==*}" | tr - _)
+		echo wheel > "$dest/$name-${req##*==}-py3-none-any.whl"
+	done ;;
+"-m pip install") echo "$@" >> ` + log + ` ;;
+*) exit 1 ;;
+esac
+`
+	if err := os.WriteFile(filepath.Join(bin, "python3"), []byte(script), 0755); err != nil { // #nosec G306 -- Test executable
+		t.Fatalf("Failed to write python3: %v", err)
+	}
+	return func() string {
+		data, _ := os.ReadFile(log) // #nosec G304 -- Test file
+		return strings.TrimSpace(string(data))
+	}
+}
+
+func TestBundleInstallPlugin(t *testing.T) {
+	installer, _ := newLockServer(t, "1.2.0", "0.9")
+	bin := t.TempDir()
+	t.Setenv("PATH", bin+":/usr/bin:/bin")
+	installs := fakePython(t, bin)
+
+	lock := &Lockfile{Version: lockfileVersion, Plugins: []LockedTool{{Name: "llm-ollama", Version: "0.9"}}}
+	bundle := filepath.Join(t.TempDir(), "bundle.tar.gz")
+	manifest, err := installer.Bundle(lock, bundle)
+	if err != nil {
+		t.Fatalf("Bundle failed: %v", err)
+	}
+	if manifest.Python != "3.12" || manifest.Files["wheels/llm_ollama-0.9-py3-none-any.whl"] == "" {
+		t.Fatalf("Unexpected manifest: %+v", manifest)
+	}
+
+	// llm runs with an interpreter of its own, which has no llm python command
+	env := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(env, "bin"), 0750); err != nil {
+		t.Fatalf("Failed to create environment: %v", err)
+	}
+	if err := os.Symlink(filepath.Join(bin, "python3"), filepath.Join(env, "bin", "python")); err != nil {
+		t.Fatalf("Failed to link interpreter: %v", err)
+	}
+	llm := "#!" + filepath.Join(env, "bin", "python") + "\nfrom llm.cli import cli\n"
+	if err := os.WriteFile(filepath.Join(bin, "llm"), []byte(llm), 0755); err != nil { // #nosec G306 -- Test executable
+		t.Fatalf("Failed to write llm: %v", err)
+	}
+
+	if err := installer.InstallBundle(bundle, []string{"llm-ollama"}); err != nil {
+		t.Fatalf("InstallBundle failed: %v", err)
+	}
+	args := installs()
+	if !strings.HasPrefix(args, "-m pip install --no-index --find-links ") || !strings.HasSuffix(args, "/wheels llm-ollama==0.9") {
+		t.Errorf("Expected the plugin to be installed offline with llm's interpreter, got %q", args)
+	}
+}
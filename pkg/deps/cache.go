@@ -0,0 +1,244 @@
+package deps
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"time"
+)
+
+const (
+	checkCacheVersion = 1
+	checkCacheName    = "deps-check.json"
+
+	// checkCacheTTL bounds how long a result is trusted, since a tool can
+	// change in ways neither its binary nor its environment shows
+	checkCacheTTL = 24 * time.Hour
+)
+
+// checkCache holds the results of earlier dependency checks, keyed by the
+// binary they checked and, for Python tools such as llm, the packages
+// installed next to it, so they are only repeated once either changes
+type checkCache struct {
+	Version int                    `json:"version"`
+	Entries map[string]cachedCheck `json:"entries"`
+}
+
+// cachedCheck is the result of checking a dependency along with what it
+// depended on
+type cachedCheck struct {
+	Path       string           `json:"path"`     // Binary with symlinks resolved
+	ModTime    time.Time        `json:"mod_time"` // Modification time of the binary
+	Size       int64            `json:"size"`     // Size of the binary
+	Packages   time.Time        `json:"packages"` // Modification time of the site-packages of a Python tool
+	Constraint string           `json:"constraint"`
+	Plugins    []string         `json:"plugins,omitempty"`
+	Checked    time.Time        `json:"checked"`
+	Status     DependencyStatus `json:"status"`
+}
+
+// CheckCachePath returns the path of the dependency check cache in the
+// user cache directory
+func CheckCachePath() (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to find cache directory: %w", err)
+	}
+	return filepath.Join(dir, "waffles", checkCacheName), nil
+}
+
+// ClearCheckCache discards the cached dependency check results, so the next
+// check runs every tool again
+func ClearCheckCache() error {
+	path, err := CheckCachePath()
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to clear dependency check cache: %w", err)
+	}
+	return nil
+}
+
+// loadCheckCache reads the dependency check cache, starting an empty one if
+// it is missing, unreadable or of another version
+func loadCheckCache() *checkCache {
+	cache := &checkCache{Version: checkCacheVersion, Entries: make(map[string]cachedCheck)}
+
+	path, err := CheckCachePath()
+	if err != nil {
+		return cache
+	}
+	data, err := os.ReadFile(path) // #nosec G304 -- path is within the user cache directory
+	if err != nil {
+		return cache
+	}
+
+	var stored checkCache
+	if err := json.Unmarshal(data, &stored); err != nil || stored.Version != checkCacheVersion || stored.Entries == nil {
+		return cache
+	}
+	cache.Entries = stored.Entries
+	return cache
+}
+
+// save writes the cache atomically, so concurrent runs never read a
+// partially written file
+func (c *checkCache) save() error {
+	path, err := CheckCachePath()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0750); err != nil {
+		return fmt.Errorf("failed to create cache directory: %w", err)
+	}
+
+	data, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode dependency check cache: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), checkCacheName+".*")
+	if err != nil {
+		return fmt.Errorf("failed to write dependency check cache: %w", err)
+	}
+	defer func() { _ = os.Remove(tmp.Name()) }()
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		return fmt.Errorf("failed to write dependency check cache: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write dependency check cache: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("failed to write dependency check cache: %w", err)
+	}
+	return nil
+}
+
+// lookup returns the cached status of a dependency, or nil if its binary
+// or requirements changed since it was checked or the result has expired
+func (c *checkCache) lookup(dep Dependency) *DependencyStatus {
+	entry, ok := c.Entries[dep.Name]
+	if !ok || time.Since(entry.Checked) > checkCacheTTL {
+		return nil
+	}
+
+	key, ok := binaryKey(dep.Command)
+	if !ok || key.Path != entry.Path || !key.ModTime.Equal(entry.ModTime) || key.Size != entry.Size ||
+		!key.Packages.Equal(entry.Packages) {
+		return nil
+	}
+	if entry.Constraint != dep.VersionConstraint() || !reflect.DeepEqual(entry.Plugins, cachedPlugins(dep)) {
+		return nil
+	}
+
+	status := entry.Status
+	return &status
+}
+
+// store caches the status of an installed dependency, reporting whether the
+// cache changed
+func (c *checkCache) store(dep Dependency, status DependencyStatus) bool {
+	if !status.Installed {
+		return false
+	}
+	key, ok := binaryKey(dep.Command)
+	if !ok {
+		return false
+	}
+
+	key.Constraint = dep.VersionConstraint()
+	key.Plugins = cachedPlugins(dep)
+	key.Checked = time.Now()
+	key.Status = status
+
+	c.Entries[dep.Name] = key
+	return true
+}
+
+// binaryKey identifies the binary a command currently resolves to
+func binaryKey(command string) (cachedCheck, bool) {
+	path, err := LookPath(command)
+	if err != nil {
+		return cachedCheck{}, false
+	}
+	// Shims and package manager links point at the actual binary, which is
+	// what changes on upgrade
+	if resolved, err := filepath.EvalSymlinks(path); err == nil {
+		path = resolved
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return cachedCheck{}, false
+	}
+	return cachedCheck{Path: path, ModTime: info.ModTime(), Size: info.Size(), Packages: packagesModTime(path)}, true
+}
+
+// packagesModTime returns when the site-packages of the virtual environment
+// running a Python script last changed, or the zero time for other binaries.
+// Installing or removing llm plugins, even outside waffles, changes it.
+func packagesModTime(script string) time.Time {
+	interpreter := scriptInterpreter(script)
+	if interpreter == "" {
+		return time.Time{}
+	}
+
+	// The interpreter of a virtual environment is <env>/bin/python
+	env := filepath.Dir(filepath.Dir(interpreter))
+	dirs, _ := filepath.Glob(filepath.Join(env, "lib", "python*", "site-packages"))
+	dirs = append(dirs, filepath.Join(env, "Lib", "site-packages"))
+
+	var latest time.Time
+	for _, dir := range dirs {
+		if info, err := os.Stat(dir); err == nil && info.ModTime().After(latest) {
+			latest = info.ModTime()
+		}
+	}
+	return latest
+}
+
+// scriptInterpreter returns the interpreter in the shebang of a script,
+// looking it up on PATH for "#!/usr/bin/env python3", or "" if the file is
+// not a script
+func scriptInterpreter(script string) string {
+	file, err := os.Open(script) // #nosec G304 -- Binary resolved from the tool prefix or PATH
+	if err != nil {
+		return ""
+	}
+	defer file.Close()
+
+	header := make([]byte, 512)
+	n, _ := io.ReadFull(file, header)
+	line, _, _ := strings.Cut(string(header[:n]), "\n")
+	interpreter, ok := strings.CutPrefix(line, "#!")
+	if !ok {
+		return ""
+	}
+	fields := strings.Fields(interpreter)
+	if len(fields) == 0 {
+		return ""
+	}
+	if filepath.Base(fields[0]) == "env" && len(fields) > 1 {
+		path, err := exec.LookPath(fields[1])
+		if err != nil {
+			return ""
+		}
+		return path
+	}
+	return fields[0]
+}
+
+// cachedPlugins returns the plugins a cached llm check covered
+func cachedPlugins(dep Dependency) []string {
+	if dep.Name != "llm" || len(dep.Plugins) == 0 {
+		return nil
+	}
+	return dep.Plugins
+}
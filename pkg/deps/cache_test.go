@@ -0,0 +1,174 @@
+package deps
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeTool writes a wheresmyprompt or files2prompt script into bin that runs
+// script and counts its invocations in a file next to it
+func fakeTool(t *testing.T, bin, name, script string) (count func() int) {
+	t.Helper()
+
+	calls := filepath.Join(bin, name+".calls")
+	content := fmt.Sprintf("#!/bin/sh\necho x >> %q\n%s\n", calls, script)
+	if err := os.WriteFile(filepath.Join(bin, name), []byte(content), 0755); err != nil { // #nosec G306 -- Test executable
+		t.Fatalf("Failed to write %s: %v", name, err)
+	}
+	return func() int {
+		data, _ := os.ReadFile(calls) // #nosec G304 -- Test file
+		return strings.Count(string(data), "x")
+	}
+}
+
+// isolateChecks points the tool prefix, PATH and the check cache at
+// temporary directories, returning the directory for fake tools
+func isolateChecks(t *testing.T) string {
+	t.Helper()
+
+	bin := t.TempDir()
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("XDG_DATA_HOME", "")
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	t.Setenv("PATH", bin+":/usr/bin:/bin")
+	return bin
+}
+
+func TestCheckAllDependenciesCachesResults(t *testing.T) {
+	bin := isolateChecks(t)
+	setRequirements(t, Requirements{Tools: []string{"wheresmyprompt"}})
+	count := fakeTool(t, bin, "wheresmyprompt", "echo 0.2.0")
+
+	for i := 0; i < 2; i++ {
+		statuses, err := CheckAllDependencies()
+		if err != nil {
+			t.Fatalf("CheckAllDependencies failed: %v", err)
+		}
+		if len(statuses) != 1 || !statuses[0].Valid || statuses[0].Version != "0.2.0" {
+			t.Fatalf("Unexpected statuses %+v", statuses)
+		}
+	}
+	if count() != 1 {
+		t.Errorf("Expected the second check to be cached, ran %d times", count())
+	}
+
+	// Changing the binary invalidates its result
+	count = fakeTool(t, bin, "wheresmyprompt", "echo 0.3.0 # upgraded")
+	statuses, _ := CheckAllDependencies()
+	if statuses[0].Version != "0.3.0" || count() != 2 {
+		t.Errorf("Expected the changed binary to be checked again, got %+v after %d runs", statuses[0], count())
+	}
+
+	// So does a changed requirement
+	setRequirements(t, Requirements{Tools: []string{"wheresmyprompt"}, Versions: map[string]string{"wheresmyprompt": "<0.3"}})
+	statuses, _ = CheckAllDependencies()
+	if statuses[0].Valid || count() != 3 {
+		t.Errorf("Expected the new constraint to be checked, got %+v after %d runs", statuses[0], count())
+	}
+
+	if err := ClearCheckCache(); err != nil {
+		t.Fatalf("ClearCheckCache failed: %v", err)
+	}
+	_, _ = CheckAllDependencies()
+	if count() != 4 {
+		t.Errorf("Expected a cleared cache to check again, ran %d times", count())
+	}
+}
+
+func TestCheckAllDependenciesSkipsFailedChecks(t *testing.T) {
+	bin := isolateChecks(t)
+	setRequirements(t, Requirements{Tools: []string{"wheresmyprompt"}})
+	count := fakeTool(t, bin, "wheresmyprompt", "exit 1")
+
+	for i := 0; i < 2; i++ {
+		statuses, _ := CheckAllDependencies()
+		if statuses[0].Valid || !strings.Contains(statuses[0].Error, "version check failed") {
+			t.Fatalf("Expected a failed version check, got %+v", statuses[0])
+		}
+	}
+	if count() != 2 {
+		t.Errorf("Expected failed checks not to be cached, ran %d times", count())
+	}
+}
+
+func TestCheckAllDependenciesConcurrently(t *testing.T) {
+	bin := isolateChecks(t)
+	setRequirements(t, Requirements{Tools: []string{"wheresmyprompt", "files2prompt"}})
+	fakeTool(t, bin, "wheresmyprompt", "sleep 1; echo 0.2.0")
+	fakeTool(t, bin, "files2prompt", `sleep 1; echo '{"Version": "0.3.0"}'`)
+
+	start := time.Now()
+	statuses, err := CheckAllDependencies()
+	if err != nil {
+		t.Fatalf("CheckAllDependencies failed: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 1800*time.Millisecond {
+		t.Errorf("Expected the checks to run concurrently, took %v", elapsed)
+	}
+	for _, status := range statuses {
+		if !status.Valid {
+			t.Errorf("Expected %s to be valid, got %+v", status.Name, status)
+		}
+	}
+}
+
+func TestCheckAllDependenciesDeadline(t *testing.T) {
+	bin := isolateChecks(t)
+	setRequirements(t, Requirements{Tools: []string{"wheresmyprompt", "files2prompt"}})
+	fakeTool(t, bin, "wheresmyprompt", "exec sleep 5")
+	fakeTool(t, bin, "files2prompt", `echo '{"Version": "0.3.0"}'`)
+
+	timeout := dependencyCheckTimeout
+	dependencyCheckTimeout = 200 * time.Millisecond
+	t.Cleanup(func() { dependencyCheckTimeout = timeout })
+
+	start := time.Now()
+	statuses, _ := CheckAllDependencies()
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("Expected the checks to stop at the deadline, took %v", elapsed)
+	}
+	if statuses[0].Valid || !strings.Contains(statuses[0].Error, "timed out") {
+		t.Errorf("Expected wheresmyprompt to time out, got %+v", statuses[0])
+	}
+	if !statuses[1].Valid {
+		t.Errorf("Expected files2prompt to be checked, got %+v", statuses[1])
+	}
+}
+
+func TestCheckCacheTracksPythonPackages(t *testing.T) {
+	bin := isolateChecks(t)
+	env := t.TempDir()
+	sitePackages := filepath.Join(env, "lib", "python3.12", "site-packages")
+	if err := os.MkdirAll(sitePackages, 0750); err != nil {
+		t.Fatalf("Failed to create site-packages: %v", err)
+	}
+	script := fmt.Sprintf("#!%s\nfrom llm.cli import cli\n", filepath.Join(env, "bin", "python"))
+	if err := os.WriteFile(filepath.Join(bin, "llm"), []byte(script), 0755); err != nil { // #nosec G306 -- Test executable
+		t.Fatalf("Failed to write llm: %v", err)
+	}
+
+	dep := builtinDependency("llm")
+	cache := loadCheckCache()
+	if !cache.store(dep, DependencyStatus{Name: "llm", Installed: true, Valid: true}) {
+		t.Fatal("Expected the llm status to be cached")
+	}
+	if cache.lookup(dep) == nil {
+		t.Fatal("Expected the cached status while nothing changed")
+	}
+
+	// A plugin installed with pip or llm directly changes site-packages only
+	if err := os.Mkdir(filepath.Join(sitePackages, "llm_ollama-0.9.dist-info"), 0750); err != nil {
+		t.Fatalf("Failed to install plugin: %v", err)
+	}
+	later := time.Now().Add(time.Minute)
+	if err := os.Chtimes(sitePackages, later, later); err != nil {
+		t.Fatalf("Failed to touch site-packages: %v", err)
+	}
+	if status := cache.lookup(dep); status != nil {
+		t.Errorf("Expected a changed plugin environment to invalidate the check, got %+v", status)
+	}
+}
@@ -1,76 +1,134 @@
 package deps
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"os/exec"
 	"regexp"
 	"runtime"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/Masterminds/semver/v3"
 )
 
+// dependencyCheckTimeout is the deadline shared by all checks of
+// CheckAllDependencies, and of a single version or plugin check otherwise
+var dependencyCheckTimeout = 15 * time.Second
+
 // CheckDependency checks the status of a single dependency
 func CheckDependency(dep Dependency) (*DependencyStatus, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), dependencyCheckTimeout)
+	defer cancel()
+
+	status, _ := checkDependency(ctx, dep)
+	return status, nil
+}
+
+// checkDependency checks the status of a dependency, reporting whether the
+// check completed so that its result may be cached
+func checkDependency(ctx context.Context, dep Dependency) (*DependencyStatus, bool) {
 	status := &DependencyStatus{
 		Name:      dep.Name,
 		Installed: false,
 		Valid:     false,
 	}
 
-	// Check if command exists in PATH
-	_, err := exec.LookPath(dep.Command)
+	// Check if command exists in the private tool prefix or PATH
+	path, err := LookPath(dep.Command)
 	if err != nil {
 		status.Error = fmt.Sprintf("Command '%s' not found in PATH", dep.Command)
-		return status, nil
+		return status, false
 	}
 
 	status.Installed = true
+	status.Path = path
+
+	// Check plugins if this is the llm CLI, alongside its version
+	var plugins []PluginStatus
+	var pluginErr error
+	done := make(chan struct{})
+	if dep.Name == "llm" && len(dep.Plugins) > 0 {
+		go func() {
+			defer close(done)
+			plugins, pluginErr = checkLLMPlugins(ctx, dep.Plugins)
+		}()
+	} else {
+		close(done)
+	}
 
 	// Check version if available
+	complete := true
 	if dep.CheckCommand != "" {
-		valid, version, err := CheckVersion(dep.CheckCommand, dep.MinVersion)
+		constraint := dep.VersionConstraint()
+		valid, version, err := checkVersionConstraint(ctx, dep.CheckCommand, constraint)
 		if err != nil {
 			status.Error = fmt.Sprintf("Failed to check version: %v", err)
-			return status, nil
-		}
-		status.Version = version
-		status.Valid = valid
+			complete = false
+		} else {
+			status.Version = version
+			status.Valid = valid
 
-		if !valid && dep.MinVersion != "" {
-			status.Error = fmt.Sprintf("Version %s is below minimum required %s", version, dep.MinVersion)
+			if !valid {
+				status.Error = fmt.Sprintf("Version %s does not satisfy %s", version, constraint)
+			}
 		}
 	} else {
 		// If no version check command, assume valid if installed
 		status.Valid = true
 	}
 
-	// Check plugins if this is the llm CLI
-	if dep.Name == "llm" && len(dep.Plugins) > 0 {
-		pluginStatuses, err := CheckLLMPlugins()
-		if err != nil {
-			status.Error = fmt.Sprintf("Failed to check plugins: %v", err)
-		} else {
-			status.Plugins = pluginStatuses
-		}
+	<-done
+	if pluginErr != nil {
+		status.Error = fmt.Sprintf("Failed to check plugins: %v", pluginErr)
+		complete = false
+	} else if plugins != nil {
+		status.Plugins = plugins
 	}
 
-	return status, nil
+	return status, complete
 }
 
-// CheckAllDependencies checks the status of all required dependencies
+// CheckAllDependencies checks the status of all required dependencies. The
+// checks run concurrently under a shared deadline, and the results for
+// binaries that have not changed since the last check are taken from a cache.
 func CheckAllDependencies() ([]DependencyStatus, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), dependencyCheckTimeout)
+	defer cancel()
+
 	deps := RequiredDependencies()
 	statuses := make([]DependencyStatus, len(deps))
+	complete := make([]bool, len(deps))
+	cache := loadCheckCache()
 
+	var wg sync.WaitGroup
 	for i, dep := range deps {
-		status, err := CheckDependency(dep)
-		if err != nil {
-			return nil, fmt.Errorf("error checking dependency %s: %w", dep.Name, err)
+		if status := cache.lookup(dep); status != nil {
+			statuses[i] = *status
+			continue
 		}
-		statuses[i] = *status
+
+		wg.Add(1)
+		go func(i int, dep Dependency) {
+			defer wg.Done()
+			status, ok := checkDependency(ctx, dep)
+			statuses[i] = *status
+			complete[i] = ok
+		}(i, dep)
+	}
+	wg.Wait()
+
+	changed := false
+	for i, dep := range deps {
+		if complete[i] {
+			changed = cache.store(dep, statuses[i]) || changed
+		}
+	}
+	if changed {
+		// Caching is an optimization, so failing to save is not an error
+		_ = cache.save()
 	}
 
 	return statuses, nil
@@ -82,10 +140,74 @@ func CheckVersion(checkCommand, minVersion string) (bool, string, error) {
 		return true, "", nil
 	}
 
+	ctx, cancel := context.WithTimeout(context.Background(), dependencyCheckTimeout)
+	defer cancel()
+	version, err := runVersionCheck(ctx, checkCommand)
+	if err != nil {
+		return false, version, err
+	}
+
+	// Handle special version strings like "local" for development builds
+	if version == "local" {
+		// Local/dev builds are considered valid
+		return true, version, nil
+	}
+
+	// Compare versions using semver
+	currentVer, err := semver.NewVersion(version)
+	if err != nil {
+		// If semver parsing fails, do string comparison as fallback
+		return version >= minVersion, version, nil
+	}
+
+	minVer, err := semver.NewVersion(minVersion)
+	if err != nil {
+		return false, version, fmt.Errorf("invalid minimum version format: %s", minVersion)
+	}
+
+	return currentVer.GreaterThan(minVer) || currentVer.Equal(minVer), version, nil
+}
+
+// CheckVersionConstraint checks if the version of a command satisfies a
+// semver range such as ">= 0.13, < 1.0"
+func CheckVersionConstraint(checkCommand, constraint string) (bool, string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), dependencyCheckTimeout)
+	defer cancel()
+	return checkVersionConstraint(ctx, checkCommand, constraint)
+}
+
+// checkVersionConstraint checks a version against a semver range, giving up
+// when ctx is done
+func checkVersionConstraint(ctx context.Context, checkCommand, constraint string) (bool, string, error) {
+	if constraint == "" {
+		return true, "", nil
+	}
+	constraints, err := semver.NewConstraint(constraint)
+	if err != nil {
+		return false, "", fmt.Errorf("invalid version constraint %q: %w", constraint, err)
+	}
+
+	version, err := runVersionCheck(ctx, checkCommand)
+	if err != nil {
+		return false, version, err
+	}
+
+	// Local/dev builds such as "local" report no semantic version and are
+	// considered valid
+	currentVer, err := semver.NewVersion(version)
+	if err != nil {
+		return true, version, nil
+	}
+	return constraints.Check(currentVer), version, nil
+}
+
+// runVersionCheck runs a version command and extracts the version it
+// reports, returning the raw output instead if it contains none
+func runVersionCheck(ctx context.Context, checkCommand string) (string, error) {
 	// Split command and args
 	parts := strings.Fields(checkCommand)
 	if len(parts) == 0 {
-		return false, "", fmt.Errorf("empty check command")
+		return "", fmt.Errorf("empty check command")
 	}
 
 	// Validate command is safe for execution
@@ -109,95 +231,65 @@ func CheckVersion(checkCommand, minVersion string) (bool, string, error) {
 	}
 
 	if !allowedCommands[commandName] {
-		return false, "", fmt.Errorf("command '%s' is not in the allowed list for version checking", commandName)
+		return "", fmt.Errorf("command '%s' is not in the allowed list for version checking", commandName)
 	}
 
-	// Execute version command with timeout
-	cmd := exec.Command(commandName, parts[1:]...) // #nosec G204 # nosemgrep: go.lang.security.audit.dangerous-exec-command.dangerous-exec-command
+	// Execute version command until the deadline
+	cmd := exec.CommandContext(ctx, ResolveCommand(commandName), parts[1:]...) // #nosec G204 # nosemgrep: go.lang.security.audit.dangerous-exec-command.dangerous-exec-command
 	cmd.Env = os.Environ()
+	cmd.WaitDelay = time.Second // Don't wait for children holding the output open
 
-	// Set a reasonable timeout
-	done := make(chan error, 1)
-	var output []byte
-	var err error
-
-	go func() {
-		output, err = cmd.CombinedOutput()
-		done <- err
-	}()
-
-	select {
-	case <-time.After(10 * time.Second):
-		if cmd.Process != nil {
-			_ = cmd.Process.Kill() // Ignore error from Kill as process might already be dead
-		}
-		return false, "", fmt.Errorf("version check timed out")
-	case err := <-done:
-		if err != nil {
-			return false, "", fmt.Errorf("version check failed: %w", err)
-		}
+	output, err := cmd.CombinedOutput()
+	if ctx.Err() != nil {
+		return "", fmt.Errorf("version check timed out")
+	}
+	if err != nil {
+		return "", fmt.Errorf("version check failed: %w", err)
 	}
 
 	// Extract version from output
 	version := extractVersion(string(output))
 	if version == "" {
-		return false, string(output), fmt.Errorf("could not parse version from output")
-	}
-
-	// Handle special version strings like "local" for development builds
-	if version == "local" {
-		// Local/dev builds are considered valid
-		return true, version, nil
+		return string(output), fmt.Errorf("could not parse version from output")
 	}
-
-	// Compare versions using semver
-	currentVer, err := semver.NewVersion(version)
-	if err != nil {
-		// If semver parsing fails, do string comparison as fallback
-		return version >= minVersion, version, nil
-	}
-
-	minVer, err := semver.NewVersion(minVersion)
-	if err != nil {
-		return false, version, fmt.Errorf("invalid minimum version format: %s", minVersion)
-	}
-
-	return currentVer.GreaterThan(minVer) || currentVer.Equal(minVer), version, nil
+	return version, nil
 }
 
 // CheckLLMPlugins checks the status of required LLM plugins
 func CheckLLMPlugins() ([]PluginStatus, error) {
-	deps := RequiredDependencies()
-	var llmDep *Dependency
-
-	// Find the llm dependency
-	for _, dep := range deps {
-		if dep.Name == "llm" {
-			llmDep = &dep
-			break
-		}
-	}
+	ctx, cancel := context.WithTimeout(context.Background(), dependencyCheckTimeout)
+	defer cancel()
+	return checkLLMPlugins(ctx, requiredPlugins())
+}
 
-	if llmDep == nil || len(llmDep.Plugins) == 0 {
+// checkLLMPlugins checks which of the given plugins llm has installed,
+// giving up when ctx is done
+func checkLLMPlugins(ctx context.Context, plugins []string) ([]PluginStatus, error) {
+	if len(plugins) == 0 {
 		return []PluginStatus{}, nil
 	}
 
 	// Check if llm command is available
-	if _, err := exec.LookPath("llm"); err != nil {
+	llm, err := LookPath("llm")
+	if err != nil {
 		return nil, fmt.Errorf("llm command not found")
 	}
 
 	// Get installed plugins
-	cmd := exec.Command("llm", "plugins", "list")
+	cmd := exec.CommandContext(ctx, llm, "plugins", "list") // #nosec G204 -- llm resolved from the tool prefix or PATH
+	cmd.WaitDelay = time.Second
 	output, err := cmd.CombinedOutput()
+	if ctx.Err() != nil {
+		return nil, fmt.Errorf("plugin check timed out")
+	}
 	if err != nil {
 		return nil, fmt.Errorf("failed to list plugins: %w", err)
 	}
 
 	installedPlugins := parseInstalledPlugins(string(output))
-	statuses := make([]PluginStatus, len(llmDep.Plugins))
+	statuses := make([]PluginStatus, len(plugins))
 
-	for i, requiredPlugin := range llmDep.Plugins {
+	for i, requiredPlugin := range plugins {
 		status := PluginStatus{
 			Name:      requiredPlugin,
 			Installed: contains(installedPlugins, requiredPlugin),
@@ -26,23 +26,31 @@ func GetInstallInstructions(dep Dependency) string {
 // InstallLLMPlugin attempts to install an LLM plugin
 func InstallLLMPlugin(plugin string) error {
 	// Check if llm is available
-	if _, err := exec.LookPath("llm"); err != nil {
+	llm, err := LookPath("llm")
+	if err != nil {
 		return fmt.Errorf("llm command not found - install llm CLI first")
 	}
 
-	// Install the plugin
-	cmd := exec.Command("llm", "install", plugin)
+	// Install the plugin into llm's own environment
+	cmd := exec.Command(llm, "install", plugin) // #nosec G204 -- llm resolved from the tool prefix or PATH
 	output, err := cmd.CombinedOutput()
 	if err != nil {
 		return fmt.Errorf("failed to install plugin %s: %w\nOutput: %s", plugin, err, string(output))
 	}
 
+	// The llm binary is unchanged, so its cached plugin check is stale
+	_ = ClearCheckCache()
 	return nil
 }
 
 // AutoInstallAll attempts to auto-install all missing dependencies
 func AutoInstallAll() error {
-	installer := NewPlatformInstaller()
+	return NewPlatformInstaller().AutoInstallAll()
+}
+
+// AutoInstallAll attempts to install all missing dependencies with the
+// installer's package manager, only printing the commands in dry-run mode
+func (p *PlatformInstaller) AutoInstallAll() error {
 	deps := RequiredDependencies()
 
 	fmt.Printf("Starting auto-installation for %d dependencies...\n", len(deps))
@@ -67,26 +75,31 @@ func AutoInstallAll() error {
 		}
 
 		// Attempt installation
-		result, err := InstallDependency(dep)
+		result, err := p.InstallDependency(dep)
 		if err != nil {
 			errors = append(errors, fmt.Sprintf("Failed to install %s: %v", dep.Name, err))
 			continue
 		}
 
-		if result.Success {
+		if result.Success && p.DryRun {
+			successCount++
+			if dep.Name == "llm" && len(dep.Plugins) > 0 {
+				_ = p.installPlugins(dep.Plugins)
+			}
+		} else if result.Success {
 			fmt.Printf("✓ %s\n", result.Message)
 
 			// Verify installation
-			if err := installer.VerifyInstallation(dep.Command); err != nil {
+			if err := p.VerifyInstallation(dep.Command); err != nil {
 				fmt.Printf("⚠ Warning: %s may not be accessible in PATH: %v\n", dep.Name, err)
 			} else {
 				successCount++
 			}
 
-			// Install plugins for llm
+			// Install plugins for llm the same way
 			if dep.Name == "llm" && len(dep.Plugins) > 0 {
 				fmt.Printf("Installing %d LLM plugins...\n", len(dep.Plugins))
-				if err := installLLMPlugins(dep.Plugins); err != nil {
+				if err := p.installPlugins(dep.Plugins); err != nil {
 					fmt.Printf("⚠ Warning: Plugin installation issues: %v\n", err)
 				}
 			}
@@ -96,6 +109,11 @@ func AutoInstallAll() error {
 	}
 
 	// Summary
+	if p.DryRun && len(errors) == 0 {
+		fmt.Printf("\n🔍 Dry run - nothing was installed\n")
+		return nil
+	}
+
 	fmt.Printf("\nInstallation Summary:\n")
 	fmt.Printf("✓ Successfully installed/verified: %d/%d dependencies\n", successCount, len(deps))
 
@@ -114,15 +132,19 @@ func AutoInstallAll() error {
 
 // InstallDependency attempts to install a single dependency
 func InstallDependency(dep Dependency) (*InstallationResult, error) {
-	installer := NewPlatformInstaller()
+	return NewPlatformInstaller().InstallDependency(dep)
+}
 
+// InstallDependency attempts to install a single dependency with the
+// installer's package manager
+func (p *PlatformInstaller) InstallDependency(dep Dependency) (*InstallationResult, error) {
 	switch dep.Name {
 	case "wheresmyprompt":
-		return installer.InstallWheresmyprompt()
+		return p.InstallWheresmyprompt()
 	case "files2prompt":
-		return installer.InstallFiles2prompt()
+		return p.InstallFiles2prompt()
 	case "llm":
-		return installer.InstallLLM()
+		return p.InstallLLM()
 	default:
 		return &InstallationResult{
 			Success: false,
@@ -242,7 +264,7 @@ func getLLMInstructions(sysInfo *SystemInfo) string {
 	}
 
 	instructions.WriteString("After installation, install required plugins:\n")
-	for _, plugin := range RequiredDependencies()[2].Plugins { // llm is the 3rd dependency
+	for _, plugin := range requiredPlugins() {
 		instructions.WriteString(fmt.Sprintf("  llm install %s\n", plugin))
 	}
 
@@ -278,15 +300,20 @@ func DetectPackageManager() string {
 		if hasApt() {
 			return "apt"
 		}
-		if _, err := exec.LookPath("yum"); err == nil {
-			return "yum"
-		}
-		if _, err := exec.LookPath("pacman"); err == nil {
-			return "pacman"
+		for _, manager := range []string{"dnf", "yum", "pacman", "apk"} {
+			if _, err := exec.LookPath(manager); err == nil {
+				return manager
+			}
 		}
 	}
+	if _, err := exec.LookPath("nix"); err == nil {
+		return "nix"
+	}
 
 	// Python package managers
+	if _, err := exec.LookPath("uv"); err == nil {
+		return "uv"
+	}
 	if _, err := exec.LookPath("pipx"); err == nil {
 		return "pipx"
 	}
@@ -40,7 +40,7 @@ func TestDetectPackageManager(t *testing.T) {
 	}
 
 	// Should be one of the supported package managers
-	validManagers := []string{"go", "homebrew", "apt", "yum", "pacman", "pipx", "pip", "unknown"}
+	validManagers := []string{"go", "homebrew", "apt", "dnf", "yum", "pacman", "apk", "nix", "uv", "pipx", "pip", "unknown"}
 	found := false
 	for _, manager := range validManagers {
 		if pkgManager == manager {
@@ -0,0 +1,507 @@
+package deps
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/Masterminds/semver/v3"
+)
+
+// LockfileName is the default name of the lockfile pinning tool versions
+const LockfileName = "waffles.lock"
+
+// lockfileVersion is the format version written to new lockfiles
+const lockfileVersion = 1
+
+// Lockfile pins the exact versions and checksums of the external tools and
+// llm plugins, so that every machine installs the same ones
+type Lockfile struct {
+	Version int          `json:"version"`
+	Tools   []LockedTool `json:"tools"`
+	Plugins []LockedTool `json:"plugins,omitempty"`
+}
+
+// LockedTool pins one tool or llm plugin to an exact version. Go tools are
+// installed from GitHub release archives, Python tools and plugins from PyPI.
+type LockedTool struct {
+	Name    string         `json:"name"`
+	Version string         `json:"version"`
+	Release *LockedRelease `json:"release,omitempty"`
+	Package *LockedPackage `json:"package,omitempty"`
+}
+
+// LockedRelease is a GitHub release with the SHA-256 checksums of its
+// archives for every platform
+type LockedRelease struct {
+	Repository string            `json:"repository"`
+	Tag        string            `json:"tag"`
+	Checksums  map[string]string `json:"checksums"`
+}
+
+// LockedPackage is a PyPI distribution file
+type LockedPackage struct {
+	Name   string `json:"name"`
+	File   string `json:"file"`
+	URL    string `json:"url"`
+	SHA256 string `json:"sha256"`
+}
+
+// LockOptions select which lockfile entries to update and to which versions
+type LockOptions struct {
+	Names   []string // Only update these tools and plugins; all if empty
+	Upgrade bool     // Lock the latest versions instead of the installed ones
+}
+
+// ReadLockfile reads a lockfile
+func ReadLockfile(path string) (*Lockfile, error) {
+	data, err := os.ReadFile(path) // #nosec G304 -- Lockfile path from command line
+	if err != nil {
+		return nil, fmt.Errorf("failed to read lockfile: %w", err)
+	}
+
+	var lock Lockfile
+	if err := json.Unmarshal(data, &lock); err != nil {
+		return nil, fmt.Errorf("failed to parse lockfile %s: %w", path, err)
+	}
+	if lock.Version != lockfileVersion {
+		return nil, fmt.Errorf("unsupported lockfile version %d in %s", lock.Version, path)
+	}
+
+	return &lock, nil
+}
+
+// Write writes the lockfile
+func (l *Lockfile) Write(path string) error {
+	data, err := json.MarshalIndent(l, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal lockfile: %w", err)
+	}
+
+	// The lockfile is meant to be committed and shared
+	if err := os.WriteFile(path, append(data, '\n'), 0644); err != nil { // #nosec G306 -- Not secret
+		return fmt.Errorf("failed to write lockfile: %w", err)
+	}
+	return nil
+}
+
+// Find returns the locked tool or plugin with the given name, or nil
+func (l *Lockfile) Find(name string) *LockedTool {
+	if l == nil {
+		return nil
+	}
+	for _, list := range [][]LockedTool{l.Tools, l.Plugins} {
+		for i := range list {
+			if list[i].Name == name {
+				return &list[i]
+			}
+		}
+	}
+	return nil
+}
+
+// Lock resolves the versions and checksums of all tools and llm plugins.
+// Entries of an existing lockfile that are not selected by opts.Names are
+// kept as they are. Unless opts.Upgrade is set, installed versions are locked
+// where they can be determined, and the latest versions otherwise.
+func (p *PlatformInstaller) Lock(existing *Lockfile, opts LockOptions) (*Lockfile, error) {
+	selected := func(name string) bool {
+		return len(opts.Names) == 0 || contains(opts.Names, name)
+	}
+
+	dependencies := RequiredDependencies()
+	var plugins []string
+	known := make(map[string]bool)
+	for _, dep := range dependencies {
+		plugins = append(plugins, dep.Plugins...)
+		known[dep.Name] = true
+	}
+	for _, plugin := range plugins {
+		known[plugin] = true
+	}
+	for _, name := range opts.Names {
+		if !known[name] {
+			return nil, fmt.Errorf("unknown dependency or plugin: %s", name)
+		}
+	}
+
+	lock := &Lockfile{Version: lockfileVersion}
+	for _, dep := range dependencies {
+		if old := existing.Find(dep.Name); old != nil && !selected(dep.Name) {
+			lock.Tools = append(lock.Tools, *old)
+			continue
+		}
+
+		version := ""
+		if !opts.Upgrade {
+			version = installedVersion(dep)
+		}
+		tool, err := p.lockTool(dep, version)
+		if err != nil {
+			return nil, fmt.Errorf("failed to lock %s: %w", dep.Name, err)
+		}
+		lock.Tools = append(lock.Tools, *tool)
+	}
+
+	var installed map[string]string
+	if !opts.Upgrade {
+		installed = installedPluginVersions()
+	}
+	for _, plugin := range plugins {
+		if old := existing.Find(plugin); old != nil && !selected(plugin) {
+			lock.Plugins = append(lock.Plugins, *old)
+			continue
+		}
+
+		tool, err := p.lockPackage(plugin, installed[plugin])
+		if err != nil {
+			return nil, fmt.Errorf("failed to lock %s: %w", plugin, err)
+		}
+		lock.Plugins = append(lock.Plugins, *tool)
+	}
+
+	return lock, nil
+}
+
+// lockTool pins a dependency to a version, or to its latest version if
+// version is empty
+func (p *PlatformInstaller) lockTool(dep Dependency, version string) (*LockedTool, error) {
+	switch {
+	case dep.Repository != "":
+		owner, repo, _ := strings.Cut(dep.Repository, "/")
+		tag, err := p.resolveReleaseTag(owner, repo, version)
+		if err != nil {
+			return nil, err
+		}
+		sums, err := p.releaseChecksums(owner, repo, tag)
+		if err != nil {
+			return nil, err
+		}
+
+		// Keep the assets of every platform so the lockfile works for everyone
+		names := make([]string, 0, len(sums))
+		for asset := range sums {
+			names = append(names, asset)
+		}
+		archives := make(map[string]string)
+		for _, asset := range p.platformAssets(names, repo, tag) {
+			archives[asset] = sums[asset]
+		}
+		if len(archives) == 0 {
+			return nil, fmt.Errorf("release %s of %s lists no assets named by %q", tag, dep.Repository, p.assetTemplateText())
+		}
+
+		return &LockedTool{
+			Name:    dep.Name,
+			Version: strings.TrimPrefix(tag, "v"),
+			Release: &LockedRelease{Repository: dep.Repository, Tag: tag, Checksums: archives},
+		}, nil
+	case dep.Package != "":
+		tool, err := p.lockPackage(dep.Package, version)
+		if err != nil {
+			return nil, err
+		}
+		tool.Name = dep.Name
+		return tool, nil
+	default:
+		return nil, fmt.Errorf("no release repository or package known")
+	}
+}
+
+// resolveReleaseTag returns the tag of the GitHub release of a version, or of
+// the latest release if version is empty
+func (p *PlatformInstaller) resolveReleaseTag(owner, repo, version string) (string, error) {
+	if version == "" {
+		release, err := p.fetchRelease(owner, repo, "")
+		if err != nil {
+			return "", fmt.Errorf("failed to find latest release: %w", err)
+		}
+		return release.TagName, nil
+	}
+
+	for _, tag := range []string{"v" + version, version} {
+		_, err := p.fetch(p.gitHubAPIURL() + "/repos/" + owner + "/" + repo + "/releases/tags/" + url.PathEscape(tag))
+		if err == nil {
+			return tag, nil
+		}
+		if !errors.Is(err, errAssetNotFound) {
+			return "", fmt.Errorf("failed to find release %s: %w", tag, err)
+		}
+	}
+	return "", fmt.Errorf("no release of %s/%s for version %s", owner, repo, version)
+}
+
+// lockPackage pins a PyPI package to a distribution file of a version, or of
+// its latest version if version is empty, preferring a universal wheel
+func (p *PlatformInstaller) lockPackage(name, version string) (*LockedTool, error) {
+	endpoint := p.pyPIURL() + "/pypi/" + url.PathEscape(name)
+	if version != "" {
+		endpoint += "/" + url.PathEscape(version)
+	}
+	data, err := p.fetch(endpoint + "/json")
+	if errors.Is(err, errAssetNotFound) {
+		return nil, fmt.Errorf("package %s %s not found on PyPI", name, version)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get package metadata: %w", err)
+	}
+
+	var metadata struct {
+		Info struct {
+			Version string `json:"version"`
+		} `json:"info"`
+		URLs []struct {
+			Filename    string            `json:"filename"`
+			PackageType string            `json:"packagetype"`
+			URL         string            `json:"url"`
+			Digests     map[string]string `json:"digests"`
+		} `json:"urls"`
+	}
+	if err := json.Unmarshal(data, &metadata); err != nil {
+		return nil, fmt.Errorf("failed to parse package metadata: %w", err)
+	}
+
+	rank := func(filename, packageType string) int {
+		switch {
+		case packageType == "bdist_wheel" && strings.HasSuffix(filename, "-none-any.whl"):
+			return 0
+		case packageType == "bdist_wheel":
+			return 1
+		default:
+			return 2
+		}
+	}
+	sort.SliceStable(metadata.URLs, func(i, j int) bool {
+		return rank(metadata.URLs[i].Filename, metadata.URLs[i].PackageType) < rank(metadata.URLs[j].Filename, metadata.URLs[j].PackageType)
+	})
+	for _, file := range metadata.URLs {
+		if sum := file.Digests["sha256"]; sum != "" {
+			return &LockedTool{
+				Name:    name,
+				Version: metadata.Info.Version,
+				Package: &LockedPackage{Name: name, File: file.Filename, URL: file.URL, SHA256: sum},
+			}, nil
+		}
+	}
+	return nil, fmt.Errorf("package %s %s publishes no files with SHA-256 digests", name, metadata.Info.Version)
+}
+
+// InstallLocked installs the tools and plugins pinned by a lockfile, or only
+// the named ones, skipping those already installed at the pinned version
+func (p *PlatformInstaller) InstallLocked(lock *Lockfile, names []string) error {
+	for _, name := range names {
+		if lock.Find(name) == nil {
+			return fmt.Errorf("%s is not in the lockfile", name)
+		}
+	}
+
+	if problems := installPinned(lock, names, p.installLockedTool); len(problems) > 0 {
+		return fmt.Errorf("failed to install locked versions:\n  - %s", strings.Join(problems, "\n  - "))
+	}
+	return nil
+}
+
+// installPinned installs the pinned tools and plugins of a lockfile, or only
+// the named ones, with the given install function. Tools already installed
+// at the pinned version are skipped. It returns the problems encountered.
+func installPinned(lock *Lockfile, names []string, install func(tool LockedTool, plugin bool) error) []string {
+	var problems []string
+	installed := installedPluginVersions()
+	for i, list := range [][]LockedTool{lock.Tools, lock.Plugins} {
+		plugin := i == 1
+		if plugin {
+			// llm may just have been installed
+			installed = installedPluginVersions()
+		}
+
+		for _, tool := range list {
+			if len(names) > 0 && !contains(names, tool.Name) {
+				continue
+			}
+
+			current := installed[tool.Name]
+			if !plugin {
+				current = installedToolVersion(tool.Name)
+			}
+			if current == tool.Version {
+				fmt.Printf("✓ %s %s is already installed\n", tool.Name, tool.Version)
+				continue
+			}
+
+			if !plugin && activateInstalledVersion(tool.Name, tool.Version) {
+				fmt.Printf("✓ Switched %s to installed version %s\n", tool.Name, tool.Version)
+				continue
+			}
+
+			fmt.Printf("Installing %s %s...\n", tool.Name, tool.Version)
+			if err := install(tool, plugin); err != nil {
+				problems = append(problems, fmt.Sprintf("%s: %v", tool.Name, err))
+				continue
+			}
+			fmt.Printf("✓ Installed %s %s\n", tool.Name, tool.Version)
+		}
+	}
+
+	return problems
+}
+
+// installLockedTool installs one locked tool or plugin after verifying its
+// download against the locked checksum
+func (p *PlatformInstaller) installLockedTool(tool LockedTool, plugin bool) error {
+	switch {
+	case tool.Release != nil:
+		owner, repo, _ := strings.Cut(tool.Release.Repository, "/")
+		if _, err := p.lockedAsset(tool); err != nil {
+			return err
+		}
+		_, err := p.installRelease(owner, repo, tool.Name, tool.Release.Tag, tool.Release.Checksums)
+		return err
+	case tool.Package != nil:
+		dir, err := os.MkdirTemp("", "waffles-lock-*")
+		if err != nil {
+			return fmt.Errorf("failed to create temp dir: %w", err)
+		}
+		defer os.RemoveAll(dir)
+
+		// pip derives the package version from the file name
+		path := filepath.Join(dir, filepath.Base(tool.Package.File))
+		if err := p.downloadTo(tool.Package.URL, path); err != nil {
+			return err
+		}
+		if err := verifyChecksum(tool.Package.File, path, tool.Package.SHA256); err != nil {
+			return err
+		}
+
+		if plugin {
+			return InstallLLMPlugin(path)
+		}
+		_, err = installPythonTool(tool.Name, tool.Version, path)
+		return err
+	default:
+		return fmt.Errorf("lockfile entry has no release or package")
+	}
+}
+
+// lockedAsset returns the locked release asset of a tool for this platform
+func (p *PlatformInstaller) lockedAsset(tool LockedTool) (string, error) {
+	names := make([]string, 0, len(tool.Release.Checksums))
+	for asset := range tool.Release.Checksums {
+		names = append(names, asset)
+	}
+	_, repo, _ := strings.Cut(tool.Release.Repository, "/")
+	asset, err := p.matchAsset(names, repo, tool.Version)
+	if err != nil {
+		return "", fmt.Errorf("no locked archive for %s/%s: %w", p.OS, p.Architecture, err)
+	}
+	return asset, nil
+}
+
+// downloadTo downloads a URL into a new file
+func (p *PlatformInstaller) downloadTo(url, path string) error {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_EXCL, 0600) // #nosec G304 -- Path in a fresh temp dir
+	if err != nil {
+		return fmt.Errorf("failed to create %s: %w", path, err)
+	}
+	if err := p.downloadFile(url, file); err != nil {
+		_ = file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// activateInstalledVersion switches the shim of a tool to a version already
+// present in the private tool prefix, reporting whether there was one
+func activateInstalledVersion(name, version string) bool {
+	dir, err := ToolDir(name, version)
+	if err != nil {
+		return false
+	}
+	for _, target := range []string{
+		filepath.Join(dir, executableName(name)),
+		filepath.Join(dir, "bin", name),
+		filepath.Join(dir, "Scripts", executableName(name)),
+	} {
+		if info, err := os.Stat(target); err == nil && !info.IsDir() {
+			return activateTool(name, target) == nil
+		}
+	}
+	return false
+}
+
+// installedVersion returns the installed semantic version of a dependency,
+// or "" if it is missing or reports no usable version
+func installedVersion(dep Dependency) string {
+	if dep.CheckCommand == "" {
+		return ""
+	}
+	if _, err := LookPath(dep.Command); err != nil {
+		return ""
+	}
+	_, version, err := CheckVersion(dep.CheckCommand, "0.0.0")
+	if err != nil {
+		return ""
+	}
+	// Development builds report versions such as "local"
+	if _, err := semver.NewVersion(version); err != nil {
+		return ""
+	}
+	return strings.TrimPrefix(version, "v")
+}
+
+// installedToolVersion returns the installed version of a required tool
+func installedToolVersion(name string) string {
+	for _, dep := range RequiredDependencies() {
+		if dep.Name == name {
+			return installedVersion(dep)
+		}
+	}
+	return ""
+}
+
+// installedPluginVersions returns the versions of the installed llm plugins
+// as reported by 'llm plugins', which lists them as JSON
+func installedPluginVersions() map[string]string {
+	versions := make(map[string]string)
+	llm, err := LookPath("llm")
+	if err != nil {
+		return versions
+	}
+	output, err := exec.Command(llm, "plugins").Output() // #nosec G204 -- llm resolved from the tool prefix or PATH
+	if err != nil {
+		return versions
+	}
+
+	var plugins []struct {
+		Name    string `json:"name"`
+		Version string `json:"version"`
+	}
+	if err := json.Unmarshal(output, &plugins); err != nil {
+		return versions
+	}
+	for _, plugin := range plugins {
+		versions[plugin.Name] = plugin.Version
+	}
+	return versions
+}
+
+// gitHubAPIURL returns the base URL of the GitHub REST API
+func (p *PlatformInstaller) gitHubAPIURL() string {
+	if p.GitHubAPIURL == "" {
+		return DefaultGitHubAPIURL
+	}
+	return strings.TrimSuffix(p.GitHubAPIURL, "/")
+}
+
+// pyPIURL returns the base URL of the Python package index
+func (p *PlatformInstaller) pyPIURL() string {
+	if p.PyPIURL == "" {
+		return DefaultPyPIURL
+	}
+	return strings.TrimSuffix(p.PyPIURL, "/")
+}
@@ -0,0 +1,197 @@
+package deps
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// newLockServer serves the GitHub API, release downloads and PyPI metadata
+// for all required tools at the given release and package versions
+func newLockServer(t *testing.T, release, pkg string) (*PlatformInstaller, map[string][]byte) {
+	t.Helper()
+
+	files := make(map[string][]byte)
+	for _, repo := range []string{"wheresmyprompt", "files2prompt"} {
+		archive := releaseArchive(t, repo, repo+" "+release)
+		asset := repo + "_linux_x86_64.tar.gz"
+		prefix := "/toozej/" + repo + "/releases/download/v" + release + "/"
+		files[prefix+asset] = archive
+		files[prefix+ChecksumsFile] = []byte(checksumLine(asset, archive) +
+			checksumLine(repo+"_macOS_arm64.tar.gz", []byte("darwin")) +
+			checksumLine("unrelated.txt", []byte("other")))
+	}
+
+	var packages []string
+	for _, dep := range RequiredDependencies() {
+		packages = append(packages, dep.Plugins...)
+	}
+	packages = append(packages, "llm")
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, ok := files[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		_, _ = w.Write(data)
+	}))
+	t.Cleanup(server.Close)
+
+	for _, repo := range []string{"wheresmyprompt", "files2prompt"} {
+		metadata := releaseJSON(t, "v"+release, server.URL+"/toozej/"+repo+"/releases/download/v"+release,
+			repo+"_linux_x86_64.tar.gz", ChecksumsFile)
+		files["/api/repos/toozej/"+repo+"/releases/latest"] = metadata
+		files["/api/repos/toozej/"+repo+"/releases/tags/v"+release] = metadata
+	}
+
+	for _, name := range packages {
+		wheel := []byte(name + " wheel")
+		sum := sha256.Sum256(wheel)
+		file := fmt.Sprintf("%s-%s-py3-none-any.whl", strings.ReplaceAll(name, "-", "_"), pkg)
+		files["/files/"+file] = wheel
+		metadata := fmt.Sprintf(`{"info": {"version": %q}, "urls": [
+			{"filename": "%s-%s.tar.gz", "packagetype": "sdist", "url": "%s/files/sdist", "digests": {"sha256": "00"}},
+			{"filename": %q, "packagetype": "bdist_wheel", "url": "%s/files/%s", "digests": {"sha256": %q}}
+		]}`, pkg, name, pkg, server.URL, file, server.URL, file, hex.EncodeToString(sum[:]))
+		files["/pypi/"+name+"/json"] = []byte(metadata)
+		files["/pypi/"+name+"/"+pkg+"/json"] = []byte(metadata)
+	}
+
+	// Install into a temporary prefix with none of the tools on PATH
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("XDG_DATA_HOME", "")
+	t.Setenv("PATH", t.TempDir())
+
+	return &PlatformInstaller{
+		OS:             "linux",
+		Architecture:   "amd64",
+		ReleaseBaseURL: server.URL,
+		GitHubAPIURL:   server.URL + "/api",
+		PyPIURL:        server.URL,
+		HTTPClient:     server.Client(),
+	}, files
+}
+
+func TestLock(t *testing.T) {
+	installer, _ := newLockServer(t, "1.2.0", "0.9")
+
+	lock, err := installer.Lock(nil, LockOptions{Upgrade: true})
+	if err != nil {
+		t.Fatalf("Lock failed: %v", err)
+	}
+
+	files2prompt := lock.Find("files2prompt")
+	if files2prompt == nil || files2prompt.Version != "1.2.0" || files2prompt.Release == nil {
+		t.Fatalf("Unexpected files2prompt entry: %+v", files2prompt)
+	}
+	if files2prompt.Release.Tag != "v1.2.0" || len(files2prompt.Release.Checksums) != 2 {
+		t.Errorf("Expected the archives of both platforms, got %+v", files2prompt.Release)
+	}
+
+	llm := lock.Find("llm")
+	if llm == nil || llm.Version != "0.9" || llm.Package == nil || !strings.HasSuffix(llm.Package.File, "-none-any.whl") {
+		t.Errorf("Expected llm locked to its wheel, got %+v", llm)
+	}
+	if len(lock.Plugins) != len(RequiredDependencies()[2].Plugins) {
+		t.Errorf("Expected every plugin to be locked, got %d", len(lock.Plugins))
+	}
+
+	path := filepath.Join(t.TempDir(), LockfileName)
+	if err := lock.Write(path); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	read, err := ReadLockfile(path)
+	if err != nil {
+		t.Fatalf("ReadLockfile failed: %v", err)
+	}
+	if read.Find("llm-gemini") == nil || read.Find("wheresmyprompt").Release.Checksums["wheresmyprompt_linux_x86_64.tar.gz"] == "" {
+		t.Errorf("Lockfile did not round-trip: %+v", read)
+	}
+}
+
+func TestLockSelectedNames(t *testing.T) {
+	installer, _ := newLockServer(t, "1.3.0", "1.0")
+	existing := &Lockfile{
+		Version: lockfileVersion,
+		Tools:   []LockedTool{{Name: "wheresmyprompt", Version: "1.0.0"}, {Name: "files2prompt", Version: "1.0.0"}, {Name: "llm", Version: "0.8"}},
+		Plugins: []LockedTool{{Name: "llm-gemini", Version: "0.1"}},
+	}
+
+	lock, err := installer.Lock(existing, LockOptions{Names: []string{"files2prompt", "llm-gemini"}, Upgrade: true})
+	if err != nil {
+		t.Fatalf("Lock failed: %v", err)
+	}
+	if lock.Find("files2prompt").Version != "1.3.0" || lock.Find("llm-gemini").Version != "1.0" {
+		t.Errorf("Expected the named entries to be updated, got %+v", lock)
+	}
+	if lock.Find("wheresmyprompt").Version != "1.0.0" || lock.Find("llm").Version != "0.8" {
+		t.Errorf("Expected the other entries to be kept, got %+v", lock)
+	}
+
+	if _, err := installer.Lock(existing, LockOptions{Names: []string{"nope"}}); err == nil {
+		t.Error("Expected an error for an unknown name")
+	}
+}
+
+func TestReadLockfileVersion(t *testing.T) {
+	path := filepath.Join(t.TempDir(), LockfileName)
+	if err := os.WriteFile(path, []byte(`{"version": 99, "tools": []}`), 0600); err != nil {
+		t.Fatalf("Failed to write lockfile: %v", err)
+	}
+	if _, err := ReadLockfile(path); err == nil || !strings.Contains(err.Error(), "unsupported lockfile version") {
+		t.Errorf("Expected an unsupported version error, got %v", err)
+	}
+}
+
+func TestInstallLocked(t *testing.T) {
+	installer, _ := newLockServer(t, "1.2.0", "0.9")
+	lock, err := installer.Lock(nil, LockOptions{Upgrade: true})
+	if err != nil {
+		t.Fatalf("Lock failed: %v", err)
+	}
+
+	if err := installer.InstallLocked(lock, []string{"files2prompt"}); err != nil {
+		t.Fatalf("InstallLocked failed: %v", err)
+	}
+	data, err := os.ReadFile(filepath.Join(os.Getenv("HOME"), ".local", "share", "waffles", "bin", "files2prompt"))
+	if err != nil || string(data) != "files2prompt 1.2.0" {
+		t.Errorf("Expected the locked binary to be installed, got %q: %v", data, err)
+	}
+	if active := ActiveToolVersion("files2prompt"); active != "1.2.0" {
+		t.Errorf("Expected 1.2.0 to be active, got %q", active)
+	}
+
+	if err := installer.InstallLocked(lock, []string{"not-locked"}); err == nil {
+		t.Error("Expected an error for a name missing from the lockfile")
+	}
+}
+
+func TestInstallLockedChecksumMismatch(t *testing.T) {
+	installer, _ := newLockServer(t, "1.2.0", "0.9")
+	lock, err := installer.Lock(nil, LockOptions{Upgrade: true})
+	if err != nil {
+		t.Fatalf("Lock failed: %v", err)
+	}
+	lock.Find("wheresmyprompt").Release.Checksums["wheresmyprompt_linux_x86_64.tar.gz"] = strings.Repeat("0", 64)
+
+	err = installer.InstallLocked(lock, []string{"wheresmyprompt"})
+	if err == nil || !strings.Contains(err.Error(), "SHA-256 mismatch") {
+		t.Fatalf("Expected a checksum mismatch, got %v", err)
+	}
+	if ResolveCommand("wheresmyprompt") != "wheresmyprompt" {
+		t.Error("Expected nothing to be installed")
+	}
+
+	// An archive for another platform is not locked
+	installer.OS = "windows"
+	if err := installer.InstallLocked(lock, []string{"files2prompt"}); err == nil || !strings.Contains(err.Error(), "no locked archive") {
+		t.Errorf("Expected a missing platform error, got %v", err)
+	}
+}
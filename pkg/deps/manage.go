@@ -0,0 +1,458 @@
+package deps
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/Masterminds/semver/v3"
+)
+
+// UpgradeInfo compares the installed version of a tool or llm plugin with
+// its latest release
+type UpgradeInfo struct {
+	Name      string `json:"name"`
+	Plugin    bool   `json:"plugin,omitempty"`
+	Current   string `json:"current"`
+	Latest    string `json:"latest,omitempty"`
+	Method    string `json:"method"` // How it was installed: waffles, homebrew, go, pipx, pip or llm
+	Changelog string `json:"changelog,omitempty"`
+	Blocked   string `json:"blocked,omitempty"` // Why it cannot be upgraded
+
+	tag string // Release tag of the latest version
+}
+
+// Available reports whether a newer version can be installed
+func (u *UpgradeInfo) Available() bool {
+	if u.Blocked != "" {
+		return false
+	}
+	current, err := semver.NewVersion(u.Current)
+	if err != nil {
+		return false
+	}
+	latest, err := semver.NewVersion(u.Latest)
+	if err != nil {
+		return false
+	}
+	return current.LessThan(latest)
+}
+
+// CheckUpgrades compares the installed tools and llm plugins, or only the
+// named ones, with their latest releases
+func (p *PlatformInstaller) CheckUpgrades(names []string) ([]UpgradeInfo, error) {
+	pluginVersions := installedPluginVersions()
+	tools, plugins, err := selectManaged(names, pluginVersions)
+	if err != nil {
+		return nil, err
+	}
+
+	var upgrades []UpgradeInfo
+	for _, dep := range tools {
+		info, err := p.toolUpgrade(dep)
+		if err != nil {
+			return nil, fmt.Errorf("failed to check %s: %w", dep.Name, err)
+		}
+		upgrades = append(upgrades, *info)
+	}
+	for _, plugin := range plugins {
+		info, err := p.pluginUpgrade(plugin, pluginVersions[plugin])
+		if err != nil {
+			return nil, fmt.Errorf("failed to check %s: %w", plugin, err)
+		}
+		upgrades = append(upgrades, *info)
+	}
+	return upgrades, nil
+}
+
+// selectManaged resolves names to tools and llm plugins, defaulting to the
+// required tools and the required or installed plugins
+func selectManaged(names []string, pluginVersions map[string]string) ([]Dependency, []string, error) {
+	required := RequiredDependencies()
+	requiredNames := requiredPlugins()
+
+	if len(names) == 0 {
+		plugins := append([]string{}, requiredNames...)
+		for plugin := range pluginVersions {
+			if !contains(plugins, plugin) {
+				plugins = append(plugins, plugin)
+			}
+		}
+		return required, plugins, nil
+	}
+
+	var tools []Dependency
+	var plugins []string
+	for _, name := range names {
+		if dep, ok := findDependency(name, required); ok {
+			tools = append(tools, dep)
+			continue
+		}
+		if _, installed := pluginVersions[name]; !installed && !contains(requiredNames, name) {
+			return nil, nil, fmt.Errorf("unknown tool or plugin %q", name)
+		}
+		plugins = append(plugins, name)
+	}
+	return tools, plugins, nil
+}
+
+// findDependency returns the required dependency of a name, or the
+// built-in one if it is not required
+func findDependency(name string, required []Dependency) (Dependency, bool) {
+	for _, dep := range required {
+		if dep.Name == name {
+			return dep, true
+		}
+	}
+	for _, dep := range builtinDependencies() {
+		if dep.Name == name {
+			return dep, true
+		}
+	}
+	return Dependency{}, false
+}
+
+// toolUpgrade compares the installed version of a tool with its latest
+// release
+func (p *PlatformInstaller) toolUpgrade(dep Dependency) (*UpgradeInfo, error) {
+	info := &UpgradeInfo{Name: dep.Name, Method: p.installMethod(dep)}
+	if _, err := LookPath(dep.Command); err != nil {
+		info.Blocked = "not installed, run 'waffles deps install'"
+		return info, nil
+	}
+
+	info.Current = ActiveToolVersion(dep.Name)
+	if info.Current == "" {
+		info.Current = installedVersion(dep)
+	}
+	if info.Current == "" {
+		info.Current = "unknown"
+	}
+
+	switch {
+	case dep.Repository != "":
+		owner, repo, _ := strings.Cut(dep.Repository, "/")
+		tag, err := p.resolveReleaseTag(owner, repo, "")
+		if err != nil {
+			return nil, err
+		}
+		info.tag = tag
+		info.Latest = strings.TrimPrefix(tag, "v")
+		baseURL := p.ReleaseBaseURL
+		if baseURL == "" {
+			baseURL = DefaultReleaseBaseURL
+		}
+		info.Changelog = fmt.Sprintf("%s/%s/releases/tag/%s", strings.TrimSuffix(baseURL, "/"), dep.Repository, tag)
+	case dep.Package != "":
+		latest, err := p.lockPackage(dep.Package, "")
+		if err != nil {
+			return nil, err
+		}
+		info.Latest = latest.Version
+		info.Changelog = dep.Changelog
+		if info.Changelog == "" {
+			info.Changelog = p.pyPIURL() + "/project/" + dep.Package + "/" + latest.Version + "/"
+		}
+	default:
+		info.Blocked = "no release repository or package known"
+		return info, nil
+	}
+
+	if constraint := dep.VersionConstraint(); constraint != "" {
+		valid, err := satisfies(info.Latest, constraint)
+		if err != nil {
+			return nil, err
+		}
+		if !valid {
+			info.Blocked = fmt.Sprintf("latest version %s does not satisfy %s", info.Latest, constraint)
+		}
+	}
+	return info, nil
+}
+
+// pluginUpgrade compares the installed version of an llm plugin with its
+// latest release on PyPI
+func (p *PlatformInstaller) pluginUpgrade(plugin, current string) (*UpgradeInfo, error) {
+	info := &UpgradeInfo{Name: plugin, Plugin: true, Current: current, Method: "llm"}
+	if current == "" {
+		info.Blocked = fmt.Sprintf("not installed, run 'llm install %s'", plugin)
+		return info, nil
+	}
+
+	latest, err := p.lockPackage(plugin, "")
+	if err != nil {
+		return nil, err
+	}
+	info.Latest = latest.Version
+	info.Changelog = p.pyPIURL() + "/project/" + plugin + "/" + latest.Version + "/"
+	return info, nil
+}
+
+// satisfies reports whether a version is within a semver range
+func satisfies(version, constraint string) (bool, error) {
+	constraints, err := semver.NewConstraint(constraint)
+	if err != nil {
+		return false, fmt.Errorf("invalid version constraint %q: %w", constraint, err)
+	}
+	v, err := semver.NewVersion(version)
+	if err != nil {
+		return false, nil
+	}
+	return constraints.Check(v), nil
+}
+
+// installMethod returns how a tool is installed, by waffles into the private
+// prefix or by the package manager PlatformInstaller would have used
+func (p *PlatformInstaller) installMethod(dep Dependency) string {
+	if ActiveToolVersion(dep.Name) != "" {
+		return "waffles"
+	}
+	if versions, _ := ToolVersions(dep.Name); len(versions) > 0 {
+		return "waffles"
+	}
+
+	switch p.PackageManager {
+	case "homebrew":
+		if dep.Formula != "" {
+			return "homebrew"
+		}
+	case "go":
+		if dep.GoPackage != "" {
+			return "go"
+		}
+	case "pipx", "pip", "uv":
+		if dep.Package != "" {
+			return p.PackageManager
+		}
+	}
+	return "waffles"
+}
+
+// Upgrade installs the latest version of a tool or llm plugin the same way
+// it was installed
+func (p *PlatformInstaller) Upgrade(info UpgradeInfo) (*InstallationResult, error) {
+	if info.Plugin {
+		// uv installs plugins into llm's environment, which has no pip
+		if p.installMethod(builtinDependency("llm")) == "uv" {
+			result, err := runPackageCommand("upgraded llm and its plugins via uv", "uv", "tool", "upgrade", "llm")
+			_ = ClearCheckCache()
+			return result, err
+		}
+		llm, err := LookPath("llm")
+		if err != nil {
+			return failedResult("llm command not found - install llm CLI first", err)
+		}
+		result, err := runPackageCommand(fmt.Sprintf("upgraded %s to %s", info.Name, info.Latest), llm, "install", "--upgrade", info.Name)
+		_ = ClearCheckCache()
+		return result, err
+	}
+
+	dep, ok := findDependency(info.Name, RequiredDependencies())
+	if !ok {
+		return failedResult(fmt.Sprintf("Unknown dependency: %s", info.Name), fmt.Errorf("unknown dependency: %s", info.Name))
+	}
+	message := fmt.Sprintf("upgraded %s to %s via %s", dep.Name, info.Latest, info.Method)
+
+	switch info.Method {
+	case "homebrew":
+		return runPackageCommand(message, "brew", "upgrade", dep.Formula)
+	case "go":
+		return p.installViaGo(dep.GoPackage + "@" + info.tag)
+	case "pipx":
+		return runPackageCommand(message, "pipx", "upgrade", dep.Package)
+	case "uv":
+		return runPackageCommand(message, "uv", "tool", "upgrade", dep.Package)
+	case "pip":
+		return runPackageCommand(message, "pip", "install", "--upgrade", dep.Package+"=="+info.Latest)
+	}
+
+	if dep.Repository != "" {
+		owner, repo, _ := strings.Cut(dep.Repository, "/")
+		return p.installRelease(owner, repo, dep.Command, info.tag, nil)
+	}
+	dir, err := installPythonTool(dep.Name, info.Latest, dep.Package+"=="+info.Latest)
+	if err != nil {
+		return failedResult(fmt.Sprintf("Failed to upgrade %s: %v", dep.Name, err), err)
+	}
+	return &InstallationResult{
+		Success: true,
+		Message: fmt.Sprintf("Successfully installed %s %s to %s", dep.Name, info.Latest, dir),
+	}, nil
+}
+
+// Uninstall removes a tool the same way it was installed, or an llm plugin
+// with llm uninstall. A version removes only that version of a tool from the
+// private prefix, activating the newest remaining one if it was active.
+func (p *PlatformInstaller) Uninstall(name, version string) (*InstallationResult, error) {
+	dep, ok := findDependency(name, RequiredDependencies())
+	if !ok {
+		if version != "" {
+			return failedResult("Plugin versions cannot be removed individually", fmt.Errorf("cannot remove version %s of plugin %s", version, name))
+		}
+		if err := UninstallLLMPlugin(name); err != nil {
+			return failedResult(err.Error(), err)
+		}
+		return &InstallationResult{Success: true, Message: fmt.Sprintf("Successfully uninstalled %s", name)}, nil
+	}
+
+	method := p.installMethod(dep)
+	if method == "waffles" {
+		return removeToolVersions(dep.Name, version)
+	}
+	if version != "" {
+		err := fmt.Errorf("%s is installed via %s, which keeps no separate versions", dep.Name, method)
+		return failedResult(err.Error(), err)
+	}
+	if _, err := LookPath(dep.Command); err != nil {
+		err := fmt.Errorf("%s is not installed", dep.Name)
+		return failedResult(err.Error(), err)
+	}
+
+	message := fmt.Sprintf("uninstalled %s via %s", dep.Name, method)
+	switch method {
+	case "homebrew":
+		return runPackageCommand(message, "brew", "uninstall", dep.Formula)
+	case "go":
+		return removeGoBinary(dep.Command)
+	case "pipx":
+		return runPackageCommand(message, "pipx", "uninstall", dep.Package)
+	case "uv":
+		return runPackageCommand(message, "uv", "tool", "uninstall", dep.Package)
+	default:
+		return runPackageCommand(message, "pip", "uninstall", "--yes", dep.Package)
+	}
+}
+
+// UninstallLLMPlugin removes an LLM plugin from llm's environment
+func UninstallLLMPlugin(plugin string) error {
+	llm, err := LookPath("llm")
+	if err != nil {
+		return fmt.Errorf("llm command not found - install llm CLI first")
+	}
+
+	cmd := exec.Command(llm, "uninstall", plugin, "--yes") // #nosec G204 -- llm resolved from the tool prefix or PATH
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("failed to uninstall plugin %s: %w\nOutput: %s", plugin, err, string(output))
+	}
+
+	_ = ClearCheckCache()
+	return nil
+}
+
+// removeToolVersions removes one or all versions of a tool from the private
+// prefix, keeping the shim pointed at an installed version
+func removeToolVersions(name, version string) (*InstallationResult, error) {
+	versions, err := ToolVersions(name)
+	if err != nil {
+		return failedResult(err.Error(), err)
+	}
+	removed := name
+	if version != "" {
+		removed += " " + version
+	}
+	if len(versions) == 0 || (version != "" && !contains(versions, version)) {
+		err := fmt.Errorf("%s was not installed by waffles", removed)
+		return failedResult(err.Error(), err)
+	}
+
+	active := ActiveToolVersion(name)
+	var remaining []string
+	for _, v := range versions {
+		if version == "" || v == version {
+			dir, err := ToolDir(name, v)
+			if err != nil {
+				return failedResult(err.Error(), err)
+			}
+			if err := os.RemoveAll(dir); err != nil {
+				return failedResult(fmt.Sprintf("Failed to remove %s %s: %v", name, v, err), err)
+			}
+		} else {
+			remaining = append(remaining, v)
+		}
+	}
+
+	if len(remaining) > 0 && (active == "" || !contains(remaining, active)) {
+		newest := remaining[len(remaining)-1]
+		if !activateInstalledVersion(name, newest) {
+			err := fmt.Errorf("failed to activate %s %s", name, newest)
+			return failedResult(err.Error(), err)
+		}
+		return &InstallationResult{Success: true, Message: fmt.Sprintf("Removed %s, %s %s is now active", removed, name, newest)}, nil
+	}
+	if len(remaining) == 0 {
+		if err := removeShim(name); err != nil {
+			return failedResult(err.Error(), err)
+		}
+		if dir, err := ToolsDir(); err == nil {
+			_ = os.Remove(filepath.Join(dir, name))
+		}
+	}
+	return &InstallationResult{Success: true, Message: fmt.Sprintf("Removed %s", removed)}, nil
+}
+
+// removeShim removes the shim of a tool
+func removeShim(name string) error {
+	dir, err := ShimDir()
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(filepath.Join(dir, executableName(name))); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to remove shim of %s: %w", name, err)
+	}
+	return nil
+}
+
+// removeGoBinary removes a binary installed with go install, which has no
+// uninstall command, refusing binaries outside Go's bin directories
+func removeGoBinary(command string) (*InstallationResult, error) {
+	path, err := exec.LookPath(command)
+	if err != nil {
+		return failedResult(fmt.Sprintf("%s is not installed", command), err)
+	}
+
+	output, err := exec.Command("go", "env", "GOBIN", "GOPATH").Output()
+	if err != nil {
+		return failedResult(fmt.Sprintf("Failed to find Go's bin directory: %v", err), err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(output)), "\n")
+	var binDirs []string
+	if gobin := strings.TrimSpace(lines[0]); gobin != "" {
+		binDirs = append(binDirs, gobin)
+	}
+	if len(lines) > 1 {
+		for _, gopath := range filepath.SplitList(strings.TrimSpace(lines[1])) {
+			binDirs = append(binDirs, filepath.Join(gopath, "bin"))
+		}
+	}
+
+	for _, dir := range binDirs {
+		if filepath.Clean(filepath.Dir(path)) == filepath.Clean(dir) {
+			if err := os.Remove(path); err != nil {
+				return failedResult(fmt.Sprintf("Failed to remove %s: %v", path, err), err)
+			}
+			return &InstallationResult{Success: true, Message: fmt.Sprintf("Successfully removed %s", path)}, nil
+		}
+	}
+	err = fmt.Errorf("%s at %s was not installed with go install", command, path)
+	return failedResult(err.Error(), err)
+}
+
+// runPackageCommand runs a package manager command, describing a success
+// with message
+func runPackageCommand(message, name string, args ...string) (*InstallationResult, error) {
+	cmd := exec.Command(name, args...) // #nosec G204 -- package manager commands with arguments from the dependency list
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return failedResult(fmt.Sprintf("%s %s failed: %v\nOutput: %s", name, args[0], err, string(output)), err)
+	}
+	return &InstallationResult{Success: true, Message: "Successfully " + message}, nil
+}
+
+// failedResult returns a failed installation result along with its error
+func failedResult(message string, err error) (*InstallationResult, error) {
+	return &InstallationResult{Success: false, Error: message}, err
+}
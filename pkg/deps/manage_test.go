@@ -0,0 +1,136 @@
+package deps
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// fakeCommand writes a script into bin that records its arguments in a file
+// next to it, returning a function reading them back
+func fakeCommand(t *testing.T, bin, name string) (args func() string) {
+	t.Helper()
+
+	log := filepath.Join(bin, name+".args")
+	script := "#!/bin/sh\necho \"$@\" >> " + log + "\n"
+	if err := os.WriteFile(filepath.Join(bin, name), []byte(script), 0755); err != nil { // #nosec G306 -- Test executable
+		t.Fatalf("Failed to write %s: %v", name, err)
+	}
+	return func() string {
+		data, _ := os.ReadFile(log) // #nosec G304 -- Test file
+		return strings.TrimSpace(string(data))
+	}
+}
+
+func TestUpgradeManagedTool(t *testing.T) {
+	installer, _ := newLockServer(t, "1.2.0", "0.9")
+	installFakeVersion(t, "files2prompt", "1.0.0")
+	if !activateInstalledVersion("files2prompt", "1.0.0") {
+		t.Fatal("Failed to activate files2prompt 1.0.0")
+	}
+
+	upgrades, err := installer.CheckUpgrades([]string{"files2prompt", "wheresmyprompt"})
+	if err != nil {
+		t.Fatalf("CheckUpgrades failed: %v", err)
+	}
+	upgrade := upgrades[0]
+	if !upgrade.Available() || upgrade.Current != "1.0.0" || upgrade.Latest != "1.2.0" || upgrade.Method != "waffles" {
+		t.Fatalf("Expected an upgrade from 1.0.0 to 1.2.0, got %+v", upgrade)
+	}
+	if !strings.HasSuffix(upgrade.Changelog, "/toozej/files2prompt/releases/tag/v1.2.0") {
+		t.Errorf("Unexpected changelog link %q", upgrade.Changelog)
+	}
+	if upgrades[1].Available() || !strings.Contains(upgrades[1].Blocked, "not installed") {
+		t.Errorf("Expected wheresmyprompt to be reported as not installed, got %+v", upgrades[1])
+	}
+
+	result, err := installer.Upgrade(upgrade)
+	if err != nil || !result.Success {
+		t.Fatalf("Upgrade failed: %v %+v", err, result)
+	}
+	if active := ActiveToolVersion("files2prompt"); active != "1.2.0" {
+		t.Errorf("Expected 1.2.0 to be active, got %q", active)
+	}
+
+	upgrades, err = installer.CheckUpgrades([]string{"files2prompt"})
+	if err != nil || upgrades[0].Available() || upgrades[0].Current != "1.2.0" {
+		t.Errorf("Expected files2prompt to be up to date, got %+v: %v", upgrades, err)
+	}
+
+	if _, err := installer.CheckUpgrades([]string{"nope"}); err == nil || !strings.Contains(err.Error(), "unknown tool or plugin") {
+		t.Errorf("Expected an unknown name error, got %v", err)
+	}
+}
+
+func TestUpgradeHonorsConstraint(t *testing.T) {
+	installer, _ := newLockServer(t, "1.2.0", "0.9")
+	installFakeVersion(t, "files2prompt", "1.0.0")
+	activateInstalledVersion("files2prompt", "1.0.0")
+	setRequirements(t, Requirements{Versions: map[string]string{"files2prompt": "<1.2"}})
+
+	upgrades, err := installer.CheckUpgrades([]string{"files2prompt"})
+	if err != nil {
+		t.Fatalf("CheckUpgrades failed: %v", err)
+	}
+	if upgrades[0].Available() || !strings.Contains(upgrades[0].Blocked, "does not satisfy") {
+		t.Errorf("Expected the upgrade to be blocked by the constraint, got %+v", upgrades[0])
+	}
+}
+
+func TestUninstallToolVersions(t *testing.T) {
+	t.Setenv("XDG_DATA_HOME", t.TempDir())
+	t.Setenv("PATH", t.TempDir())
+	installer := &PlatformInstaller{}
+
+	installFakeVersion(t, "files2prompt", "1.0.0")
+	installFakeVersion(t, "files2prompt", "1.1.0")
+	activateInstalledVersion("files2prompt", "1.1.0")
+
+	if _, err := installer.Uninstall("files2prompt", "2.0.0"); err == nil {
+		t.Error("Expected an error for a version that is not installed")
+	}
+
+	result, err := installer.Uninstall("files2prompt", "1.1.0")
+	if err != nil || !strings.Contains(result.Message, "1.0.0 is now active") {
+		t.Fatalf("Expected 1.0.0 to be activated, got %+v: %v", result, err)
+	}
+	if active := ActiveToolVersion("files2prompt"); active != "1.0.0" {
+		t.Errorf("Expected 1.0.0 to be active, got %q", active)
+	}
+
+	if _, err := installer.Uninstall("files2prompt", ""); err != nil {
+		t.Fatalf("Uninstall failed: %v", err)
+	}
+	if versions, _ := ToolVersions("files2prompt"); len(versions) != 0 || ResolveCommand("files2prompt") != "files2prompt" {
+		t.Errorf("Expected files2prompt to be removed, got versions %v", versions)
+	}
+}
+
+func TestUninstallViaPackageManager(t *testing.T) {
+	bin := t.TempDir()
+	t.Setenv("XDG_DATA_HOME", t.TempDir())
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	t.Setenv("PATH", bin)
+	installer := &PlatformInstaller{PackageManager: "pipx"}
+
+	pipx := fakeCommand(t, bin, "pipx")
+	llm := fakeCommand(t, bin, "llm")
+
+	if _, err := installer.Uninstall("llm-commit", ""); err != nil {
+		t.Fatalf("Uninstall of a plugin failed: %v", err)
+	}
+	if args := llm(); args != "uninstall llm-commit --yes" {
+		t.Errorf("Expected llm uninstall, got %q", args)
+	}
+
+	if _, err := installer.Uninstall("llm", "0.9"); err == nil {
+		t.Error("Expected versions of pipx installs to be rejected")
+	}
+	if _, err := installer.Uninstall("llm", ""); err != nil {
+		t.Fatalf("Uninstall failed: %v", err)
+	}
+	if args := pipx(); args != "uninstall llm" {
+		t.Errorf("Expected pipx uninstall, got %q", args)
+	}
+}
@@ -1,8 +1,6 @@
 package deps
 
 import (
-	"archive/tar"
-	"compress/gzip"
 	"fmt"
 	"io"
 	"net/http"
@@ -11,13 +9,32 @@ import (
 	"path/filepath"
 	"runtime"
 	"strings"
+	"time"
 )
 
+// Default servers for release downloads and package metadata
+const (
+	DefaultReleaseBaseURL = "https://github.com"
+	DefaultGitHubAPIURL   = "https://api.github.com"
+	DefaultPyPIURL        = "https://pypi.org"
+)
+
+// maxMetadataSize limits the size of checksum and signature assets
+const maxMetadataSize = 1024 * 1024
+
 // PlatformInstaller handles platform-specific installations
 type PlatformInstaller struct {
 	OS             string
 	Architecture   string
 	PackageManager string
+	ReleaseBaseURL string                 // Server of GitHub release downloads
+	GitHubAPIURL   string                 // GitHub REST API, for latest release tags
+	PyPIURL        string                 // Python package index, for package metadata
+	ReleaseKeys    map[string]ReleaseKeys // Trusted signing keys by "owner/repo"
+	AssetTemplate  string                 // Release asset name without extension, see SetAssetTemplate
+	DryRun         bool                   // Print the commands that would run instead of installing
+	Strict         bool                   // Install only with PackageManager, as selected with --via
+	HTTPClient     *http.Client
 }
 
 // NewPlatformInstaller creates a new platform installer
@@ -26,35 +43,52 @@ func NewPlatformInstaller() *PlatformInstaller {
 		OS:             runtime.GOOS,
 		Architecture:   runtime.GOARCH,
 		PackageManager: DetectPackageManager(),
+		ReleaseBaseURL: DefaultReleaseBaseURL,
+		GitHubAPIURL:   DefaultGitHubAPIURL,
+		PyPIURL:        DefaultPyPIURL,
+		AssetTemplate:  currentAssetTemplate(),
+		HTTPClient:     &http.Client{Timeout: 5 * time.Minute},
 	}
 }
 
 // InstallWheresmyprompt installs wheresmyprompt using the best available method
 func (p *PlatformInstaller) InstallWheresmyprompt() (*InstallationResult, error) {
+	dep := builtinDependency("wheresmyprompt")
 	switch p.PackageManager {
 	case "homebrew":
-		return p.installViaHomebrew("toozej/tap/wheresmyprompt")
+		return p.installViaHomebrew(dep.Formula)
 	case "go":
-		return p.installViaGo("github.com/toozej/wheresmyprompt/cmd/wheresmyprompt@latest")
+		return p.installViaGo(dep.GoPackage + "@latest")
 	default:
+		if p.Strict {
+			return p.unsupported(dep.Name)
+		}
 		return p.installBinaryFromGitHub("toozej", "wheresmyprompt", "wheresmyprompt")
 	}
 }
 
 // InstallFiles2prompt installs files2prompt using the best available method
 func (p *PlatformInstaller) InstallFiles2prompt() (*InstallationResult, error) {
+	dep := builtinDependency("files2prompt")
 	switch p.PackageManager {
 	case "homebrew":
-		return p.installViaHomebrew("toozej/tap/files2prompt")
+		return p.installViaHomebrew(dep.Formula)
 	case "go":
-		return p.installViaGo("github.com/toozej/files2prompt/cmd/files2prompt@latest")
+		return p.installViaGo(dep.GoPackage + "@latest")
 	default:
+		if p.Strict {
+			return p.unsupported(dep.Name)
+		}
 		return p.installBinaryFromGitHub("toozej", "files2prompt", "files2prompt")
 	}
 }
 
 // InstallLLM installs llm CLI using the best available method
 func (p *PlatformInstaller) InstallLLM() (*InstallationResult, error) {
+	if backend, ok := systemBackends[p.PackageManager]; ok && p.Strict {
+		return p.installViaSystem("llm", backend.LLM)
+	}
+
 	switch p.PackageManager {
 	case "homebrew":
 		return p.installViaHomebrew("llm")
@@ -62,7 +96,18 @@ func (p *PlatformInstaller) InstallLLM() (*InstallationResult, error) {
 		return p.installViaPipx("llm")
 	case "pip":
 		return p.installViaPip("llm")
+	case "uv":
+		return p.installViaUv("llm", requiredPlugins())
 	default:
+		if p.Strict {
+			return p.unsupported("llm")
+		}
+
+		// Prefer a private virtual environment, which needs nothing but Python
+		if _, err := findPython(); err == nil {
+			return p.installPythonPackage("llm")
+		}
+
 		// Try to install pipx first, then llm
 		if err := p.ensurePipxInstalled(); err != nil {
 			return &InstallationResult{
@@ -74,8 +119,46 @@ func (p *PlatformInstaller) InstallLLM() (*InstallationResult, error) {
 	}
 }
 
+// installPythonPackage installs the latest version of a PyPI package into
+// the private tool prefix
+func (p *PlatformInstaller) installPythonPackage(packageName string) (*InstallationResult, error) {
+	latest, err := p.lockPackage(packageName, "")
+	if err != nil {
+		return &InstallationResult{
+			Success: false,
+			Error:   fmt.Sprintf("Failed to find latest %s release: %v", packageName, err),
+		}, err
+	}
+
+	if p.DryRun {
+		_, venv, pip, err := pythonToolCommands(packageName, latest.Version, packageName+"=="+latest.Version)
+		if err != nil {
+			return failedResult(fmt.Sprintf("Failed to plan %s install: %v", packageName, err), err)
+		}
+		_, _ = p.planned(venv[0], venv[1:]...)
+		return p.planned(pip[0], pip[1:]...)
+	}
+
+	dir, err := installPythonTool(packageName, latest.Version, packageName+"=="+latest.Version)
+	if err != nil {
+		return &InstallationResult{
+			Success: false,
+			Error:   fmt.Sprintf("Failed to install %s: %v", packageName, err),
+		}, err
+	}
+
+	return &InstallationResult{
+		Success: true,
+		Message: fmt.Sprintf("Successfully installed %s %s to %s", packageName, latest.Version, dir),
+	}, nil
+}
+
 // installViaHomebrew installs a package using Homebrew
 func (p *PlatformInstaller) installViaHomebrew(packageName string) (*InstallationResult, error) {
+	if p.DryRun {
+		return p.planned("brew", "install", packageName)
+	}
+
 	cmd := exec.Command("brew", "install", packageName)
 	output, err := cmd.CombinedOutput()
 
@@ -94,6 +177,10 @@ func (p *PlatformInstaller) installViaHomebrew(packageName string) (*Installatio
 
 // installViaGo installs a package using go install
 func (p *PlatformInstaller) installViaGo(packagePath string) (*InstallationResult, error) {
+	if p.DryRun {
+		return p.planned("go", "install", packagePath)
+	}
+
 	// Check if Go is available
 	if _, err := exec.LookPath("go"); err != nil {
 		return &InstallationResult{
@@ -120,6 +207,10 @@ func (p *PlatformInstaller) installViaGo(packagePath string) (*InstallationResul
 
 // installViaPipx installs a package using pipx
 func (p *PlatformInstaller) installViaPipx(packageName string) (*InstallationResult, error) {
+	if p.DryRun {
+		return p.planned("pipx", "install", packageName)
+	}
+
 	cmd := exec.Command("pipx", "install", packageName)
 	output, err := cmd.CombinedOutput()
 
@@ -138,6 +229,10 @@ func (p *PlatformInstaller) installViaPipx(packageName string) (*InstallationRes
 
 // installViaPip installs a package using pip
 func (p *PlatformInstaller) installViaPip(packageName string) (*InstallationResult, error) {
+	if p.DryRun {
+		return p.planned("pip", "install", packageName)
+	}
+
 	cmd := exec.Command("pip", "install", packageName)
 	output, err := cmd.CombinedOutput()
 
@@ -154,19 +249,56 @@ func (p *PlatformInstaller) installViaPip(packageName string) (*InstallationResu
 	}, nil
 }
 
-// installBinaryFromGitHub downloads and installs a binary from GitHub releases
+// installBinaryFromGitHub downloads and installs a binary from GitHub releases,
+// refusing to install an archive that fails checksum or signature verification
 func (p *PlatformInstaller) installBinaryFromGitHub(owner, repo, binaryName string) (*InstallationResult, error) {
-	// Get the latest release URL
-	downloadURL, err := p.getGitHubReleaseURL(owner, repo)
+	return p.installRelease(owner, repo, binaryName, "", nil)
+}
+
+// installRelease installs a binary from the GitHub release with the given tag,
+// or the latest release if tag is empty, into the private tool prefix and
+// activates it. The asset for this platform is found in the release metadata
+// by the asset name template. It is checked against checksums when given, as
+// pinned by a lockfile, and otherwise against the release checksums.
+func (p *PlatformInstaller) installRelease(owner, repo, binaryName, tag string, checksums map[string]string) (*InstallationResult, error) {
+	release, err := p.fetchRelease(owner, repo, tag)
 	if err != nil {
 		return &InstallationResult{
 			Success: false,
-			Error:   fmt.Sprintf("Failed to get release URL: %v", err),
+			Error:   fmt.Sprintf("Failed to get release metadata: %v", err),
 		}, err
 	}
+	tag = release.TagName
+	version := strings.TrimPrefix(tag, "v")
 
-	// Create temporary file
-	tempFile, err := os.CreateTemp("", fmt.Sprintf("%s-*.tar.gz", binaryName))
+	asset, err := p.matchAsset(release.assetNames(), repo, version)
+	if err != nil {
+		return &InstallationResult{
+			Success: false,
+			Error:   fmt.Sprintf("Failed to find release asset: %v", err),
+		}, err
+	}
+	checksum := ""
+	if checksums != nil {
+		if checksum = checksums[asset]; checksum == "" {
+			err := fmt.Errorf("no locked checksum for %s", asset)
+			return &InstallationResult{
+				Success: false,
+				Error:   fmt.Sprintf("Refusing to install %s: %v", binaryName, err),
+			}, err
+		}
+	}
+	downloadURL := release.assetURL(asset)
+	if downloadURL == "" {
+		downloadURL = p.releaseAssetURL(owner, repo, tag, asset)
+	}
+	if p.DryRun {
+		fmt.Printf("  Would download %s\n", downloadURL)
+		return &InstallationResult{Success: true, Message: "Would download " + downloadURL}, nil
+	}
+
+	// Create temporary file, keeping the extension of archives
+	tempFile, err := os.CreateTemp("", fmt.Sprintf("%s-*%s", binaryName, assetExtension(asset)))
 	if err != nil {
 		return &InstallationResult{
 			Success: false,
@@ -174,7 +306,7 @@ func (p *PlatformInstaller) installBinaryFromGitHub(owner, repo, binaryName stri
 		}, err
 	}
 	defer os.Remove(tempFile.Name())
-	defer tempFile.Close()
+	defer tempFile.Close() // #nosec G307 -- Closed explicitly before verification; a second close is harmless
 
 	// Download the binary
 	fmt.Printf("Downloading %s from %s...\n", binaryName, downloadURL)
@@ -185,9 +317,29 @@ func (p *PlatformInstaller) installBinaryFromGitHub(owner, repo, binaryName stri
 		}, err
 	}
 
+	if err := tempFile.Close(); err != nil {
+		return &InstallationResult{
+			Success: false,
+			Error:   fmt.Sprintf("Failed to write download: %v", err),
+		}, err
+	}
+
+	// Verify before anything is extracted
+	if checksum != "" {
+		err = verifyChecksum(asset, tempFile.Name(), checksum)
+	} else {
+		err = p.verifyRelease(owner, repo, tag, asset, tempFile.Name())
+	}
+	if err != nil {
+		return &InstallationResult{
+			Success: false,
+			Error:   fmt.Sprintf("Refusing to install %s: %v", binaryName, err),
+		}, err
+	}
+
 	// Extract and install
-	installPath := p.getInstallPath(binaryName)
-	if err := p.extractAndInstall(tempFile.Name(), binaryName, installPath); err != nil {
+	toolDir, err := p.installArchive(binaryName, version, asset, tempFile.Name())
+	if err != nil {
 		return &InstallationResult{
 			Success: false,
 			Error:   fmt.Sprintf("Failed to extract and install: %v", err),
@@ -196,34 +348,85 @@ func (p *PlatformInstaller) installBinaryFromGitHub(owner, repo, binaryName stri
 
 	return &InstallationResult{
 		Success: true,
-		Message: fmt.Sprintf("Successfully installed %s to %s", binaryName, installPath),
+		Message: fmt.Sprintf("Successfully installed %s %s to %s", binaryName, version, toolDir),
 	}, nil
 }
 
-// getGitHubReleaseURL constructs the GitHub release download URL
-func (p *PlatformInstaller) getGitHubReleaseURL(owner, repo string) (string, error) {
-	osName := p.OS
-	if osName == "darwin" {
-		osName = "macOS"
+// installArchive installs the binary from a verified release asset into the
+// private tool prefix and activates it, returning the tool directory
+func (p *PlatformInstaller) installArchive(binaryName, version, asset, archivePath string) (string, error) {
+	toolDir, err := ToolDir(binaryName, version)
+	if err != nil {
+		return "", err
+	}
+	installPath := filepath.Join(toolDir, executableName(binaryName))
+	if err := p.extractAndInstall(archivePath, asset, binaryName, installPath); err != nil {
+		return "", err
+	}
+	if err := activateTool(binaryName, installPath); err != nil {
+		return "", fmt.Errorf("failed to activate %s: %w", binaryName, err)
 	}
+	return toolDir, nil
+}
 
-	arch := p.Architecture
-	if arch == "amd64" {
-		arch = "x86_64"
-	} else if arch == "arm64" {
-		arch = "arm64"
+// releaseAssetURL returns the download URL of an asset of the release with
+// the given tag, or of the latest release if tag is empty
+func (p *PlatformInstaller) releaseAssetURL(owner, repo, tag, asset string) string {
+	baseURL := p.ReleaseBaseURL
+	if baseURL == "" {
+		baseURL = DefaultReleaseBaseURL
+	}
+	release := "latest/download"
+	if tag != "" {
+		release = "download/" + tag
 	}
+	return fmt.Sprintf("%s/%s/%s/releases/%s/%s", strings.TrimSuffix(baseURL, "/"), owner, repo, release, asset)
+}
 
-	// GitHub releases typically follow this pattern
-	filename := fmt.Sprintf("%s_%s_%s.tar.gz", repo, osName, arch)
-	url := fmt.Sprintf("https://github.com/%s/%s/releases/latest/download/%s", owner, repo, filename)
+// httpClient returns the client used for downloads
+func (p *PlatformInstaller) httpClient() *http.Client {
+	if p.HTTPClient != nil {
+		return p.HTTPClient
+	}
+	return http.DefaultClient
+}
 
-	return url, nil
+// fetchReleaseAsset downloads a small asset of a release into memory,
+// returning errAssetNotFound if the release does not publish it
+func (p *PlatformInstaller) fetchReleaseAsset(owner, repo, tag, asset string) ([]byte, error) {
+	return p.fetch(p.releaseAssetURL(owner, repo, tag, asset))
+}
+
+// fetch downloads a small document into memory, returning errAssetNotFound
+// if the server does not have it
+func (p *PlatformInstaller) fetch(url string) ([]byte, error) {
+	resp, err := p.httpClient().Get(url) // #nosec G107 -- URL from trusted dependency configuration
+	if err != nil {
+		return nil, fmt.Errorf("failed to download: %w", err)
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotFound:
+		return nil, errAssetNotFound
+	default:
+		return nil, fmt.Errorf("download failed with status: %s", resp.Status)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxMetadataSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to download: %w", err)
+	}
+	if len(data) > maxMetadataSize {
+		return nil, fmt.Errorf("%s is larger than %d bytes", url, maxMetadataSize)
+	}
+	return data, nil
 }
 
 // downloadFile downloads a file from URL to the given writer with progress indication
 func (p *PlatformInstaller) downloadFile(url string, dest io.Writer) error {
-	resp, err := http.Get(url) // #nosec G107 -- URL from trusted dependency configuration
+	resp, err := p.httpClient().Get(url) // #nosec G107 -- URL from trusted dependency configuration
 	if err != nil {
 		return fmt.Errorf("failed to download: %w", err)
 	}
@@ -243,95 +446,6 @@ func (p *PlatformInstaller) downloadFile(url string, dest io.Writer) error {
 	return err
 }
 
-// extractAndInstall extracts a tar.gz file and installs the binary
-func (p *PlatformInstaller) extractAndInstall(archivePath, binaryName, installPath string) error {
-	// Open the archive
-	file, err := os.Open(archivePath) // #nosec G304 -- Archive path is from validated dependency configuration
-	if err != nil {
-		return fmt.Errorf("failed to open archive: %w", err)
-	}
-	defer file.Close()
-
-	// Create gzip reader
-	gzReader, err := gzip.NewReader(file)
-	if err != nil {
-		return fmt.Errorf("failed to create gzip reader: %w", err)
-	}
-	defer gzReader.Close()
-
-	// Create tar reader
-	tarReader := tar.NewReader(gzReader)
-
-	// Extract the binary
-	for {
-		header, err := tarReader.Next()
-		if err == io.EOF {
-			break
-		}
-		if err != nil {
-			return fmt.Errorf("failed to read tar entry: %w", err)
-		}
-
-		// Look for the binary file
-		if header.Typeflag == tar.TypeReg && strings.Contains(header.Name, binaryName) {
-			// Check file size to prevent decompression bomb
-			const maxFileSize = 100 * 1024 * 1024 // 100MB limit
-			if header.Size > maxFileSize {
-				return fmt.Errorf("file %s is too large (%d bytes > %d bytes limit)", header.Name, header.Size, maxFileSize)
-			}
-
-			// Ensure install directory exists
-			installDir := filepath.Dir(installPath)
-			if err := os.MkdirAll(installDir, 0750); err != nil {
-				return fmt.Errorf("failed to create install directory: %w", err)
-			}
-
-			// Create the binary file
-			outFile, err := os.OpenFile(installPath, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0750) // #nosec G304 G302 -- Install path from validated config, 0750 appropriate for executables
-			if err != nil {
-				return fmt.Errorf("failed to create binary file: %w", err)
-			}
-
-			// Copy binary content with size limit
-			_, err = io.CopyN(outFile, tarReader, header.Size)
-			if closeErr := outFile.Close(); closeErr != nil {
-				return fmt.Errorf("failed to close binary file: %w", closeErr)
-			}
-			if err != nil {
-				return fmt.Errorf("failed to write binary: %w", err)
-			}
-
-			return nil
-		}
-	}
-
-	return fmt.Errorf("binary %s not found in archive", binaryName)
-}
-
-// getInstallPath returns the appropriate install path for a binary
-func (p *PlatformInstaller) getInstallPath(binaryName string) string {
-	// Try to use ~/bin if it exists, otherwise use /usr/local/bin on Unix systems
-	homeDir, err := os.UserHomeDir()
-	if err == nil {
-		binDir := filepath.Join(homeDir, "bin")
-		if _, err := os.Stat(binDir); err == nil {
-			return filepath.Join(binDir, binaryName)
-		}
-
-		// Create ~/bin if it doesn't exist
-		if err := os.MkdirAll(binDir, 0750); err == nil {
-			return filepath.Join(binDir, binaryName)
-		}
-	}
-
-	// Fallback to system paths
-	if p.OS == "windows" {
-		return filepath.Join("C:", "tools", "bin", binaryName+".exe")
-	}
-
-	return filepath.Join("/usr/local/bin", binaryName)
-}
-
 // ensurePipxInstalled ensures pipx is installed on the system
 func (p *PlatformInstaller) ensurePipxInstalled() error {
 	// Check if pipx is already available
@@ -340,32 +454,36 @@ func (p *PlatformInstaller) ensurePipxInstalled() error {
 	}
 
 	// Try to install pipx using the platform package manager
-	switch p.PackageManager {
-	case "homebrew":
-		cmd := exec.Command("brew", "install", "pipx")
-		if err := cmd.Run(); err != nil {
-			return fmt.Errorf("failed to install pipx via homebrew: %w", err)
-		}
-	case "apt":
-		cmd := exec.Command("sudo", "apt", "install", "-y", "pipx")
-		if err := cmd.Run(); err != nil {
-			return fmt.Errorf("failed to install pipx via apt: %w", err)
+	var command []string
+	if _, ok := systemBackends[p.PackageManager]; ok {
+		command = systemCommand(p.PackageManager, systemBackends[p.PackageManager].Pipx)
+	} else {
+		switch p.PackageManager {
+		case "homebrew":
+			command = []string{"brew", "install", "pipx"}
+		case "pip":
+			command = []string{"pip", "install", "--user", "pipx"}
+		default:
+			return fmt.Errorf("cannot install pipx: no supported package manager found")
 		}
-	case "pip":
-		cmd := exec.Command("pip", "install", "--user", "pipx")
-		if err := cmd.Run(); err != nil {
-			return fmt.Errorf("failed to install pipx via pip: %w", err)
-		}
-	default:
-		return fmt.Errorf("cannot install pipx: no supported package manager found")
+	}
+
+	if p.DryRun {
+		_, _ = p.planned(command[0], command[1:]...)
+		return nil
+	}
+	cmd := exec.Command(command[0], command[1:]...) // #nosec G204 -- Package manager commands from systemBackends
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("failed to install pipx via %s: %w", p.PackageManager, err)
 	}
 
 	return nil
 }
 
-// VerifyInstallation verifies that a binary was installed correctly
+// VerifyInstallation verifies that a binary was installed correctly, either
+// in the private tool prefix or in PATH
 func (p *PlatformInstaller) VerifyInstallation(binaryName string) error {
-	_, err := exec.LookPath(binaryName)
+	_, err := LookPath(binaryName)
 	if err != nil {
 		return fmt.Errorf("binary %s not found in PATH after installation", binaryName)
 	}
@@ -0,0 +1,154 @@
+package deps
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/toozej/waffles/pkg/config"
+)
+
+// Requirements override which tools and llm plugins are required and which
+// of their versions are acceptable, usually as declared in configuration
+type Requirements struct {
+	Tools    []string          // Required tools; all built-in tools if empty
+	Versions map[string]string // Semver range by tool, on top of its minimum version
+	Plugins  []string          // Required llm plugins; the built-in list if nil
+}
+
+var (
+	requirementsMu sync.RWMutex
+	requirements   Requirements
+)
+
+// SetRequirements validates requirements and applies them to
+// RequiredDependencies and thereby to all dependency checks and installs
+func SetRequirements(r Requirements) error {
+	known := make(map[string]bool)
+	var names []string
+	for _, dep := range builtinDependencies() {
+		known[dep.Name] = true
+		names = append(names, dep.Name)
+	}
+
+	for _, tool := range r.Tools {
+		if !known[tool] {
+			return fmt.Errorf("unknown tool %q, expected one of %s", tool, strings.Join(names, ", "))
+		}
+	}
+	for tool, constraint := range r.Versions {
+		if !known[tool] {
+			return fmt.Errorf("unknown tool %q, expected one of %s", tool, strings.Join(names, ", "))
+		}
+		if _, err := semver.NewConstraint(constraint); err != nil {
+			return fmt.Errorf("invalid version constraint %q for %s: %w", constraint, tool, err)
+		}
+	}
+	if len(r.Plugins) > 0 && len(r.Tools) > 0 && !contains(r.Tools, "llm") {
+		return fmt.Errorf("llm plugins are required but llm is not")
+	}
+
+	requirementsMu.Lock()
+	defer requirementsMu.Unlock()
+	requirements = r
+	return nil
+}
+
+// RequirementsFromConfig returns the requirements declared in configuration.
+// Lists are separated by commas or spaces, and a plugin list of "none"
+// requires no plugins.
+func RequirementsFromConfig(cfg *config.Config) Requirements {
+	r := Requirements{
+		Tools:    splitList(cfg.RequiredTools),
+		Versions: make(map[string]string),
+	}
+
+	for tool, constraint := range map[string]string{
+		"wheresmyprompt": cfg.WheresmypromptVersion,
+		"files2prompt":   cfg.Files2promptVersion,
+		"llm":            cfg.LLMVersion,
+	} {
+		if constraint = strings.TrimSpace(constraint); constraint != "" {
+			r.Versions[tool] = constraint
+		}
+	}
+
+	switch plugins := strings.TrimSpace(cfg.LLMPlugins); plugins {
+	case "":
+	case "none":
+		r.Plugins = []string{}
+	default:
+		r.Plugins = splitList(plugins)
+	}
+
+	return r
+}
+
+// RequiredDependencies returns the list of all required dependencies, as
+// narrowed down by the requirements set with SetRequirements
+func RequiredDependencies() []Dependency {
+	requirementsMu.RLock()
+	r := requirements
+	requirementsMu.RUnlock()
+
+	var deps []Dependency
+	for _, dep := range builtinDependencies() {
+		if len(r.Tools) > 0 && !contains(r.Tools, dep.Name) {
+			continue
+		}
+		dep.Constraint = r.Versions[dep.Name]
+		if dep.Name == "llm" && r.Plugins != nil {
+			dep.Plugins = append([]string{}, r.Plugins...)
+		}
+		deps = append(deps, dep)
+	}
+	return deps
+}
+
+// requiredPlugins returns the required llm plugins
+func requiredPlugins() []string {
+	for _, dep := range RequiredDependencies() {
+		if dep.Name == "llm" {
+			return dep.Plugins
+		}
+	}
+	return nil
+}
+
+// VersionConstraint returns the semver range combining the minimum, maximum
+// and further constraint of a dependency, or "" if any version will do
+func (d Dependency) VersionConstraint() string {
+	var bounds []string
+	if d.MinVersion != "" {
+		bounds = append(bounds, ">= "+d.MinVersion)
+	}
+	if d.MaxVersion != "" {
+		bounds = append(bounds, "<= "+d.MaxVersion)
+	}
+	if d.Constraint == "" {
+		return strings.Join(bounds, ", ")
+	}
+	if len(bounds) == 0 {
+		return d.Constraint
+	}
+
+	// AND binds tighter than OR, so the bounds apply to each alternative
+	alternatives := strings.Split(d.Constraint, "||")
+	for i, alternative := range alternatives {
+		alternatives[i] = strings.Join(append(append([]string{}, bounds...), strings.TrimSpace(alternative)), ", ")
+	}
+	return strings.Join(alternatives, " || ")
+}
+
+// splitList splits a list separated by commas or whitespace, returning nil
+// for an empty list
+func splitList(list string) []string {
+	items := strings.FieldsFunc(list, func(r rune) bool {
+		return r == ',' || r == ' ' || r == '\t' || r == '\n'
+	})
+	if len(items) == 0 {
+		return nil
+	}
+	return items
+}
@@ -0,0 +1,158 @@
+package deps
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/toozej/waffles/pkg/config"
+)
+
+// setRequirements applies requirements for the duration of a test
+func setRequirements(t *testing.T, r Requirements) {
+	t.Helper()
+	if err := SetRequirements(r); err != nil {
+		t.Fatalf("SetRequirements failed: %v", err)
+	}
+	t.Cleanup(func() { _ = SetRequirements(Requirements{}) })
+}
+
+func TestRequiredDependenciesHonorRequirements(t *testing.T) {
+	setRequirements(t, Requirements{
+		Tools:    []string{"files2prompt", "llm"},
+		Versions: map[string]string{"llm": "<0.20"},
+		Plugins:  []string{"llm-ollama"},
+	})
+
+	deps := RequiredDependencies()
+	if len(deps) != 2 || deps[0].Name != "files2prompt" || deps[1].Name != "llm" {
+		t.Fatalf("Expected files2prompt and llm, got %+v", deps)
+	}
+	if deps[1].Constraint != "<0.20" || deps[0].Constraint != "" {
+		t.Errorf("Expected the constraint on llm only, got %q and %q", deps[0].Constraint, deps[1].Constraint)
+	}
+	if !reflect.DeepEqual(requiredPlugins(), []string{"llm-ollama"}) {
+		t.Errorf("Expected only llm-ollama, got %v", requiredPlugins())
+	}
+
+	// No plugins at all
+	setRequirements(t, Requirements{Plugins: []string{}})
+	if len(RequiredDependencies()) != 3 || len(requiredPlugins()) != 0 {
+		t.Errorf("Expected all tools without plugins, got %+v", RequiredDependencies())
+	}
+}
+
+func TestSetRequirementsValidates(t *testing.T) {
+	t.Cleanup(func() { _ = SetRequirements(Requirements{}) })
+
+	tests := []struct {
+		name string
+		r    Requirements
+		want string
+	}{
+		{"unknown tool", Requirements{Tools: []string{"llm", "nope"}}, `unknown tool "nope"`},
+		{"unknown versioned tool", Requirements{Versions: map[string]string{"nope": ">=1"}}, `unknown tool "nope"`},
+		{"invalid constraint", Requirements{Versions: map[string]string{"llm": "about 1"}}, "invalid version constraint"},
+		{"plugins without llm", Requirements{Tools: []string{"files2prompt"}, Plugins: []string{"llm-ollama"}}, "llm is not"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := SetRequirements(tt.r)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Expected an error containing %q, got %v", tt.want, err)
+			}
+		})
+	}
+
+	if len(RequiredDependencies()) != 3 {
+		t.Error("Expected invalid requirements not to be applied")
+	}
+}
+
+func TestRequirementsFromConfig(t *testing.T) {
+	r := RequirementsFromConfig(&config.Config{
+		RequiredTools:       "wheresmyprompt, llm",
+		LLMPlugins:          "llm-anthropic llm-ollama",
+		Files2promptVersion: " ",
+		LLMVersion:          ">=0.13, <1.0",
+	})
+	want := Requirements{
+		Tools:    []string{"wheresmyprompt", "llm"},
+		Versions: map[string]string{"llm": ">=0.13, <1.0"},
+		Plugins:  []string{"llm-anthropic", "llm-ollama"},
+	}
+	if !reflect.DeepEqual(r, want) {
+		t.Errorf("Expected %+v, got %+v", want, r)
+	}
+
+	if r := RequirementsFromConfig(&config.Config{LLMPlugins: "none"}); r.Plugins == nil || len(r.Plugins) != 0 {
+		t.Errorf("Expected no plugins for none, got %#v", r.Plugins)
+	}
+	if r := RequirementsFromConfig(&config.Config{}); r.Plugins != nil || r.Tools != nil {
+		t.Errorf("Expected the built-in defaults, got %+v", r)
+	}
+}
+
+func TestVersionConstraint(t *testing.T) {
+	tests := []struct {
+		dep  Dependency
+		want string
+	}{
+		{Dependency{}, ""},
+		{Dependency{MinVersion: "0.10.0"}, ">= 0.10.0"},
+		{Dependency{MinVersion: "0.10.0", MaxVersion: "0.19.0"}, ">= 0.10.0, <= 0.19.0"},
+		{Dependency{Constraint: "~0.13"}, "~0.13"},
+		{Dependency{MinVersion: "0.10.0", Constraint: "0.13.x || >=0.15 <0.16"}, ">= 0.10.0, 0.13.x || >= 0.10.0, >=0.15 <0.16"},
+	}
+
+	for _, tt := range tests {
+		if got := tt.dep.VersionConstraint(); got != tt.want {
+			t.Errorf("VersionConstraint(%+v) = %q, want %q", tt.dep, got, tt.want)
+		}
+	}
+}
+
+func TestCheckVersionConstraint(t *testing.T) {
+	tests := []struct {
+		command     string
+		constraint  string
+		expectValid bool
+		expectError bool
+	}{
+		{"echo 0.13.1", ">= 0.10.0, < 0.20", true, false},
+		{"echo 0.21.0", ">= 0.10.0, < 0.20", false, false},
+		{"echo 0.9.0", ">= 0.10.0, <= 0.19.0", false, false},
+		{"echo 1.2", "1.2.x || 2.x", true, false},
+		{`echo {"Version": "local"}`, ">= 1.0.0", true, false},
+		{"echo 1.0.0", "not a range", false, true},
+		{"echo", ">= 1.0.0", false, true},
+	}
+
+	for _, tt := range tests {
+		valid, version, err := CheckVersionConstraint(tt.command, tt.constraint)
+		if (err != nil) != tt.expectError {
+			t.Errorf("%s against %q: unexpected error %v", tt.command, tt.constraint, err)
+			continue
+		}
+		if valid != tt.expectValid {
+			t.Errorf("%s against %q: expected valid=%t, got %t (version %s)", tt.command, tt.constraint, tt.expectValid, valid, version)
+		}
+	}
+}
+
+func TestCheckDependencyConstraint(t *testing.T) {
+	status, err := CheckDependency(Dependency{
+		Name:         "echo",
+		Command:      "echo",
+		MinVersion:   "1.0.0",
+		MaxVersion:   "1.9.0",
+		CheckCommand: "echo 2.0.0",
+	})
+	if err != nil {
+		t.Fatalf("CheckDependency failed: %v", err)
+	}
+	if status.Valid || !strings.Contains(status.Error, "does not satisfy >= 1.0.0, <= 1.9.0") {
+		t.Errorf("Expected version 2.0.0 to exceed the maximum, got %+v", status)
+	}
+}
@@ -0,0 +1,339 @@
+package deps
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+
+	"github.com/Masterminds/semver/v3"
+)
+
+// Tools installed by waffles live in a private prefix, one directory per
+// version, so they never clash with system installs:
+//
+//	~/.local/share/waffles/tools/<name>/<version>/  an installed version
+//	~/.local/share/waffles/bin/<name>               shim for the active version
+//
+// The pipeline resolves commands through the shim directory before PATH.
+
+// DataDir returns the waffles data directory, $XDG_DATA_HOME/waffles or
+// ~/.local/share/waffles
+func DataDir() (string, error) {
+	if dir := os.Getenv("XDG_DATA_HOME"); dir != "" {
+		return filepath.Join(dir, "waffles"), nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to find home directory: %w", err)
+	}
+	return filepath.Join(home, ".local", "share", "waffles"), nil
+}
+
+// ToolsDir returns the directory holding the installed tool versions
+func ToolsDir() (string, error) {
+	dir, err := DataDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "tools"), nil
+}
+
+// ShimDir returns the directory holding a shim for the active version of
+// each installed tool
+func ShimDir() (string, error) {
+	dir, err := DataDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "bin"), nil
+}
+
+// ToolDir returns the installation directory of one version of a tool
+func ToolDir(name, version string) (string, error) {
+	if name == "" || version == "" || strings.ContainsAny(name+version, `/\`) || name == ".." || version == ".." {
+		return "", fmt.Errorf("invalid tool name or version: %q %q", name, version)
+	}
+	dir, err := ToolsDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, name, version), nil
+}
+
+// ResolveCommand returns the shim of a tool installed by waffles, or name
+// unchanged so that it is looked up in PATH
+func ResolveCommand(name string) string {
+	dir, err := ShimDir()
+	if err != nil {
+		return name
+	}
+	shim := filepath.Join(dir, executableName(name))
+	if info, err := os.Stat(shim); err == nil && !info.IsDir() {
+		return shim
+	}
+	return name
+}
+
+// LookPath finds a command like exec.LookPath, preferring waffles' shims
+func LookPath(name string) (string, error) {
+	return exec.LookPath(ResolveCommand(name))
+}
+
+// ToolVersions returns the versions of a tool installed in the private
+// prefix, oldest first
+func ToolVersions(name string) ([]string, error) {
+	dir, err := ToolsDir()
+	if err != nil {
+		return nil, err
+	}
+	entries, err := os.ReadDir(filepath.Join(dir, name))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to list versions of %s: %w", name, err)
+	}
+
+	var versions []string
+	for _, entry := range entries {
+		if entry.IsDir() && !strings.HasPrefix(entry.Name(), ".") {
+			versions = append(versions, entry.Name())
+		}
+	}
+	sort.Slice(versions, func(i, j int) bool {
+		vi, errI := semver.NewVersion(versions[i])
+		vj, errJ := semver.NewVersion(versions[j])
+		if errI != nil || errJ != nil {
+			return versions[i] < versions[j]
+		}
+		return vi.LessThan(vj)
+	})
+	return versions, nil
+}
+
+// ActiveToolVersion returns the version that the shim of a tool points to,
+// or "" if waffles has not installed the tool
+func ActiveToolVersion(name string) string {
+	shimDir, err := ShimDir()
+	if err != nil {
+		return ""
+	}
+	toolsDir, err := ToolsDir()
+	if err != nil {
+		return ""
+	}
+	target, err := os.Readlink(filepath.Join(shimDir, executableName(name)))
+	if err != nil {
+		return ""
+	}
+	rel, err := filepath.Rel(filepath.Join(toolsDir, name), target)
+	if err != nil || strings.HasPrefix(rel, "..") {
+		return ""
+	}
+	version, _, _ := strings.Cut(filepath.ToSlash(rel), "/")
+	return version
+}
+
+// activateTool points the shim of a tool at the executable of an installed
+// version. Where symlinks are unavailable the executable is copied instead.
+func activateTool(name, target string) error {
+	dir, err := ShimDir()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(dir, 0750); err != nil {
+		return fmt.Errorf("failed to create shim directory: %w", err)
+	}
+
+	shim := filepath.Join(dir, executableName(name))
+	if err := os.Remove(shim); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to replace shim %s: %w", shim, err)
+	}
+	if err := os.Symlink(target, shim); err == nil {
+		return nil
+	}
+	return copyExecutable(target, shim)
+}
+
+// copyExecutable copies an executable file
+func copyExecutable(src, dst string) error {
+	in, err := os.Open(src) // #nosec G304 -- Executable inside the private tool prefix
+	if err != nil {
+		return fmt.Errorf("failed to open %s: %w", src, err)
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(dst, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0750) // #nosec G302 G304 -- Shim path in the private prefix, 0750 appropriate for executables
+	if err != nil {
+		return fmt.Errorf("failed to create %s: %w", dst, err)
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		_ = out.Close()
+		return fmt.Errorf("failed to copy %s: %w", src, err)
+	}
+	return out.Close()
+}
+
+// installPythonTool installs a Python package into its own virtual
+// environment in the private prefix and activates its command. args are
+// passed to pip install, such as "llm==0.13" or the path of a wheel.
+func installPythonTool(name, version string, args ...string) (string, error) {
+	dir, venv, pip, err := pythonToolCommands(name, version, args...)
+	if err != nil {
+		return "", err
+	}
+
+	// Virtual environments cannot be moved, so the new one is built in place
+	// while the previous one waits beside it until the new one works. The
+	// packages added to it, such as llm plugins, are carried over.
+	added := addedPackages(filepath.Dir(pip[0]))
+	previous, err := setAside(dir)
+	if err != nil {
+		return "", err
+	}
+	restore := func() {
+		_ = os.RemoveAll(dir)
+		if previous != "" {
+			_ = os.Rename(previous, dir)
+		}
+	}
+	pip = append(pip, added...)
+
+	if output, err := exec.Command(venv[0], venv[1:]...).CombinedOutput(); err != nil { // #nosec G204 -- Interpreter from PATH, directory in the private prefix
+		restore()
+		return "", fmt.Errorf("failed to create virtual environment: %w\nOutput: %s", err, string(output))
+	}
+	if output, err := exec.Command(pip[0], pip[1:]...).CombinedOutput(); err != nil { // #nosec G204 -- pip of the private virtual environment
+		restore()
+		if len(added) > 0 {
+			return "", fmt.Errorf("pip install failed, along with %s from the previous install: %w\nOutput: %s", strings.Join(added, " "), err, string(output))
+		}
+		return "", fmt.Errorf("pip install failed: %w\nOutput: %s", err, string(output))
+	}
+
+	target := filepath.Join(filepath.Dir(pip[0]), executableName(name))
+	if err := activateTool(name, target); err != nil {
+		restore()
+		return "", err
+	}
+	if previous != "" {
+		_ = os.RemoveAll(previous)
+	}
+	return dir, nil
+}
+
+// addedPackages lists the packages of a virtual environment, given its
+// executable directory, that no other package there requires, pinned to
+// their installed versions. It returns nil if there is no environment.
+func addedPackages(bin string) []string {
+	python := filepath.Join(bin, executableName("python"))
+	if _, err := os.Stat(python); err != nil {
+		return nil
+	}
+	output, err := exec.Command(python, "-m", "pip", "list", "--not-required", "--format=freeze").Output() // #nosec G204 -- Interpreter of the private virtual environment
+	if err != nil {
+		return nil
+	}
+
+	var packages []string
+	for _, line := range strings.Split(string(output), "\n") {
+		line = strings.TrimSpace(line)
+		name, _, _ := strings.Cut(line, "==")
+		switch strings.ToLower(name) {
+		case "", "pip", "setuptools", "wheel":
+			continue
+		}
+		packages = append(packages, line)
+	}
+	return packages
+}
+
+// setAside moves a previous install out of the way into a hidden sibling
+// directory, which ToolVersions ignores, and returns its path or "" if
+// there is none
+func setAside(dir string) (string, error) {
+	if _, err := os.Stat(dir); errors.Is(err, os.ErrNotExist) {
+		return "", nil
+	}
+	previous, err := os.MkdirTemp(filepath.Dir(dir), "."+filepath.Base(dir)+".old-*")
+	if err != nil {
+		return "", fmt.Errorf("failed to set aside previous install: %w", err)
+	}
+	if err := os.Remove(previous); err != nil {
+		return "", fmt.Errorf("failed to set aside previous install: %w", err)
+	}
+	if err := os.Rename(dir, previous); err != nil {
+		return "", fmt.Errorf("failed to set aside previous install: %w", err)
+	}
+	return previous, nil
+}
+
+// pythonToolCommands returns the directory of a Python tool in the private
+// prefix and the commands creating its virtual environment and installing
+// args into it
+func pythonToolCommands(name, version string, args ...string) (dir string, venv, pip []string, err error) {
+	python, err := findPython()
+	if err != nil {
+		return "", nil, nil, err
+	}
+	dir, err = ToolDir(name, version)
+	if err != nil {
+		return "", nil, nil, err
+	}
+
+	bin := filepath.Join(dir, "bin")
+	if runtime.GOOS == "windows" {
+		bin = filepath.Join(dir, "Scripts")
+	}
+	venv = []string{python, "-m", "venv", dir}
+	pip = append([]string{filepath.Join(bin, executableName("pip")), "install"}, args...)
+	return dir, venv, pip, nil
+}
+
+// llmPython returns the Python interpreter of the environment llm runs in,
+// into which its plugins are installed
+func llmPython() (string, error) {
+	llm, err := LookPath("llm")
+	if err != nil {
+		return "", fmt.Errorf("llm command not found - install llm CLI first")
+	}
+	if resolved, err := filepath.EvalSymlinks(llm); err == nil {
+		llm = resolved
+	}
+
+	// A script names its interpreter, a Windows launcher sits next to it
+	candidates := []string{scriptInterpreter(llm), filepath.Join(filepath.Dir(llm), executableName("python"))}
+	for _, python := range candidates {
+		if python == "" {
+			continue
+		}
+		if _, err := os.Stat(python); err == nil {
+			return python, nil
+		}
+	}
+	return "", fmt.Errorf("cannot find the Python interpreter of %s", llm)
+}
+
+// findPython returns the Python interpreter used to create virtual environments
+func findPython() (string, error) {
+	for _, name := range []string{"python3", "python"} {
+		if path, err := exec.LookPath(name); err == nil {
+			return path, nil
+		}
+	}
+	return "", fmt.Errorf("python3 not found - please install Python first")
+}
+
+// executableName returns the file name of an executable on this platform
+func executableName(name string) string {
+	if runtime.GOOS == "windows" && !strings.HasSuffix(name, ".exe") {
+		return name + ".exe"
+	}
+	return name
+}
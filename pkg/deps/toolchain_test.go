@@ -0,0 +1,191 @@
+package deps
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// installFakeVersion writes an executable for one version of a tool into
+// the private prefix and returns its path
+func installFakeVersion(t *testing.T, name, version string) string {
+	t.Helper()
+
+	dir, err := ToolDir(name, version)
+	if err != nil {
+		t.Fatalf("ToolDir failed: %v", err)
+	}
+	if err := os.MkdirAll(dir, 0750); err != nil {
+		t.Fatalf("Failed to create tool directory: %v", err)
+	}
+	path := filepath.Join(dir, executableName(name))
+	if err := os.WriteFile(path, []byte(name+" "+version), 0755); err != nil { // #nosec G306 -- Test executable
+		t.Fatalf("Failed to write executable: %v", err)
+	}
+	return path
+}
+
+func TestToolDirs(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_DATA_HOME", "")
+
+	dir, err := ToolDir("llm", "0.13")
+	if err != nil || dir != filepath.Join(home, ".local", "share", "waffles", "tools", "llm", "0.13") {
+		t.Errorf("Unexpected tool directory %q: %v", dir, err)
+	}
+
+	data := t.TempDir()
+	t.Setenv("XDG_DATA_HOME", data)
+	if dir, _ := ShimDir(); dir != filepath.Join(data, "waffles", "bin") {
+		t.Errorf("Expected the shim directory to follow XDG_DATA_HOME, got %q", dir)
+	}
+
+	for _, bad := range [][2]string{{"", "1.0"}, {"llm", ""}, {"../llm", "1.0"}, {"llm", ".."}} {
+		if _, err := ToolDir(bad[0], bad[1]); err == nil {
+			t.Errorf("Expected an error for %q", bad)
+		}
+	}
+}
+
+func TestActivateTool(t *testing.T) {
+	t.Setenv("XDG_DATA_HOME", t.TempDir())
+	t.Setenv("PATH", t.TempDir())
+
+	if ResolveCommand("tool") != "tool" || ActiveToolVersion("tool") != "" {
+		t.Fatal("Expected no shim before installing")
+	}
+
+	older := installFakeVersion(t, "tool", "1.9.0")
+	newer := installFakeVersion(t, "tool", "1.10.0")
+
+	for _, tt := range []struct {
+		target  string
+		version string
+	}{
+		{newer, "1.10.0"},
+		{older, "1.9.0"},
+	} {
+		if err := activateTool("tool", tt.target); err != nil {
+			t.Fatalf("activateTool failed: %v", err)
+		}
+		if active := ActiveToolVersion("tool"); active != tt.version {
+			t.Errorf("Expected %s to be active, got %q", tt.version, active)
+		}
+		data, err := os.ReadFile(ResolveCommand("tool"))
+		if err != nil || string(data) != "tool "+tt.version {
+			t.Errorf("Expected the shim to run %s, got %q: %v", tt.version, data, err)
+		}
+		if path, err := LookPath("tool"); err != nil || path != ResolveCommand("tool") {
+			t.Errorf("Expected LookPath to find the shim, got %q: %v", path, err)
+		}
+	}
+
+	versions, err := ToolVersions("tool")
+	if err != nil || !reflect.DeepEqual(versions, []string{"1.9.0", "1.10.0"}) {
+		t.Errorf("Expected versions in semver order, got %v: %v", versions, err)
+	}
+}
+
+func TestActivateInstalledVersion(t *testing.T) {
+	t.Setenv("XDG_DATA_HOME", t.TempDir())
+
+	if activateInstalledVersion("files2prompt", "1.2.0") {
+		t.Error("Expected a missing version not to be activated")
+	}
+
+	installFakeVersion(t, "files2prompt", "1.2.0")
+	if !activateInstalledVersion("files2prompt", "1.2.0") || ActiveToolVersion("files2prompt") != "1.2.0" {
+		t.Error("Expected the installed version to be activated")
+	}
+}
+
+// fakeVenvPython writes a python3 into bin whose virtual environments hold a
+// pip that records its arguments and installs a tool command, or fails when
+// PIP_FAIL is set, and whose pip list reports a plugin
+func fakeVenvPython(t *testing.T, bin string) {
+	t.Helper()
+
+	pip := `#!/bin/sh
+[ -z "$PIP_FAIL" ] || exit 1
+dir=$(dirname "$0")
+echo "$@" > "$dir/../installed"
+printf '#!/bin/sh\n' > "$dir/tool"
+chmod +x "$dir/tool"
+`
+	python := `#!/bin/sh
+case "$1 $2 $3" in
+"-m venv "*)
+	mkdir -p "$3/bin"
+	ln -s "$0" "$3/bin/python"
+	cp ` + filepath.Join(bin, "pip.sh") + ` "$3/bin/pip" ;;
+"-m pip list") printf 'pip==24.0\ntool-plugin==0.2\n' ;;
+*) exit 1 ;;
+esac
+`
+	for name, script := range map[string]string{"pip.sh": pip, "python3": python} {
+		if err := os.WriteFile(filepath.Join(bin, name), []byte(script), 0755); err != nil { // #nosec G306 -- Test executable
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+	}
+}
+
+func TestInstallPythonToolKeepsPreviousInstall(t *testing.T) {
+	t.Setenv("XDG_DATA_HOME", t.TempDir())
+	bin := t.TempDir()
+	t.Setenv("PATH", bin+string(os.PathListSeparator)+"/usr/bin:/bin")
+	fakeVenvPython(t, bin)
+
+	dir, err := installPythonTool("tool", "1.0", "tool==1.0")
+	if err != nil {
+		t.Fatalf("installPythonTool failed: %v", err)
+	}
+	if ActiveToolVersion("tool") != "1.0" {
+		t.Fatal("Expected the tool to be active")
+	}
+	marker := filepath.Join(dir, "marker")
+	if err := os.WriteFile(marker, nil, 0600); err != nil {
+		t.Fatalf("Failed to write marker: %v", err)
+	}
+	siblings := func() []string {
+		entries, _ := os.ReadDir(filepath.Dir(dir))
+		var names []string
+		for _, entry := range entries {
+			names = append(names, entry.Name())
+		}
+		return names
+	}
+
+	// A failed reinstall leaves the working one untouched
+	t.Setenv("PIP_FAIL", "1")
+	if _, err := installPythonTool("tool", "1.0", "tool==1.0"); err == nil || !strings.Contains(err.Error(), "tool-plugin==0.2") {
+		t.Fatalf("Expected pip to fail along with the carried-over plugin, got %v", err)
+	}
+	if _, err := os.Stat(marker); err != nil {
+		t.Errorf("Expected the previous install to be restored: %v", err)
+	}
+	if _, err := os.Stat(ResolveCommand("tool")); err != nil || ActiveToolVersion("tool") != "1.0" {
+		t.Errorf("Expected the shim to keep working: %v", err)
+	}
+	if names := siblings(); !reflect.DeepEqual(names, []string{"1.0"}) {
+		t.Errorf("Expected no leftovers, got %v", names)
+	}
+
+	// A successful one replaces it and reinstalls the added plugin
+	t.Setenv("PIP_FAIL", "")
+	if _, err := installPythonTool("tool", "1.0", "tool==1.0"); err != nil {
+		t.Fatalf("installPythonTool failed: %v", err)
+	}
+	if _, err := os.Stat(marker); !os.IsNotExist(err) {
+		t.Error("Expected a fresh virtual environment")
+	}
+	installed, _ := os.ReadFile(filepath.Join(dir, "installed")) // #nosec G304 -- Test file
+	if got := strings.TrimSpace(string(installed)); got != "install tool==1.0 tool-plugin==0.2" {
+		t.Errorf("Expected the plugin to be carried over, got %q", got)
+	}
+	if names := siblings(); !reflect.DeepEqual(names, []string{"1.0"}) {
+		t.Errorf("Expected no leftovers, got %v", names)
+	}
+}
@@ -5,9 +5,16 @@ type Dependency struct {
 	Name         string   // Display name
 	Command      string   // Command to check in PATH
 	MinVersion   string   // Minimum required version
+	MaxVersion   string   // Maximum allowed version
+	Constraint   string   // Further semver range, such as ">=0.13, <1.0"
 	CheckCommand string   // Command to check version
 	InstallURL   string   // URL with installation instructions
 	Plugins      []string // Required plugins (for llm CLI)
+	Repository   string   // GitHub "owner/repo" publishing binary releases
+	Package      string   // PyPI package name of Python tools
+	Formula      string   // Homebrew formula
+	GoPackage    string   // Package path for go install
+	Changelog    string   // Release notes, unless on the GitHub releases page
 }
 
 // DependencyStatus represents the status of a dependency
@@ -15,6 +22,7 @@ type DependencyStatus struct {
 	Name      string         `json:"name"`
 	Installed bool           `json:"installed"`
 	Version   string         `json:"version"`
+	Path      string         `json:"path,omitempty"`
 	Valid     bool           `json:"valid"`
 	Error     string         `json:"error,omitempty"`
 	Plugins   []PluginStatus `json:"plugins,omitempty"`
@@ -42,8 +50,9 @@ type InstallationResult struct {
 	Error   string `json:"error,omitempty"`
 }
 
-// RequiredDependencies returns the list of all required dependencies
-func RequiredDependencies() []Dependency {
+// builtinDependencies returns the tools waffles requires unless configured
+// otherwise
+func builtinDependencies() []Dependency {
 	return []Dependency{
 		{
 			Name:         "wheresmyprompt",
@@ -52,6 +61,9 @@ func RequiredDependencies() []Dependency {
 			CheckCommand: "wheresmyprompt version",
 			InstallURL:   "https://github.com/toozej/wheresmyprompt#installation",
 			Plugins:      []string{},
+			Repository:   "toozej/wheresmyprompt",
+			Formula:      "toozej/tap/wheresmyprompt",
+			GoPackage:    "github.com/toozej/wheresmyprompt/cmd/wheresmyprompt",
 		},
 		{
 			Name:         "files2prompt",
@@ -60,6 +72,9 @@ func RequiredDependencies() []Dependency {
 			CheckCommand: "files2prompt version",
 			InstallURL:   "https://github.com/toozej/files2prompt#installation",
 			Plugins:      []string{},
+			Repository:   "toozej/files2prompt",
+			Formula:      "toozej/tap/files2prompt",
+			GoPackage:    "github.com/toozej/files2prompt/cmd/files2prompt",
 		},
 		{
 			Name:         "llm",
@@ -76,6 +91,19 @@ func RequiredDependencies() []Dependency {
 				"llm-fragments-go",
 				"llm-commit",
 			},
+			Package:   "llm",
+			Formula:   "llm",
+			Changelog: "https://llm.datasette.io/en/stable/changelog.html",
 		},
 	}
 }
+
+// builtinDependency returns the built-in dependency with the given name
+func builtinDependency(name string) Dependency {
+	for _, dep := range builtinDependencies() {
+		if dep.Name == name {
+			return dep
+		}
+	}
+	return Dependency{Name: name, Command: name}
+}
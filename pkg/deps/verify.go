@@ -0,0 +1,299 @@
+package deps
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"golang.org/x/crypto/blake2b"
+)
+
+// ChecksumsFile is the name of the release asset listing the SHA-256
+// checksums of all other assets, in sha256sum format
+const ChecksumsFile = "checksums.txt"
+
+// Signature assets published next to the checksums file
+const (
+	minisignSignatureFile   = ChecksumsFile + ".minisig"
+	cosignSignatureFile     = ChecksumsFile + ".sig"
+	cosignCertificateFile   = ChecksumsFile + ".pem"
+	githubActionsOIDCIssuer = "https://token.actions.githubusercontent.com"
+)
+
+// ReleaseKeys are the trusted public keys for the release signatures of one
+// repository. Cosign keyless signatures need no key: their certificate is
+// checked against the repository's GitHub Actions identity instead.
+type ReleaseKeys struct {
+	Minisign string // minisign public key, as printed by minisign -G
+	Cosign   string // Path or KMS URI of a cosign public key
+}
+
+// VerificationError reports a release asset that failed integrity or
+// signature verification and must not be installed
+type VerificationError struct {
+	Asset  string
+	Reason string
+}
+
+func (e *VerificationError) Error() string {
+	return fmt.Sprintf("verification of %s failed: %s", e.Asset, e.Reason)
+}
+
+// errAssetNotFound is returned when a release does not publish an asset
+var errAssetNotFound = errors.New("asset not found")
+
+// verifyRelease checks a downloaded release asset against the release
+// checksums, after verifying any signature published for the checksums
+func (p *PlatformInstaller) verifyRelease(owner, repo, tag, asset, path string) error {
+	sums, err := p.releaseChecksums(owner, repo, tag)
+	if err != nil {
+		return err
+	}
+	expected, ok := sums[asset]
+	if !ok {
+		return &VerificationError{Asset: asset, Reason: "no checksum listed in " + ChecksumsFile}
+	}
+	return verifyChecksum(asset, path, expected)
+}
+
+// releaseChecksums downloads and parses the checksums of a release, after
+// verifying any signature published for them
+func (p *PlatformInstaller) releaseChecksums(owner, repo, tag string) (map[string]string, error) {
+	checksums, err := p.fetchReleaseAsset(owner, repo, tag, ChecksumsFile)
+	if errors.Is(err, errAssetNotFound) {
+		return nil, &VerificationError{Asset: ChecksumsFile, Reason: "release does not publish " + ChecksumsFile}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to download %s: %w", ChecksumsFile, err)
+	}
+
+	if err := p.verifyChecksumsSignature(owner, repo, tag, checksums); err != nil {
+		return nil, err
+	}
+
+	sums, err := parseChecksums(checksums)
+	if err != nil {
+		return nil, &VerificationError{Asset: ChecksumsFile, Reason: err.Error()}
+	}
+	return sums, nil
+}
+
+// verifyChecksum checks the SHA-256 digest of a downloaded asset
+func verifyChecksum(asset, path, expected string) error {
+	actual, err := sha256File(path)
+	if err != nil {
+		return err
+	}
+	if actual != strings.ToLower(expected) {
+		return &VerificationError{Asset: asset, Reason: fmt.Sprintf("SHA-256 mismatch: expected %s, got %s", expected, actual)}
+	}
+
+	fmt.Printf("✓ SHA-256 checksum of %s verified\n", asset)
+	return nil
+}
+
+// verifyChecksumsSignature verifies the minisign and cosign signatures of the
+// checksums file that the release publishes. A signature that does not match
+// is an error; one that cannot be checked, for lack of a trusted key or of the
+// cosign binary, only warns, since the checksums still protect the download.
+func (p *PlatformInstaller) verifyChecksumsSignature(owner, repo, tag string, checksums []byte) error {
+	keys := p.ReleaseKeys[owner+"/"+repo]
+
+	minisig, err := p.fetchReleaseAsset(owner, repo, tag, minisignSignatureFile)
+	switch {
+	case errors.Is(err, errAssetNotFound):
+	case err != nil:
+		return fmt.Errorf("failed to download %s: %w", minisignSignatureFile, err)
+	case keys.Minisign == "":
+		fmt.Printf("Warning: %s is published but no trusted minisign key is configured for %s/%s\n", minisignSignatureFile, owner, repo)
+	default:
+		if err := verifyMinisign(keys.Minisign, checksums, minisig); err != nil {
+			return &VerificationError{Asset: ChecksumsFile, Reason: "minisign: " + err.Error()}
+		}
+		fmt.Printf("✓ minisign signature of %s verified\n", ChecksumsFile)
+	}
+
+	sig, err := p.fetchReleaseAsset(owner, repo, tag, cosignSignatureFile)
+	if errors.Is(err, errAssetNotFound) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to download %s: %w", cosignSignatureFile, err)
+	}
+	cert, err := p.fetchReleaseAsset(owner, repo, tag, cosignCertificateFile)
+	if err != nil && !errors.Is(err, errAssetNotFound) {
+		return fmt.Errorf("failed to download %s: %w", cosignCertificateFile, err)
+	}
+	if cert == nil && keys.Cosign == "" {
+		fmt.Printf("Warning: %s is published but no certificate or trusted cosign key is available for %s/%s\n", cosignSignatureFile, owner, repo)
+		return nil
+	}
+
+	verified, err := verifyCosign(owner, repo, checksums, sig, cert, keys.Cosign)
+	if err != nil {
+		return &VerificationError{Asset: ChecksumsFile, Reason: "cosign: " + err.Error()}
+	}
+	if verified {
+		fmt.Printf("✓ cosign signature of %s verified\n", ChecksumsFile)
+	} else {
+		fmt.Printf("Warning: %s is published but cosign is not installed; skipping signature verification\n", cosignSignatureFile)
+	}
+	return nil
+}
+
+// parseChecksums parses sha256sum output into a map of file name to
+// lowercase hex digest
+func parseChecksums(data []byte) (map[string]string, error) {
+	sums := make(map[string]string)
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		digest, name, ok := strings.Cut(line, " ")
+		digest = strings.ToLower(digest)
+		if _, err := hex.DecodeString(digest); !ok || err != nil || len(digest) != sha256.Size*2 {
+			return nil, fmt.Errorf("malformed checksum line: %q", line)
+		}
+		// sha256sum marks binary mode with a leading '*'
+		name = strings.TrimPrefix(strings.TrimSpace(name), "*")
+		sums[name] = digest
+	}
+	return sums, scanner.Err()
+}
+
+// sha256File returns the lowercase hex SHA-256 digest of a file
+func sha256File(path string) (string, error) {
+	file, err := os.Open(path) // #nosec G304 -- Path of a downloaded temporary file
+	if err != nil {
+		return "", fmt.Errorf("failed to open %s: %w", path, err)
+	}
+	defer file.Close()
+
+	hash := sha256.New()
+	if _, err := io.Copy(hash, file); err != nil {
+		return "", fmt.Errorf("failed to hash %s: %w", path, err)
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// verifyMinisign verifies a minisign signature file over message with a
+// minisign public key, including the signature of its trusted comment
+func verifyMinisign(publicKey string, message, signature []byte) error {
+	key, err := decodeMinisignLine(publicKey, 42)
+	if err != nil {
+		return fmt.Errorf("invalid public key: %w", err)
+	}
+	if string(key[:2]) != "Ed" {
+		return fmt.Errorf("unsupported public key algorithm %q", key[:2])
+	}
+
+	lines := strings.Split(strings.TrimSpace(string(signature)), "\n")
+	if len(lines) != 4 || !strings.HasPrefix(lines[2], "trusted comment: ") {
+		return errors.New("malformed signature file")
+	}
+	sig, err := decodeMinisignLine(lines[1], 74)
+	if err != nil {
+		return fmt.Errorf("invalid signature: %w", err)
+	}
+	globalSig, err := decodeMinisignLine(lines[3], ed25519.SignatureSize)
+	if err != nil {
+		return fmt.Errorf("invalid trusted comment signature: %w", err)
+	}
+
+	if !bytes.Equal(sig[2:10], key[2:10]) {
+		return fmt.Errorf("signed with key %X, expected %X", sig[2:10], key[2:10])
+	}
+	pub := ed25519.PublicKey(key[10:])
+
+	switch string(sig[:2]) {
+	case "Ed":
+	case "ED":
+		digest := blake2b.Sum512(message)
+		message = digest[:]
+	default:
+		return fmt.Errorf("unsupported signature algorithm %q", sig[:2])
+	}
+	if !ed25519.Verify(pub, message, sig[10:]) {
+		return errors.New("signature does not match")
+	}
+
+	trustedComment := strings.TrimPrefix(strings.TrimRight(lines[2], "\r"), "trusted comment: ")
+	signed := append(append([]byte{}, sig[10:]...), trustedComment...)
+	if !ed25519.Verify(pub, signed, globalSig) {
+		return errors.New("trusted comment signature does not match")
+	}
+	return nil
+}
+
+// decodeMinisignLine decodes the base64 payload of a minisign key or
+// signature, skipping an "untrusted comment" line if present
+func decodeMinisignLine(text string, size int) ([]byte, error) {
+	lines := strings.Split(strings.TrimSpace(text), "\n")
+	line := strings.TrimSpace(lines[len(lines)-1])
+	data, err := base64.StdEncoding.DecodeString(line)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) != size {
+		return nil, fmt.Errorf("expected %d bytes, got %d", size, len(data))
+	}
+	return data, nil
+}
+
+// verifyCosign verifies a cosign blob signature of the checksums with the
+// cosign CLI, either keyless against the repository's GitHub Actions workflow
+// identity or with a trusted public key. It reports false without error when
+// cosign is not installed.
+func verifyCosign(owner, repo string, checksums, signature, certificate []byte, key string) (bool, error) {
+	cosign, err := exec.LookPath("cosign")
+	if err != nil {
+		return false, nil
+	}
+
+	dir, err := os.MkdirTemp("", "waffles-cosign-*")
+	if err != nil {
+		return false, fmt.Errorf("failed to create temp dir: %w", err)
+	}
+	defer os.RemoveAll(dir)
+
+	files := map[string][]byte{ChecksumsFile: checksums, cosignSignatureFile: signature}
+	if certificate != nil {
+		files[cosignCertificateFile] = certificate
+	}
+	for name, data := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), data, 0600); err != nil {
+			return false, fmt.Errorf("failed to write %s: %w", name, err)
+		}
+	}
+
+	args := []string{"verify-blob", "--signature", filepath.Join(dir, cosignSignatureFile)}
+	if key != "" {
+		args = append(args, "--key", key)
+	} else {
+		identity := "^https://github.com/" + regexp.QuoteMeta(owner+"/"+repo) + "/"
+		args = append(args,
+			"--certificate", filepath.Join(dir, cosignCertificateFile),
+			"--certificate-identity-regexp", identity,
+			"--certificate-oidc-issuer", githubActionsOIDCIssuer)
+	}
+	args = append(args, filepath.Join(dir, ChecksumsFile))
+
+	output, err := exec.Command(cosign, args...).CombinedOutput() // #nosec G204 -- Arguments are temp file paths and the repository identity
+	if err != nil {
+		return false, fmt.Errorf("%v: %s", err, strings.TrimSpace(string(output)))
+	}
+	return true, nil
+}
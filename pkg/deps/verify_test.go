@@ -0,0 +1,319 @@
+package deps
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"golang.org/x/crypto/blake2b"
+)
+
+// newReleaseServer serves the given assets as the latest release of
+// toozej/tool and returns an installer that downloads from it
+func newReleaseServer(t *testing.T, assets map[string][]byte) *PlatformInstaller {
+	t.Helper()
+
+	names := make([]string, 0, len(assets))
+	for name := range assets {
+		names = append(names, name)
+	}
+
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/api/repos/toozej/tool/releases/latest" {
+			_, _ = w.Write(releaseJSON(t, "v1.0.0", server.URL+"/toozej/tool/releases/download/v1.0.0", names...))
+			return
+		}
+		name, ok := strings.CutPrefix(r.URL.Path, "/toozej/tool/releases/download/v1.0.0/")
+		data, found := assets[name]
+		if !ok || !found {
+			http.NotFound(w, r)
+			return
+		}
+		_, _ = w.Write(data)
+	}))
+	t.Cleanup(server.Close)
+
+	// Install into a temporary prefix and keep any real cosign off PATH
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("XDG_DATA_HOME", "")
+	t.Setenv("PATH", t.TempDir())
+
+	return &PlatformInstaller{
+		OS:             "linux",
+		Architecture:   "amd64",
+		ReleaseBaseURL: server.URL,
+		GitHubAPIURL:   server.URL + "/api",
+		HTTPClient:     server.Client(),
+	}
+}
+
+// releaseJSON returns GitHub release metadata listing assets downloaded
+// from baseURL
+func releaseJSON(t *testing.T, tag, baseURL string, assets ...string) []byte {
+	t.Helper()
+
+	type asset struct {
+		Name string `json:"name"`
+		URL  string `json:"browser_download_url"`
+	}
+	release := struct {
+		TagName string  `json:"tag_name"`
+		Assets  []asset `json:"assets"`
+	}{TagName: tag, Assets: []asset{}}
+	for _, name := range assets {
+		release.Assets = append(release.Assets, asset{Name: name, URL: baseURL + "/" + name})
+	}
+
+	data, err := json.Marshal(release)
+	if err != nil {
+		t.Fatalf("Failed to marshal release: %v", err)
+	}
+	return data
+}
+
+// releaseArchive builds a tar.gz archive holding one executable
+func releaseArchive(t *testing.T, name, content string) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	if err := tw.WriteHeader(&tar.Header{Name: name, Mode: 0755, Size: int64(len(content)), Typeflag: tar.TypeReg}); err != nil {
+		t.Fatalf("Failed to write tar header: %v", err)
+	}
+	if _, err := tw.Write([]byte(content)); err != nil {
+		t.Fatalf("Failed to write tar entry: %v", err)
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatalf("Failed to close tar writer: %v", err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatalf("Failed to close gzip writer: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func checksumLine(name string, data []byte) string {
+	sum := sha256.Sum256(data)
+	return fmt.Sprintf("%s  %s\n", hex.EncodeToString(sum[:]), name)
+}
+
+// minisignKey generates a minisign key pair, returning the public key text
+func minisignKey(t *testing.T) (string, ed25519.PrivateKey, []byte) {
+	t.Helper()
+
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate key: %v", err)
+	}
+	keyID := []byte{1, 2, 3, 4, 5, 6, 7, 8}
+	key := append(append([]byte("Ed"), keyID...), pub...)
+	return "untrusted comment: minisign public key\n" + base64.StdEncoding.EncodeToString(key), priv, keyID
+}
+
+// minisignSign produces a prehashed minisign signature file
+func minisignSign(priv ed25519.PrivateKey, keyID, message []byte) []byte {
+	digest := blake2b.Sum512(message)
+	sig := ed25519.Sign(priv, digest[:])
+	comment := "timestamp:1700000000\tfile:checksums.txt"
+	global := ed25519.Sign(priv, append(append([]byte{}, sig...), comment...))
+
+	return []byte(fmt.Sprintf("untrusted comment: signature\n%s\ntrusted comment: %s\n%s\n",
+		base64.StdEncoding.EncodeToString(append(append([]byte("ED"), keyID...), sig...)),
+		comment,
+		base64.StdEncoding.EncodeToString(global)))
+}
+
+const testAsset = "tool_linux_x86_64.tar.gz"
+
+// installedTool returns the content of the active tool binary, or ""
+func installedTool(t *testing.T) string {
+	t.Helper()
+	data, err := os.ReadFile(ResolveCommand("tool"))
+	if err != nil {
+		return ""
+	}
+	return string(data)
+}
+
+func TestInstallBinaryFromGitHubVerifiesChecksum(t *testing.T) {
+	archive := releaseArchive(t, "tool", "#!/bin/sh\necho tool\n")
+	installer := newReleaseServer(t, map[string][]byte{
+		testAsset:     archive,
+		ChecksumsFile: []byte(checksumLine("tool_darwin_arm64.tar.gz", []byte("other")) + checksumLine(testAsset, archive)),
+	})
+
+	result, err := installer.installBinaryFromGitHub("toozej", "tool", "tool")
+	if err != nil || !result.Success {
+		t.Fatalf("Expected successful install, got %+v: %v", result, err)
+	}
+	if installedTool(t) != "#!/bin/sh\necho tool\n" {
+		t.Errorf("Expected the binary to be installed")
+	}
+}
+
+func TestInstallBinaryFromGitHubRefusesUnverified(t *testing.T) {
+	archive := releaseArchive(t, "tool", "tampered")
+
+	tests := []struct {
+		name   string
+		assets map[string][]byte
+	}{
+		{"checksum mismatch", map[string][]byte{
+			testAsset:     archive,
+			ChecksumsFile: []byte(checksumLine(testAsset, []byte("original"))),
+		}},
+		{"no checksums file", map[string][]byte{
+			testAsset: archive,
+		}},
+		{"asset not listed", map[string][]byte{
+			testAsset:     archive,
+			ChecksumsFile: []byte(checksumLine("tool_windows_x86_64.zip", archive)),
+		}},
+		{"malformed checksums", map[string][]byte{
+			testAsset:     archive,
+			ChecksumsFile: []byte("not a checksum\n"),
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			installer := newReleaseServer(t, tt.assets)
+
+			result, err := installer.installBinaryFromGitHub("toozej", "tool", "tool")
+			var verr *VerificationError
+			if !errors.As(err, &verr) {
+				t.Fatalf("Expected a VerificationError, got %v", err)
+			}
+			if result.Success || !strings.Contains(result.Error, "Refusing to install") {
+				t.Errorf("Expected a refused install, got %+v", result)
+			}
+			if installedTool(t) != "" {
+				t.Error("Expected nothing to be installed")
+			}
+		})
+	}
+}
+
+func TestInstallBinaryFromGitHubMinisign(t *testing.T) {
+	archive := releaseArchive(t, "tool", "signed")
+	checksums := []byte(checksumLine(testAsset, archive))
+	publicKey, priv, keyID := minisignKey(t)
+	_, otherPriv, _ := minisignKey(t)
+
+	tests := []struct {
+		name      string
+		signature []byte
+		key       string
+		wantErr   bool
+	}{
+		{"valid signature", minisignSign(priv, keyID, checksums), publicKey, false},
+		{"wrong key", minisignSign(otherPriv, keyID, checksums), publicKey, true},
+		{"signature over other checksums", minisignSign(priv, keyID, []byte("other")), publicKey, true},
+		{"no trusted key", minisignSign(otherPriv, keyID, checksums), "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			installer := newReleaseServer(t, map[string][]byte{
+				testAsset:             archive,
+				ChecksumsFile:         checksums,
+				minisignSignatureFile: tt.signature,
+			})
+			if tt.key != "" {
+				installer.ReleaseKeys = map[string]ReleaseKeys{"toozej/tool": {Minisign: tt.key}}
+			}
+
+			_, err := installer.installBinaryFromGitHub("toozej", "tool", "tool")
+			if tt.wantErr {
+				var verr *VerificationError
+				if !errors.As(err, &verr) || !strings.Contains(err.Error(), "minisign") {
+					t.Fatalf("Expected a minisign VerificationError, got %v", err)
+				}
+				if installedTool(t) != "" {
+					t.Error("Expected nothing to be installed")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Expected successful install, got %v", err)
+			}
+			if installedTool(t) != "signed" {
+				t.Error("Expected the binary to be installed")
+			}
+		})
+	}
+}
+
+func TestInstallBinaryFromGitHubCosignUnavailable(t *testing.T) {
+	archive := releaseArchive(t, "tool", "cosigned")
+	installer := newReleaseServer(t, map[string][]byte{
+		testAsset:             archive,
+		ChecksumsFile:         []byte(checksumLine(testAsset, archive)),
+		cosignSignatureFile:   []byte("signature"),
+		cosignCertificateFile: []byte("certificate"),
+	})
+
+	// Without cosign on PATH the checksum alone protects the install
+	if _, err := installer.installBinaryFromGitHub("toozej", "tool", "tool"); err != nil {
+		t.Fatalf("Expected successful install, got %v", err)
+	}
+	if installedTool(t) != "cosigned" {
+		t.Error("Expected the binary to be installed")
+	}
+}
+
+func TestInstallBinaryFromGitHubCosignMismatch(t *testing.T) {
+	archive := releaseArchive(t, "tool", "cosigned")
+	installer := newReleaseServer(t, map[string][]byte{
+		testAsset:             archive,
+		ChecksumsFile:         []byte(checksumLine(testAsset, archive)),
+		cosignSignatureFile:   []byte("signature"),
+		cosignCertificateFile: []byte("certificate"),
+	})
+
+	// A stand-in cosign that rejects every signature
+	script := "#!/bin/sh\necho 'error: invalid signature' >&2\nexit 1\n"
+	if err := os.WriteFile(filepath.Join(os.Getenv("PATH"), "cosign"), []byte(script), 0755); err != nil { // #nosec G306 -- Test executable
+		t.Fatalf("Failed to write fake cosign: %v", err)
+	}
+
+	_, err := installer.installBinaryFromGitHub("toozej", "tool", "tool")
+	var verr *VerificationError
+	if !errors.As(err, &verr) || !strings.Contains(err.Error(), "invalid signature") {
+		t.Fatalf("Expected a cosign VerificationError, got %v", err)
+	}
+	if installedTool(t) != "" {
+		t.Error("Expected nothing to be installed")
+	}
+}
+
+func TestParseChecksums(t *testing.T) {
+	digest := strings.Repeat("ab", sha256.Size)
+	sums, err := parseChecksums([]byte(digest + "  a.tar.gz\n\n" + strings.ToUpper(digest) + " *b.zip\n"))
+	if err != nil {
+		t.Fatalf("parseChecksums failed: %v", err)
+	}
+	if sums["a.tar.gz"] != digest || sums["b.zip"] != digest {
+		t.Errorf("Unexpected checksums: %v", sums)
+	}
+
+	if _, err := parseChecksums([]byte("abc  short.tar.gz\n")); err == nil {
+		t.Error("Expected an error for a short digest")
+	}
+}
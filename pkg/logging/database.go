@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
@@ -13,6 +14,25 @@ import (
 
 // NewDatabase creates a new database instance and initializes the schema
 func NewDatabase(path string) (*Database, error) {
+	database, err := OpenDatabase(path)
+	if err != nil {
+		return nil, err
+	}
+
+	// Initialize schema
+	if err := database.InitializeSchema(); err != nil {
+		if closeErr := database.db.Close(); closeErr != nil {
+			return nil, fmt.Errorf("failed to initialize schema: %w (close error: %v)", err, closeErr)
+		}
+		return nil, fmt.Errorf("failed to initialize schema: %w", err)
+	}
+
+	return database, nil
+}
+
+// OpenDatabase opens a database without applying migrations, so maintenance
+// commands can inspect and repair databases at any schema version
+func OpenDatabase(path string) (*Database, error) {
 	// Ensure directory exists
 	if err := os.MkdirAll(filepath.Dir(path), 0750); err != nil {
 		return nil, fmt.Errorf("failed to create database directory: %w", err)
@@ -32,20 +52,10 @@ func NewDatabase(path string) (*Database, error) {
 		return nil, fmt.Errorf("failed to ping database: %w", err)
 	}
 
-	database := &Database{
+	return &Database{
 		path: path,
 		db:   db,
-	}
-
-	// Initialize schema
-	if err := database.InitializeSchema(); err != nil {
-		if closeErr := db.Close(); closeErr != nil {
-			return nil, fmt.Errorf("failed to initialize schema: %w (close error: %v)", err, closeErr)
-		}
-		return nil, fmt.Errorf("failed to initialize schema: %w", err)
-	}
-
-	return database, nil
+	}, nil
 }
 
 // InitializeSchema creates tables and runs migrations
@@ -67,69 +77,20 @@ func (d *Database) InitializeSchema() error {
 		return fmt.Errorf("failed to disable foreign keys: %w", err)
 	}
 
-	// Check if schema version table exists
-	var versionTableExists bool
-	err := d.db.QueryRow(`
-		SELECT COUNT(*) > 0 
-		FROM sqlite_master 
-		WHERE type='table' AND name='schema_version'
-	`).Scan(&versionTableExists)
-	if err != nil {
-		return fmt.Errorf("failed to check schema version table: %w", err)
-	}
-
-	// Create schema version table if it doesn't exist
-	if !versionTableExists {
-		_, err := d.db.Exec(`
-			CREATE TABLE schema_version (
-				version INTEGER PRIMARY KEY,
-				applied_at DATETIME DEFAULT CURRENT_TIMESTAMP
-			)
-		`)
-		if err != nil {
-			return fmt.Errorf("failed to create schema version table: %w", err)
-		}
+	if err := d.ensureVersionTable(); err != nil {
+		return err
 	}
 
 	// Get current schema version
-	currentVersion := 0
-	err = d.db.QueryRow("SELECT COALESCE(MAX(version), 0) FROM schema_version").Scan(&currentVersion)
+	currentVersion, err := d.SchemaVersion()
 	if err != nil {
-		return fmt.Errorf("failed to get current schema version: %w", err)
+		return err
 	}
 
 	// Apply migrations
-	targetVersion := GetCurrentSchemaVersion()
-	migrations := GetRequiredMigrations(currentVersion, targetVersion)
-
-	for i, migration := range migrations {
-		version := currentVersion + i + 1
-
-		// Begin transaction
-		tx, err := d.db.Begin()
-		if err != nil {
-			return fmt.Errorf("failed to begin migration transaction: %w", err)
-		}
-
-		// Execute migration
-		if _, err := tx.Exec(migration); err != nil {
-			if rollbackErr := tx.Rollback(); rollbackErr != nil {
-				return fmt.Errorf("failed to execute migration %d: %w (rollback error: %v)", version, err, rollbackErr)
-			}
-			return fmt.Errorf("failed to execute migration %d: %w", version, err)
-		}
-
-		// Record migration
-		if _, err := tx.Exec("INSERT INTO schema_version (version) VALUES (?)", version); err != nil {
-			if rollbackErr := tx.Rollback(); rollbackErr != nil {
-				return fmt.Errorf("failed to record migration %d: %w (rollback error: %v)", version, err, rollbackErr)
-			}
-			return fmt.Errorf("failed to record migration %d: %w", version, err)
-		}
-
-		// Commit transaction
-		if err := tx.Commit(); err != nil {
-			return fmt.Errorf("failed to commit migration %d: %w", version, err)
+	for version := currentVersion + 1; version <= GetCurrentSchemaVersion(); version++ {
+		if err := d.applyMigration(version, true); err != nil {
+			return err
 		}
 	}
 
@@ -150,32 +111,33 @@ func (d *Database) LogExecution(exec *WafflesExecution) error {
 		exec.Updated = now
 	}
 
-	// Handle null conversation_id
-	var conversationID interface{}
-	if exec.ConversationID == "" {
-		conversationID = nil
-	} else {
-		conversationID = exec.ConversationID
+	if err := insertExecution(d.db, exec); err != nil {
+		return fmt.Errorf("failed to log execution: %w", err)
 	}
 
-	_, err := d.db.Exec(`
+	return nil
+}
+
+// execer is implemented by both *sql.DB and *sql.Tx
+type execer interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+}
+
+// insertExecution inserts a single execution row as-is
+func insertExecution(db execer, exec *WafflesExecution) error {
+	_, err := db.Exec(`
 		INSERT INTO waffles_executions (
 			id, conversation_id, command_args, wheresmyprompt_query,
-			files2prompt_args, llm_args, detected_language, file_count,
-			execution_time_ms, success, error_message, model_used,
-			provider_used, created, updated
-		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
-		exec.ID, conversationID, exec.CommandArgs, exec.WheresmypromptQuery,
-		exec.Files2promptArgs, exec.LLMArgs, exec.DetectedLanguage, exec.FileCount,
-		exec.ExecutionTimeMS, exec.Success, exec.ErrorMessage, exec.ModelUsed,
-		exec.ProviderUsed, exec.Created, exec.Updated,
+			wheresmyprompt_args, files2prompt_args, llm_args, detected_language,
+			file_count, execution_time_ms, success, error_message, model_used,
+			provider_used, parent_execution_id, source, created, updated
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		exec.ID, nullString(exec.ConversationID), exec.CommandArgs, exec.WheresmypromptQuery,
+		exec.WheresmypromptArgs, exec.Files2promptArgs, exec.LLMArgs, exec.DetectedLanguage,
+		exec.FileCount, exec.ExecutionTimeMS, exec.Success, exec.ErrorMessage, exec.ModelUsed,
+		exec.ProviderUsed, nullString(exec.ParentExecutionID), nullString(exec.Source), exec.Created, exec.Updated,
 	)
-
-	if err != nil {
-		return fmt.Errorf("failed to log execution: %w", err)
-	}
-
-	return nil
+	return err
 }
 
 // LogFiles records files processed during an execution
@@ -270,6 +232,70 @@ func (d *Database) LogSteps(executionID string, steps []WafflesStep) error {
 	return tx.Commit()
 }
 
+// InsertBatch writes executions together with their files and steps in a
+// single transaction, keeping their existing IDs and timestamps
+func (d *Database) InsertBatch(executions []WafflesExecution, files map[string][]WafflesFile, steps map[string][]WafflesStep) error {
+	tx, err := d.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin batch transaction: %w", err)
+	}
+	defer func() {
+		// Only rollback if transaction hasn't been committed
+		_ = tx.Rollback() // Ignore rollback errors on deferred cleanup
+	}()
+
+	for i := range executions {
+		if err := insertExecutionDetails(tx, &executions[i], files[executions[i].ID], steps[executions[i].ID]); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// insertExecutionDetails inserts an execution with its files and steps
+func insertExecutionDetails(db execer, exec *WafflesExecution, files []WafflesFile, steps []WafflesStep) error {
+	if err := insertExecution(db, exec); err != nil {
+		return fmt.Errorf("failed to insert execution %s: %w", exec.ID, err)
+	}
+
+	for _, file := range files {
+		if file.ID == "" {
+			file.ID = uuid.New().String()
+		}
+		_, err := db.Exec(`
+			INSERT INTO waffles_files (
+				id, execution_id, file_path, file_size,
+				included, exclusion_reason, created
+			) VALUES (?, ?, ?, ?, ?, ?, ?)`,
+			file.ID, exec.ID, file.FilePath, file.FileSize,
+			file.Included, file.ExclusionReason, file.Created,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to insert file %s: %w", file.FilePath, err)
+		}
+	}
+
+	for _, step := range steps {
+		if step.ID == "" {
+			step.ID = uuid.New().String()
+		}
+		_, err := db.Exec(`
+			INSERT INTO waffles_steps (
+				id, execution_id, tool, command, output, error_output,
+				success, duration_ms, step_order, created
+			) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+			step.ID, exec.ID, step.Tool, step.Command, step.Output,
+			step.ErrorOutput, step.Success, step.DurationMS, step.StepOrder, step.Created,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to insert step %s: %w", step.Tool, err)
+		}
+	}
+
+	return nil
+}
+
 // UpdateExecution updates an existing execution record
 func (d *Database) UpdateExecution(exec *WafflesExecution) error {
 	exec.Updated = time.Now()
@@ -277,15 +303,16 @@ func (d *Database) UpdateExecution(exec *WafflesExecution) error {
 	_, err := d.db.Exec(`
 		UPDATE waffles_executions SET 
 			conversation_id = ?, command_args = ?, wheresmyprompt_query = ?,
-			files2prompt_args = ?, llm_args = ?, detected_language = ?,
-			file_count = ?, execution_time_ms = ?, success = ?,
-			error_message = ?, model_used = ?, provider_used = ?, updated = ?
+			wheresmyprompt_args = ?, files2prompt_args = ?, llm_args = ?,
+			detected_language = ?, file_count = ?, execution_time_ms = ?,
+			success = ?, error_message = ?, model_used = ?, provider_used = ?,
+			parent_execution_id = ?, updated = ?
 		WHERE id = ?`,
-		exec.ConversationID, exec.CommandArgs, exec.WheresmypromptQuery,
-		exec.Files2promptArgs, exec.LLMArgs, exec.DetectedLanguage,
-		exec.FileCount, exec.ExecutionTimeMS, exec.Success,
-		exec.ErrorMessage, exec.ModelUsed, exec.ProviderUsed,
-		exec.Updated, exec.ID,
+		nullString(exec.ConversationID), exec.CommandArgs, exec.WheresmypromptQuery,
+		exec.WheresmypromptArgs, exec.Files2promptArgs, exec.LLMArgs,
+		exec.DetectedLanguage, exec.FileCount, exec.ExecutionTimeMS,
+		exec.Success, exec.ErrorMessage, exec.ModelUsed, exec.ProviderUsed,
+		nullString(exec.ParentExecutionID), exec.Updated, exec.ID,
 	)
 
 	if err != nil {
@@ -297,21 +324,9 @@ func (d *Database) UpdateExecution(exec *WafflesExecution) error {
 
 // GetExecution retrieves a single execution by ID
 func (d *Database) GetExecution(id string) (*WafflesExecution, error) {
-	var exec WafflesExecution
-	var conversationID sql.NullString
-
-	err := d.db.QueryRow(`
-		SELECT id, conversation_id, command_args, wheresmyprompt_query,
-			files2prompt_args, llm_args, detected_language, file_count,
-			execution_time_ms, success, error_message, model_used,
-			provider_used, created, updated
-		FROM waffles_executions WHERE id = ?`, id).Scan(
-		&exec.ID, &conversationID, &exec.CommandArgs, &exec.WheresmypromptQuery,
-		&exec.Files2promptArgs, &exec.LLMArgs, &exec.DetectedLanguage, &exec.FileCount,
-		&exec.ExecutionTimeMS, &exec.Success, &exec.ErrorMessage, &exec.ModelUsed,
-		&exec.ProviderUsed, &exec.Created, &exec.Updated,
-	)
+	row := d.db.QueryRow("SELECT "+executionColumns+" FROM waffles_executions WHERE id = ?", id)
 
+	exec, err := scanExecution(row)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, fmt.Errorf("execution not found: %s", id)
@@ -319,11 +334,70 @@ func (d *Database) GetExecution(id string) (*WafflesExecution, error) {
 		return nil, fmt.Errorf("failed to get execution: %w", err)
 	}
 
-	if conversationID.Valid {
-		exec.ConversationID = conversationID.String
+	return exec, nil
+}
+
+// ResolveExecutionID expands a (possibly abbreviated) execution ID to the full
+// ID of the single execution it identifies
+func (d *Database) ResolveExecutionID(prefix string) (string, error) {
+	if prefix == "" {
+		return "", fmt.Errorf("execution ID is required")
+	}
+
+	rows, err := d.db.Query(`
+		SELECT id FROM waffles_executions
+		WHERE substr(id, 1, ?) = ?
+		ORDER BY created DESC
+		LIMIT 2`, len(prefix), prefix)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve execution ID: %w", err)
 	}
+	defer rows.Close()
 
-	return &exec, nil
+	var matches []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return "", fmt.Errorf("failed to scan execution ID: %w", err)
+		}
+		matches = append(matches, id)
+	}
+	if err := rows.Err(); err != nil {
+		return "", fmt.Errorf("failed to resolve execution ID: %w", err)
+	}
+
+	switch len(matches) {
+	case 0:
+		return "", fmt.Errorf("execution not found: %s", prefix)
+	case 1:
+		return matches[0], nil
+	default:
+		return "", fmt.Errorf("execution ID %s is ambiguous, use more characters", prefix)
+	}
+}
+
+// GetChildExecutions retrieves all executions that were re-run from the given execution
+func (d *Database) GetChildExecutions(parentID string) ([]WafflesExecution, error) {
+	rows, err := d.db.Query(`
+		SELECT `+executionColumns+`
+		FROM waffles_executions
+		WHERE parent_execution_id = ?
+		ORDER BY created`, parentID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query child executions: %w", err)
+	}
+	defer rows.Close()
+
+	var executions []WafflesExecution
+	for rows.Next() {
+		exec, err := scanExecution(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan child execution: %w", err)
+		}
+		executions = append(executions, *exec)
+	}
+
+	return executions, rows.Err()
 }
 
 // GetExecutionFiles retrieves all files for an execution
@@ -384,6 +458,149 @@ func (d *Database) GetExecutionSteps(executionID string) ([]WafflesStep, error)
 	return steps, rows.Err()
 }
 
+// batchQuerySize bounds the number of IDs bound in a single IN clause,
+// staying well below SQLite's host parameter limit
+const batchQuerySize = 500
+
+// GetFilesForExecutions retrieves the files of many executions, keyed by execution ID
+func (d *Database) GetFilesForExecutions(executionIDs []string) (map[string][]WafflesFile, error) {
+	files := make(map[string][]WafflesFile, len(executionIDs))
+
+	err := forEachIDBatch(executionIDs, func(placeholders string, args []interface{}) error {
+		rows, err := d.db.Query(`
+			SELECT id, execution_id, file_path, file_size, included,
+				COALESCE(exclusion_reason, ''), created
+			FROM waffles_files
+			WHERE execution_id IN (`+placeholders+`)
+			ORDER BY execution_id, file_path`, args...)
+		if err != nil {
+			return fmt.Errorf("failed to query execution files: %w", err)
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			var file WafflesFile
+			err := rows.Scan(
+				&file.ID, &file.ExecutionID, &file.FilePath, &file.FileSize,
+				&file.Included, &file.ExclusionReason, &file.Created,
+			)
+			if err != nil {
+				return fmt.Errorf("failed to scan execution file: %w", err)
+			}
+			files[file.ExecutionID] = append(files[file.ExecutionID], file)
+		}
+
+		return rows.Err()
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return files, nil
+}
+
+// GetStepsForExecutions retrieves the steps of many executions, keyed by execution ID
+func (d *Database) GetStepsForExecutions(executionIDs []string) (map[string][]WafflesStep, error) {
+	steps := make(map[string][]WafflesStep, len(executionIDs))
+
+	err := forEachIDBatch(executionIDs, func(placeholders string, args []interface{}) error {
+		rows, err := d.db.Query(`
+			SELECT id, execution_id, tool, command, COALESCE(output, ''), COALESCE(error_output, ''),
+				success, duration_ms, step_order, created
+			FROM waffles_steps
+			WHERE execution_id IN (`+placeholders+`)
+			ORDER BY execution_id, step_order`, args...)
+		if err != nil {
+			return fmt.Errorf("failed to query execution steps: %w", err)
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			var step WafflesStep
+			err := rows.Scan(
+				&step.ID, &step.ExecutionID, &step.Tool, &step.Command, &step.Output,
+				&step.ErrorOutput, &step.Success, &step.DurationMS, &step.StepOrder, &step.Created,
+			)
+			if err != nil {
+				return fmt.Errorf("failed to scan execution step: %w", err)
+			}
+			steps[step.ExecutionID] = append(steps[step.ExecutionID], step)
+		}
+
+		return rows.Err()
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return steps, nil
+}
+
+// forEachIDBatch calls fn with placeholders and arguments for consecutive
+// batches of at most batchQuerySize IDs
+func forEachIDBatch(ids []string, fn func(placeholders string, args []interface{}) error) error {
+	for start := 0; start < len(ids); start += batchQuerySize {
+		end := start + batchQuerySize
+		if end > len(ids) {
+			end = len(ids)
+		}
+
+		args := make([]interface{}, 0, end-start)
+		for _, id := range ids[start:end] {
+			args = append(args, id)
+		}
+
+		placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(args)), ", ")
+		if err := fn(placeholders, args); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// executionColumns lists the waffles_executions columns in the order expected by scanExecution
+const executionColumns = `id, conversation_id, COALESCE(command_args, ''), COALESCE(wheresmyprompt_query, ''),
+	wheresmyprompt_args, COALESCE(files2prompt_args, ''), COALESCE(llm_args, ''), COALESCE(detected_language, ''),
+	file_count, execution_time_ms, success, error_message, COALESCE(model_used, ''),
+	COALESCE(provider_used, ''), parent_execution_id, source, created, updated`
+
+// rowScanner is implemented by both *sql.Row and *sql.Rows
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// scanExecution scans a row selected with executionColumns into a WafflesExecution
+func scanExecution(row rowScanner) (*WafflesExecution, error) {
+	var exec WafflesExecution
+	var conversationID, wheresmypromptArgs, errorMessage, parentID, source sql.NullString
+
+	err := row.Scan(
+		&exec.ID, &conversationID, &exec.CommandArgs, &exec.WheresmypromptQuery,
+		&wheresmypromptArgs, &exec.Files2promptArgs, &exec.LLMArgs, &exec.DetectedLanguage,
+		&exec.FileCount, &exec.ExecutionTimeMS, &exec.Success, &errorMessage, &exec.ModelUsed,
+		&exec.ProviderUsed, &parentID, &source, &exec.Created, &exec.Updated,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	exec.ConversationID = conversationID.String
+	exec.WheresmypromptArgs = wheresmypromptArgs.String
+	exec.ErrorMessage = errorMessage.String
+	exec.ParentExecutionID = parentID.String
+	exec.Source = source.String
+
+	return &exec, nil
+}
+
+// nullString converts empty strings to NULL for optional columns
+func nullString(value string) interface{} {
+	if value == "" {
+		return nil
+	}
+	return value
+}
+
 // Close closes the database connection
 func (d *Database) Close() error {
 	if d.db != nil {
@@ -561,3 +561,126 @@ func TestDatabaseRecovery(t *testing.T) {
 		t.Errorf("Expected 1 execution after reopening, got %d", len(results))
 	}
 }
+
+func TestGetFilesAndStepsForExecutions(t *testing.T) {
+	db := newRetentionTestDB(t)
+
+	ids := []string{"old-success", "new-success", "missing"}
+
+	files, err := db.GetFilesForExecutions(ids)
+	if err != nil {
+		t.Fatalf("Failed to get files: %v", err)
+	}
+	if len(files) != 2 || len(files["old-success"]) != 1 || len(files["missing"]) != 0 {
+		t.Errorf("Unexpected files: %+v", files)
+	}
+
+	steps, err := db.GetStepsForExecutions(ids)
+	if err != nil {
+		t.Fatalf("Failed to get steps: %v", err)
+	}
+	if len(steps) != 2 || steps["new-success"][0].Tool != "llm" {
+		t.Errorf("Unexpected steps: %+v", steps)
+	}
+
+	// More IDs than fit into a single batch
+	many := make([]string, batchQuerySize*2+1)
+	for i := range many {
+		many[i] = fmt.Sprintf("id-%d", i)
+	}
+	many[len(many)-1] = "old-failure"
+
+	files, err = db.GetFilesForExecutions(many)
+	if err != nil {
+		t.Fatalf("Failed to get files across batches: %v", err)
+	}
+	if len(files) != 1 || len(files["old-failure"]) != 1 {
+		t.Errorf("Expected files from the last batch, got %+v", files)
+	}
+}
+
+func TestIterateExecutions(t *testing.T) {
+	db := newRetentionTestDB(t)
+
+	iter, err := db.IterateExecutions(&ExecutionFilter{Offset: 1})
+	if err != nil {
+		t.Fatalf("Failed to iterate executions: %v", err)
+	}
+	defer iter.Close()
+
+	var ids []string
+	for iter.Next() {
+		ids = append(ids, iter.Execution().ID)
+	}
+	if err := iter.Err(); err != nil {
+		t.Fatalf("Iteration failed: %v", err)
+	}
+
+	// Newest first, skipping the newest because of the offset
+	expected := []string{"mid-success", "old-failure", "old-success"}
+	if fmt.Sprint(ids) != fmt.Sprint(expected) {
+		t.Errorf("Expected %v, got %v", expected, ids)
+	}
+
+	minDuration := int64(1)
+	count, err := db.CountExecutions(&ExecutionFilter{MinDuration: &minDuration})
+	if err != nil {
+		t.Fatalf("Failed to count executions: %v", err)
+	}
+	if count != 0 {
+		t.Errorf("Expected duration filter to apply to counts, got %d", count)
+	}
+}
+
+func TestInsertBatch(t *testing.T) {
+	source := newRetentionTestDB(t)
+
+	executions, err := source.QueryExecutions(&ExecutionFilter{})
+	if err != nil {
+		t.Fatalf("Failed to query executions: %v", err)
+	}
+	ids := make([]string, len(executions))
+	for i, exec := range executions {
+		ids[i] = exec.ID
+	}
+	files, err := source.GetFilesForExecutions(ids)
+	if err != nil {
+		t.Fatalf("Failed to get files: %v", err)
+	}
+	steps, err := source.GetStepsForExecutions(ids)
+	if err != nil {
+		t.Fatalf("Failed to get steps: %v", err)
+	}
+
+	dest, err := NewDatabase(filepath.Join(t.TempDir(), "copy.db"))
+	if err != nil {
+		t.Fatalf("Failed to create database: %v", err)
+	}
+	defer dest.Close()
+
+	if err := dest.InsertBatch(executions, files, steps); err != nil {
+		t.Fatalf("Failed to insert batch: %v", err)
+	}
+
+	for _, table := range []string{"waffles_executions", "waffles_files", "waffles_steps"} {
+		if got, want := countTable(t, dest, table), countTable(t, source, table); got != want {
+			t.Errorf("Expected %d rows in %s, got %d", want, table, got)
+		}
+	}
+
+	copied, err := dest.GetExecution("old-failure")
+	if err != nil {
+		t.Fatalf("Failed to get copied execution: %v", err)
+	}
+	if copied.Success || !copied.Created.Equal(executions[2].Created) {
+		t.Errorf("Copied execution does not match source: %+v", copied)
+	}
+
+	// Inserting the same IDs again must fail without partial writes
+	if err := dest.InsertBatch(executions[:1], nil, nil); err == nil {
+		t.Error("Expected duplicate insert to fail")
+	}
+	if got := countTable(t, dest, "waffles_executions"); got != len(executions) {
+		t.Errorf("Expected %d executions after failed insert, got %d", len(executions), got)
+	}
+}
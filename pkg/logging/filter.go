@@ -0,0 +1,138 @@
+package logging
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// FieldKind is the value type of a filterable execution field
+type FieldKind string
+
+const (
+	FieldString   FieldKind = "string"
+	FieldBool     FieldKind = "bool"
+	FieldInt      FieldKind = "int"
+	FieldDuration FieldKind = "duration"
+	FieldTime     FieldKind = "time"
+)
+
+// FilterField describes an execution field that conditions can compare
+type FilterField struct {
+	Column      string
+	Kind        FieldKind
+	Description string
+}
+
+// FilterFields maps the field names accepted in conditions to their columns
+var FilterFields = map[string]FilterField{
+	"id":           {Column: "id", Kind: FieldString, Description: "execution ID"},
+	"conversation": {Column: "conversation_id", Kind: FieldString, Description: "llm conversation ID"},
+	"parent":       {Column: "parent_execution_id", Kind: FieldString, Description: "ID of the execution this one re-ran"},
+	"command":      {Column: "command_args", Kind: FieldString, Description: "command line arguments"},
+	"query":        {Column: "wheresmyprompt_query", Kind: FieldString, Description: "wheresmyprompt query"},
+	"language":     {Column: "detected_language", Kind: FieldString, Description: "detected language"},
+	"model":        {Column: "model_used", Kind: FieldString, Description: "LLM model"},
+	"provider":     {Column: "provider_used", Kind: FieldString, Description: "LLM provider"},
+	"source":       {Column: "source", Kind: FieldString, Description: "import source tag"},
+	"error":        {Column: "error_message", Kind: FieldString, Description: "error message"},
+	"success":      {Column: "success", Kind: FieldBool, Description: "whether the execution succeeded"},
+	"files":        {Column: "file_count", Kind: FieldInt, Description: "number of files processed"},
+	"duration":     {Column: "execution_time_ms", Kind: FieldDuration, Description: "execution time"},
+	"created":      {Column: "created", Kind: FieldTime, Description: "when the execution ran"},
+}
+
+// FilterFieldNames returns the accepted condition field names in sorted order
+func FilterFieldNames() []string {
+	names := make([]string, 0, len(FilterFields))
+	for name := range FilterFields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Condition operators
+const (
+	OpEqual        = "="
+	OpNotEqual     = "!="
+	OpLess         = "<"
+	OpLessEqual    = "<="
+	OpGreater      = ">"
+	OpGreaterEqual = ">="
+	OpContains     = "~"
+)
+
+// Condition compares a single execution field with a value. Conditions are
+// pushed down to SQL and combined with AND.
+type Condition struct {
+	Field    string      `json:"field"`
+	Operator string      `json:"operator"`
+	Value    interface{} `json:"value"`
+}
+
+// Validate checks that the field and operator are known and that the value
+// has the type the field expects
+func (c Condition) Validate() error {
+	field, ok := FilterFields[c.Field]
+	if !ok {
+		return fmt.Errorf("unknown field %q", c.Field)
+	}
+
+	allowed := map[FieldKind][]string{
+		FieldString:   {OpEqual, OpNotEqual, OpContains},
+		FieldBool:     {OpEqual, OpNotEqual},
+		FieldInt:      {OpEqual, OpNotEqual, OpLess, OpLessEqual, OpGreater, OpGreaterEqual},
+		FieldDuration: {OpEqual, OpNotEqual, OpLess, OpLessEqual, OpGreater, OpGreaterEqual},
+		FieldTime:     {OpLess, OpLessEqual, OpGreater, OpGreaterEqual},
+	}[field.Kind]
+
+	valid := false
+	for _, op := range allowed {
+		valid = valid || op == c.Operator
+	}
+	if !valid {
+		return fmt.Errorf("operator %q is not supported for %s field %q", c.Operator, field.Kind, c.Field)
+	}
+
+	switch field.Kind {
+	case FieldString:
+		_, ok = c.Value.(string)
+	case FieldBool:
+		_, ok = c.Value.(bool)
+	case FieldInt:
+		_, ok = c.Value.(int)
+	case FieldDuration:
+		_, ok = c.Value.(time.Duration)
+	case FieldTime:
+		_, ok = c.Value.(time.Time)
+	}
+	if !ok {
+		return fmt.Errorf("field %q expects a %s value, got %T", c.Field, field.Kind, c.Value)
+	}
+
+	return nil
+}
+
+// likeEscaper escapes the LIKE wildcards in a value, so that "~" matches it literally
+var likeEscaper = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
+
+// sql returns the SQL condition and argument for a validated condition
+func (c Condition) sql() (string, interface{}) {
+	field := FilterFields[c.Field]
+
+	switch value := c.Value.(type) {
+	case string:
+		// Treat NULL text columns as empty strings
+		column := "COALESCE(" + field.Column + ", '')"
+		if c.Operator == OpContains {
+			return column + ` LIKE ? ESCAPE '\'`, "%" + likeEscaper.Replace(value) + "%"
+		}
+		return column + " " + c.Operator + " ?", value
+	case time.Duration:
+		return field.Column + " " + c.Operator + " ?", value.Milliseconds()
+	default:
+		return field.Column + " " + c.Operator + " ?", value
+	}
+}
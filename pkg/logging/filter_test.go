@@ -0,0 +1,134 @@
+package logging
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestConditions(t *testing.T) {
+	db := newRetentionTestDB(t)
+
+	// Give the fixture executions distinct durations, file counts, models and
+	// commands, some with LIKE wildcards
+	for id, values := range map[string][]interface{}{
+		"old-success": {1000, 2, "gpt-4o", "waffles query 100 percent"},
+		"old-failure": {45000, 12, "gpt-4o-mini", "waffles query a_b"},
+		"mid-success": {31000, 30, "claude-3-5-sonnet", "waffles query 100%\\"},
+		"new-success": {500, 0, "", "waffles query axb"},
+	} {
+		if _, err := db.db.Exec("UPDATE waffles_executions SET execution_time_ms = ?, file_count = ?, model_used = ?, command_args = ? WHERE id = ?",
+			values[0], values[1], values[2], values[3], id); err != nil {
+			t.Fatalf("Failed to update fixture: %v", err)
+		}
+	}
+
+	minFiles, maxFiles := 10, 20
+	tests := []struct {
+		name     string
+		filter   ExecutionFilter
+		expected []string
+	}{
+		{
+			name:     "failed and slow",
+			filter:   ExecutionFilter{Conditions: []Condition{{"success", OpEqual, false}, {"duration", OpGreater, 30 * time.Second}}},
+			expected: []string{"old-failure"},
+		},
+		{
+			name:     "contains",
+			filter:   ExecutionFilter{Conditions: []Condition{{"model", OpContains, "gpt"}}},
+			expected: []string{"old-failure", "old-success"},
+		},
+		{
+			name:     "contains matches underscore literally",
+			filter:   ExecutionFilter{Conditions: []Condition{{"command", OpContains, "a_b"}}},
+			expected: []string{"old-failure"},
+		},
+		{
+			name:     "contains matches percent and backslash literally",
+			filter:   ExecutionFilter{Conditions: []Condition{{"command", OpContains, `100%\`}}},
+			expected: []string{"mid-success"},
+		},
+		{
+			name:     "contains percent without a literal match",
+			filter:   ExecutionFilter{Conditions: []Condition{{"model", OpContains, "gpt%mini"}}},
+			expected: nil,
+		},
+		{
+			name:     "search matches underscore literally",
+			filter:   ExecutionFilter{SearchQuery: "a_b"},
+			expected: []string{"old-failure"},
+		},
+		{
+			name:     "search matches percent literally",
+			filter:   ExecutionFilter{SearchQuery: "100%"},
+			expected: []string{"mid-success"},
+		},
+		{
+			name:     "not equal matches empty columns",
+			filter:   ExecutionFilter{Conditions: []Condition{{"model", OpNotEqual, "gpt-4o"}}},
+			expected: []string{"new-success", "mid-success", "old-failure"},
+		},
+		{
+			name:     "created range",
+			filter:   ExecutionFilter{Conditions: []Condition{{"created", OpLess, time.Now().Add(-30 * 24 * time.Hour)}, {"files", OpGreaterEqual, 12}}},
+			expected: []string{"mid-success", "old-failure"},
+		},
+		{
+			name:     "file count fields",
+			filter:   ExecutionFilter{MinFileCount: &minFiles, MaxFileCount: &maxFiles},
+			expected: []string{"old-failure"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			executions, err := db.QueryExecutions(&tt.filter)
+			if err != nil {
+				t.Fatalf("Query failed: %v", err)
+			}
+			var ids []string
+			for _, exec := range executions {
+				ids = append(ids, exec.ID)
+			}
+			if fmt.Sprint(ids) != fmt.Sprint(tt.expected) {
+				t.Errorf("Expected %v, got %v", tt.expected, ids)
+			}
+
+			count, err := db.CountExecutions(&tt.filter)
+			if err != nil {
+				t.Fatalf("Count failed: %v", err)
+			}
+			if count != len(tt.expected) {
+				t.Errorf("Expected count %d, got %d", len(tt.expected), count)
+			}
+		})
+	}
+}
+
+func TestConditionValidate(t *testing.T) {
+	tests := []struct {
+		condition Condition
+		valid     bool
+	}{
+		{Condition{"model", OpContains, "gpt"}, true},
+		{Condition{"duration", OpGreaterEqual, time.Second}, true},
+		{Condition{"unknown", OpEqual, "x"}, false},
+		{Condition{"success", OpGreater, true}, false},
+		{Condition{"files", OpEqual, "ten"}, false},
+		{Condition{"created", OpEqual, time.Now()}, false},
+	}
+
+	for _, tt := range tests {
+		err := tt.condition.Validate()
+		if (err == nil) != tt.valid {
+			t.Errorf("Validate(%+v) = %v, expected valid=%t", tt.condition, err, tt.valid)
+		}
+	}
+
+	// Invalid conditions are rejected before reaching SQL
+	db := newRetentionTestDB(t)
+	if _, err := db.QueryExecutions(&ExecutionFilter{Conditions: []Condition{{"id; DROP TABLE x", OpEqual, "a"}}}); err == nil {
+		t.Error("Expected invalid condition to fail")
+	}
+}
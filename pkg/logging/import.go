@@ -0,0 +1,170 @@
+package logging
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ImportOptions controls how executions from another database or export are
+// merged into this one
+type ImportOptions struct {
+	Source  string // Tag every imported execution with this source (empty keeps the record's own)
+	Replace bool   // Overwrite existing executions whose content differs
+	DryRun  bool   // Report what would be imported without writing anything
+}
+
+// ImportConflict describes an incoming execution whose ID already exists with
+// different content
+type ImportConflict struct {
+	ID       string   `json:"id"`
+	Fields   []string `json:"fields"`
+	Replaced bool     `json:"replaced"`
+}
+
+// ImportResult summarizes an import. Counts accumulate across batches.
+type ImportResult struct {
+	Imported   int              `json:"imported"`
+	Duplicates int              `json:"duplicates"`
+	Replaced   int              `json:"replaced"`
+	Conflicts  []ImportConflict `json:"conflicts,omitempty"`
+	DryRun     bool             `json:"dry_run"`
+}
+
+// Import merges a batch of executions with their files and steps into the
+// database in a single transaction. Executions are matched by ID: identical
+// ones are skipped as duplicates and differing ones are reported as
+// conflicts, replacing the existing execution only when opts.Replace is set.
+func (d *Database) Import(executions []WafflesExecution, files map[string][]WafflesFile, steps map[string][]WafflesStep, opts ImportOptions, result *ImportResult) error {
+	result.DryRun = opts.DryRun
+
+	tx, err := d.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin import transaction: %w", err)
+	}
+	defer func() {
+		// Only rollback if transaction hasn't been committed
+		_ = tx.Rollback() // Ignore rollback errors on deferred cleanup
+	}()
+
+	for i := range executions {
+		exec := executions[i]
+		if exec.ID == "" {
+			return fmt.Errorf("cannot import execution without an ID")
+		}
+		if opts.Source != "" {
+			exec.Source = opts.Source
+		}
+
+		existing, err := scanExecution(tx.QueryRow("SELECT "+executionColumns+" FROM waffles_executions WHERE id = ?", exec.ID))
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("failed to look up execution %s: %w", exec.ID, err)
+		}
+
+		if existing != nil {
+			fields := executionDifferences(existing, &exec)
+			if len(fields) == 0 {
+				result.Duplicates++
+				continue
+			}
+
+			result.Conflicts = append(result.Conflicts, ImportConflict{ID: exec.ID, Fields: fields, Replaced: opts.Replace})
+			if !opts.Replace {
+				continue
+			}
+			result.Replaced++
+			if opts.DryRun {
+				continue
+			}
+			if err := deleteExecution(tx, exec.ID); err != nil {
+				return err
+			}
+		} else {
+			result.Imported++
+			if opts.DryRun {
+				continue
+			}
+		}
+
+		if err := insertExecutionDetails(tx, &exec, files[exec.ID], steps[exec.ID]); err != nil {
+			return err
+		}
+	}
+
+	if opts.DryRun {
+		return nil
+	}
+	return tx.Commit()
+}
+
+// ExecScript runs the INSERT statements of a SQL export in a single
+// transaction. Scripts with any other statement are rejected before anything
+// runs, so an import file cannot drop tables or attach other databases.
+func (d *Database) ExecScript(script string) error {
+	inserts, err := scriptInserts(script)
+	if err != nil {
+		return fmt.Errorf("invalid SQL export: %w", err)
+	}
+
+	tx, err := d.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin script transaction: %w", err)
+	}
+	defer func() {
+		// Only rollback if transaction hasn't been committed
+		_ = tx.Rollback() // Ignore rollback errors on deferred cleanup
+	}()
+
+	for _, insert := range inserts {
+		if _, err := tx.Exec(insert); err != nil {
+			return fmt.Errorf("failed to execute SQL script: %w", err)
+		}
+	}
+	return tx.Commit()
+}
+
+// deleteExecution removes an execution together with its files and steps
+func deleteExecution(tx *sql.Tx, id string) error {
+	for _, query := range []string{
+		"DELETE FROM waffles_steps WHERE execution_id = ?",
+		"DELETE FROM waffles_files WHERE execution_id = ?",
+		"DELETE FROM waffles_executions WHERE id = ?",
+	} {
+		if _, err := tx.Exec(query, id); err != nil {
+			return fmt.Errorf("failed to delete execution %s: %w", id, err)
+		}
+	}
+	return nil
+}
+
+// executionDifferences lists the fields that differ between two executions
+// with the same ID. Timestamps are compared to the second because some export
+// formats do not keep sub-second precision.
+func executionDifferences(a, b *WafflesExecution) []string {
+	var fields []string
+	compare := func(name string, equal bool) {
+		if !equal {
+			fields = append(fields, name)
+		}
+	}
+
+	compare("conversation_id", a.ConversationID == b.ConversationID)
+	compare("command_args", a.CommandArgs == b.CommandArgs)
+	compare("wheresmyprompt_query", a.WheresmypromptQuery == b.WheresmypromptQuery)
+	compare("wheresmyprompt_args", a.WheresmypromptArgs == b.WheresmypromptArgs)
+	compare("files2prompt_args", a.Files2promptArgs == b.Files2promptArgs)
+	compare("llm_args", a.LLMArgs == b.LLMArgs)
+	compare("detected_language", a.DetectedLanguage == b.DetectedLanguage)
+	compare("file_count", a.FileCount == b.FileCount)
+	compare("execution_time_ms", a.ExecutionTimeMS == b.ExecutionTimeMS)
+	compare("success", a.Success == b.Success)
+	compare("error_message", a.ErrorMessage == b.ErrorMessage)
+	compare("model_used", a.ModelUsed == b.ModelUsed)
+	compare("provider_used", a.ProviderUsed == b.ProviderUsed)
+	compare("parent_execution_id", a.ParentExecutionID == b.ParentExecutionID)
+	compare("source", a.Source == b.Source)
+	compare("created", a.Created.Truncate(time.Second).Equal(b.Created.Truncate(time.Second)))
+
+	return fields
+}
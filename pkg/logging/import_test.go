@@ -0,0 +1,190 @@
+package logging
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestImport(t *testing.T) {
+	db := newRetentionTestDB(t)
+
+	existing, err := db.GetExecution("old-failure")
+	if err != nil {
+		t.Fatalf("Failed to get execution: %v", err)
+	}
+
+	changed := *existing
+	changed.Success = true
+	changed.ErrorMessage = "fixed"
+
+	incoming := []WafflesExecution{
+		*existing, // Duplicate
+		changed,   // Conflict
+		{ID: "imported", CommandArgs: "waffles query", Created: time.Now()},
+	}
+	files := map[string][]WafflesFile{"imported": {{ID: "imported-file", FilePath: "lib.go", Included: true}}}
+	steps := map[string][]WafflesStep{
+		"imported":    {{ID: "imported-step", Tool: "llm", StepOrder: 1}},
+		"old-failure": {{ID: "replaced-step", Tool: "files2prompt", StepOrder: 1}},
+	}
+
+	t.Run("dry run", func(t *testing.T) {
+		result := &ImportResult{}
+		if err := db.Import(incoming, files, steps, ImportOptions{DryRun: true}, result); err != nil {
+			t.Fatalf("Import failed: %v", err)
+		}
+		if !result.DryRun || result.Imported != 1 || result.Duplicates != 1 || len(result.Conflicts) != 1 {
+			t.Errorf("Unexpected result: %+v", result)
+		}
+		if got := countTable(t, db, "waffles_executions"); got != 4 {
+			t.Errorf("Dry run changed the database: %d executions", got)
+		}
+	})
+
+	t.Run("keep existing", func(t *testing.T) {
+		result := &ImportResult{}
+		if err := db.Import(incoming, files, steps, ImportOptions{}, result); err != nil {
+			t.Fatalf("Import failed: %v", err)
+		}
+		if result.Imported != 1 || result.Duplicates != 1 || result.Replaced != 0 {
+			t.Errorf("Unexpected result: %+v", result)
+		}
+		if len(result.Conflicts) != 1 || result.Conflicts[0].ID != "old-failure" ||
+			len(result.Conflicts[0].Fields) != 2 || result.Conflicts[0].Replaced {
+			t.Errorf("Unexpected conflicts: %+v", result.Conflicts)
+		}
+
+		imported, err := db.GetExecution("imported")
+		if err != nil {
+			t.Fatalf("Imported execution missing: %v", err)
+		}
+		if imported.CommandArgs != "waffles query" {
+			t.Errorf("Unexpected imported execution: %+v", imported)
+		}
+		if got := countTable(t, db, "waffles_files"); got != 5 {
+			t.Errorf("Expected 5 files, got %d", got)
+		}
+
+		kept, _ := db.GetExecution("old-failure")
+		if kept.Success {
+			t.Error("Conflicting execution was overwritten without Replace")
+		}
+	})
+
+	t.Run("replace with source", func(t *testing.T) {
+		result := &ImportResult{}
+		if err := db.Import(incoming[1:2], files, steps, ImportOptions{Source: "alice", Replace: true}, result); err != nil {
+			t.Fatalf("Import failed: %v", err)
+		}
+		if result.Replaced != 1 || len(result.Conflicts) != 1 || !result.Conflicts[0].Replaced {
+			t.Errorf("Unexpected result: %+v", result)
+		}
+
+		replaced, _ := db.GetExecution("old-failure")
+		if !replaced.Success || replaced.Source != "alice" {
+			t.Errorf("Execution was not replaced: %+v", replaced)
+		}
+
+		replacedSteps, err := db.GetStepsForExecutions([]string{"old-failure"})
+		if err != nil {
+			t.Fatalf("Failed to get steps: %v", err)
+		}
+		if len(replacedSteps["old-failure"]) != 1 || replacedSteps["old-failure"][0].Tool != "files2prompt" {
+			t.Errorf("Steps were not replaced: %+v", replacedSteps)
+		}
+
+		tagged, err := db.QueryExecutions(&ExecutionFilter{Source: "alice"})
+		if err != nil {
+			t.Fatalf("Failed to filter by source: %v", err)
+		}
+		if len(tagged) != 1 || tagged[0].ID != "old-failure" {
+			t.Errorf("Unexpected executions for source: %+v", tagged)
+		}
+	})
+}
+
+func TestExecScript(t *testing.T) {
+	db, err := NewDatabase(filepath.Join(t.TempDir(), "script.db"))
+	if err != nil {
+		t.Fatalf("Failed to create database: %v", err)
+	}
+	defer db.Close()
+
+	script := `
+INSERT INTO waffles_executions (id, command_args, success, created, updated) VALUES ('a', 'one', true, '2024-05-01T10:00:00.5Z', '2024-05-01T10:00:00.5Z');
+INSERT INTO waffles_executions (id, command_args, success, created, updated) VALUES ('b', 'two', false, '2024-05-02T10:00:00Z', '2024-05-02T10:00:00Z');
+`
+	if err := db.ExecScript(script); err != nil {
+		t.Fatalf("Failed to execute script: %v", err)
+	}
+
+	exec, err := db.GetExecution("a")
+	if err != nil {
+		t.Fatalf("Failed to get execution: %v", err)
+	}
+	if !exec.Success || !exec.Created.Equal(time.Date(2024, 5, 1, 10, 0, 0, 5e8, time.UTC)) {
+		t.Errorf("Unexpected execution: %+v", exec)
+	}
+
+	// A failing statement rolls back the whole script
+	if err := db.ExecScript("INSERT INTO waffles_executions (id, command_args) VALUES ('c', 'x'); INSERT INTO missing VALUES (1);"); err == nil {
+		t.Error("Expected script with an invalid statement to fail")
+	}
+	if got := countTable(t, db, "waffles_executions"); got != 2 {
+		t.Errorf("Expected 2 executions after failed script, got %d", got)
+	}
+
+	// Only inserts of literal values into the waffles tables may run
+	attached := filepath.Join(t.TempDir(), "attached.db")
+	for _, statement := range []string{
+		"DROP TABLE waffles_executions",
+		"PRAGMA writable_schema = ON",
+		"ATTACH DATABASE '" + attached + "' AS other",
+		"CREATE TABLE other (id TEXT)",
+		"DELETE FROM waffles_executions",
+		"INSERT INTO sqlite_master VALUES ('table', 'x', 'x', 0, 'x')",
+		"INSERT INTO waffles_executions (id, command_args) SELECT id, command_args FROM waffles_executions",
+		"INSERT INTO waffles_executions (id, command_args) VALUES ('d', (SELECT 'x'))",
+		"INSERT INTO waffles_executions (id, command_args) VALUES ('d', load_extension('x'))",
+		"INSERT OR REPLACE INTO waffles_executions (id, command_args) VALUES ('a', 'x')",
+	} {
+		script := "INSERT INTO waffles_executions (id, command_args) VALUES ('c', 'x');\n" + statement + ";"
+		if err := db.ExecScript(script); err == nil || !strings.Contains(err.Error(), "invalid SQL export") {
+			t.Errorf("Expected %q to be rejected, got %v", statement, err)
+		}
+	}
+	if got := countTable(t, db, "waffles_executions"); got != 2 {
+		t.Errorf("Expected rejected scripts to run nothing, got %d executions", got)
+	}
+	if _, err := os.Stat(attached); !os.IsNotExist(err) {
+		t.Errorf("Expected no database to be attached, got %v", err)
+	}
+}
+
+func TestScriptInserts(t *testing.T) {
+	script := `-- Waffles Execution Data Export
+CREATE TABLE IF NOT EXISTS waffles_steps (
+    id TEXT PRIMARY KEY, -- comment; with a semicolon
+    step_order INTEGER NOT NULL
+);
+/* block; comment */
+INSERT INTO waffles_steps (id, execution_id, tool, command, success, duration_ms, step_order, created) VALUES ('s;1', 'a', 'llm', 'it''s -- not a comment', true, -5, 1.5, '2024-05-01T10:00:00Z');
+insert into "waffles_files" ("id", execution_id, file_path) values ('f', 'a', ''), ('g', 'a', NULL)`
+
+	inserts, err := scriptInserts(script)
+	if err != nil {
+		t.Fatalf("Failed to parse script: %v", err)
+	}
+	if len(inserts) != 2 || !strings.Contains(inserts[0], "'it''s -- not a comment'") || !strings.HasPrefix(inserts[1], "insert into") {
+		t.Errorf("Expected the two inserts with their strings intact, got %q", inserts)
+	}
+
+	for _, script := range []string{"INSERT INTO waffles_files (id) VALUES ('x", "/* open", "INSERT INTO waffles_files (id) VALUES ('x') ; SELECT 1"} {
+		if _, err := scriptInserts(script); err == nil {
+			t.Errorf("Expected %q to be rejected", script)
+		}
+	}
+}
@@ -0,0 +1,381 @@
+package logging
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/mattn/go-sqlite3"
+)
+
+// backupPagesPerStep is the number of pages copied per backup step; copying in
+// small steps lets other connections keep using the database during a backup
+const backupPagesPerStep = 256
+
+// MigrationRecord describes an applied schema migration
+type MigrationRecord struct {
+	Version   int       `json:"version"`
+	AppliedAt time.Time `json:"applied_at"`
+}
+
+// TableCount holds the number of rows in a table
+type TableCount struct {
+	Table string `json:"table"`
+	Rows  int64  `json:"rows"`
+}
+
+// SchemaStatus summarizes the migration state of a database
+type SchemaStatus struct {
+	Path           string            `json:"path"`
+	SizeBytes      int64             `json:"size_bytes"`
+	CurrentVersion int               `json:"current_version"`
+	LatestVersion  int               `json:"latest_version"`
+	Applied        []MigrationRecord `json:"applied"`
+	Tables         []TableCount      `json:"tables"`
+}
+
+// Pending returns the number of migrations that have not been applied yet
+func (s *SchemaStatus) Pending() int {
+	if s.CurrentVersion >= s.LatestVersion {
+		return 0
+	}
+	return s.LatestVersion - s.CurrentVersion
+}
+
+// IsNewer reports whether the database was migrated by a newer waffles version
+func (s *SchemaStatus) IsNewer() bool {
+	return s.CurrentVersion > s.LatestVersion
+}
+
+// CheckResult reports the outcome of a database health check
+type CheckResult struct {
+	IntegrityErrors []string `json:"integrity_errors,omitempty"`
+	OrphanedFiles   int      `json:"orphaned_files"`
+	OrphanedSteps   int      `json:"orphaned_steps"`
+}
+
+// OK reports whether the check found no problems
+func (c *CheckResult) OK() bool {
+	return len(c.IntegrityErrors) == 0 && c.OrphanedFiles == 0 && c.OrphanedSteps == 0
+}
+
+// Path returns the database file path
+func (d *Database) Path() string {
+	return d.path
+}
+
+// SchemaVersion returns the highest applied migration version, or 0 for an
+// uninitialized database. It only reads, so that status checks never change
+// the database.
+func (d *Database) SchemaVersion() (int, error) {
+	exists, err := d.tableExists("schema_version")
+	if err != nil || !exists {
+		return 0, err
+	}
+
+	version := 0
+	if err := d.db.QueryRow("SELECT COALESCE(MAX(version), 0) FROM schema_version").Scan(&version); err != nil {
+		return 0, fmt.Errorf("failed to get current schema version: %w", err)
+	}
+	return version, nil
+}
+
+// Status reports the schema version, applied migrations and table sizes
+func (d *Database) Status() (*SchemaStatus, error) {
+	current, err := d.SchemaVersion()
+	if err != nil {
+		return nil, err
+	}
+
+	status := &SchemaStatus{
+		Path:           d.path,
+		SizeBytes:      d.fileSize(),
+		CurrentVersion: current,
+		LatestVersion:  GetCurrentSchemaVersion(),
+	}
+
+	if status.Applied, err = d.appliedMigrations(); err != nil {
+		return nil, err
+	}
+
+	for _, table := range []string{"waffles_executions", "waffles_files", "waffles_steps"} {
+		exists, err := d.tableExists(table)
+		if err != nil {
+			return nil, err
+		}
+		if !exists {
+			continue
+		}
+
+		count := TableCount{Table: table}
+		// #nosec G202 -- table name comes from the fixed list above
+		if err := d.db.QueryRow("SELECT COUNT(*) FROM " + table).Scan(&count.Rows); err != nil {
+			return nil, fmt.Errorf("failed to count rows in %s: %w", table, err)
+		}
+		status.Tables = append(status.Tables, count)
+	}
+
+	return status, nil
+}
+
+// appliedMigrations lists the applied migrations, if any
+func (d *Database) appliedMigrations() ([]MigrationRecord, error) {
+	exists, err := d.tableExists("schema_version")
+	if err != nil || !exists {
+		return nil, err
+	}
+
+	rows, err := d.db.Query("SELECT version, applied_at FROM schema_version ORDER BY version")
+	if err != nil {
+		return nil, fmt.Errorf("failed to query applied migrations: %w", err)
+	}
+	defer rows.Close()
+
+	var applied []MigrationRecord
+	for rows.Next() {
+		var record MigrationRecord
+		if err := rows.Scan(&record.Version, &record.AppliedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan migration: %w", err)
+		}
+		applied = append(applied, record)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read applied migrations: %w", err)
+	}
+	return applied, nil
+}
+
+// MigrateTo applies up or down migrations until the schema reaches the target
+// version. Each migration runs in its own transaction.
+func (d *Database) MigrateTo(target int) error {
+	if target < 0 || target > GetCurrentSchemaVersion() {
+		return fmt.Errorf("unknown schema version %d (latest is %d)", target, GetCurrentSchemaVersion())
+	}
+
+	if err := d.ensureVersionTable(); err != nil {
+		return err
+	}
+	current, err := d.SchemaVersion()
+	if err != nil {
+		return err
+	}
+
+	for version := current + 1; version <= target; version++ {
+		if err := d.applyMigration(version, true); err != nil {
+			return err
+		}
+	}
+
+	for version := current; version > target; version-- {
+		if err := d.applyMigration(version, false); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Check runs PRAGMA integrity_check and looks for files and steps whose
+// execution no longer exists
+func (d *Database) Check() (*CheckResult, error) {
+	result := &CheckResult{}
+
+	rows, err := d.db.Query("PRAGMA integrity_check")
+	if err != nil {
+		return nil, fmt.Errorf("failed to run integrity check: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var message string
+		if err := rows.Scan(&message); err != nil {
+			return nil, fmt.Errorf("failed to scan integrity check result: %w", err)
+		}
+		if message != "ok" {
+			result.IntegrityErrors = append(result.IntegrityErrors, message)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read integrity check results: %w", err)
+	}
+
+	exists, err := d.tableExists("waffles_executions")
+	if err != nil || !exists {
+		return result, err
+	}
+
+	orphans := map[string]*int{
+		"waffles_files": &result.OrphanedFiles,
+		"waffles_steps": &result.OrphanedSteps,
+	}
+	for table, count := range orphans {
+		// #nosec G202 -- table name comes from the fixed map above
+		query := "SELECT COUNT(*) FROM " + table + " WHERE execution_id NOT IN (SELECT id FROM waffles_executions)"
+		if err := d.db.QueryRow(query).Scan(count); err != nil {
+			return nil, fmt.Errorf("failed to check %s for orphans: %w", table, err)
+		}
+	}
+
+	return result, nil
+}
+
+// Backup writes a consistent copy of the database to destPath using the
+// SQLite online backup API, so the database can stay in use meanwhile
+func (d *Database) Backup(destPath string) error {
+	dest, err := sql.Open("sqlite3", destPath)
+	if err != nil {
+		return fmt.Errorf("failed to open backup destination: %w", err)
+	}
+	defer dest.Close()
+
+	if err := copyDatabase(d.db, dest); err != nil {
+		return fmt.Errorf("failed to back up database: %w", err)
+	}
+	return nil
+}
+
+// Restore replaces the contents of the database with the database at srcPath.
+// The source is integrity-checked first so a corrupt backup is never restored.
+func (d *Database) Restore(srcPath string) error {
+	if _, err := os.Stat(srcPath); err != nil {
+		return fmt.Errorf("failed to read backup: %w", err)
+	}
+
+	src, err := OpenDatabase(srcPath)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	check, err := src.Check()
+	if err != nil {
+		return err
+	}
+	if len(check.IntegrityErrors) > 0 {
+		return fmt.Errorf("backup failed integrity check: %s", check.IntegrityErrors[0])
+	}
+
+	if err := copyDatabase(src.db, d.db); err != nil {
+		return fmt.Errorf("failed to restore database: %w", err)
+	}
+	return nil
+}
+
+// copyDatabase copies the main database of src into dest page by page
+func copyDatabase(src, dest *sql.DB) error {
+	ctx := context.Background()
+
+	srcConn, err := src.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer srcConn.Close()
+
+	destConn, err := dest.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer destConn.Close()
+
+	return destConn.Raw(func(destDriver interface{}) error {
+		return srcConn.Raw(func(srcDriver interface{}) error {
+			destSQLite, ok := destDriver.(*sqlite3.SQLiteConn)
+			if !ok {
+				return fmt.Errorf("unexpected destination driver connection %T", destDriver)
+			}
+			srcSQLite, ok := srcDriver.(*sqlite3.SQLiteConn)
+			if !ok {
+				return fmt.Errorf("unexpected source driver connection %T", srcDriver)
+			}
+
+			backup, err := destSQLite.Backup("main", srcSQLite, "main")
+			if err != nil {
+				return err
+			}
+
+			for {
+				done, err := backup.Step(backupPagesPerStep)
+				if err != nil {
+					_ = backup.Finish() // Report the step error rather than the cleanup error
+					return err
+				}
+				if done {
+					break
+				}
+			}
+
+			return backup.Finish()
+		})
+	})
+}
+
+// ensureVersionTable creates the schema_version table if it doesn't exist
+func (d *Database) ensureVersionTable() error {
+	_, err := d.db.Exec(`
+		CREATE TABLE IF NOT EXISTS schema_version (
+			version INTEGER PRIMARY KEY,
+			applied_at DATETIME DEFAULT CURRENT_TIMESTAMP
+		)
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to create schema version table: %w", err)
+	}
+	return nil
+}
+
+// applyMigration applies (up) or reverts (down) a single versioned migration
+// and records the change in schema_version within one transaction
+func (d *Database) applyMigration(version int, up bool) error {
+	direction := "migration"
+	query, exists := GetMigrationQuery(version)
+	record := "INSERT INTO schema_version (version) VALUES (?)"
+	if !up {
+		direction = "down-migration"
+		query, exists = GetDownMigrationQuery(version)
+		record = "DELETE FROM schema_version WHERE version = ?"
+	}
+	if !exists {
+		return fmt.Errorf("no %s defined for version %d", direction, version)
+	}
+
+	// Begin transaction
+	tx, err := d.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin %s transaction: %w", direction, err)
+	}
+
+	// Execute migration
+	if _, err := tx.Exec(query); err != nil {
+		if rollbackErr := tx.Rollback(); rollbackErr != nil {
+			return fmt.Errorf("failed to execute %s %d: %w (rollback error: %v)", direction, version, err, rollbackErr)
+		}
+		return fmt.Errorf("failed to execute %s %d: %w", direction, version, err)
+	}
+
+	// Record migration
+	if _, err := tx.Exec(record, version); err != nil {
+		if rollbackErr := tx.Rollback(); rollbackErr != nil {
+			return fmt.Errorf("failed to record %s %d: %w (rollback error: %v)", direction, version, err, rollbackErr)
+		}
+		return fmt.Errorf("failed to record %s %d: %w", direction, version, err)
+	}
+
+	// Commit transaction
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit %s %d: %w", direction, version, err)
+	}
+
+	return nil
+}
+
+// tableExists reports whether a table exists in the database
+func (d *Database) tableExists(name string) (bool, error) {
+	var exists bool
+	err := d.db.QueryRow("SELECT COUNT(*) > 0 FROM sqlite_master WHERE type='table' AND name=?", name).Scan(&exists)
+	if err != nil {
+		return false, fmt.Errorf("failed to check for table %s: %w", name, err)
+	}
+	return exists, nil
+}
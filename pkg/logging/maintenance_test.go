@@ -0,0 +1,151 @@
+package logging
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestSchemaStatus(t *testing.T) {
+	db := newRetentionTestDB(t)
+
+	status, err := db.Status()
+	if err != nil {
+		t.Fatalf("Status failed: %v", err)
+	}
+
+	if status.CurrentVersion != GetCurrentSchemaVersion() || status.Pending() != 0 {
+		t.Errorf("Expected fully migrated database, got version %d of %d", status.CurrentVersion, status.LatestVersion)
+	}
+	if len(status.Applied) != GetCurrentSchemaVersion() {
+		t.Errorf("Expected %d applied migrations, got %d", GetCurrentSchemaVersion(), len(status.Applied))
+	}
+	if len(status.Tables) != 3 || status.Tables[0].Rows != 4 {
+		t.Errorf("Unexpected table counts: %+v", status.Tables)
+	}
+}
+
+func TestSchemaStatusIsReadOnly(t *testing.T) {
+	db, err := OpenDatabase(filepath.Join(t.TempDir(), "empty.db"))
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	status, err := db.Status()
+	if err != nil {
+		t.Fatalf("Status failed: %v", err)
+	}
+	if status.CurrentVersion != 0 || status.Pending() != GetCurrentSchemaVersion() || len(status.Applied) != 0 || len(status.Tables) != 0 {
+		t.Errorf("Expected an uninitialized database, got %+v", status)
+	}
+	if exists, err := db.tableExists("schema_version"); err != nil || exists {
+		t.Errorf("Expected Status not to create the schema_version table: %v", err)
+	}
+
+	if err := db.MigrateTo(GetCurrentSchemaVersion()); err != nil {
+		t.Fatalf("MigrateTo failed: %v", err)
+	}
+	if version, err := db.SchemaVersion(); err != nil || version != GetCurrentSchemaVersion() {
+		t.Errorf("Expected the migrated version, got %d: %v", version, err)
+	}
+}
+
+func TestMigrateDownAndUp(t *testing.T) {
+	db := newRetentionTestDB(t)
+	latest := GetCurrentSchemaVersion()
+
+	for version := range MigrationQueries {
+		if _, exists := GetDownMigrationQuery(version); !exists {
+			t.Errorf("Migration %d has no down-migration", version)
+		}
+	}
+
+	if err := db.MigrateTo(1); err != nil {
+		t.Fatalf("Down-migration failed: %v", err)
+	}
+	if version, _ := db.SchemaVersion(); version != 1 {
+		t.Errorf("Expected version 1 after down-migration, got %d", version)
+	}
+	if _, err := db.db.Exec("SELECT parent_execution_id FROM waffles_executions"); err == nil {
+		t.Error("Expected parent_execution_id column to be dropped")
+	}
+
+	if err := db.MigrateTo(latest); err != nil {
+		t.Fatalf("Up-migration failed: %v", err)
+	}
+	if _, err := db.GetExecution("old-success"); err != nil {
+		t.Errorf("Expected data to survive the round trip: %v", err)
+	}
+
+	if err := db.MigrateTo(0); err != nil {
+		t.Fatalf("Full down-migration failed: %v", err)
+	}
+	if exists, _ := db.tableExists("waffles_executions"); exists {
+		t.Error("Expected waffles tables to be dropped at version 0")
+	}
+
+	if err := db.MigrateTo(latest + 1); err == nil {
+		t.Error("Expected error for unknown schema version")
+	}
+}
+
+func TestCheck(t *testing.T) {
+	db := newRetentionTestDB(t)
+
+	result, err := db.Check()
+	if err != nil {
+		t.Fatalf("Check failed: %v", err)
+	}
+	if !result.OK() {
+		t.Errorf("Expected healthy database, got %+v", result)
+	}
+
+	if _, err := db.db.Exec("DELETE FROM waffles_executions WHERE id = ?", "old-success"); err != nil {
+		t.Fatalf("Failed to delete execution: %v", err)
+	}
+
+	result, err = db.Check()
+	if err != nil {
+		t.Fatalf("Check failed: %v", err)
+	}
+	if result.OK() || result.OrphanedFiles != 1 || result.OrphanedSteps != 1 {
+		t.Errorf("Expected orphaned rows to be reported, got %+v", result)
+	}
+}
+
+func TestBackupAndRestore(t *testing.T) {
+	db := newRetentionTestDB(t)
+	backupPath := filepath.Join(t.TempDir(), "backup.db")
+
+	if err := db.Backup(backupPath); err != nil {
+		t.Fatalf("Backup failed: %v", err)
+	}
+
+	backup, err := OpenDatabase(backupPath)
+	if err != nil {
+		t.Fatalf("Failed to open backup: %v", err)
+	}
+	if _, err := backup.GetExecution("new-success"); err != nil {
+		t.Errorf("Expected backup to contain executions: %v", err)
+	}
+	backup.Close()
+
+	if _, err := db.Prune(&RetentionPolicy{MaxAge: 1}); err != nil {
+		t.Fatalf("Prune failed: %v", err)
+	}
+	if err := db.Restore(backupPath); err != nil {
+		t.Fatalf("Restore failed: %v", err)
+	}
+
+	status, err := db.Status()
+	if err != nil {
+		t.Fatalf("Status failed: %v", err)
+	}
+	if status.Tables[0].Rows != 4 {
+		t.Errorf("Expected 4 executions after restore, got %d", status.Tables[0].Rows)
+	}
+
+	if err := db.Restore(filepath.Join(t.TempDir(), "missing.db")); err == nil {
+		t.Error("Expected error restoring a missing backup")
+	}
+}
@@ -0,0 +1,135 @@
+package logging
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// StepDurationBounds are the upper bounds in seconds of the step duration histogram buckets
+var StepDurationBounds = []float64{0.1, 0.5, 1, 2.5, 5, 10, 30, 60, 120, 300}
+
+// FileCountBounds are the upper bounds of the per-run file count histogram buckets
+var FileCountBounds = []float64{0, 1, 5, 10, 25, 50, 100, 250}
+
+// MetricsSnapshot holds the aggregates of the whole log database that are
+// exposed as metrics
+type MetricsSnapshot struct {
+	Runs          []RunCount    `json:"runs"`
+	StepDurations []Histogram   `json:"step_durations"` // Per tool, in seconds
+	FileCounts    Histogram     `json:"file_counts"`
+	LastRun       *time.Time    `json:"last_run,omitempty"`
+	CollectedAt   time.Time     `json:"collected_at"`
+	CollectTime   time.Duration `json:"collect_time"`
+}
+
+// RunCount is the number of runs with one combination of labels
+type RunCount struct {
+	Model    string `json:"model"`
+	Provider string `json:"provider"`
+	Language string `json:"language"`
+	Success  bool   `json:"success"`
+	Count    int    `json:"count"`
+}
+
+// Histogram is a cumulative histogram: Counts[i] is the number of
+// observations less than or equal to Bounds[i]
+type Histogram struct {
+	Label  string    `json:"label,omitempty"`
+	Bounds []float64 `json:"bounds"`
+	Counts []int     `json:"counts"`
+	Count  int       `json:"count"`
+	Sum    float64   `json:"sum"`
+}
+
+// CollectMetrics aggregates run counts and step duration and file count
+// histograms over all logged executions
+func (d *Database) CollectMetrics() (*MetricsSnapshot, error) {
+	start := time.Now()
+	snapshot := &MetricsSnapshot{CollectedAt: start}
+
+	rows, err := d.db.Query(`
+		SELECT COALESCE(model_used, ''), COALESCE(provider_used, ''), COALESCE(detected_language, ''), success, COUNT(*)
+		FROM waffles_executions
+		GROUP BY 1, 2, 3, 4
+		ORDER BY 1, 2, 3, 4`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count runs: %w", err)
+	}
+	for rows.Next() {
+		var run RunCount
+		if err := rows.Scan(&run.Model, &run.Provider, &run.Language, &run.Success, &run.Count); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan run count: %w", err)
+		}
+		snapshot.Runs = append(snapshot.Runs, run)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to count runs: %w", err)
+	}
+
+	if snapshot.StepDurations, err = d.histograms("tool", "duration_ms", 1000, StepDurationBounds, "waffles_steps"); err != nil {
+		return nil, fmt.Errorf("failed to collect step durations: %w", err)
+	}
+
+	fileCounts, err := d.histograms("''", "file_count", 1, FileCountBounds, "waffles_executions")
+	if err != nil {
+		return nil, fmt.Errorf("failed to collect file counts: %w", err)
+	}
+	snapshot.FileCounts = Histogram{Bounds: FileCountBounds, Counts: make([]int, len(FileCountBounds))}
+	if len(fileCounts) > 0 {
+		snapshot.FileCounts = fileCounts[0]
+	}
+
+	var lastRun time.Time
+	err = d.db.QueryRow("SELECT created FROM waffles_executions ORDER BY created DESC LIMIT 1").Scan(&lastRun)
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+	case err != nil:
+		return nil, fmt.Errorf("failed to get last run: %w", err)
+	default:
+		snapshot.LastRun = &lastRun
+	}
+
+	snapshot.CollectTime = time.Since(start)
+	return snapshot, nil
+}
+
+// histograms builds one cumulative histogram per value of labelExpr over a
+// column of table. Column values are divided by scale to get the unit of
+// bounds, so durations in milliseconds become seconds with a scale of 1000.
+func (d *Database) histograms(labelExpr, column string, scale float64, bounds []float64, table string) ([]Histogram, error) {
+	var columns strings.Builder
+	for _, bound := range bounds {
+		fmt.Fprintf(&columns, "SUM(CASE WHEN %s <= %g THEN 1 ELSE 0 END), ", column, bound*scale)
+	}
+
+	query := "SELECT " + labelExpr + ", " + columns.String() + "COUNT(*), COALESCE(SUM(" + column + "), 0) FROM " + table +
+		" GROUP BY 1 ORDER BY 1"
+	rows, err := d.db.Query(query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var histograms []Histogram
+	for rows.Next() {
+		h := Histogram{Bounds: bounds, Counts: make([]int, len(bounds))}
+		var sum float64
+		dest := []interface{}{&h.Label}
+		for i := range h.Counts {
+			dest = append(dest, &h.Counts[i])
+		}
+		dest = append(dest, &h.Count, &sum)
+		if err := rows.Scan(dest...); err != nil {
+			return nil, err
+		}
+		h.Sum = sum / scale
+		histograms = append(histograms, h)
+	}
+
+	return histograms, rows.Err()
+}
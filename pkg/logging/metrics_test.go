@@ -0,0 +1,71 @@
+package logging
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestCollectMetrics(t *testing.T) {
+	db := newStatsTestDB(t)
+
+	metrics, err := db.CollectMetrics()
+	if err != nil {
+		t.Fatalf("CollectMetrics failed: %v", err)
+	}
+
+	// exec-04 and exec-08 failed, one per model
+	runs := make(map[string]int)
+	for _, run := range metrics.Runs {
+		key := run.Model + "/failed"
+		if run.Success {
+			key = run.Model + "/ok"
+		}
+		runs[key] = run.Count
+	}
+	expectedRuns := map[string]int{"gpt-4o/ok": 5, "gpt-4o/failed": 1, "llama3/ok": 3, "llama3/failed": 1}
+	for key, count := range expectedRuns {
+		if runs[key] != count {
+			t.Errorf("Expected %d runs for %s, got %v", count, key, runs)
+		}
+	}
+
+	// Steps take 100ms to 1s
+	if len(metrics.StepDurations) != 1 {
+		t.Fatalf("Expected one step duration histogram, got %+v", metrics.StepDurations)
+	}
+	steps := metrics.StepDurations[0]
+	if steps.Label != "llm" || steps.Count != 10 || steps.Sum != 5.5 {
+		t.Errorf("Unexpected step histogram totals: %+v", steps)
+	}
+	if steps.Counts[0] != 1 || steps.Counts[1] != 5 || steps.Counts[2] != 10 || steps.Counts[len(steps.Counts)-1] != 10 {
+		t.Errorf("Unexpected step histogram buckets: %v", steps.Counts)
+	}
+
+	// File counts are 3, 6, ..., 30
+	files := metrics.FileCounts
+	if files.Count != 10 || files.Sum != 165 || files.Counts[2] != 1 || files.Counts[3] != 3 || files.Counts[4] != 8 {
+		t.Errorf("Unexpected file count histogram: %+v", files)
+	}
+
+	expectedLast := time.Date(2026, 3, 15, 9, 30, 0, 0, time.UTC)
+	if metrics.LastRun == nil || !metrics.LastRun.Equal(expectedLast) {
+		t.Errorf("Expected last run %v, got %v", expectedLast, metrics.LastRun)
+	}
+}
+
+func TestCollectMetricsEmpty(t *testing.T) {
+	db, err := NewDatabase(filepath.Join(t.TempDir(), "empty.db"))
+	if err != nil {
+		t.Fatalf("Failed to create database: %v", err)
+	}
+	defer db.Close()
+
+	metrics, err := db.CollectMetrics()
+	if err != nil {
+		t.Fatalf("CollectMetrics failed: %v", err)
+	}
+	if len(metrics.Runs) != 0 || metrics.LastRun != nil || metrics.FileCounts.Count != 0 || len(metrics.FileCounts.Counts) != len(FileCountBounds) {
+		t.Errorf("Expected empty metrics, got %+v", metrics)
+	}
+}
@@ -12,72 +12,42 @@ import (
 
 // QueryExecutions retrieves executions based on filter criteria
 func (d *Database) QueryExecutions(filter *ExecutionFilter) ([]WafflesExecution, error) {
-	if filter == nil {
-		filter = &ExecutionFilter{}
-	}
-
-	// Build WHERE clause
-	var conditions []string
-	var args []interface{}
-
-	if filter.DateFrom != nil {
-		conditions = append(conditions, "created >= ?")
-		args = append(args, *filter.DateFrom)
-	}
-
-	if filter.DateTo != nil {
-		conditions = append(conditions, "created <= ?")
-		args = append(args, *filter.DateTo)
-	}
-
-	if filter.Language != "" {
-		conditions = append(conditions, "detected_language = ?")
-		args = append(args, filter.Language)
-	}
-
-	if filter.Model != "" {
-		conditions = append(conditions, "model_used = ?")
-		args = append(args, filter.Model)
+	iter, err := d.IterateExecutions(filter)
+	if err != nil {
+		return nil, err
 	}
+	defer iter.Close()
 
-	if filter.Provider != "" {
-		conditions = append(conditions, "provider_used = ?")
-		args = append(args, filter.Provider)
+	var executions []WafflesExecution
+	for iter.Next() {
+		executions = append(executions, *iter.Execution())
 	}
 
-	if filter.Success != nil {
-		conditions = append(conditions, "success = ?")
-		args = append(args, *filter.Success)
-	}
+	return executions, iter.Err()
+}
 
-	if filter.MinDuration != nil {
-		conditions = append(conditions, "execution_time_ms >= ?")
-		args = append(args, *filter.MinDuration)
-	}
+// ExecutionIterator streams executions matching a filter one row at a time,
+// so large histories can be processed without loading them into memory
+type ExecutionIterator struct {
+	rows    *sql.Rows
+	current *WafflesExecution
+	err     error
+}
 
-	if filter.MaxDuration != nil {
-		conditions = append(conditions, "execution_time_ms <= ?")
-		args = append(args, *filter.MaxDuration)
+// IterateExecutions returns an iterator over the executions matching the
+// filter, newest first. The caller must Close the iterator.
+func (d *Database) IterateExecutions(filter *ExecutionFilter) (*ExecutionIterator, error) {
+	if filter == nil {
+		filter = &ExecutionFilter{}
 	}
 
-	if filter.SearchQuery != "" {
-		conditions = append(conditions, "(wheresmyprompt_query LIKE ? OR command_args LIKE ? OR error_message LIKE ?)")
-		searchPattern := "%" + filter.SearchQuery + "%"
-		args = append(args, searchPattern, searchPattern, searchPattern)
+	where, args, err := executionWhereClause(filter)
+	if err != nil {
+		return nil, err
 	}
 
 	// Build query
-	query := `
-		SELECT id, conversation_id, command_args, wheresmyprompt_query,
-			files2prompt_args, llm_args, detected_language, file_count,
-			execution_time_ms, success, error_message, model_used,
-			provider_used, created, updated
-		FROM waffles_executions`
-
-	if len(conditions) > 0 {
-		query += " WHERE " + strings.Join(conditions, " AND ")
-	}
-
+	query := "SELECT " + executionColumns + " FROM waffles_executions" + where
 	query += " ORDER BY created DESC"
 
 	if filter.Limit > 0 {
@@ -85,6 +55,9 @@ func (d *Database) QueryExecutions(filter *ExecutionFilter) ([]WafflesExecution,
 	}
 
 	if filter.Offset > 0 {
+		if filter.Limit <= 0 {
+			query += " LIMIT -1"
+		}
 		query += fmt.Sprintf(" OFFSET %d", filter.Offset)
 	}
 
@@ -93,38 +66,70 @@ func (d *Database) QueryExecutions(filter *ExecutionFilter) ([]WafflesExecution,
 	if err != nil {
 		return nil, fmt.Errorf("failed to query executions: %w", err)
 	}
-	defer rows.Close()
 
-	var executions []WafflesExecution
-	for rows.Next() {
-		var exec WafflesExecution
-		var conversationID sql.NullString
-		err := rows.Scan(
-			&exec.ID, &conversationID, &exec.CommandArgs, &exec.WheresmypromptQuery,
-			&exec.Files2promptArgs, &exec.LLMArgs, &exec.DetectedLanguage, &exec.FileCount,
-			&exec.ExecutionTimeMS, &exec.Success, &exec.ErrorMessage, &exec.ModelUsed,
-			&exec.ProviderUsed, &exec.Created, &exec.Updated,
-		)
-		if err != nil {
-			return nil, fmt.Errorf("failed to scan execution: %w", err)
-		}
+	return &ExecutionIterator{rows: rows}, nil
+}
 
-		if conversationID.Valid {
-			exec.ConversationID = conversationID.String
-		}
+// Next advances to the next execution, returning false when there are no
+// more rows or an error occurred
+func (it *ExecutionIterator) Next() bool {
+	if it.err != nil || !it.rows.Next() {
+		return false
+	}
+
+	exec, err := scanExecution(it.rows)
+	if err != nil {
+		it.err = fmt.Errorf("failed to scan execution: %w", err)
+		return false
+	}
+
+	it.current = exec
+	return true
+}
+
+// Execution returns the current execution
+func (it *ExecutionIterator) Execution() *WafflesExecution {
+	return it.current
+}
 
-		executions = append(executions, exec)
+// Err returns the first error encountered while iterating
+func (it *ExecutionIterator) Err() error {
+	if it.err != nil {
+		return it.err
 	}
+	return it.rows.Err()
+}
 
-	return executions, rows.Err()
+// Close releases the underlying rows
+func (it *ExecutionIterator) Close() error {
+	return it.rows.Close()
 }
 
-// CountExecutions returns the count of executions matching the filter
+// CountExecutions returns the count of executions matching the filter,
+// ignoring Limit and Offset
 func (d *Database) CountExecutions(filter *ExecutionFilter) (int, error) {
 	if filter == nil {
 		filter = &ExecutionFilter{}
 	}
 
+	where, args, err := executionWhereClause(filter)
+	if err != nil {
+		return 0, err
+	}
+	query := "SELECT COUNT(*) FROM waffles_executions" + where
+
+	var count int
+	err = d.db.QueryRow(query, args...).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count executions: %w", err)
+	}
+
+	return count, nil
+}
+
+// executionWhereClause builds the WHERE clause (including the leading
+// keyword) and arguments for an execution filter
+func executionWhereClause(filter *ExecutionFilter) (string, []interface{}, error) {
 	var conditions []string
 	var args []interface{}
 
@@ -158,76 +163,85 @@ func (d *Database) CountExecutions(filter *ExecutionFilter) (int, error) {
 		args = append(args, *filter.Success)
 	}
 
-	if filter.SearchQuery != "" {
-		conditions = append(conditions, "(wheresmyprompt_query LIKE ? OR command_args LIKE ? OR error_message LIKE ?)")
-		searchPattern := "%" + filter.SearchQuery + "%"
-		args = append(args, searchPattern, searchPattern, searchPattern)
+	if filter.MinDuration != nil {
+		conditions = append(conditions, "execution_time_ms >= ?")
+		args = append(args, *filter.MinDuration)
 	}
 
-	query := "SELECT COUNT(*) FROM waffles_executions"
+	if filter.MaxDuration != nil {
+		conditions = append(conditions, "execution_time_ms <= ?")
+		args = append(args, *filter.MaxDuration)
+	}
 
-	if len(conditions) > 0 {
-		query += " WHERE " + strings.Join(conditions, " AND ")
+	if filter.MinFileCount != nil {
+		conditions = append(conditions, "file_count >= ?")
+		args = append(args, *filter.MinFileCount)
 	}
 
-	var count int
-	err := d.db.QueryRow(query, args...).Scan(&count)
-	if err != nil {
-		return 0, fmt.Errorf("failed to count executions: %w", err)
+	if filter.MaxFileCount != nil {
+		conditions = append(conditions, "file_count <= ?")
+		args = append(args, *filter.MaxFileCount)
 	}
 
-	return count, nil
-}
+	if filter.Source != "" {
+		conditions = append(conditions, "source = ?")
+		args = append(args, filter.Source)
+	}
 
-// GetExecutionStats calculates statistics about executions
-func (d *Database) GetExecutionStats(filter *ExecutionFilter) (*ExecutionStats, error) {
-	stats := &ExecutionStats{
-		LanguageBreakdown: make(map[string]int),
-		ModelBreakdown:    make(map[string]int),
-		ProviderBreakdown: make(map[string]int),
+	for _, condition := range filter.Conditions {
+		if err := condition.Validate(); err != nil {
+			return "", nil, fmt.Errorf("invalid filter condition: %w", err)
+		}
+		clause, arg := condition.sql()
+		conditions = append(conditions, clause)
+		args = append(args, arg)
 	}
 
-	// Build WHERE clause for filtering
-	var conditions []string
-	var args []interface{}
+	if filter.SearchQuery != "" {
+		conditions = append(conditions, `(wheresmyprompt_query LIKE ? ESCAPE '\' OR command_args LIKE ? ESCAPE '\' OR error_message LIKE ? ESCAPE '\')`)
+		searchPattern := "%" + likeEscaper.Replace(filter.SearchQuery) + "%"
+		args = append(args, searchPattern, searchPattern, searchPattern)
+	}
 
-	if filter != nil {
-		if filter.DateFrom != nil {
-			conditions = append(conditions, "created >= ?")
-			args = append(args, *filter.DateFrom)
-		}
+	if len(conditions) == 0 {
+		return "", args, nil
+	}
 
-		if filter.DateTo != nil {
-			conditions = append(conditions, "created <= ?")
-			args = append(args, *filter.DateTo)
-		}
+	return " WHERE " + strings.Join(conditions, " AND "), args, nil
+}
+
+// GetExecutionStats calculates statistics about the executions matching the
+// filter, ignoring Limit and Offset
+func (d *Database) GetExecutionStats(filter *ExecutionFilter) (*ExecutionStats, error) {
+	if filter == nil {
+		filter = &ExecutionFilter{}
 	}
 
-	whereClause := ""
-	if len(conditions) > 0 {
-		whereClause = " WHERE " + strings.Join(conditions, " AND ")
+	where, args, err := executionWhereClause(filter)
+	if err != nil {
+		return nil, err
 	}
 
+	stats := &ExecutionStats{}
+
 	// Get basic counts and averages
 	query := `
 		SELECT
 			COUNT(*) as total,
-			SUM(CASE WHEN success = 1 THEN 1 ELSE 0 END) as successful,
-			SUM(CASE WHEN success = 0 THEN 1 ELSE 0 END) as failed,
+			COALESCE(SUM(CASE WHEN success = 1 THEN 1 ELSE 0 END), 0) as successful,
+			COALESCE(SUM(CASE WHEN success = 0 THEN 1 ELSE 0 END), 0) as failed,
 			AVG(execution_time_ms) as avg_time,
-			SUM(file_count) as total_files
-		FROM waffles_executions`
-
-	if whereClause != "" {
-		query += whereClause
-	}
+			COALESCE(SUM(execution_time_ms), 0) as total_time,
+			COALESCE(SUM(file_count), 0) as total_files
+		FROM waffles_executions` + where
 
 	var avgTime sql.NullFloat64
-	err := d.db.QueryRow(query, args...).Scan(
+	err = d.db.QueryRow(query, args...).Scan(
 		&stats.TotalExecutions,
 		&stats.SuccessfulExecutions,
 		&stats.FailedExecutions,
 		&avgTime,
+		&stats.TotalExecutionTime,
 		&stats.TotalFiles,
 	)
 	if err != nil {
@@ -238,89 +252,16 @@ func (d *Database) GetExecutionStats(filter *ExecutionFilter) (*ExecutionStats,
 		stats.AverageExecutionTime = avgTime.Float64
 	}
 
-	// Get language breakdown
-	query = `
-		SELECT detected_language, COUNT(*)
-		FROM waffles_executions`
-
-	if whereClause != "" {
-		query += whereClause
-	}
-
-	query += " GROUP BY detected_language"
-
-	rows, err := d.db.Query(query, args...)
-	if err != nil {
+	// Get language, model and provider breakdowns
+	if stats.LanguageBreakdown, err = d.groupCounts("COALESCE(detected_language, '')", where, args); err != nil {
 		return nil, fmt.Errorf("failed to get language breakdown: %w", err)
 	}
-	defer rows.Close()
-
-	for rows.Next() {
-		var language string
-		var count int
-		if err := rows.Scan(&language, &count); err != nil {
-			return nil, fmt.Errorf("failed to scan language breakdown: %w", err)
-		}
-		if language != "" {
-			stats.LanguageBreakdown[language] = count
-		}
-	}
-
-	// Get model breakdown
-	query = `
-		SELECT model_used, COUNT(*)
-		FROM waffles_executions`
-
-	if whereClause != "" {
-		query += whereClause
-	}
-
-	query += " GROUP BY model_used"
-
-	rows, err = d.db.Query(query, args...)
-	if err != nil {
+	if stats.ModelBreakdown, err = d.groupCounts("COALESCE(model_used, '')", where, args); err != nil {
 		return nil, fmt.Errorf("failed to get model breakdown: %w", err)
 	}
-	defer rows.Close()
-
-	for rows.Next() {
-		var model string
-		var count int
-		if err := rows.Scan(&model, &count); err != nil {
-			return nil, fmt.Errorf("failed to scan model breakdown: %w", err)
-		}
-		if model != "" {
-			stats.ModelBreakdown[model] = count
-		}
-	}
-
-	// Get provider breakdown
-	query = `
-		SELECT provider_used, COUNT(*)
-		FROM waffles_executions`
-
-	if whereClause != "" {
-		query += whereClause
-	}
-
-	query += " GROUP BY provider_used"
-
-	rows, err = d.db.Query(query, args...)
-	if err != nil {
+	if stats.ProviderBreakdown, err = d.groupCounts("COALESCE(provider_used, '')", where, args); err != nil {
 		return nil, fmt.Errorf("failed to get provider breakdown: %w", err)
 	}
-	defer rows.Close()
-
-	for rows.Next() {
-		var provider string
-		var count int
-		if err := rows.Scan(&provider, &count); err != nil {
-			return nil, fmt.Errorf("failed to scan provider breakdown: %w", err)
-		}
-		if provider != "" {
-			stats.ProviderBreakdown[provider] = count
-		}
-	}
 
 	return stats, nil
 }
@@ -400,9 +341,9 @@ func (d *Database) exportCSV(executions []WafflesExecution, options *ExportOptio
 	// Write header
 	header := []string{
 		"ID", "ConversationID", "CommandArgs", "WheresmypromptQuery",
-		"Files2promptArgs", "LLMArgs", "DetectedLanguage", "FileCount",
-		"ExecutionTimeMS", "Success", "ErrorMessage", "ModelUsed",
-		"ProviderUsed", "Created", "Updated",
+		"WheresmypromptArgs", "Files2promptArgs", "LLMArgs", "DetectedLanguage",
+		"FileCount", "ExecutionTimeMS", "Success", "ErrorMessage", "ModelUsed",
+		"ProviderUsed", "ParentExecutionID", "Source", "Created", "Updated",
 	}
 
 	if err := csvWriter.Write(header); err != nil {
@@ -413,9 +354,9 @@ func (d *Database) exportCSV(executions []WafflesExecution, options *ExportOptio
 	for _, exec := range executions {
 		record := []string{
 			exec.ID, exec.ConversationID, exec.CommandArgs, exec.WheresmypromptQuery,
-			exec.Files2promptArgs, exec.LLMArgs, exec.DetectedLanguage, fmt.Sprintf("%d", exec.FileCount),
-			fmt.Sprintf("%d", exec.ExecutionTimeMS), fmt.Sprintf("%t", exec.Success), exec.ErrorMessage, exec.ModelUsed,
-			exec.ProviderUsed, exec.Created.Format(time.RFC3339), exec.Updated.Format(time.RFC3339),
+			exec.WheresmypromptArgs, exec.Files2promptArgs, exec.LLMArgs, exec.DetectedLanguage,
+			fmt.Sprintf("%d", exec.FileCount), fmt.Sprintf("%d", exec.ExecutionTimeMS), fmt.Sprintf("%t", exec.Success), exec.ErrorMessage, exec.ModelUsed,
+			exec.ProviderUsed, exec.ParentExecutionID, exec.Source, exec.Created.Format(time.RFC3339), exec.Updated.Format(time.RFC3339),
 		}
 
 		if err := csvWriter.Write(record); err != nil {
@@ -0,0 +1,213 @@
+package logging
+
+import (
+	"database/sql"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// RetentionPolicy describes which executions are removed or trimmed when pruning
+type RetentionPolicy struct {
+	MaxAge           time.Duration `json:"max_age"`            // Delete executions older than this (0 = never)
+	StripOutputAfter time.Duration `json:"strip_output_after"` // Clear step output older than this, keeping metadata (0 = never)
+	KeepFailed       bool          `json:"keep_failed"`        // Never delete or strip failed executions
+	KeepLast         int           `json:"keep_last"`          // Always keep the N most recent executions
+	DryRun           bool          `json:"dry_run"`            // Only count what would be affected
+}
+
+// IsEmpty reports whether the policy would never prune anything
+func (p *RetentionPolicy) IsEmpty() bool {
+	return p == nil || (p.MaxAge <= 0 && p.StripOutputAfter <= 0)
+}
+
+// PruneResult reports what a prune removed (or would remove in dry-run mode)
+type PruneResult struct {
+	DeletedExecutions int  `json:"deleted_executions"`
+	DeletedFiles      int  `json:"deleted_files"`
+	DeletedSteps      int  `json:"deleted_steps"`
+	DetachedChildren  int  `json:"detached_children"` // Kept executions whose parent was deleted
+	StrippedSteps     int  `json:"stripped_steps"`
+	DryRun            bool `json:"dry_run"`
+}
+
+// Prune applies a retention policy, deleting old executions together with their
+// files and steps, and optionally stripping step output from older executions.
+// Executions whose parent is deleted are kept with their parent ID cleared.
+func (d *Database) Prune(policy *RetentionPolicy) (*PruneResult, error) {
+	result := &PruneResult{}
+	if policy.IsEmpty() {
+		return result, nil
+	}
+	result.DryRun = policy.DryRun
+
+	tx, err := d.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin prune transaction: %w", err)
+	}
+	defer func() {
+		// Only rollback if transaction hasn't been committed
+		_ = tx.Rollback() // Ignore rollback errors on deferred cleanup
+	}()
+
+	now := time.Now()
+
+	// Executions deleted by MaxAge are excluded from stripping, so that dry
+	// runs count the same steps as real ones
+	notDeleted, notDeletedArgs := "", []interface{}(nil)
+
+	if policy.MaxAge > 0 {
+		where, args := retentionCondition(now.Add(-policy.MaxAge), policy)
+		notDeleted, notDeletedArgs = " AND NOT ("+where+")", args
+		selectIDs := "SELECT id FROM waffles_executions WHERE " + where
+		childCondition := "parent_execution_id IN (" + selectIDs + ")" + notDeleted
+		childArgs := append(append([]interface{}{}, args...), args...)
+
+		if policy.DryRun {
+			if err := countRows(tx, &result.DeletedSteps, "SELECT COUNT(*) FROM waffles_steps WHERE execution_id IN ("+selectIDs+")", args...); err != nil {
+				return nil, fmt.Errorf("failed to count steps to prune: %w", err)
+			}
+			if err := countRows(tx, &result.DeletedFiles, "SELECT COUNT(*) FROM waffles_files WHERE execution_id IN ("+selectIDs+")", args...); err != nil {
+				return nil, fmt.Errorf("failed to count files to prune: %w", err)
+			}
+			if err := countRows(tx, &result.DeletedExecutions, "SELECT COUNT(*) FROM waffles_executions WHERE "+where, args...); err != nil {
+				return nil, fmt.Errorf("failed to count executions to prune: %w", err)
+			}
+			if err := countRows(tx, &result.DetachedChildren, "SELECT COUNT(*) FROM waffles_executions WHERE "+childCondition, childArgs...); err != nil {
+				return nil, fmt.Errorf("failed to count child executions: %w", err)
+			}
+		} else {
+			// Kept children would otherwise point at executions that no longer exist
+			if err := execCount(tx, &result.DetachedChildren, "UPDATE waffles_executions SET parent_execution_id = NULL WHERE "+childCondition, childArgs...); err != nil {
+				return nil, fmt.Errorf("failed to detach child executions: %w", err)
+			}
+			// Child rows first, since the schema does not enforce ON DELETE CASCADE
+			if err := execCount(tx, &result.DeletedSteps, "DELETE FROM waffles_steps WHERE execution_id IN ("+selectIDs+")", args...); err != nil {
+				return nil, fmt.Errorf("failed to prune steps: %w", err)
+			}
+			if err := execCount(tx, &result.DeletedFiles, "DELETE FROM waffles_files WHERE execution_id IN ("+selectIDs+")", args...); err != nil {
+				return nil, fmt.Errorf("failed to prune files: %w", err)
+			}
+			if err := execCount(tx, &result.DeletedExecutions, "DELETE FROM waffles_executions WHERE "+where, args...); err != nil {
+				return nil, fmt.Errorf("failed to prune executions: %w", err)
+			}
+		}
+	}
+
+	if policy.StripOutputAfter > 0 {
+		where, args := retentionCondition(now.Add(-policy.StripOutputAfter), policy)
+		stepCondition := `execution_id IN (SELECT id FROM waffles_executions WHERE ` + where + notDeleted + `)
+			AND (COALESCE(output, '') != '' OR COALESCE(error_output, '') != '')`
+		args = append(args, notDeletedArgs...)
+
+		if policy.DryRun {
+			if err := countRows(tx, &result.StrippedSteps, "SELECT COUNT(*) FROM waffles_steps WHERE "+stepCondition, args...); err != nil {
+				return nil, fmt.Errorf("failed to count step output to strip: %w", err)
+			}
+		} else {
+			if err := execCount(tx, &result.StrippedSteps, "UPDATE waffles_steps SET output = '', error_output = '' WHERE "+stepCondition, args...); err != nil {
+				return nil, fmt.Errorf("failed to strip step output: %w", err)
+			}
+		}
+	}
+
+	if policy.DryRun {
+		return result, nil
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit prune: %w", err)
+	}
+
+	return result, nil
+}
+
+// Vacuum rebuilds the database file to reclaim space freed by pruning and
+// returns the file size before and after
+func (d *Database) Vacuum() (before, after int64, err error) {
+	before = d.fileSize()
+
+	if _, err := d.db.Exec("VACUUM"); err != nil {
+		return before, before, fmt.Errorf("failed to vacuum database: %w", err)
+	}
+
+	return before, d.fileSize(), nil
+}
+
+// fileSize returns the size of the database file, or 0 for in-memory databases
+func (d *Database) fileSize() int64 {
+	info, err := os.Stat(d.path)
+	if err != nil {
+		return 0
+	}
+	return info.Size()
+}
+
+// retentionCondition builds the WHERE clause selecting executions covered by
+// a policy. Times are compared as julian days, since the stored text sorts
+// wrongly across UTC offsets.
+func retentionCondition(cutoff time.Time, policy *RetentionPolicy) (string, []interface{}) {
+	conditions := []string{"julianday(created) < julianday(?)"}
+	args := []interface{}{cutoff.UTC()}
+
+	if policy.KeepFailed {
+		conditions = append(conditions, "success = 1")
+	}
+
+	if policy.KeepLast > 0 {
+		conditions = append(conditions, "id NOT IN (SELECT id FROM waffles_executions ORDER BY julianday(created) DESC LIMIT ?)")
+		args = append(args, policy.KeepLast)
+	}
+
+	return strings.Join(conditions, " AND "), args
+}
+
+// countRows runs a COUNT query inside a transaction
+func countRows(tx *sql.Tx, count *int, query string, args ...interface{}) error {
+	return tx.QueryRow(query, args...).Scan(count)
+}
+
+// execCount runs a statement inside a transaction and records the affected row count
+func execCount(tx *sql.Tx, count *int, query string, args ...interface{}) error {
+	res, err := tx.Exec(query, args...)
+	if err != nil {
+		return err
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	*count = int(affected)
+	return nil
+}
+
+// ParseAge parses retention ages such as "90d", "12w", "1y" or any
+// time.ParseDuration value like "36h". An empty string means no limit.
+func ParseAge(value string) (time.Duration, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0, nil
+	}
+
+	units := map[byte]time.Duration{
+		'd': 24 * time.Hour,
+		'w': 7 * 24 * time.Hour,
+		'y': 365 * 24 * time.Hour,
+	}
+
+	if unit, exists := units[value[len(value)-1]]; exists {
+		count, err := strconv.Atoi(value[:len(value)-1])
+		if err != nil || count < 0 {
+			return 0, fmt.Errorf("invalid age: %s", value)
+		}
+		return time.Duration(count) * unit, nil
+	}
+
+	duration, err := time.ParseDuration(value)
+	if err != nil || duration < 0 {
+		return 0, fmt.Errorf("invalid age: %s (use e.g. 90d, 12w, 1y or 36h)", value)
+	}
+	return duration, nil
+}
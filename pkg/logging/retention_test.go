@@ -0,0 +1,279 @@
+package logging
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// newRetentionTestDB creates a file database with executions of varying age
+// and outcome, each with one file and one step
+func newRetentionTestDB(t *testing.T) *Database {
+	t.Helper()
+
+	db, err := NewDatabase(filepath.Join(t.TempDir(), "retention.db"))
+	if err != nil {
+		t.Fatalf("Failed to create database: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	now := time.Now()
+	executions := []struct {
+		id      string
+		age     time.Duration
+		success bool
+	}{
+		{"old-success", 200 * 24 * time.Hour, true},
+		{"old-failure", 150 * 24 * time.Hour, false},
+		{"mid-success", 40 * 24 * time.Hour, true},
+		{"new-success", time.Hour, true},
+	}
+
+	for _, e := range executions {
+		exec := &WafflesExecution{ID: e.id, Success: e.success, Created: now.Add(-e.age)}
+		if err := db.LogExecution(exec); err != nil {
+			t.Fatalf("Failed to log execution: %v", err)
+		}
+		if err := db.LogFiles(e.id, []WafflesFile{{FilePath: "main.go", Included: true}}); err != nil {
+			t.Fatalf("Failed to log files: %v", err)
+		}
+		if err := db.LogSteps(e.id, []WafflesStep{{Tool: "llm", Output: "answer", ErrorOutput: "warn", Success: e.success, StepOrder: 1}}); err != nil {
+			t.Fatalf("Failed to log steps: %v", err)
+		}
+	}
+
+	return db
+}
+
+func countTable(t *testing.T, db *Database, table string) int {
+	t.Helper()
+	var count int
+	if err := db.db.QueryRow("SELECT COUNT(*) FROM " + table).Scan(&count); err != nil {
+		t.Fatalf("Failed to count %s: %v", table, err)
+	}
+	return count
+}
+
+func TestPrune(t *testing.T) {
+	tests := []struct {
+		name           string
+		policy         RetentionPolicy
+		wantExecutions int
+		wantRemaining  []string
+	}{
+		{
+			name:           "max age",
+			policy:         RetentionPolicy{MaxAge: 90 * 24 * time.Hour},
+			wantExecutions: 2,
+			wantRemaining:  []string{"mid-success", "new-success"},
+		},
+		{
+			name:           "keep failed",
+			policy:         RetentionPolicy{MaxAge: 90 * 24 * time.Hour, KeepFailed: true},
+			wantExecutions: 1,
+			wantRemaining:  []string{"old-failure", "mid-success", "new-success"},
+		},
+		{
+			name:           "keep last",
+			policy:         RetentionPolicy{MaxAge: 24 * time.Hour, KeepLast: 3},
+			wantExecutions: 1,
+			wantRemaining:  []string{"old-failure", "mid-success", "new-success"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := newRetentionTestDB(t)
+
+			result, err := db.Prune(&tt.policy)
+			if err != nil {
+				t.Fatalf("Prune failed: %v", err)
+			}
+
+			if result.DeletedExecutions != tt.wantExecutions {
+				t.Errorf("Expected %d deleted executions, got %d", tt.wantExecutions, result.DeletedExecutions)
+			}
+			if result.DeletedFiles != tt.wantExecutions || result.DeletedSteps != tt.wantExecutions {
+				t.Errorf("Expected files and steps to cascade, got %d files and %d steps", result.DeletedFiles, result.DeletedSteps)
+			}
+
+			for _, id := range tt.wantRemaining {
+				if _, err := db.GetExecution(id); err != nil {
+					t.Errorf("Expected execution %s to remain: %v", id, err)
+				}
+			}
+			if got := countTable(t, db, "waffles_files"); got != len(tt.wantRemaining) {
+				t.Errorf("Expected %d remaining files, got %d", len(tt.wantRemaining), got)
+			}
+			if got := countTable(t, db, "waffles_steps"); got != len(tt.wantRemaining) {
+				t.Errorf("Expected %d remaining steps, got %d", len(tt.wantRemaining), got)
+			}
+		})
+	}
+}
+
+func TestPruneAcrossOffsets(t *testing.T) {
+	db, err := NewDatabase(filepath.Join(t.TempDir(), "offsets.db"))
+	if err != nil {
+		t.Fatalf("Failed to create database: %v", err)
+	}
+	defer db.Close()
+
+	// Stored as text, these sort the wrong way around the cutoff: the older
+	// one reads as later and the newer one as earlier
+	cutoff := time.Now().Add(-24 * time.Hour)
+	for id, created := range map[string]time.Time{
+		"older-east": cutoff.Add(-2 * time.Hour).In(time.FixedZone("east", 14*60*60)),
+		"newer-west": cutoff.Add(2 * time.Hour).In(time.FixedZone("west", -12*60*60)),
+	} {
+		if err := db.LogExecution(&WafflesExecution{ID: id, Success: true, Created: created}); err != nil {
+			t.Fatalf("Failed to log execution: %v", err)
+		}
+	}
+
+	result, err := db.Prune(&RetentionPolicy{MaxAge: 24 * time.Hour})
+	if err != nil {
+		t.Fatalf("Prune failed: %v", err)
+	}
+	if result.DeletedExecutions != 1 {
+		t.Errorf("Expected 1 deleted execution, got %d", result.DeletedExecutions)
+	}
+	if _, err := db.GetExecution("older-east"); err == nil {
+		t.Error("Expected the older execution to be pruned")
+	}
+	if _, err := db.GetExecution("newer-west"); err != nil {
+		t.Errorf("Expected the newer execution to remain: %v", err)
+	}
+}
+
+func TestPruneDryRun(t *testing.T) {
+	db := newRetentionTestDB(t)
+	policy := RetentionPolicy{MaxAge: 90 * 24 * time.Hour, StripOutputAfter: 30 * 24 * time.Hour, DryRun: true}
+
+	result, err := db.Prune(&policy)
+	if err != nil {
+		t.Fatalf("Prune failed: %v", err)
+	}
+
+	// Steps of executions that are deleted are not also counted as stripped
+	if !result.DryRun || result.DeletedExecutions != 2 || result.StrippedSteps != 1 {
+		t.Errorf("Unexpected dry-run result: %+v", result)
+	}
+	if got := countTable(t, db, "waffles_executions"); got != 4 {
+		t.Errorf("Dry run should not delete executions, %d remain", got)
+	}
+
+	policy.DryRun = false
+	actual, err := db.Prune(&policy)
+	if err != nil {
+		t.Fatalf("Prune failed: %v", err)
+	}
+	actual.DryRun = true
+	if *actual != *result {
+		t.Errorf("Expected the dry run to match the prune, got %+v and %+v", result, actual)
+	}
+}
+
+func TestPruneDetachesChildren(t *testing.T) {
+	db := newRetentionTestDB(t)
+
+	now := time.Now()
+	for _, exec := range []*WafflesExecution{
+		{ID: "new-child", ParentExecutionID: "old-success", Success: true, Created: now},
+		{ID: "old-child", ParentExecutionID: "old-success", Success: true, Created: now.Add(-100 * 24 * time.Hour)},
+		{ID: "kept-child", ParentExecutionID: "new-success", Success: true, Created: now},
+	} {
+		if err := db.LogExecution(exec); err != nil {
+			t.Fatalf("Failed to log execution: %v", err)
+		}
+	}
+
+	policy := RetentionPolicy{MaxAge: 90 * 24 * time.Hour, DryRun: true}
+	result, err := db.Prune(&policy)
+	if err != nil || result.DetachedChildren != 1 {
+		t.Errorf("Expected one child to be detached in a dry run, got %+v: %v", result, err)
+	}
+
+	policy.DryRun = false
+	result, err = db.Prune(&policy)
+	if err != nil || result.DeletedExecutions != 3 || result.DetachedChildren != 1 {
+		t.Fatalf("Expected one child to be detached, got %+v: %v", result, err)
+	}
+
+	for id, parent := range map[string]string{"new-child": "", "kept-child": "new-success"} {
+		exec, err := db.GetExecution(id)
+		if err != nil || exec.ParentExecutionID != parent {
+			t.Errorf("Expected %s to have parent %q, got %+v: %v", id, parent, exec, err)
+		}
+	}
+}
+
+func TestPruneStripOutput(t *testing.T) {
+	db := newRetentionTestDB(t)
+
+	result, err := db.Prune(&RetentionPolicy{StripOutputAfter: 30 * 24 * time.Hour})
+	if err != nil {
+		t.Fatalf("Prune failed: %v", err)
+	}
+
+	if result.DeletedExecutions != 0 || result.StrippedSteps != 3 {
+		t.Errorf("Unexpected strip result: %+v", result)
+	}
+
+	steps, err := db.GetExecutionSteps("old-success")
+	if err != nil || len(steps) != 1 {
+		t.Fatalf("Expected stripped step to remain: %v", err)
+	}
+	if steps[0].Output != "" || steps[0].ErrorOutput != "" || steps[0].Tool != "llm" {
+		t.Errorf("Expected output stripped and metadata kept, got %+v", steps[0])
+	}
+
+	steps, err = db.GetExecutionSteps("new-success")
+	if err != nil || len(steps) != 1 || steps[0].Output != "answer" {
+		t.Errorf("Expected recent output to be kept")
+	}
+}
+
+func TestVacuum(t *testing.T) {
+	db := newRetentionTestDB(t)
+
+	if _, err := db.Prune(&RetentionPolicy{MaxAge: time.Minute}); err != nil {
+		t.Fatalf("Prune failed: %v", err)
+	}
+
+	before, after, err := db.Vacuum()
+	if err != nil {
+		t.Fatalf("Vacuum failed: %v", err)
+	}
+	if before == 0 || after > before {
+		t.Errorf("Unexpected sizes before=%d after=%d", before, after)
+	}
+}
+
+func TestParseAge(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    time.Duration
+		wantErr bool
+	}{
+		{"", 0, false},
+		{"90d", 90 * 24 * time.Hour, false},
+		{"2w", 14 * 24 * time.Hour, false},
+		{"1y", 365 * 24 * time.Hour, false},
+		{"36h", 36 * time.Hour, false},
+		{"abc", 0, true},
+		{"-5d", 0, true},
+		{"d", 0, true},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseAge(tt.input)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseAge(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ParseAge(%q) = %v, want %v", tt.input, got, tt.want)
+		}
+	}
+}
@@ -81,11 +81,50 @@ CREATE TRIGGER IF NOT EXISTS update_waffles_executions_updated
     END;
 `
 
+// ExecutionLineageSchema records the inputs needed to replay an execution and
+// links re-runs back to the execution they were derived from
+const ExecutionLineageSchema = `
+ALTER TABLE waffles_executions ADD COLUMN wheresmyprompt_args TEXT;
+ALTER TABLE waffles_executions ADD COLUMN parent_execution_id TEXT;
+
+CREATE INDEX IF NOT EXISTS idx_waffles_executions_parent_id ON waffles_executions(parent_execution_id);
+`
+
+// ExecutionSourceSchema tags executions with where they were imported from,
+// so histories from several machines can be merged into one database
+const ExecutionSourceSchema = `
+ALTER TABLE waffles_executions ADD COLUMN source TEXT;
+
+CREATE INDEX IF NOT EXISTS idx_waffles_executions_source ON waffles_executions(source);
+`
+
 // MigrationQueries contains versioned migration queries
 var MigrationQueries = map[int]string{
 	1: WafflesSchema,
+	2: ExecutionLineageSchema,
+	3: ExecutionSourceSchema,
 	// Future migrations can be added here
-	// 2: "ALTER TABLE waffles_executions ADD COLUMN new_field TEXT;",
+	// 4: "ALTER TABLE waffles_executions ADD COLUMN new_field TEXT;",
+}
+
+// DownMigrationQueries contains the queries that revert each versioned
+// migration, returning the schema to the previous version
+var DownMigrationQueries = map[int]string{
+	1: `
+DROP TRIGGER IF EXISTS update_waffles_executions_updated;
+DROP TABLE IF EXISTS waffles_steps;
+DROP TABLE IF EXISTS waffles_files;
+DROP TABLE IF EXISTS waffles_executions;
+`,
+	2: `
+DROP INDEX IF EXISTS idx_waffles_executions_parent_id;
+ALTER TABLE waffles_executions DROP COLUMN parent_execution_id;
+ALTER TABLE waffles_executions DROP COLUMN wheresmyprompt_args;
+`,
+	3: `
+DROP INDEX IF EXISTS idx_waffles_executions_source;
+ALTER TABLE waffles_executions DROP COLUMN source;
+`,
 }
 
 // GetCurrentSchemaVersion returns the current schema version
@@ -105,6 +144,12 @@ func GetMigrationQuery(version int) (string, bool) {
 	return query, exists
 }
 
+// GetDownMigrationQuery returns the query that reverts a specific version
+func GetDownMigrationQuery(version int) (string, bool) {
+	query, exists := DownMigrationQueries[version]
+	return query, exists
+}
+
 // GetRequiredMigrations returns all migrations needed from current to target version
 func GetRequiredMigrations(currentVersion, targetVersion int) []string {
 	var migrations []string
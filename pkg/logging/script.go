@@ -0,0 +1,283 @@
+package logging
+
+import (
+	"fmt"
+	"strings"
+)
+
+// scriptTables are the tables a SQL export may insert into
+var scriptTables = []string{"waffles_executions", "waffles_files", "waffles_steps"}
+
+// scriptInserts splits a SQL export into its statements and checks that each
+// is a plain INSERT of literal values into one of scriptTables. The schema a
+// SQL export may start with is skipped, since the database already has it.
+// Anything else, from DROP TABLE to ATTACH DATABASE, PRAGMA or a subquery, is
+// rejected.
+func scriptInserts(script string) ([]string, error) {
+	statements, err := splitScript(script)
+	if err != nil {
+		return nil, err
+	}
+
+	var inserts []string
+	for _, statement := range statements {
+		tokens, err := sqlTokens(statement)
+		if err != nil {
+			return nil, err
+		}
+		if isSchemaStatement(tokens) {
+			continue
+		}
+		if err := checkInsert(tokens); err != nil {
+			return nil, fmt.Errorf("%w in statement: %s", err, abbreviate(statement))
+		}
+		inserts = append(inserts, statement)
+	}
+	return inserts, nil
+}
+
+// splitScript splits a SQL script into statements at semicolons outside of
+// quotes, dropping comments and empty statements
+func splitScript(script string) ([]string, error) {
+	var statements []string
+	var current strings.Builder
+	flush := func() {
+		if statement := strings.TrimSpace(current.String()); statement != "" {
+			statements = append(statements, statement)
+		}
+		current.Reset()
+	}
+
+	for i := 0; i < len(script); i++ {
+		c := script[i]
+		switch {
+		case c == '\'' || c == '"':
+			end, err := quoteEnd(script, i)
+			if err != nil {
+				return nil, err
+			}
+			current.WriteString(script[i : end+1])
+			i = end
+		case c == '-' && strings.HasPrefix(script[i:], "--"):
+			end := strings.IndexByte(script[i:], '\n')
+			if end < 0 {
+				end = len(script) - i
+			}
+			current.WriteByte(' ')
+			i += end
+		case c == '/' && strings.HasPrefix(script[i:], "/*"):
+			end := strings.Index(script[i+2:], "*/")
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated comment in SQL script")
+			}
+			current.WriteByte(' ')
+			i += end + 3
+		case c == ';':
+			flush()
+		default:
+			current.WriteByte(c)
+		}
+	}
+	flush()
+	return statements, nil
+}
+
+// quoteEnd returns the index of the quote closing the one at start, where a
+// doubled quote stands for the quote itself
+func quoteEnd(script string, start int) (int, error) {
+	quote := script[start]
+	for i := start + 1; i < len(script); i++ {
+		if script[i] != quote {
+			continue
+		}
+		if i+1 < len(script) && script[i+1] == quote {
+			i++
+			continue
+		}
+		return i, nil
+	}
+	return 0, fmt.Errorf("unterminated quote in SQL script")
+}
+
+// sqlTokens splits a statement into keywords and identifiers, quoted strings
+// and identifiers, numbers and punctuation. Any other character is rejected.
+func sqlTokens(statement string) ([]string, error) {
+	var tokens []string
+	for i := 0; i < len(statement); {
+		c := statement[i]
+		start := i
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+			continue
+		case c == '\'' || c == '"':
+			end, err := quoteEnd(statement, i)
+			if err != nil {
+				return nil, err
+			}
+			i = end + 1
+		case isWordByte(c):
+			for i < len(statement) && (isWordByte(statement[i]) || (statement[i] == '.' && c >= '0' && c <= '9')) {
+				i++
+			}
+		case c == '(' || c == ')' || c == ',' || c == '-' || c == '+':
+			i++
+		default:
+			return nil, fmt.Errorf("unexpected %q in SQL script", c)
+		}
+		tokens = append(tokens, statement[start:i])
+	}
+	return tokens, nil
+}
+
+// isWordByte tells whether c can be part of a keyword, identifier or number
+func isWordByte(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9')
+}
+
+// isSchemaStatement tells whether tokens create one of scriptTables if it
+// does not exist, as the schema of a SQL export does
+func isSchemaStatement(tokens []string) bool {
+	return len(tokens) > 5 && keywords(tokens[:5], "CREATE", "TABLE", "IF", "NOT", "EXISTS") &&
+		contains(scriptTables, identifier(tokens[5]))
+}
+
+// checkInsert checks that tokens form an INSERT of literal values into one
+// of scriptTables:
+//
+//	INSERT INTO table (column, ...) VALUES (literal, ...), ...
+func checkInsert(tokens []string) error {
+	if len(tokens) < 3 || !keywords(tokens[:2], "INSERT", "INTO") {
+		return fmt.Errorf("only INSERT statements are allowed")
+	}
+	if table := identifier(tokens[2]); !contains(scriptTables, table) {
+		return fmt.Errorf("inserting into %s is not allowed", tokens[2])
+	}
+
+	rest := tokens[3:]
+	rest, err := checkList(rest, func(token string) bool { return identifier(token) != "" })
+	if err != nil {
+		return fmt.Errorf("invalid column list: %w", err)
+	}
+	if len(rest) == 0 || !keywords(rest[:1], "VALUES") {
+		return fmt.Errorf("only INSERT ... VALUES statements are allowed")
+	}
+	rest = rest[1:]
+	for {
+		if rest, err = checkList(rest, isLiteral); err != nil {
+			return fmt.Errorf("invalid values: %w", err)
+		}
+		if len(rest) == 0 {
+			return nil
+		}
+		if rest[0] != "," {
+			return fmt.Errorf("unexpected %s after values", rest[0])
+		}
+		rest = rest[1:]
+	}
+}
+
+// checkList checks that tokens start with a parenthesized, comma-separated
+// list of items accepted by valid, with an optional sign before numbers, and
+// returns the tokens after it
+func checkList(tokens []string, valid func(string) bool) ([]string, error) {
+	if len(tokens) == 0 || tokens[0] != "(" {
+		return nil, fmt.Errorf("expected (")
+	}
+	i := 1
+	for {
+		if i < len(tokens) && (tokens[i] == "-" || tokens[i] == "+") && i+1 < len(tokens) && isNumber(tokens[i+1]) {
+			i++
+		}
+		if i >= len(tokens) || !valid(tokens[i]) {
+			return nil, fmt.Errorf("unexpected %s", tokenAt(tokens, i))
+		}
+		i++
+		if i >= len(tokens) {
+			return nil, fmt.Errorf("expected )")
+		}
+		switch tokens[i] {
+		case ",":
+			i++
+		case ")":
+			return tokens[i+1:], nil
+		default:
+			return nil, fmt.Errorf("unexpected %s", tokens[i])
+		}
+	}
+}
+
+// isLiteral tells whether a token is a string, number, NULL or boolean
+func isLiteral(token string) bool {
+	if token[0] == '\'' || isNumber(token) {
+		return true
+	}
+	switch strings.ToUpper(token) {
+	case "NULL", "TRUE", "FALSE":
+		return true
+	}
+	return false
+}
+
+// isNumber tells whether a token is an integer or decimal number
+func isNumber(token string) bool {
+	dot := false
+	for i := 0; i < len(token); i++ {
+		switch {
+		case token[i] >= '0' && token[i] <= '9':
+		case token[i] == '.' && !dot && i > 0:
+			dot = true
+		default:
+			return false
+		}
+	}
+	return token != ""
+}
+
+// identifier returns a bare or double-quoted identifier in lower case, or ""
+// if the token is neither
+func identifier(token string) string {
+	if strings.HasPrefix(token, `"`) {
+		return strings.ToLower(strings.ReplaceAll(token[1:len(token)-1], `""`, `"`))
+	}
+	if !isWordByte(token[0]) || (token[0] >= '0' && token[0] <= '9') {
+		return ""
+	}
+	return strings.ToLower(token)
+}
+
+// keywords tells whether tokens are the given keywords, ignoring case
+func keywords(tokens []string, want ...string) bool {
+	for i, keyword := range want {
+		if !strings.EqualFold(tokens[i], keyword) {
+			return false
+		}
+	}
+	return true
+}
+
+// tokenAt describes the token at i for error messages
+func tokenAt(tokens []string, i int) string {
+	if i >= len(tokens) {
+		return "end of statement"
+	}
+	return tokens[i]
+}
+
+// abbreviate shortens a statement for error messages
+func abbreviate(statement string) string {
+	if len(statement) > 80 {
+		return statement[:77] + "..."
+	}
+	return statement
+}
+
+// contains tells whether a slice holds a string
+func contains(slice []string, s string) bool {
+	for _, item := range slice {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
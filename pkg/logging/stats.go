@@ -0,0 +1,260 @@
+package logging
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Bucket is a labelled range of integer values. Max is the inclusive upper
+// bound; a negative Max makes the bucket unbounded.
+type Bucket struct {
+	Label string
+	Max   int64
+}
+
+// FileCountBuckets groups executions by the number of files processed
+var FileCountBuckets = []Bucket{
+	{"0", 0}, {"1-5", 5}, {"6-10", 10}, {"11-25", 25}, {"26-50", 50}, {"51-100", 100}, {"100+", -1},
+}
+
+// DurationBuckets groups executions by execution time in whole seconds
+var DurationBuckets = []Bucket{
+	{"<1s", 0}, {"1-5s", 5}, {"6-15s", 15}, {"16-30s", 30}, {"31-60s", 60}, {"1-2min", 120}, {"2-5min", 300}, {"5min+", -1},
+}
+
+// BucketLabel returns the label of the first bucket containing value
+func BucketLabel(value int64, buckets []Bucket) string {
+	for _, bucket := range buckets {
+		if bucket.Max < 0 || value <= bucket.Max {
+			return bucket.Label
+		}
+	}
+	return buckets[len(buckets)-1].Label
+}
+
+// bucketCase returns a SQL CASE expression mapping expr to bucket labels
+func bucketCase(expr string, buckets []Bucket) string {
+	var b strings.Builder
+	b.WriteString("CASE")
+	for _, bucket := range buckets {
+		label := "'" + strings.ReplaceAll(bucket.Label, "'", "''") + "'"
+		if bucket.Max < 0 {
+			fmt.Fprintf(&b, " ELSE %s", label)
+			break
+		}
+		fmt.Fprintf(&b, " WHEN %s <= %d THEN %s", expr, bucket.Max, label)
+	}
+	b.WriteString(" END")
+	return b.String()
+}
+
+// ExecutionDistribution counts executions over time and per bucket
+type ExecutionDistribution struct {
+	Daily            map[string]int `json:"daily"`  // Keyed by YYYY-MM-DD
+	Hourly           map[int]int    `json:"hourly"` // Keyed by hour of day
+	FileCountBuckets map[string]int `json:"file_count_buckets"`
+	DurationBuckets  map[string]int `json:"duration_buckets"`
+	// Heatmap counts executions per weekday (Sunday first) and hour of day
+	Heatmap [7][24]int `json:"heatmap"`
+}
+
+// GetExecutionDistribution aggregates the executions matching the filter by
+// day, hour, file count bucket and duration bucket in SQL. Days and hours are
+// taken from the stored timestamps as written.
+func (d *Database) GetExecutionDistribution(filter *ExecutionFilter) (*ExecutionDistribution, error) {
+	if filter == nil {
+		filter = &ExecutionFilter{}
+	}
+
+	where, args, err := executionWhereClause(filter)
+	if err != nil {
+		return nil, err
+	}
+
+	dist := &ExecutionDistribution{Hourly: make(map[int]int)}
+
+	if dist.Daily, err = d.groupCounts("substr(created, 1, 10)", where, args); err != nil {
+		return nil, fmt.Errorf("failed to get daily counts: %w", err)
+	}
+
+	hourly, err := d.groupCounts("CAST(substr(created, 12, 2) AS INTEGER)", where, args)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get hourly counts: %w", err)
+	}
+	for hour, count := range hourly {
+		var h int
+		if _, err := fmt.Sscan(hour, &h); err == nil {
+			dist.Hourly[h] = count
+		}
+	}
+
+	dayHours, err := d.groupCounts("substr(created, 1, 13)", where, args)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get heatmap counts: %w", err)
+	}
+	for dayHour, count := range dayHours {
+		if t, err := time.Parse("2006-01-02 15", dayHour); err == nil {
+			dist.Heatmap[t.Weekday()][t.Hour()] += count
+		}
+	}
+
+	if dist.FileCountBuckets, err = d.groupCounts(bucketCase("file_count", FileCountBuckets), where, args); err != nil {
+		return nil, fmt.Errorf("failed to get file count buckets: %w", err)
+	}
+	if dist.DurationBuckets, err = d.groupCounts(bucketCase("(execution_time_ms / 1000)", DurationBuckets), where, args); err != nil {
+		return nil, fmt.Errorf("failed to get duration buckets: %w", err)
+	}
+
+	return dist, nil
+}
+
+// DurationPercentiles summarizes the durations of one group of executions or steps
+type DurationPercentiles struct {
+	Group   string  `json:"group"`
+	Count   int     `json:"count"`
+	Failed  int     `json:"failed"`
+	Average float64 `json:"average_ms"`
+	P50     int64   `json:"p50_ms"`
+	P90     int64   `json:"p90_ms"`
+	P99     int64   `json:"p99_ms"`
+	Max     int64   `json:"max_ms"`
+}
+
+// GetModelDurationPercentiles returns execution time percentiles per model
+// for the executions matching the filter, busiest model first
+func (d *Database) GetModelDurationPercentiles(filter *ExecutionFilter) ([]DurationPercentiles, error) {
+	if filter == nil {
+		filter = &ExecutionFilter{}
+	}
+
+	where, args, err := executionWhereClause(filter)
+	if err != nil {
+		return nil, err
+	}
+
+	source := "SELECT COALESCE(model_used, '') AS grp, execution_time_ms AS duration, success AS ok FROM waffles_executions" + where
+	return d.durationPercentiles(source, args)
+}
+
+// GetStepDurationPercentiles returns step duration percentiles per tool for
+// the steps of the executions matching the filter, busiest tool first
+func (d *Database) GetStepDurationPercentiles(filter *ExecutionFilter) ([]DurationPercentiles, error) {
+	if filter == nil {
+		filter = &ExecutionFilter{}
+	}
+
+	where, args, err := executionWhereClause(filter)
+	if err != nil {
+		return nil, err
+	}
+
+	source := "SELECT tool AS grp, duration_ms AS duration, success AS ok FROM waffles_steps WHERE execution_id IN (SELECT id FROM waffles_executions" + where + ")"
+	return d.durationPercentiles(source, args)
+}
+
+// durationPercentiles computes nearest-rank percentiles per group over a
+// subquery selecting grp, duration and ok columns, using window functions since
+// SQLite has no percentile aggregate
+func (d *Database) durationPercentiles(source string, args []interface{}) ([]DurationPercentiles, error) {
+	query := `
+		WITH ranked AS (
+			SELECT grp, duration, ok,
+				ROW_NUMBER() OVER (PARTITION BY grp ORDER BY duration) AS rn,
+				COUNT(*) OVER (PARTITION BY grp) AS n
+			FROM (` + source + `)
+		)
+		SELECT grp, MAX(n), SUM(CASE WHEN ok THEN 0 ELSE 1 END), AVG(duration),
+			MIN(CASE WHEN rn >= n * 0.50 THEN duration END),
+			MIN(CASE WHEN rn >= n * 0.90 THEN duration END),
+			MIN(CASE WHEN rn >= n * 0.99 THEN duration END),
+			MAX(duration)
+		FROM ranked
+		GROUP BY grp
+		ORDER BY MAX(n) DESC, grp`
+
+	rows, err := d.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get duration percentiles: %w", err)
+	}
+	defer rows.Close()
+
+	var results []DurationPercentiles
+	for rows.Next() {
+		var p DurationPercentiles
+		if err := rows.Scan(&p.Group, &p.Count, &p.Failed, &p.Average, &p.P50, &p.P90, &p.P99, &p.Max); err != nil {
+			return nil, fmt.Errorf("failed to scan duration percentiles: %w", err)
+		}
+		results = append(results, p)
+	}
+
+	return results, rows.Err()
+}
+
+// GetSlowestSteps returns the longest running steps of the executions
+// matching the filter, slowest first. Step output is left out.
+func (d *Database) GetSlowestSteps(filter *ExecutionFilter, limit int) ([]WafflesStep, error) {
+	if filter == nil {
+		filter = &ExecutionFilter{}
+	}
+
+	where, args, err := executionWhereClause(filter)
+	if err != nil {
+		return nil, err
+	}
+
+	query := `
+		SELECT id, execution_id, tool, command, COALESCE(error_output, ''),
+			success, duration_ms, step_order, created
+		FROM waffles_steps
+		WHERE execution_id IN (SELECT id FROM waffles_executions` + where + `)
+		ORDER BY duration_ms DESC, created DESC
+		LIMIT ?`
+
+	rows, err := d.db.Query(query, append(args, limit)...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query slowest steps: %w", err)
+	}
+	defer rows.Close()
+
+	var steps []WafflesStep
+	for rows.Next() {
+		var step WafflesStep
+		err := rows.Scan(
+			&step.ID, &step.ExecutionID, &step.Tool, &step.Command, &step.ErrorOutput,
+			&step.Success, &step.DurationMS, &step.StepOrder, &step.Created,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan step: %w", err)
+		}
+		steps = append(steps, step)
+	}
+
+	return steps, rows.Err()
+}
+
+// groupCounts counts the filtered executions per value of a SQL expression,
+// skipping empty values
+func (d *Database) groupCounts(expr, where string, args []interface{}) (map[string]int, error) {
+	query := "SELECT " + expr + " AS grp, COUNT(*) FROM waffles_executions" + where + " GROUP BY grp"
+
+	rows, err := d.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	counts := make(map[string]int)
+	for rows.Next() {
+		var value string
+		var count int
+		if err := rows.Scan(&value, &count); err != nil {
+			return nil, err
+		}
+		if value != "" {
+			counts[value] = count
+		}
+	}
+
+	return counts, rows.Err()
+}
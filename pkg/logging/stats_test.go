@@ -0,0 +1,146 @@
+package logging
+
+import (
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// newStatsTestDB creates a database with ten executions split over two models,
+// taking 1s to 10s each, with one step per execution
+func newStatsTestDB(t *testing.T) *Database {
+	t.Helper()
+
+	db, err := NewDatabase(filepath.Join(t.TempDir(), "stats.db"))
+	if err != nil {
+		t.Fatalf("Failed to create database: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	created := time.Date(2026, 3, 10, 9, 30, 0, 0, time.UTC)
+	for i := 1; i <= 10; i++ {
+		model := "gpt-4o"
+		if i > 6 {
+			model = "llama3"
+		}
+		exec := &WafflesExecution{
+			ID:              fmt.Sprintf("exec-%02d", i),
+			ModelUsed:       model,
+			Success:         i%4 != 0,
+			FileCount:       i * 3,
+			ExecutionTimeMS: int64(i) * 1000,
+			Created:         created.Add(time.Duration(i) * 12 * time.Hour),
+		}
+		if err := db.LogExecution(exec); err != nil {
+			t.Fatalf("Failed to log execution: %v", err)
+		}
+		if err := db.LogSteps(exec.ID, []WafflesStep{{Tool: "llm", DurationMS: int64(i) * 100, Success: exec.Success, StepOrder: 1}}); err != nil {
+			t.Fatalf("Failed to log steps: %v", err)
+		}
+	}
+
+	return db
+}
+
+func TestGetExecutionStatsFiltered(t *testing.T) {
+	db := newStatsTestDB(t)
+
+	stats, err := db.GetExecutionStats(&ExecutionFilter{Model: "llama3"})
+	if err != nil {
+		t.Fatalf("GetExecutionStats failed: %v", err)
+	}
+	if stats.TotalExecutions != 4 || stats.FailedExecutions != 1 || stats.TotalFiles != 102 || stats.TotalExecutionTime != 34000 {
+		t.Errorf("Unexpected stats: %+v", stats)
+	}
+	if len(stats.ModelBreakdown) != 1 || stats.ModelBreakdown["llama3"] != 4 {
+		t.Errorf("Expected only llama3 in model breakdown, got %v", stats.ModelBreakdown)
+	}
+
+	// An empty selection yields zero values rather than a scan error
+	stats, err = db.GetExecutionStats(&ExecutionFilter{Model: "missing"})
+	if err != nil {
+		t.Fatalf("GetExecutionStats on empty selection failed: %v", err)
+	}
+	if stats.TotalExecutions != 0 || stats.TotalFiles != 0 {
+		t.Errorf("Expected empty stats, got %+v", stats)
+	}
+}
+
+func TestGetExecutionDistribution(t *testing.T) {
+	db := newStatsTestDB(t)
+
+	dist, err := db.GetExecutionDistribution(nil)
+	if err != nil {
+		t.Fatalf("GetExecutionDistribution failed: %v", err)
+	}
+
+	if dist.Daily["2026-03-10"] != 1 || dist.Daily["2026-03-11"] != 2 || dist.Daily["2026-03-15"] != 1 {
+		t.Errorf("Unexpected daily counts: %v", dist.Daily)
+	}
+	if dist.Hourly[21] != 5 || dist.Hourly[9] != 5 {
+		t.Errorf("Unexpected hourly counts: %v", dist.Hourly)
+	}
+	// File counts are 3, 6, ..., 30
+	expectedFiles := map[string]int{"1-5": 1, "6-10": 2, "11-25": 5, "26-50": 2}
+	for bucket, count := range expectedFiles {
+		if dist.FileCountBuckets[bucket] != count {
+			t.Errorf("Expected %d executions in file bucket %s, got %v", count, bucket, dist.FileCountBuckets)
+		}
+	}
+	// 2026-03-10 is a Tuesday; runs alternate between 21:30 and 09:30
+	if dist.Heatmap[time.Tuesday][21] != 1 || dist.Heatmap[time.Wednesday][9] != 1 || dist.Heatmap[time.Sunday][9] != 1 {
+		t.Errorf("Unexpected heatmap: %v", dist.Heatmap)
+	}
+	// Durations are 1s to 10s
+	if dist.DurationBuckets["1-5s"] != 5 || dist.DurationBuckets["6-15s"] != 5 {
+		t.Errorf("Unexpected duration buckets: %v", dist.DurationBuckets)
+	}
+
+	for _, tt := range []struct {
+		value    int64
+		expected string
+	}{{0, "0"}, {5, "1-5"}, {6, "6-10"}, {100, "51-100"}, {101, "100+"}} {
+		if label := BucketLabel(tt.value, FileCountBuckets); label != tt.expected {
+			t.Errorf("BucketLabel(%d) = %s, want %s", tt.value, label, tt.expected)
+		}
+	}
+}
+
+func TestDurationPercentiles(t *testing.T) {
+	db := newStatsTestDB(t)
+
+	models, err := db.GetModelDurationPercentiles(nil)
+	if err != nil {
+		t.Fatalf("GetModelDurationPercentiles failed: %v", err)
+	}
+	if len(models) != 2 {
+		t.Fatalf("Expected 2 models, got %+v", models)
+	}
+
+	// gpt-4o ran 1s..6s: nearest rank p50 is the 3rd value, p90 and p99 the 6th
+	gpt := models[0]
+	if gpt.Group != "gpt-4o" || gpt.Count != 6 || gpt.Failed != 1 || gpt.P50 != 3000 || gpt.P90 != 6000 || gpt.P99 != 6000 || gpt.Max != 6000 || gpt.Average != 3500 {
+		t.Errorf("Unexpected gpt-4o percentiles: %+v", gpt)
+	}
+
+	tools, err := db.GetStepDurationPercentiles(&ExecutionFilter{Conditions: []Condition{{"duration", OpLessEqual, 5 * time.Second}}})
+	if err != nil {
+		t.Fatalf("GetStepDurationPercentiles failed: %v", err)
+	}
+	if len(tools) != 1 || tools[0].Group != "llm" || tools[0].Count != 5 || tools[0].P50 != 300 || tools[0].Max != 500 {
+		t.Errorf("Unexpected step percentiles: %+v", tools)
+	}
+}
+
+func TestGetSlowestSteps(t *testing.T) {
+	db := newStatsTestDB(t)
+
+	steps, err := db.GetSlowestSteps(&ExecutionFilter{Model: "gpt-4o"}, 2)
+	if err != nil {
+		t.Fatalf("GetSlowestSteps failed: %v", err)
+	}
+	if len(steps) != 2 || steps[0].ExecutionID != "exec-06" || steps[1].ExecutionID != "exec-05" {
+		t.Errorf("Expected the steps of exec-06 and exec-05, got %+v", steps)
+	}
+}
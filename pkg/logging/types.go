@@ -17,6 +17,7 @@ type WafflesExecution struct {
 	ConversationID      string    `json:"conversation_id"`
 	CommandArgs         string    `json:"command_args"`
 	WheresmypromptQuery string    `json:"wheresmyprompt_query"`
+	WheresmypromptArgs  string    `json:"wheresmyprompt_args,omitempty"`
 	Files2promptArgs    string    `json:"files2prompt_args"`
 	LLMArgs             string    `json:"llm_args"`
 	DetectedLanguage    string    `json:"detected_language"`
@@ -26,6 +27,8 @@ type WafflesExecution struct {
 	ErrorMessage        string    `json:"error_message,omitempty"`
 	ModelUsed           string    `json:"model_used"`
 	ProviderUsed        string    `json:"provider_used"`
+	ParentExecutionID   string    `json:"parent_execution_id,omitempty"`
+	Source              string    `json:"source,omitempty"`
 	Created             time.Time `json:"created"`
 	Updated             time.Time `json:"updated"`
 }
@@ -57,17 +60,21 @@ type WafflesStep struct {
 
 // ExecutionFilter represents filtering criteria for querying executions
 type ExecutionFilter struct {
-	DateFrom    *time.Time `json:"date_from,omitempty"`
-	DateTo      *time.Time `json:"date_to,omitempty"`
-	Language    string     `json:"language,omitempty"`
-	Model       string     `json:"model,omitempty"`
-	Provider    string     `json:"provider,omitempty"`
-	Success     *bool      `json:"success,omitempty"`
-	MinDuration *int64     `json:"min_duration,omitempty"`
-	MaxDuration *int64     `json:"max_duration,omitempty"`
-	SearchQuery string     `json:"search_query,omitempty"`
-	Limit       int        `json:"limit,omitempty"`
-	Offset      int        `json:"offset,omitempty"`
+	DateFrom     *time.Time  `json:"date_from,omitempty"`
+	DateTo       *time.Time  `json:"date_to,omitempty"`
+	Language     string      `json:"language,omitempty"`
+	Model        string      `json:"model,omitempty"`
+	Provider     string      `json:"provider,omitempty"`
+	Success      *bool       `json:"success,omitempty"`
+	MinDuration  *int64      `json:"min_duration,omitempty"`
+	MaxDuration  *int64      `json:"max_duration,omitempty"`
+	MinFileCount *int        `json:"min_file_count,omitempty"`
+	MaxFileCount *int        `json:"max_file_count,omitempty"`
+	SearchQuery  string      `json:"search_query,omitempty"`
+	Source       string      `json:"source,omitempty"`
+	Conditions   []Condition `json:"conditions,omitempty"`
+	Limit        int         `json:"limit,omitempty"`
+	Offset       int         `json:"offset,omitempty"`
 }
 
 // ExecutionStats represents statistics about executions
@@ -76,6 +83,7 @@ type ExecutionStats struct {
 	SuccessfulExecutions int            `json:"successful_executions"`
 	FailedExecutions     int            `json:"failed_executions"`
 	AverageExecutionTime float64        `json:"average_execution_time_ms"`
+	TotalExecutionTime   int64          `json:"total_execution_time_ms"`
 	TotalFiles           int            `json:"total_files"`
 	LanguageBreakdown    map[string]int `json:"language_breakdown"`
 	ModelBreakdown       map[string]int `json:"model_breakdown"`
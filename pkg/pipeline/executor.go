@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"os/exec"
+	"path/filepath"
 	"strings"
 	"time"
 
@@ -53,6 +54,11 @@ func (p *Pipeline) Execute(promptQuery string, args []string) (*ExecutionContext
 		return execCtx, pipelineErr
 	}
 
+	if len(p.Files) > 0 {
+		applyFileSelection(repoInfo, p.Files)
+	}
+	execCtx.Files = getIncludedFiles(repoInfo)
+
 	// Store repoInfo with mutex protection for thread safety
 	p.mu.Lock()
 	p.RepoInfo = repoInfo
@@ -135,6 +141,26 @@ func (p *Pipeline) analyzeRepository() (*repo.RepositoryInfo, error) {
 	return repo.AnalyzeRepository(".", overrides)
 }
 
+// applyFileSelection restricts the analyzed files to an explicit selection,
+// such as the file set recorded for a previous execution
+func applyFileSelection(repoInfo *repo.RepositoryInfo, files []string) {
+	selected := make(map[string]bool, len(files))
+	for _, file := range files {
+		selected[filepath.ToSlash(file)] = true
+	}
+
+	for i := range repoInfo.DetectedFiles {
+		file := &repoInfo.DetectedFiles[i]
+		if selected[filepath.ToSlash(file.Path)] {
+			file.Included = true
+			file.Reason = "Included by explicit file selection"
+		} else if file.Included {
+			file.Included = false
+			file.Reason = "Not in explicit file selection"
+		}
+	}
+}
+
 // executeWheresmyprompt runs wheresmyprompt to retrieve system prompts
 func (p *Pipeline) executeWheresmyprompt(query string, args []string) (*StepResult, error) {
 	startTime := time.Now()
@@ -164,7 +190,7 @@ func (p *Pipeline) executeWheresmyprompt(query string, args []string) (*StepResu
 	timeoutCtx, cancel := context.WithTimeout(context.Background(), time.Duration(getToolTimeout("wheresmyprompt"))*time.Second)
 	defer cancel()
 
-	cmd := exec.CommandContext(timeoutCtx, cmdArgs[0], cmdArgs[1:]...) // #nosec G204 # nosemgrep: go.lang.security.audit.dangerous-exec-command.dangerous-exec-command
+	cmd := exec.CommandContext(timeoutCtx, deps.ResolveCommand(cmdArgs[0]), cmdArgs[1:]...) // #nosec G204 # nosemgrep: go.lang.security.audit.dangerous-exec-command.dangerous-exec-command
 	output, err := cmd.CombinedOutput()
 
 	result.EndTime = time.Now()
@@ -214,7 +240,7 @@ func (p *Pipeline) executeFiles2prompt(repoInfo *repo.RepositoryInfo, args []str
 	timeoutCtx, cancel := context.WithTimeout(context.Background(), time.Duration(getToolTimeout("files2prompt"))*time.Second)
 	defer cancel()
 
-	cmd := exec.CommandContext(timeoutCtx, cmdArgs[0], cmdArgs[1:]...) // #nosec G204 # nosemgrep: go.lang.security.audit.dangerous-exec-command.dangerous-exec-command
+	cmd := exec.CommandContext(timeoutCtx, deps.ResolveCommand(cmdArgs[0]), cmdArgs[1:]...) // #nosec G204 # nosemgrep: go.lang.security.audit.dangerous-exec-command.dangerous-exec-command
 	output, err := cmd.CombinedOutput()
 
 	result.EndTime = time.Now()
@@ -270,7 +296,7 @@ func (p *Pipeline) executeLLM(prompt, contextData, model string, args []string)
 	timeoutCtx, cancel := context.WithTimeout(context.Background(), time.Duration(getToolTimeout("llm"))*time.Second)
 	defer cancel()
 
-	cmd := exec.CommandContext(timeoutCtx, cmdArgs[0], cmdArgs[1:]...) // #nosec G204 # nosemgrep: go.lang.security.audit.dangerous-exec-command.dangerous-exec-command
+	cmd := exec.CommandContext(timeoutCtx, deps.ResolveCommand(cmdArgs[0]), cmdArgs[1:]...) // #nosec G204 # nosemgrep: go.lang.security.audit.dangerous-exec-command.dangerous-exec-command
 
 	// Provide the context as stdin input
 	if finalInput != "" {
@@ -126,6 +126,30 @@ func TestPipeline_SetRepoInfo(t *testing.T) {
 	}
 }
 
+func TestApplyFileSelection(t *testing.T) {
+	repoInfo := &repo.RepositoryInfo{
+		Language: repo.LanguageGo,
+		DetectedFiles: []repo.FileInfo{
+			{Path: "main.go", Included: true},
+			{Path: "pkg/util.go", Included: true},
+			{Path: "README.md", Included: false},
+		},
+	}
+
+	applyFileSelection(repoInfo, []string{"pkg/util.go", "README.md", "deleted.go"})
+
+	included := getIncludedFiles(repoInfo)
+	if len(included) != 2 {
+		t.Fatalf("Expected 2 included files, got %d: %v", len(included), included)
+	}
+	if included[0] != "pkg/util.go" || included[1] != "README.md" {
+		t.Errorf("Expected selected files to be included, got %v", included)
+	}
+	if repoInfo.DetectedFiles[0].Included {
+		t.Error("Expected main.go to be excluded by the explicit selection")
+	}
+}
+
 func TestExecutionContext(t *testing.T) {
 	// Test ExecutionContext struct
 	now := time.Now()
@@ -337,6 +361,27 @@ func TestPipelineErrorHandling(t *testing.T) {
 	}
 }
 
+func TestParsePipelineError(t *testing.T) {
+	original := &PipelineError{Phase: PhaseLLMExecution, Tool: "llm", Message: "Execution failed: Error: rate limit exceeded", Err: fmt.Errorf("exit status 1")}
+
+	parsed, ok := ParsePipelineError(original.Error())
+	if !ok {
+		t.Fatalf("Expected %q to parse", original.Error())
+	}
+	if parsed.Phase != original.Phase || parsed.Tool != original.Tool || parsed.Message != original.Message {
+		t.Errorf("Expected %+v, got %+v", original, parsed)
+	}
+
+	parsed, ok = ParsePipelineError((&PipelineError{Phase: PhaseRepoAnalysis, Message: "Failed to analyze repository"}).Error())
+	if !ok || parsed.Phase != PhaseRepoAnalysis || parsed.Tool != "" || parsed.Message != "Failed to analyze repository" {
+		t.Errorf("Unexpected parse of error without tool: %+v", parsed)
+	}
+
+	if _, ok := ParsePipelineError("exit status 1"); ok {
+		t.Error("Expected other errors not to parse")
+	}
+}
+
 // Benchmark tests
 func BenchmarkPipelineCreation(b *testing.B) {
 	cfg := &config.Config{
@@ -4,6 +4,7 @@ import (
 	"crypto/rand"
 	"fmt"
 	"math/big"
+	"regexp"
 	"sync"
 	"time"
 
@@ -23,6 +24,7 @@ type Pipeline struct {
 	Config   *config.Config
 	RepoInfo *repo.RepositoryInfo
 	Logger   Logger
+	Files    []string     // Explicit file selection; overrides pattern-based selection when set
 	mu       sync.RWMutex // Protects RepoInfo from concurrent access
 }
 
@@ -116,6 +118,34 @@ func (pe *PipelineError) Unwrap() error {
 	return pe.Err
 }
 
+// pipelineErrorPattern matches the messages produced by PipelineError.Error
+var pipelineErrorPattern = regexp.MustCompile(`^pipeline error in (\S+) phase(?: \(tool: ([^)]*)\))?: (?s)(.*)$`)
+
+// ParsePipelineError recovers the phase, tool and message from the text of a
+// PipelineError, such as the error message stored with a logged execution.
+// The underlying error is not recovered. It reports false for other errors.
+func ParsePipelineError(message string) (*PipelineError, bool) {
+	match := pipelineErrorPattern.FindStringSubmatch(message)
+	if match == nil {
+		return nil, false
+	}
+	return &PipelineError{Phase: ExecutionPhase(match[1]), Tool: match[2], Message: match[3]}, true
+}
+
+// ToolPhase returns the phase in which a pipeline tool runs
+func ToolPhase(tool string) ExecutionPhase {
+	switch tool {
+	case "wheresmyprompt":
+		return PhasePromptRetrieval
+	case "files2prompt":
+		return PhaseContextExtraction
+	case "llm":
+		return PhaseLLMExecution
+	default:
+		return ""
+	}
+}
+
 // DefaultOptions returns default pipeline options
 func DefaultOptions() *PipelineOptions {
 	return &PipelineOptions{